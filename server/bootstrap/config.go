@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"amiya-eden/config"
 	"amiya-eden/global"
+	"amiya-eden/pkg/eve"
 	"fmt"
 
 	"github.com/spf13/viper"
@@ -29,6 +30,9 @@ func InitConfig() {
 
 	global.Config = &cfg
 
+	// 所有 ESI 调用方共用的基础地址
+	eve.SetESIBaseURL(cfg.ESI.BaseURL)
+
 	// 监听配置文件变化（热重载）
 	viper.WatchConfig()
 }
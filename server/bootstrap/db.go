@@ -61,6 +61,8 @@ func InitDB() {
 func autoMigrate(db *gorm.DB) {
 	if err := db.AutoMigrate(
 		&model.User{},
+		&model.UserSession{},
+		&model.APIKey{},
 		&model.OperationLog{},
 		&model.EveCharacter{},
 		&model.SdeVersion{},
@@ -70,6 +72,7 @@ func autoMigrate(db *gorm.DB) {
 		&model.EveCharacterTitle{},
 		&model.EveCharacterCloneBaseInfo{},
 		&model.EveCharacterImplants{},
+		&model.EveCharacterLocation{},
 		&model.EveStructure{},
 		&model.CorpStructureInfo{},
 		&model.EveStation{},
@@ -93,12 +96,27 @@ func autoMigrate(db *gorm.DB) {
 
 		&model.EveCharacterFitting{},
 		&model.EveCharacterFittingItem{},
+		// ESI 刷新任务执行记录
+		&model.EsiTaskRun{},
+		&model.EsiTaskBreaker{},
+		&model.EsiTaskState{},
+		// 市场价格
+		&model.MarketPrice{},
+		&model.MarketPriceHistory{},
 		// Fleet / Operation 相关表
 		&model.Fleet{},
 		&model.FleetMember{},
+		&model.FleetMemberSegment{},
 		&model.FleetPapLog{},
+		&model.PapPolicy{},
+		&model.PapClaim{},
+		&model.PapClaimLog{},
 		&model.FleetInvite{},
 		&model.FleetBattleIncentive{},
+		&model.OperationTemplate{},
+		&model.FleetOperation{},
+		&model.OperationRSVP{},
+		&model.CalendarFeedToken{},
 		&model.SystemWallet{},
 		&model.WalletTransaction{},
 		&model.WalletLog{},
@@ -113,6 +131,7 @@ func autoMigrate(db *gorm.DB) {
 		// SRP 补损相关表
 		&model.SrpShipPrice{},
 		&model.SrpApplication{},
+		&model.SrpApplicationLine{},
 		// 舰队配置相关表
 		&model.FleetConfig{},
 		&model.FleetConfigFitting{},
@@ -134,12 +153,17 @@ func autoMigrate(db *gorm.DB) {
 		&model.Menu{},
 		&model.RoleMenu{},
 		&model.UserRole{},
+		&model.UserRoleScope{},
 		// ESI / SeAT 自动权限映射表
 		&model.SeatRoleMapping{},
 		&model.EsiRoleMapping{},
 		&model.EsiTitleMapping{},
 		&model.EveCharacterCorpRole{},
 		&model.AutoRoleLog{},
+		&model.AutoRoleRule{},
+		&model.CharacterAffiliationEvent{},
+		&model.RecruitApplication{},
+		&model.RecruitComment{},
 		// 准入名单表
 		&model.AllowedEntity{},
 		// SeAT 用户绑定表
@@ -150,6 +174,8 @@ func autoMigrate(db *gorm.DB) {
 
 	// 清理旧列（GORM AutoMigrate 不会自动删除列）
 	dropObsoleteColumns(db)
+	migrateOperationOccurrence(db)
+	migrateEsiTaskState(db)
 
 	// 种子数据：系统角色 → 系统菜单 → 默认角色权限 → 迁移已有用户
 	roleSvc := service.NewRoleService()
@@ -179,3 +205,48 @@ func dropObsoleteColumns(db *gorm.DB) {
 		}
 	}
 }
+
+// migrateOperationOccurrence 模板行动改为按排期时间（occurrence_at）去重：
+// 删除旧的 (template_id, start_at) 唯一索引，并为已有的模板行动补齐 occurrence_at
+func migrateOperationOccurrence(db *gorm.DB) {
+	migrator := db.Migrator()
+	if migrator.HasIndex(&model.FleetOperation{}, "idx_operation_template_start") {
+		if err := migrator.DropIndex(&model.FleetOperation{}, "idx_operation_template_start"); err != nil {
+			global.Logger.Warn("删除旧索引失败", zap.String("index", "idx_operation_template_start"), zap.Error(err))
+		}
+	}
+	if err := db.Model(&model.FleetOperation{}).Unscoped().
+		Where("template_id IS NOT NULL AND occurrence_at IS NULL").
+		Update("occurrence_at", gorm.Expr("start_at")).Error; err != nil {
+		global.Logger.Warn("补齐模板行动排期时间失败", zap.Error(err))
+	}
+}
+
+// migrateEsiTaskState 新建 esi_task_state 时，从已有执行记录回填每个 (任务, 角色) 的最新状态（仅执行一次）
+func migrateEsiTaskState(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&model.EsiTaskState{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	if err := db.Exec(`
+		INSERT INTO esi_task_state (task_name, character_id, last_run_id, last_success_active, updated_at)
+		SELECT DISTINCT ON (task_name, character_id) task_name, character_id, id, true, NOW()
+		FROM esi_task_run
+		ORDER BY task_name, character_id, started_at DESC, id DESC
+		ON CONFLICT (task_name, character_id) DO NOTHING`).Error; err != nil {
+		global.Logger.Warn("回填 ESI 任务最新状态失败", zap.Error(err))
+		return
+	}
+	if err := db.Exec(`
+		UPDATE esi_task_state AS s
+		SET last_success_at = x.finished_at, last_success_active = x.is_active
+		FROM (
+			SELECT DISTINCT ON (task_name, character_id) task_name, character_id, finished_at, is_active
+			FROM esi_task_run
+			WHERE status = ?
+			ORDER BY task_name, character_id, finished_at DESC
+		) AS x
+		WHERE s.task_name = x.task_name AND s.character_id = x.character_id`, model.EsiTaskRunSuccess).Error; err != nil {
+		global.Logger.Warn("回填 ESI 任务最近成功时间失败", zap.Error(err))
+	}
+}
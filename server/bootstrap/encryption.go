@@ -0,0 +1,111 @@
+package bootstrap
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/envelope"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// InitEncryption 加载敏感字段加密主密钥，并将历史明文数据加密（一次性迁移，已加密的数据会被跳过）
+// 必须在 InitDB 之后、任何读写 Token 的操作之前调用
+func InitEncryption() {
+	keyring, current, err := loadEncryptionKeys()
+	if err != nil {
+		global.Logger.Fatal("加载加密主密钥失败", zap.Error(err))
+	}
+	if err := envelope.Init(keyring, current); err != nil {
+		global.Logger.Fatal("初始化加密主密钥失败", zap.Error(err))
+	}
+	if !envelope.Enabled() {
+		global.Logger.Warn("未配置加密主密钥（encryption.keys），Token 与敏感配置将以明文存储")
+		return
+	}
+
+	result, err := repository.ReencryptSecrets(false)
+	if err != nil {
+		global.Logger.Fatal("加密历史敏感数据失败", zap.Error(err))
+	}
+	global.Logger.Info("敏感字段加密已启用",
+		zap.Int("key_version", current),
+		zap.Any("migrated_rows", result.Rows),
+		zap.Int64("migrated_configs", result.Configs),
+	)
+}
+
+// RotateEncryptionKey 将所有敏感字段重新加密为当前版本主密钥（密钥轮换命令）
+// 轮换步骤：在 encryption.keys 中追加新版本密钥并修改 current_version，执行本命令，确认无误后再移除旧密钥
+func RotateEncryptionKey() error {
+	if !envelope.Enabled() {
+		return fmt.Errorf("未配置加密主密钥")
+	}
+	result, err := repository.ReencryptSecrets(true)
+	if err != nil {
+		return err
+	}
+	global.Logger.Info("密钥轮换完成",
+		zap.Int("key_version", envelope.CurrentVersion()),
+		zap.Any("rows", result.Rows),
+		zap.Int64("configs", result.Configs),
+	)
+	return nil
+}
+
+// loadEncryptionKeys 读取主密钥配置，环境变量优先
+//
+//	AMIYA_ENCRYPTION_KEYS="1:base64key,2:base64key"
+//	AMIYA_ENCRYPTION_CURRENT_VERSION=2
+func loadEncryptionKeys() (map[int][]byte, int, error) {
+	cfg := global.Config.Encryption
+	entries := cfg.Keys
+	current := cfg.CurrentVersion
+	if env := os.Getenv("AMIYA_ENCRYPTION_KEYS"); env != "" {
+		entries = strings.Split(env, ",")
+	}
+	if env := os.Getenv("AMIYA_ENCRYPTION_CURRENT_VERSION"); env != "" {
+		v, err := strconv.Atoi(env)
+		if err != nil {
+			return nil, 0, fmt.Errorf("AMIYA_ENCRYPTION_CURRENT_VERSION 无效: %w", err)
+		}
+		current = v
+	}
+
+	keyring := make(map[int][]byte)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		verStr, keyStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, 0, fmt.Errorf("密钥格式应为 \"版本:base64\"")
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(verStr))
+		if err != nil {
+			return nil, 0, fmt.Errorf("密钥版本无效: %q", verStr)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(keyStr))
+		if err != nil {
+			return nil, 0, fmt.Errorf("版本 %d 的密钥不是有效的 base64: %w", version, err)
+		}
+		if _, dup := keyring[version]; dup {
+			return nil, 0, fmt.Errorf("密钥版本 %d 重复", version)
+		}
+		keyring[version] = key
+	}
+	if len(keyring) > 0 && current == 0 {
+		// 未指定当前版本时使用最大版本号
+		for v := range keyring {
+			if v > current {
+				current = v
+			}
+		}
+	}
+	return keyring, current, nil
+}
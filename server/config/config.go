@@ -8,10 +8,14 @@ type Config struct {
 	JWT         JWTConfig         `mapstructure:"jwt"`
 	Redis       RedisConfig       `mapstructure:"redis"`
 	EveSSO      EveSSOConfig      `mapstructure:"eve_sso"`
+	ESI         ESIConfig         `mapstructure:"esi"`
 	SDE         SDEConfig         `mapstructure:"sde"`
 	App         AppConfig         `mapstructure:"app"`
 	AlliancePAP AlliancePAPConfig `mapstructure:"alliance_pap"`
 	GitHub      GitHubConfig      `mapstructure:"github"`
+	Cron        CronConfig        `mapstructure:"cron"`
+	Encryption  EncryptionConfig  `mapstructure:"encryption"`
+	Market      MarketConfig      `mapstructure:"market"`
 }
 
 // ServerConfig HTTP 服务配置
@@ -44,8 +48,10 @@ type LogConfig struct {
 
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret    string `mapstructure:"secret"`
-	ExpireDay int    `mapstructure:"expire_day"`
+	Secret               string `mapstructure:"secret"`
+	ExpireDay            int    `mapstructure:"expire_day"`            // 会话（Refresh Token）有效天数，刷新时顺延
+	AccessTokenMinutes   int    `mapstructure:"access_token_minutes"`  // Access Token 有效分钟数，默认 15
+	ImpersonationMinutes int    `mapstructure:"impersonation_minutes"` // 模拟登录最长有效分钟数，默认 30
 }
 
 // RedisConfig Redis 缓存配置
@@ -63,9 +69,13 @@ type EveSSOConfig struct {
 	CallbackURL  string `mapstructure:"callback_url"`
 }
 
+// ESIConfig EVE ESI 接口配置
+type ESIConfig struct {
+	BaseURL string `mapstructure:"base_url"` // ESI 基础地址，留空使用 https://esi.evetech.net；可指向本地 ESI 替身用于测试/预发
+}
+
 // SDEConfig SDE 模块配置
 type SDEConfig struct {
-	APIKey      string `mapstructure:"api_key"`      // 用于保护数据查询接口的 API Key
 	Proxy       string `mapstructure:"proxy"`        // 下载 SDE 时使用的 HTTP/SOCKS5 代理，留空则不使用
 	DownloadURL string `mapstructure:"download_url"` // SDE GitHub Release API 地址
 }
@@ -86,3 +96,28 @@ type GitHubConfig struct {
 	Owner string `mapstructure:"owner"` // 仓库所有者，例如 zifox666
 	Repo  string `mapstructure:"repo"`  // 仓库名称，例如 AmiyaEden
 }
+
+// CronConfig 定时任务配置（多实例部署时通过 Redis 租约保证同一任务只在一个实例执行）
+type CronConfig struct {
+	InstanceID      string `mapstructure:"instance_id"`       // 实例标识，留空则使用 主机名-PID
+	LeaseTTLSeconds int    `mapstructure:"lease_ttl_seconds"` // 租约 TTL（秒），执行期间自动续期，默认 60
+}
+
+// EncryptionConfig 敏感字段加密配置（AES-GCM 信封加密）
+// 也可通过环境变量 AMIYA_ENCRYPTION_KEYS（逗号分隔）与 AMIYA_ENCRYPTION_CURRENT_VERSION 覆盖
+type EncryptionConfig struct {
+	CurrentVersion int      `mapstructure:"current_version"` // 当前用于加密的主密钥版本
+	Keys           []string `mapstructure:"keys"`            // 主密钥列表，格式 "版本:base64(32 字节密钥)"，轮换时保留旧版本用于解密
+}
+
+// MarketConfig 市场价格配置
+type MarketConfig struct {
+	Hubs []MarketHubConfig `mapstructure:"hubs"` // 需要拉取订单簿的交易中心，留空默认仅 Jita
+}
+
+// MarketHubConfig 交易中心（订单按 location_id 过滤；location_id 为 0 时汇总整个星域）
+type MarketHubConfig struct {
+	Name       string `mapstructure:"name"`        // 价格来源标识，如 jita、amarr
+	RegionID   int64  `mapstructure:"region_id"`   // 星域 ID
+	LocationID int64  `mapstructure:"location_id"` // 空间站 / 建筑 ID
+}
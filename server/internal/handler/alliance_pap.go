@@ -29,15 +29,24 @@ func NewAlliancePAPHandler() *AlliancePAPHandler {
 }
 
 // getAllowCorpFilter 根据调用者角色返回军团过滤列表
-// super_admin 返回 nil（不过滤），admin 返回 basic_access 名单中的军团 ID
-func getAllowCorpFilter(c *gin.Context) []int64 {
+// super_admin 返回 nil（不过滤），admin 返回 basic_access 名单中的军团 ID；
+// 经限定军团/联盟范围的角色放行时再与其军团取交集，交集为空时 ok 为 false（无可访问的数据）
+func getAllowCorpFilter(c *gin.Context) (corpIDs []int64, ok bool) {
 	roles := middleware.GetUserRoles(c)
-	if model.IsSuperAdmin(roles) {
-		return nil
+	if !model.IsSuperAdmin(roles) {
+		allowRepo := repository.NewAllowedEntityRepository()
+		corpIDs, _ = allowRepo.GetCorporationIDs(model.AllowListBasicAccess)
 	}
-	allowRepo := repository.NewAllowedEntityRepository()
-	corpIDs, _ := allowRepo.GetCorporationIDs(model.AllowListBasicAccess)
-	return corpIDs
+	scope := middleware.GetDataScope(c)
+	if scope == nil {
+		return corpIDs, true
+	}
+	if len(corpIDs) == 0 {
+		corpIDs = scope.CorporationIDs
+	} else {
+		corpIDs = (&model.DataScope{CorporationIDs: corpIDs}).Intersect(scope).CorporationIDs
+	}
+	return corpIDs, len(corpIDs) > 0
 }
 
 // GetMyAlliancePAP  GET /operation/pap/alliance
@@ -99,7 +108,12 @@ func (h *AlliancePAPHandler) GetAllAlliancePAP(c *gin.Context) {
 		}
 	}
 
-	list, total, err := h.svc.GetAllPAPPaged(year, month, page, size, getAllowCorpFilter(c))
+	corpIDs, ok := getAllowCorpFilter(c)
+	if !ok {
+		response.OKWithPage(c, []model.AlliancePAPSummary{}, 0, page, size)
+		return
+	}
+	list, total, err := h.svc.GetAllPAPPaged(year, month, page, size, corpIDs)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -220,8 +234,13 @@ func (h *AlliancePAPHandler) SettleMonth(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "不允许结算当月或未来月份，请选择已结束的月份")
 		return
 	}
+	corpIDs, ok := getAllowCorpFilter(c)
+	if !ok {
+		response.Fail(c, response.CodeForbidden, "数据范围内没有可结算的军团")
+		return
+	}
 	operatorID := middleware.GetUserID(c)
-	result, err := h.svc.SettleMonth(req.Year, req.Month, req.WalletConvert, operatorID, getAllowCorpFilter(c))
+	result, err := h.svc.SettleMonth(req.Year, req.Month, req.WalletConvert, operatorID, corpIDs)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler API Key 管理
+type APIKeyHandler struct {
+	svc *service.APIKeyService
+}
+
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{svc: service.NewAPIKeyService()}
+}
+
+// ListMine 当前用户的 API Key
+//
+// GET /api/v1/me/api-keys
+func (h *APIKeyHandler) ListMine(c *gin.Context) {
+	list, err := h.svc.ListMine(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// GetScopes 当前用户可授予 API Key 的权限范围
+//
+// GET /api/v1/me/api-keys/scopes
+func (h *APIKeyHandler) GetScopes(c *gin.Context) {
+	scopes, err := h.svc.GrantableScopes(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, scopes)
+}
+
+// CreateMine 为当前用户创建 API Key（明文 Key 仅在响应中返回一次）
+//
+// POST /api/v1/me/api-keys
+func (h *APIKeyHandler) CreateMine(c *gin.Context) {
+	var req service.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	userID := middleware.GetUserID(c)
+	result, err := h.svc.Create(c.Request.Context(), userID, userID, &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+// RevokeMine 吊销当前用户的 API Key
+//
+// DELETE /api/v1/me/api-keys/:id
+func (h *APIKeyHandler) RevokeMine(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的 API Key ID")
+		return
+	}
+	if err := h.svc.Revoke(c.Request.Context(), uint(id), middleware.GetUserID(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// List 管理员查询 API Key
+//
+// GET /api/v1/system/api-keys?current=1&size=20&user_id=
+func (h *APIKeyHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("current", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	list, total, err := h.svc.List(page, size, uint(userID))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OKWithPage(c, list, total, page, size)
+}
+
+// Create 管理员为指定用户创建（服务）API Key
+//
+// POST /api/v1/system/api-keys
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req service.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	if req.UserID == 0 {
+		response.Fail(c, response.CodeParamError, "缺少 user_id")
+		return
+	}
+	result, err := h.svc.Create(c.Request.Context(), req.UserID, middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+// Revoke 管理员吊销任意 API Key
+//
+// DELETE /api/v1/system/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的 API Key ID")
+		return
+	}
+	if err := h.svc.Revoke(c.Request.Context(), uint(id), 0); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
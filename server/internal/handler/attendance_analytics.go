@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttendanceAnalyticsHandler 出勤统计：每项统计均提供 JSON 查询与 CSV 导出
+type AttendanceAnalyticsHandler struct {
+	svc *service.AttendanceAnalyticsService
+}
+
+func NewAttendanceAnalyticsHandler() *AttendanceAnalyticsHandler {
+	return &AttendanceAnalyticsHandler{svc: service.NewAttendanceAnalyticsService()}
+}
+
+// queryDays 读取 days 参数，缺省时返回 0 由服务层取默认值
+func queryDays(c *gin.Context) (int, bool) {
+	raw := c.Query("days")
+	if raw == "" {
+		return 0, true
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 0 {
+		response.Fail(c, response.CodeParamError, "无效的天数")
+		return 0, false
+	}
+	return days, true
+}
+
+// writeCSV 以附件形式输出 CSV（带 UTF-8 BOM，便于 Excel 直接打开）
+func writeCSV(c *gin.Context, name string, header []string, records [][]string) {
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	_ = w.Write(header)
+	_ = w.WriteAll(records)
+	filename := fmt.Sprintf("%s-%s.csv", name, time.Now().UTC().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func csvFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+// ─── 滚动出勤 ───
+
+func (h *AttendanceAnalyticsHandler) participation(c *gin.Context) (*service.ParticipationReport, bool) {
+	report, err := h.svc.GetParticipation(c.Query("group_by"), c.DefaultQuery("lang", "zh"), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return nil, false
+	}
+	return report, true
+}
+
+// GetParticipation 滚动 30/90 天出勤
+// GET /operation/analytics/participation?group_by=user|corp|ship_group&lang=zh
+func (h *AttendanceAnalyticsHandler) GetParticipation(c *gin.Context) {
+	if report, ok := h.participation(c); ok {
+		response.OK(c, report)
+	}
+}
+
+// ExportParticipation 导出滚动出勤 CSV
+// GET /operation/analytics/participation/export
+func (h *AttendanceAnalyticsHandler) ExportParticipation(c *gin.Context) {
+	report, ok := h.participation(c)
+	if !ok {
+		return
+	}
+	var header []string
+	switch report.GroupBy {
+	case service.AttendanceGroupCorp:
+		header = []string{"corporation_id"}
+	case service.AttendanceGroupShipGroup:
+		header = []string{"ship_group_id", "ship_group_name"}
+	default:
+		header = []string{"user_id", "nickname", "main_character_name", "corporation_id"}
+	}
+	header = append(header, "members_30", "members_90", "fleets_30", "fleets_90", "pap_30", "pap_90", "rate_30", "rate_90")
+	if report.GroupBy == service.AttendanceGroupUser {
+		header = append(header, "alliance_pap_30", "alliance_pap_90")
+	}
+
+	records := make([][]string, 0, len(report.Rows))
+	for _, r := range report.Rows {
+		var rec []string
+		switch report.GroupBy {
+		case service.AttendanceGroupCorp:
+			rec = []string{strconv.FormatInt(r.CorporationID, 10)}
+		case service.AttendanceGroupShipGroup:
+			rec = []string{strconv.FormatInt(r.ShipGroupID, 10), r.ShipGroupName}
+		default:
+			rec = []string{strconv.FormatUint(uint64(r.UserID), 10), r.Nickname, r.MainCharacterName, strconv.FormatInt(r.CorporationID, 10)}
+		}
+		rec = append(rec,
+			strconv.Itoa(r.Members30), strconv.Itoa(r.Members90),
+			strconv.Itoa(r.Fleets30), strconv.Itoa(r.Fleets90),
+			csvFloat(r.Pap30), csvFloat(r.Pap90),
+			csvFloat(r.Rate30), csvFloat(r.Rate90),
+		)
+		if report.GroupBy == service.AttendanceGroupUser {
+			rec = append(rec, csvFloat(r.AlliancePap30), csvFloat(r.AlliancePap90))
+		}
+		records = append(records, rec)
+	}
+	writeCSV(c, "participation-"+report.GroupBy, header, records)
+}
+
+// ─── 连续出勤 ───
+
+// GetStreaks 最近 52 周连续出勤周数
+// GET /operation/analytics/streaks
+func (h *AttendanceAnalyticsHandler) GetStreaks(c *gin.Context) {
+	list, err := h.svc.GetStreaks(middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// ExportStreaks 导出连续出勤 CSV
+// GET /operation/analytics/streaks/export
+func (h *AttendanceAnalyticsHandler) ExportStreaks(c *gin.Context) {
+	list, err := h.svc.GetStreaks(middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	header := []string{"user_id", "nickname", "main_character_name", "corporation_id", "current_weeks", "longest_weeks", "active_weeks", "last_attended_at"}
+	records := make([][]string, 0, len(list))
+	for _, r := range list {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(r.UserID), 10), r.Nickname, r.MainCharacterName, strconv.FormatInt(r.CorporationID, 10),
+			strconv.Itoa(r.CurrentWeeks), strconv.Itoa(r.LongestWeeks), strconv.Itoa(r.ActiveWeeks), csvTime(r.LastAttendedAt),
+		})
+	}
+	writeCSV(c, "streaks", header, records)
+}
+
+// ─── 仍持有职权的不活跃成员 ───
+
+func (h *AttendanceAnalyticsHandler) inactiveRoles(c *gin.Context) ([]service.InactiveRoleHolder, bool) {
+	days, ok := queryDays(c)
+	if !ok {
+		return nil, false
+	}
+	list, err := h.svc.ListInactiveRoleHolders(days, middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return nil, false
+	}
+	return list, true
+}
+
+// ListInactiveRoleHolders 超过 days 天未出勤但仍持有职权的成员
+// GET /operation/analytics/inactive-roles?days=30
+func (h *AttendanceAnalyticsHandler) ListInactiveRoleHolders(c *gin.Context) {
+	if list, ok := h.inactiveRoles(c); ok {
+		response.OK(c, list)
+	}
+}
+
+// ExportInactiveRoleHolders 导出不活跃职权成员 CSV
+// GET /operation/analytics/inactive-roles/export?days=30
+func (h *AttendanceAnalyticsHandler) ExportInactiveRoleHolders(c *gin.Context) {
+	list, ok := h.inactiveRoles(c)
+	if !ok {
+		return
+	}
+	header := []string{"user_id", "nickname", "main_character_name", "corporation_id", "roles", "last_attended_at", "days_since_attended", "last_login_at"}
+	records := make([][]string, 0, len(list))
+	for _, r := range list {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(r.UserID), 10), r.Nickname, r.MainCharacterName, strconv.FormatInt(r.CorporationID, 10),
+			strings.Join(r.Roles, ";"), csvTime(r.LastAttendedAt), strconv.Itoa(r.DaysSinceAttended), csvTime(r.LastLoginAt),
+		})
+	}
+	writeCSV(c, "inactive-roles", header, records)
+}
+
+// ─── FC 排行 ───
+
+func (h *AttendanceAnalyticsHandler) fcLeaderboard(c *gin.Context) ([]service.FCLeaderboardRow, bool) {
+	days, ok := queryDays(c)
+	if !ok {
+		return nil, false
+	}
+	list, err := h.svc.GetFCLeaderboard(days, middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return nil, false
+	}
+	return list, true
+}
+
+// GetFCLeaderboard FC 带队次数与平均出勤人数排行
+// GET /operation/analytics/fc-leaderboard?days=90
+func (h *AttendanceAnalyticsHandler) GetFCLeaderboard(c *gin.Context) {
+	if list, ok := h.fcLeaderboard(c); ok {
+		response.OK(c, list)
+	}
+}
+
+// ExportFCLeaderboard 导出 FC 排行 CSV
+// GET /operation/analytics/fc-leaderboard/export?days=90
+func (h *AttendanceAnalyticsHandler) ExportFCLeaderboard(c *gin.Context) {
+	list, ok := h.fcLeaderboard(c)
+	if !ok {
+		return
+	}
+	header := []string{"user_id", "nickname", "main_character_name", "fleets", "total_pilots", "avg_pilots", "max_pilots", "total_pap", "last_fleet_at"}
+	records := make([][]string, 0, len(list))
+	for _, r := range list {
+		last := r.LastFleetAt
+		records = append(records, []string{
+			strconv.FormatUint(uint64(r.UserID), 10), r.Nickname, r.MainCharacterName,
+			strconv.Itoa(r.Fleets), strconv.Itoa(r.TotalPilots), csvFloat(r.AvgPilots), strconv.Itoa(r.MaxPilots),
+			csvFloat(r.TotalPap), csvTime(&last),
+		})
+	}
+	writeCSV(c, "fc-leaderboard", header, records)
+}
+
+// ─── 开始时间热力图 ───
+
+func (h *AttendanceAnalyticsHandler) heatmap(c *gin.Context) (*service.FleetHeatmap, bool) {
+	days, ok := queryDays(c)
+	if !ok {
+		return nil, false
+	}
+	heatmap, err := h.svc.GetFleetHeatmap(days, c.Query("tz"), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return nil, false
+	}
+	return heatmap, true
+}
+
+// GetFleetHeatmap 舰队开始时间热力图（星期 × 小时）
+// GET /operation/analytics/heatmap?days=90&tz=Asia/Shanghai
+func (h *AttendanceAnalyticsHandler) GetFleetHeatmap(c *gin.Context) {
+	if heatmap, ok := h.heatmap(c); ok {
+		response.OK(c, heatmap)
+	}
+}
+
+// ExportFleetHeatmap 导出热力图 CSV（每行一个星期 × 小时格子）
+// GET /operation/analytics/heatmap/export?days=90&tz=Asia/Shanghai
+func (h *AttendanceAnalyticsHandler) ExportFleetHeatmap(c *gin.Context) {
+	heatmap, ok := h.heatmap(c)
+	if !ok {
+		return
+	}
+	header := []string{"timezone", "weekday", "hour", "fleets", "pilots", "avg_pilots"}
+	records := make([][]string, 0, 7*24)
+	for d := 0; d < 7; d++ {
+		for hour := 0; hour < 24; hour++ {
+			records = append(records, []string{
+				heatmap.Timezone, time.Weekday(d).String(), strconv.Itoa(hour),
+				strconv.Itoa(heatmap.Fleets[d][hour]), strconv.Itoa(heatmap.Pilots[d][hour]), csvFloat(heatmap.AvgPilots[d][hour]),
+			})
+		}
+	}
+	writeCSV(c, "fleet-heatmap", header, records)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"amiya-eden/internal/middleware"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/service"
 	"amiya-eden/pkg/response"
@@ -186,17 +187,133 @@ func (h *AutoRoleHandler) DeleteSeatRoleMapping(c *gin.Context) {
 	response.OK(c, nil)
 }
 
+// ─── 自动权限规则 ───
+
+// ListAutoRoleRules 获取所有自动权限规则
+func (h *AutoRoleHandler) ListAutoRoleRules(c *gin.Context) {
+	rules, err := h.svc.ListAutoRoleRules()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, rules)
+}
+
+// CreateAutoRoleRule 创建自动权限规则
+func (h *AutoRoleHandler) CreateAutoRoleRule(c *gin.Context) {
+	var req service.AutoRoleRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误")
+		return
+	}
+	rule, err := h.svc.CreateAutoRoleRule(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, rule)
+}
+
+// UpdateAutoRoleRule 更新自动权限规则
+func (h *AutoRoleHandler) UpdateAutoRoleRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的规则ID")
+		return
+	}
+	var req service.AutoRoleRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误")
+		return
+	}
+	rule, err := h.svc.UpdateAutoRoleRule(uint(id), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, rule)
+}
+
+// DeleteAutoRoleRule 删除自动权限规则
+func (h *AutoRoleHandler) DeleteAutoRoleRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的规则ID")
+		return
+	}
+	if err := h.svc.DeleteAutoRoleRule(uint(id)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// DryRunAutoRoleRule 预览规则保存后哪些用户会新增 / 失去哪些角色（不保存规则）
+func (h *AutoRoleHandler) DryRunAutoRoleRule(c *gin.Context) {
+	var req service.AutoRoleRuleDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误")
+		return
+	}
+	result, err := h.svc.DryRunAutoRoleRule(c.Request.Context(), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
 // ─── 手动同步 ───
 
 // TriggerSync 手动触发自动权限同步
+// ?force=true 时跳过移除比例保护（应先通过预览确认变更）
 func (h *AutoRoleHandler) TriggerSync(c *gin.Context) {
+	force := c.Query("force") == "true"
 	go func(ctx context.Context) {
-		h.svc.SyncAllUsersBasicAccess(ctx)
-		h.svc.SyncAllUsersAutoRoles(ctx)
-	}(c.Request.Context())
+		_, _ = h.svc.SyncAllUsersBasicAccess(ctx, force)
+		_, _ = h.svc.SyncAllUsersAutoRoles(ctx, force)
+	}(context.Background())
 	response.OK(c, "同步任务已触发")
 }
 
+// PreviewSync 预览基础准入检查与自动权限同步的变更（不写入数据）
+func (h *AutoRoleHandler) PreviewSync(c *gin.Context) {
+	ctx := c.Request.Context()
+	basic, err := h.svc.PreviewBasicAccess(ctx)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	auto, err := h.svc.PreviewAutoRoleSync(ctx)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, gin.H{
+		"basic_access": basic,
+		"auto_role":    auto,
+	})
+}
+
+// GetSyncGuardConfig 获取批量同步保护配置
+func (h *AutoRoleHandler) GetSyncGuardConfig(c *gin.Context) {
+	response.OK(c, service.GetRoleSyncGuardConfig())
+}
+
+// UpdateSyncGuardConfig 更新批量同步保护配置
+func (h *AutoRoleHandler) UpdateSyncGuardConfig(c *gin.Context) {
+	var req service.RoleSyncGuardConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误")
+		return
+	}
+	if err := service.SetRoleSyncGuardConfig(req); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, req)
+}
+
 // ─── 同步日志 ───
 
 // ListAutoRoleLogs 分页查询自动权限操作日志
@@ -267,7 +384,7 @@ func (h *AutoRoleHandler) RemoveAllowedEntity(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "无效的ID")
 		return
 	}
-	if err := h.svc.RemoveAllowedEntity(uint(id)); err != nil {
+	if err := h.svc.RemoveAllowedEntity(c.Param("type"), uint(id)); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
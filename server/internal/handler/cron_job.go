@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"amiya-eden/jobs"
+	"amiya-eden/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CronJobHandler 定时任务管理处理器
+type CronJobHandler struct{}
+
+func NewCronJobHandler() *CronJobHandler {
+	return &CronJobHandler{}
+}
+
+// GetLeases 查看在线实例以及各定时任务的租约持有者
+//
+// GET /api/v1/system/cron-jobs
+func (h *CronJobHandler) GetLeases(c *gin.Context) {
+	overview, err := jobs.GetCronLeaseOverview(c.Request.Context())
+	if err != nil {
+		response.Fail(c, response.CodeBizError, "查询定时任务租约失败: "+err.Error())
+		return
+	}
+	response.OK(c, overview)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"amiya-eden/internal/repository"
 	"amiya-eden/jobs"
 	"amiya-eden/pkg/eve/esi"
 	"amiya-eden/pkg/response"
@@ -66,7 +67,11 @@ func (h *ESIRefreshHandler) GetStatuses(c *gin.Context) {
 		return
 	}
 
-	all := queue.GetAllStatuses()
+	all, err := queue.GetAllStatuses()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, "查询任务状态失败: "+err.Error())
+		return
+	}
 
 	// 筛选
 	taskNameFilter := c.Query("task_name")
@@ -107,6 +112,106 @@ func (h *ESIRefreshHandler) GetStatuses(c *gin.Context) {
 	response.OKWithPage(c, filtered[start:end], int64(total), current, size)
 }
 
+// GetRuns 分页查询任务执行历史（支持按任务、角色、结果、时间窗口筛选）
+//
+// GET /api/v1/esi/refresh/runs?current=1&size=20&task_name=xxx&character_id=xxx&status=failed&start_time=xxx&end_time=xxx
+// start_time / end_time 支持 RFC3339 或 2006-01-02（end_time 为日期时包含当天）
+func (h *ESIRefreshHandler) GetRuns(c *gin.Context) {
+	queue := jobs.GetESIQueue()
+	if queue == nil {
+		response.OKWithPage(c, []interface{}{}, 0, 1, 20)
+		return
+	}
+
+	current, _ := strconv.Atoi(c.DefaultQuery("current", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if current < 1 {
+		current = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	filter := repository.EsiTaskRunFilter{
+		TaskName: c.Query("task_name"),
+		Status:   c.Query("status"),
+	}
+	if charIDStr := c.Query("character_id"); charIDStr != "" {
+		if cid, err := strconv.ParseInt(charIDStr, 10, 64); err == nil {
+			filter.CharacterID = &cid
+		}
+	}
+	if startStr := c.Query("start_time"); startStr != "" {
+		if t, _, ok := parseTimeQuery(startStr); ok {
+			filter.StartTime = &t
+		}
+	}
+	if endStr := c.Query("end_time"); endStr != "" {
+		if t, dateOnly, ok := parseTimeQuery(endStr); ok {
+			if dateOnly {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			filter.EndTime = &t
+		}
+	}
+
+	list, total, err := queue.ListRuns(current, size, filter)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, "查询执行历史失败: "+err.Error())
+		return
+	}
+	response.OKWithPage(c, list, total, current, size)
+}
+
+// GetBreakers 查看熔断中的 (任务, 角色)：open / half_open / scope_missing 以及连续失败尚未熔断的记录
+//
+// GET /api/v1/esi/refresh/breakers?state=open
+func (h *ESIRefreshHandler) GetBreakers(c *gin.Context) {
+	queue := jobs.GetESIQueue()
+	if queue == nil {
+		response.OK(c, []interface{}{})
+		return
+	}
+	list, err := queue.ListBreakers()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, "查询熔断状态失败: "+err.Error())
+		return
+	}
+	if state := c.Query("state"); state != "" {
+		filtered := list[:0]
+		for _, b := range list {
+			if b.State == state {
+				filtered = append(filtered, b)
+			}
+		}
+		list = filtered
+	}
+	response.OK(c, list)
+}
+
+// ResetBreaker 手动清除熔断状态，下一轮调度立即恢复执行
+//
+// POST /api/v1/esi/refresh/breakers/reset
+func (h *ESIRefreshHandler) ResetBreaker(c *gin.Context) {
+	var req RunTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	queue := jobs.GetESIQueue()
+	if queue == nil {
+		response.Fail(c, response.CodeBizError, "刷新队列未初始化")
+		return
+	}
+
+	if err := queue.ResetBreaker(req.TaskName, req.CharacterID); err != nil {
+		response.Fail(c, response.CodeBizError, "清除熔断状态失败: "+err.Error())
+		return
+	}
+	response.OK(c, gin.H{"message": "熔断状态已清除"})
+}
+
 // RunTaskRequest 手动触发单个任务的请求（指定角色）
 type RunTaskRequest struct {
 	TaskName    string `json:"task_name" binding:"required"`
@@ -179,6 +284,17 @@ func (h *ESIRefreshHandler) RunAll(c *gin.Context) {
 	response.OK(c, gin.H{"message": "全量刷新已触发"})
 }
 
+// parseTimeQuery 解析 RFC3339 或 2006-01-02 格式的时间参数，dateOnly 表示仅包含日期
+func parseTimeQuery(s string) (t time.Time, dateOnly bool, ok bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true, true
+	}
+	return time.Time{}, false, false
+}
+
 // formatDuration 格式化 time.Duration 为可读字符串
 func formatDuration(d time.Duration) string {
 	if d >= 24*time.Hour {
@@ -83,15 +83,21 @@ func (h *EveSSOHandler) Callback(c *gin.Context) {
 		if result.IsRawRedirect {
 			c.Redirect(302, result.RedirectURL)
 		} else {
-			c.Redirect(302, result.RedirectURL+"?token="+result.Token)
+			loginCode, err := service.IssueLoginCode(c.Request.Context(), result.RefreshToken)
+			if err != nil {
+				redirectError("生成登录码失败: " + err.Error())
+				return
+			}
+			c.Redirect(302, result.RedirectURL+"?token="+result.Token+"&login_code="+loginCode)
 		}
 		return
 	}
 
 	response.OK(c, gin.H{
-		"token":     result.Token,
-		"user":      result.User,
-		"character": result.Character,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
+		"user":          result.User,
+		"character":     result.Character,
 	})
 }
 
@@ -119,9 +125,10 @@ func (h *EveSSOHandler) TransferConfirm(c *gin.Context) {
 		return
 	}
 	response.OK(c, gin.H{
-		"token":     result.Token,
-		"user":      result.User,
-		"character": result.Character,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
+		"user":          result.User,
+		"character":     result.Character,
 	})
 }
 
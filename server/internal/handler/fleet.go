@@ -107,7 +107,8 @@ func (h *FleetHandler) RefreshFleetESI(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	fleet, err := h.svc.RefreshESIFleetID(fleetID, userID, userRole)
+	scope := middleware.GetDataScope(c)
+	fleet, err := h.svc.RefreshESIFleetID(fleetID, userID, userRole, scope)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -129,7 +130,8 @@ func (h *FleetHandler) UpdateFleet(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	fleet, err := h.svc.UpdateFleet(fleetID, userID, userRole, &req)
+	scope := middleware.GetDataScope(c)
+	fleet, err := h.svc.UpdateFleet(fleetID, userID, userRole, scope, &req)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -146,7 +148,8 @@ func (h *FleetHandler) DeleteFleet(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	if err := h.svc.DeleteFleet(fleetID, userID, userRole); err != nil {
+	scope := middleware.GetDataScope(c)
+	if err := h.svc.DeleteFleet(fleetID, userID, userRole, scope); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
@@ -204,7 +207,8 @@ func (h *FleetHandler) SyncESIMembers(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	members, err := h.svc.SyncESIMembers(fleetID, userID, userRole)
+	scope := middleware.GetDataScope(c)
+	members, err := h.svc.SyncESIMembers(fleetID, userID, userRole, scope)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -225,19 +229,38 @@ func (h *FleetHandler) IssuePap(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	if err := h.svc.IssuePap(fleetID, userID, userRole); err != nil {
+	scope := middleware.GetDataScope(c)
+	// ?pro_rata=true 无论舰队 PAP 策略的模式，均按持续跟踪记录的在队时长折算
+	proRata := c.Query("pro_rata") == "true"
+	if err := h.svc.IssuePap(fleetID, userID, userRole, scope, proRata); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
 	// PAP 发放成功后异步生成战报
 	go func() {
-		if _, err := h.svc.GenerateBattleReport(fleetID, userID, userRole); err != nil {
+		if _, err := h.svc.GenerateBattleReport(fleetID, userID, userRole, scope); err != nil {
 			global.Logger.Warn("[Fleet] IssuePap 后自动生成战报失败", zap.String("fleet_id", fleetID), zap.Error(err))
 		}
 	}()
 	response.OK(c, nil)
 }
 
+// PreviewPap 按舰队 PAP 策略预览每个成员将获得的 PAP
+// ?pro_rata=true 与发放接口一致，强制按在队时长折算
+func (h *FleetHandler) PreviewPap(c *gin.Context) {
+	fleetID := c.Param("id")
+	if fleetID == "" {
+		response.Fail(c, response.CodeParamError, "缺少舰队ID")
+		return
+	}
+	preview, err := h.svc.PreviewPap(fleetID, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), c.Query("pro_rata") == "true")
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, preview)
+}
+
 // GetPapLogs 获取舰队 PAP 发放记录
 func (h *FleetHandler) GetPapLogs(c *gin.Context) {
 	fleetID := c.Param("id")
@@ -277,7 +300,8 @@ func (h *FleetHandler) CreateInvite(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	invite, err := h.svc.CreateInvite(fleetID, userID, userRole)
+	scope := middleware.GetDataScope(c)
+	invite, err := h.svc.CreateInvite(fleetID, userID, userRole, scope)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -285,6 +309,40 @@ func (h *FleetHandler) CreateInvite(c *gin.Context) {
 	response.OK(c, invite)
 }
 
+// GetTracking 获取舰队持续跟踪复盘（在队时长、提前离队、换船）
+func (h *FleetHandler) GetTracking(c *gin.Context) {
+	fleetID := c.Param("id")
+	if fleetID == "" {
+		response.Fail(c, response.CodeParamError, "缺少舰队ID")
+		return
+	}
+	report, err := h.svc.GetTrackingReport(fleetID, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, report)
+}
+
+// GetTrackingConfig 获取舰队持续跟踪配置
+func (h *FleetHandler) GetTrackingConfig(c *gin.Context) {
+	response.OK(c, service.GetFleetTrackingConfig())
+}
+
+// UpdateTrackingConfig 更新舰队持续跟踪配置
+func (h *FleetHandler) UpdateTrackingConfig(c *gin.Context) {
+	var req service.FleetTrackingConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := service.SetFleetTrackingConfig(req); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, service.GetFleetTrackingConfig())
+}
+
 // GetInvites 获取舰队邀请链接列表
 func (h *FleetHandler) GetInvites(c *gin.Context) {
 	fleetID := c.Param("id")
@@ -292,7 +350,7 @@ func (h *FleetHandler) GetInvites(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "缺少舰队ID")
 		return
 	}
-	invites, err := h.svc.GetInvites(fleetID)
+	invites, err := h.svc.GetInvites(fleetID, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c))
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -307,9 +365,7 @@ func (h *FleetHandler) DeactivateInvite(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "无效的邀请ID")
 		return
 	}
-	userID := middleware.GetUserID(c)
-	userRole := middleware.GetUserRole(c)
-	if err := h.svc.DeactivateInvite(uint(inviteID), userID, userRole); err != nil {
+	if err := h.svc.DeactivateInvite(uint(inviteID), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c)); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
@@ -373,7 +429,8 @@ func (h *FleetHandler) PingFleet(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	if err := h.svc.PingFleet(fleetID, userID, userRole); err != nil {
+	scope := middleware.GetDataScope(c)
+	if err := h.svc.PingFleet(fleetID, userID, userRole, scope); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
@@ -393,7 +450,8 @@ func (h *FleetHandler) GenerateBattleReport(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	result, err := h.svc.GenerateBattleReport(fleetID, userID, userRole)
+	scope := middleware.GetDataScope(c)
+	result, err := h.svc.GenerateBattleReport(fleetID, userID, userRole, scope)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -421,7 +479,8 @@ func (h *FleetHandler) ManualPap(c *gin.Context) {
 	}
 	userID := middleware.GetUserID(c)
 	userRole := middleware.GetUserRole(c)
-	result, err := h.svc.ManualPap(fleetID, userID, userRole, req.Text)
+	scope := middleware.GetDataScope(c)
+	result, err := h.svc.ManualPap(fleetID, userID, userRole, scope, req.Text)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -175,6 +175,31 @@ func (h *FleetConfigHandler) GetFittingItems(c *gin.Context) {
 	response.OK(c, result)
 }
 
+// GetReadiness 舰队配置战备看板
+// GET /operation/fleet-configs/:id/readiness?location_id=&lang=
+func (h *FleetConfigHandler) GetReadiness(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的配置ID")
+		return
+	}
+	var locationID int64
+	if s := c.Query("location_id"); s != "" {
+		locationID, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			response.Fail(c, response.CodeParamError, "无效的位置ID")
+			return
+		}
+	}
+	lang := c.DefaultQuery("lang", "zh")
+	result, err := h.svc.GetReadiness(uint(id), locationID, lang, middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
 // UpdateFittingItemsSettings 批量更新装配物品设置（重要性、惩罚、替代品）
 func (h *FleetConfigHandler) UpdateFittingItemsSettings(c *gin.Context) {
 	configID, err := strconv.ParseUint(c.Param("id"), 10, 64)
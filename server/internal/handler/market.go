@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarketHandler 市场价格 HTTP 处理器
+type MarketHandler struct {
+	svc *service.MarketPriceService
+}
+
+func NewMarketHandler() *MarketHandler {
+	return &MarketHandler{svc: service.NewMarketPriceService()}
+}
+
+// GetPrices godoc
+// GET /api/v1/market/prices?type_ids=587,588
+// 查询物品在各来源的最新价格
+func (h *MarketHandler) GetPrices(c *gin.Context) {
+	var typeIDs []int64
+	for _, s := range strings.Split(c.Query("type_ids"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			response.Fail(c, response.CodeParamError, "无效的 type_id: "+s)
+			return
+		}
+		typeIDs = append(typeIDs, id)
+	}
+	if len(typeIDs) == 0 {
+		response.Fail(c, response.CodeParamError, "type_ids 不能为空")
+		return
+	}
+	prices, err := h.svc.GetPrices(typeIDs)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, prices)
+}
+
+// AppraiseRequest 物品估值请求
+type AppraiseRequest struct {
+	Items []service.ValuationItem `json:"items" binding:"required"`
+	Basis string                  `json:"basis"` // adjusted | average | jita_sell | jita_buy_percentile ...，默认 jita_sell_percentile
+}
+
+// Appraise godoc
+// POST /api/v1/market/appraise
+// 按估价基准对一组物品估值
+func (h *MarketHandler) Appraise(c *gin.Context) {
+	var req AppraiseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "参数错误: "+err.Error())
+		return
+	}
+	basis, err := service.ParsePriceBasis(req.Basis)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, err.Error())
+		return
+	}
+	v, err := h.svc.ValueItems(req.Items, basis)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, v)
+}
+
+// ValueKillmail godoc
+// GET /api/v1/market/killmails/:killmail_id?basis=jita_sell
+// 击杀邮件估值（船体 + 被摧毁 / 掉落物品）
+func (h *MarketHandler) ValueKillmail(c *gin.Context) {
+	killmailID, err := strconv.ParseInt(c.Param("killmail_id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的 killmail_id")
+		return
+	}
+	basis, err := service.ParsePriceBasis(c.Query("basis"))
+	if err != nil {
+		response.Fail(c, response.CodeParamError, err.Error())
+		return
+	}
+	v, err := h.svc.ValueKillmail(killmailID, basis)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, v)
+}
+
+// GetHistory godoc
+// GET /api/v1/market/history/:type_id?source=jita&start_date=2006-01-02&end_date=2006-01-02
+// 查询物品每日价格历史
+func (h *MarketHandler) GetHistory(c *gin.Context) {
+	typeID, err := strconv.ParseInt(c.Param("type_id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的 type_id")
+		return
+	}
+	var start, end *time.Time
+	if s := c.Query("start_date"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			response.Fail(c, response.CodeParamError, "start_date 格式应为 2006-01-02")
+			return
+		}
+		start = &t
+	}
+	if s := c.Query("end_date"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			response.Fail(c, response.CodeParamError, "end_date 格式应为 2006-01-02")
+			return
+		}
+		end = &t
+	}
+	list, err := h.svc.ListHistory(typeID, c.Query("source"), start, end)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// GetStatus godoc
+// GET /api/v1/system/market/status
+// 查询已配置的交易中心及各价格来源最近更新时间
+func (h *MarketHandler) GetStatus(c *gin.Context) {
+	status, err := h.svc.GetStatus()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, status)
+}
+
+// Refresh godoc
+// POST /api/v1/system/market/refresh
+// 手动触发一次价格拉取（后台执行，拉取完整订单簿耗时较长）
+func (h *MarketHandler) Refresh(c *gin.Context) {
+	go func() {
+		_ = h.svc.RefreshAll(context.Background())
+	}()
+	response.OK(c, gin.H{"message": "价格拉取已开始"})
+}
@@ -13,6 +13,7 @@ import (
 type MeHandler struct {
 	userSvc  *service.UserService
 	roleSvc  *service.RoleService
+	tokenSvc *service.TokenHealthService
 	charRepo *repository.EveCharacterRepository
 }
 
@@ -20,11 +21,16 @@ func NewMeHandler() *MeHandler {
 	return &MeHandler{
 		userSvc:  service.NewUserService(),
 		roleSvc:  service.NewRoleService(),
+		tokenSvc: service.NewTokenHealthService(),
 		charRepo: repository.NewEveCharacterRepository(),
 	}
 }
 
 // GetMe 获取当前登录用户信息
+//
+// GET /api/v1/me
+// scoped_grants 为限定军团/联盟范围的角色权限（仅对范围内成员的数据生效）
+// reauth_characters 列出 Token 已失效、需要重新授权的角色（授权链接通过 GET /sso/eve/reauth/:character_id 获取）
 func (h *MeHandler) GetMe(c *gin.Context) {
 	userID := c.GetUint("userID")
 
@@ -48,10 +54,29 @@ func (h *MeHandler) GetMe(c *gin.Context) {
 		permissions = []string{}
 	}
 
+	scopedGrants := middleware.GetScopedGrants(c)
+	if scopedGrants == nil {
+		scopedGrants = []model.ScopedGrant{}
+	}
+
+	reauth := h.tokenSvc.ListReauthRequired(userID, characters)
+
+	// 模拟登录状态（前端据此展示模拟横幅与"结束模拟"入口）
+	var impersonation gin.H
+	if impersonatorID := middleware.GetImpersonatorID(c); impersonatorID != 0 {
+		impersonation = gin.H{
+			"impersonator_id": impersonatorID,
+			"read_only":       middleware.IsReadOnly(c),
+		}
+	}
+
 	response.OK(c, gin.H{
-		"user":        user,
-		"characters":  characters,
-		"roles":       roles,
-		"permissions": permissions,
+		"user":              user,
+		"characters":        characters,
+		"roles":             roles,
+		"permissions":       permissions,
+		"scoped_grants":     scopedGrants,
+		"reauth_characters": reauth,
+		"impersonation":     impersonation,
 	})
 }
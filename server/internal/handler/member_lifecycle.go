@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberLifecycleHandler 成员归属历史 / 离队报表 / 敌对观察名单
+type MemberLifecycleHandler struct {
+	svc *service.MemberLifecycleService
+}
+
+func NewMemberLifecycleHandler() *MemberLifecycleHandler {
+	return &MemberLifecycleHandler{svc: service.NewMemberLifecycleService()}
+}
+
+// GetUserTimeline 用户所有角色的军团 / 联盟归属时间线
+// GET /system/user/:id/affiliation-history
+func (h *MemberLifecycleHandler) GetUserTimeline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	items, err := h.svc.GetUserTimeline(uint(id))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, items)
+}
+
+// ListDepartures 最近 N 天离开军团 / 联盟的角色
+// GET /system/member/departures?days=30&corporation_ids=1,2&current=1&size=20
+func (h *MemberLifecycleHandler) ListDepartures(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("current", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的天数")
+		return
+	}
+	var corpIDs []int64
+	if raw := c.Query("corporation_ids"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				response.Fail(c, response.CodeParamError, "无效的 corporation_ids")
+				return
+			}
+			corpIDs = append(corpIDs, id)
+		}
+	}
+	items, total, err := h.svc.ListDepartures(page, size, days, corpIDs)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OKWithPage(c, items, total, page, size)
+}
+
+// ListHostileWatchlist 获取敌对观察名单
+// GET /system/member/hostile-watchlist
+func (h *MemberLifecycleHandler) ListHostileWatchlist(c *gin.Context) {
+	entities, err := h.svc.ListHostileWatchlist()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, entities)
+}
+
+// AddHostileEntity 添加军团 / 联盟到敌对观察名单
+// POST /system/member/hostile-watchlist
+func (h *MemberLifecycleHandler) AddHostileEntity(c *gin.Context) {
+	var req addAllowedEntityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误")
+		return
+	}
+	e := &model.AllowedEntity{
+		EntityID:   req.EntityID,
+		EntityType: req.EntityType,
+		EntityName: req.EntityName,
+	}
+	if err := h.svc.AddHostileEntity(e); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, e)
+}
+
+// RemoveHostileEntity 从敌对观察名单删除
+// DELETE /system/member/hostile-watchlist/:id
+func (h *MemberLifecycleHandler) RemoveHostileEntity(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的ID")
+		return
+	}
+	if err := h.svc.RemoveHostileEntity(uint(id)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
@@ -32,6 +32,16 @@ func (h *MenuHandler) GetMenuTree(c *gin.Context) {
 	response.OK(c, tree)
 }
 
+// ListPermissions 列出所有权限标识
+func (h *MenuHandler) ListPermissions(c *gin.Context) {
+	perms, err := h.menuSvc.ListPermissions()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, perms)
+}
+
 // GetMenuList 当前用户可用菜单（前端路由格式）
 func (h *MenuHandler) GetMenuList(c *gin.Context) {
 	userID := c.GetUint("userID")
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationCalendarHandler 行动日历 HTTP 处理器
+type OperationCalendarHandler struct {
+	svc *service.OperationCalendarService
+}
+
+func NewOperationCalendarHandler() *OperationCalendarHandler {
+	return &OperationCalendarHandler{svc: service.NewOperationCalendarService()}
+}
+
+// ─────────────────────────────────────────────
+//  行动
+// ─────────────────────────────────────────────
+
+// ListOperations GET /operation/ops?from=&to=
+// 默认查询过去 1 天至未来 30 天
+func (h *OperationCalendarHandler) ListOperations(c *gin.Context) {
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -1), now.AddDate(0, 0, 30)
+	if s := c.Query("from"); s != "" {
+		t, _, ok := parseTimeQuery(s)
+		if !ok {
+			response.Fail(c, response.CodeParamError, "from 格式错误")
+			return
+		}
+		from = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, dateOnly, ok := parseTimeQuery(s)
+		if !ok {
+			response.Fail(c, response.CodeParamError, "to 格式错误")
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	list, err := h.svc.ListOperations(middleware.GetUserID(c), from, to)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// GetOperation GET /operation/ops/:id
+func (h *OperationCalendarHandler) GetOperation(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	op, err := h.svc.GetOperation(middleware.GetUserID(c), id)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, op)
+}
+
+// CreateOperation POST /operation/ops
+func (h *OperationCalendarHandler) CreateOperation(c *gin.Context) {
+	var req service.OperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	op, err := h.svc.CreateOperation(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, op)
+}
+
+// UpdateOperation PUT /operation/ops/:id
+func (h *OperationCalendarHandler) UpdateOperation(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.OperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	op, err := h.svc.UpdateOperation(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, op)
+}
+
+// CancelOperation POST /operation/ops/:id/cancel
+func (h *OperationCalendarHandler) CancelOperation(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.CancelOperation(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// FormUp POST /operation/ops/:id/form-up
+func (h *OperationCalendarHandler) FormUp(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.FormUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	fleet, err := h.svc.FormUp(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, fleet)
+}
+
+// GetTurnout GET /operation/ops/:id/turnout
+func (h *OperationCalendarHandler) GetTurnout(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	turnout, err := h.svc.GetTurnout(id)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, turnout)
+}
+
+// ─────────────────────────────────────────────
+//  报名
+// ─────────────────────────────────────────────
+
+// SetRSVP PUT /operation/ops/:id/rsvp
+func (h *OperationCalendarHandler) SetRSVP(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.RSVPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	rsvp, err := h.svc.SetRSVP(id, middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, rsvp)
+}
+
+// DeleteRSVP DELETE /operation/ops/:id/rsvp
+func (h *OperationCalendarHandler) DeleteRSVP(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteRSVP(id, middleware.GetUserID(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// ─────────────────────────────────────────────
+//  周期模板
+// ─────────────────────────────────────────────
+
+// ListTemplates GET /operation/ops/templates
+func (h *OperationCalendarHandler) ListTemplates(c *gin.Context) {
+	list, err := h.svc.ListTemplates()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// CreateTemplate POST /operation/ops/templates
+func (h *OperationCalendarHandler) CreateTemplate(c *gin.Context) {
+	var req service.OperationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	t, err := h.svc.CreateTemplate(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, t)
+}
+
+// UpdateTemplate PUT /operation/ops/templates/:id
+func (h *OperationCalendarHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.OperationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	t, err := h.svc.UpdateTemplate(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, t)
+}
+
+// DeleteTemplate DELETE /operation/ops/templates/:id
+func (h *OperationCalendarHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteTemplate(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// ─────────────────────────────────────────────
+//  iCal 订阅
+// ─────────────────────────────────────────────
+
+// GetFeedStatus GET /operation/ops/calendar-feed
+func (h *OperationCalendarHandler) GetFeedStatus(c *gin.Context) {
+	status, err := h.svc.GetFeedStatus(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, status)
+}
+
+// ResetFeedToken POST /operation/ops/calendar-feed
+// 令牌明文只在此处返回一次
+func (h *OperationCalendarHandler) ResetFeedToken(c *gin.Context) {
+	token, err := h.svc.ResetFeedToken(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, gin.H{"token": token, "path": "/api/v1/calendar/feed.ics?token=" + token})
+}
+
+// RevokeFeedToken DELETE /operation/ops/calendar-feed
+func (h *OperationCalendarHandler) RevokeFeedToken(c *gin.Context) {
+	if err := h.svc.RevokeFeedToken(middleware.GetUserID(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// Feed GET /calendar/feed.ics?token=（无需登录，凭订阅令牌访问）
+func (h *OperationCalendarHandler) Feed(c *gin.Context) {
+	ics, err := h.svc.RenderFeed(c.Query("token"))
+	if err != nil {
+		c.String(http.StatusNotFound, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(ics))
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PapClaimHandler PAP 补登申请 HTTP 处理器
+type PapClaimHandler struct {
+	svc *service.PapClaimService
+}
+
+func NewPapClaimHandler() *PapClaimHandler {
+	return &PapClaimHandler{svc: service.NewPapClaimService()}
+}
+
+// ─────────────────────────────────────────────
+//  申请人
+// ─────────────────────────────────────────────
+
+// SubmitClaim POST /operation/pap-claims
+func (h *PapClaimHandler) SubmitClaim(c *gin.Context) {
+	var req service.SubmitPapClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	claim, err := h.svc.Submit(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, claim)
+}
+
+// ListMyClaims GET /operation/pap-claims/me
+func (h *PapClaimHandler) ListMyClaims(c *gin.Context) {
+	list, err := h.svc.ListMine(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// WithdrawClaim POST /operation/pap-claims/me/:id/withdraw
+func (h *PapClaimHandler) WithdrawClaim(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Withdraw(middleware.GetUserID(c), id); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// ─────────────────────────────────────────────
+//  审批
+// ─────────────────────────────────────────────
+
+// ListClaims GET /operation/pap-claims?status=&fleet_id=
+func (h *PapClaimHandler) ListClaims(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("current", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	list, total, err := h.svc.List(page, size, c.Query("status"), c.Query("fleet_id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OKWithPage(c, list, total, page, size)
+}
+
+// GetClaim GET /operation/pap-claims/:id
+func (h *PapClaimHandler) GetClaim(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.GetDetail(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, detail)
+}
+
+// RecheckClaim POST /operation/pap-claims/:id/recheck
+func (h *PapClaimHandler) RecheckClaim(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	claim, err := h.svc.Recheck(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, claim)
+}
+
+// ApproveClaim POST /operation/pap-claims/:id/approve
+func (h *PapClaimHandler) ApproveClaim(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.ReviewPapClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	claim, err := h.svc.Approve(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), req.Reason)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, claim)
+}
+
+// RejectClaim POST /operation/pap-claims/:id/reject
+func (h *PapClaimHandler) RejectClaim(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.ReviewPapClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	claim, err := h.svc.Reject(id, middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetDataScope(c), req.Reason)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, claim)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PapPolicyHandler PAP 计算策略 HTTP 处理器
+type PapPolicyHandler struct {
+	svc *service.PapPolicyService
+}
+
+func NewPapPolicyHandler() *PapPolicyHandler {
+	return &PapPolicyHandler{svc: service.NewPapPolicyService()}
+}
+
+// ListPolicies GET /operation/pap-policies
+func (h *PapPolicyHandler) ListPolicies(c *gin.Context) {
+	list, err := h.svc.ListPolicies()
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// CreatePolicy POST /operation/pap-policies
+func (h *PapPolicyHandler) CreatePolicy(c *gin.Context) {
+	var req service.PapPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	p, err := h.svc.CreatePolicy(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, p)
+}
+
+// UpdatePolicy PUT /operation/pap-policies/:id
+func (h *PapPolicyHandler) UpdatePolicy(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.PapPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	p, err := h.svc.UpdatePolicy(id, &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, p)
+}
+
+// DeletePolicy DELETE /operation/pap-policies/:id
+func (h *PapPolicyHandler) DeletePolicy(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeletePolicy(id); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
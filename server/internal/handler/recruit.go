@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecruitHandler 招新 HTTP 处理器
+type RecruitHandler struct {
+	svc *service.RecruitService
+}
+
+func NewRecruitHandler() *RecruitHandler {
+	return &RecruitHandler{svc: service.NewRecruitService()}
+}
+
+// ─────────────────────────────────────────────
+//  申请人
+// ─────────────────────────────────────────────
+
+// GetForm GET /recruit/form
+func (h *RecruitHandler) GetForm(c *gin.Context) {
+	form, err := h.svc.GetForm(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, form)
+}
+
+// SubmitApplication POST /recruit/applications
+func (h *RecruitHandler) SubmitApplication(c *gin.Context) {
+	var req service.SubmitRecruitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	app, err := h.svc.Submit(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, app)
+}
+
+// ListMyApplications GET /recruit/applications/me
+func (h *RecruitHandler) ListMyApplications(c *gin.Context) {
+	list, err := h.svc.ListMine(middleware.GetUserID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// WithdrawApplication POST /recruit/applications/me/:id/withdraw
+func (h *RecruitHandler) WithdrawApplication(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Withdraw(middleware.GetUserID(c), id); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// ─────────────────────────────────────────────
+//  招新官
+// ─────────────────────────────────────────────
+
+// ListApplications GET /recruit/applications?status=&current=&size=
+func (h *RecruitHandler) ListApplications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("current", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	list, total, err := h.svc.List(page, size, c.Query("status"))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OKWithPage(c, list, total, page, size)
+}
+
+// GetApplication GET /recruit/applications/:id
+func (h *RecruitHandler) GetApplication(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.GetDetail(id)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, detail)
+}
+
+// GetVetting GET /recruit/applications/:id/vetting
+func (h *RecruitHandler) GetVetting(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	vetting, err := h.svc.GetVetting(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, vetting)
+}
+
+type recruitCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// AddComment POST /recruit/applications/:id/comments
+func (h *RecruitHandler) AddComment(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req recruitCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	comment, err := h.svc.AddComment(id, middleware.GetUserID(c), req.Content)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, comment)
+}
+
+type recruitStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// UpdateStatus PUT /recruit/applications/:id/status
+func (h *RecruitHandler) UpdateStatus(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req recruitStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := h.svc.UpdateStatus(id, middleware.GetUserID(c), req.Status, req.Reason); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// AcceptApplication PUT /recruit/applications/:id/accept
+func (h *RecruitHandler) AcceptApplication(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.AcceptRecruitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	app, err := h.svc.Accept(c.Request.Context(), id, middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, app)
+}
+
+// RejectApplication PUT /recruit/applications/:id/reject
+func (h *RecruitHandler) RejectApplication(c *gin.Context) {
+	id, ok := parseRecruitID(c)
+	if !ok {
+		return
+	}
+	var req service.RejectRecruitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	app, err := h.svc.Reject(c.Request.Context(), id, middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, app)
+}
+
+// GetConfig GET /recruit/config
+func (h *RecruitHandler) GetConfig(c *gin.Context) {
+	response.OK(c, service.GetRecruitConfig())
+}
+
+// UpdateConfig PUT /recruit/config
+func (h *RecruitHandler) UpdateConfig(c *gin.Context) {
+	var req service.RecruitConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := service.SetRecruitConfig(req); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, service.GetRecruitConfig())
+}
+
+func parseRecruitID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeParamError, "无效的 ID")
+		return 0, false
+	}
+	return uint(id), true
+}
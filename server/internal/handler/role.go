@@ -148,7 +148,7 @@ func (h *RoleHandler) SetRoleMenus(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "请求参数错误")
 		return
 	}
-	if err := h.svc.SetRoleMenus(c.Request.Context(), uint(id), req.MenuIDs); err != nil {
+	if err := h.svc.SetRoleMenus(c.Request.Context(), middleware.GetUserID(c), uint(id), req.MenuIDs); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
@@ -189,8 +189,64 @@ func (h *RoleHandler) SetUserRoles(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "请求参数错误")
 		return
 	}
-	operatorRoles := middleware.GetUserRoles(c)
-	if err := h.svc.SetUserRoles(c.Request.Context(), operatorRoles, uint(userID), req.RoleIDs); err != nil {
+	if err := h.svc.SetUserRoles(c.Request.Context(), middleware.GetUserID(c), uint(userID), req.RoleIDs); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// ─── 限定作用范围的角色 ───
+
+// ListUserRoleScopes GET /system/user/:id/role-scopes
+func (h *RoleHandler) ListUserRoleScopes(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	list, err := h.svc.ListUserRoleScopes(uint(userID))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// AddUserRoleScope POST /system/user/:id/role-scopes
+// 分配限定在某军团/联盟范围内的角色
+func (h *RoleHandler) AddUserRoleScope(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	var req service.AddUserRoleScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	scope, err := h.svc.AddUserRoleScope(c.Request.Context(), middleware.GetUserID(c), uint(userID), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, scope)
+}
+
+// RemoveUserRoleScope DELETE /system/user/:id/role-scopes/:scope_id
+func (h *RoleHandler) RemoveUserRoleScope(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	scopeID, err := strconv.ParseUint(c.Param("scope_id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的ID")
+		return
+	}
+	if err := h.svc.RemoveUserRoleScope(c.Request.Context(), uint(userID), uint(scopeID)); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
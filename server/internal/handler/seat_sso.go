@@ -73,13 +73,19 @@ func (h *SeatSSOHandler) Callback(c *gin.Context) {
 	}
 
 	if result.RedirectURL != "" {
-		c.Redirect(302, result.RedirectURL+"?token="+result.Token+"&provider=seat")
+		loginCode, err := service.IssueLoginCode(c.Request.Context(), result.RefreshToken)
+		if err != nil {
+			redirectError("生成登录码失败: " + err.Error())
+			return
+		}
+		c.Redirect(302, result.RedirectURL+"?token="+result.Token+"&login_code="+loginCode+"&provider=seat")
 		return
 	}
 
 	response.OK(c, gin.H{
-		"token": result.Token,
-		"user":  result.User,
+		"token":         result.Token,
+		"refresh_token": result.RefreshToken,
+		"user":          result.User,
 	})
 }
 
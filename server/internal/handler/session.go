@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录会话（刷新 / 登出 / 设备列表）
+type SessionHandler struct {
+	svc *service.SessionService
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{svc: service.NewSessionService()}
+}
+
+// Refresh 使用 Refresh Token 换取新的 Access Token（Refresh Token 同时轮换）
+//
+// POST /api/v1/auth/refresh
+func (h *SessionHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "缺少 refresh_token 参数")
+		return
+	}
+	tokens, err := h.svc.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		response.Fail(c, response.CodeUnauthorized, err.Error())
+		return
+	}
+	response.OK(c, tokens)
+}
+
+// Exchange 使用 SSO 回调重定向携带的一次性登录码换取 Refresh Token
+//
+// POST /api/v1/auth/exchange
+func (h *SessionHandler) Exchange(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "缺少 code 参数")
+		return
+	}
+	token, err := h.svc.ExchangeLoginCode(c.Request.Context(), req.Code)
+	if err != nil {
+		response.Fail(c, response.CodeUnauthorized, err.Error())
+		return
+	}
+	response.OK(c, gin.H{"refresh_token": token})
+}
+
+// Logout 登出当前会话
+//
+// POST /api/v1/auth/logout
+func (h *SessionHandler) Logout(c *gin.Context) {
+	if err := h.svc.Logout(c.Request.Context(), middleware.GetSessionID(c)); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// EndImpersonation 结束模拟登录，返回管理员自己会话的新 Token
+//
+// POST /api/v1/auth/impersonation/end
+func (h *SessionHandler) EndImpersonation(c *gin.Context) {
+	if middleware.GetImpersonatorID(c) == 0 {
+		response.Fail(c, response.CodeBizError, "当前不在模拟登录中")
+		return
+	}
+	tokens, err := h.svc.EndImpersonation(c.Request.Context(), middleware.GetSessionID(c))
+	if err != nil {
+		response.Fail(c, response.CodeUnauthorized, err.Error())
+		return
+	}
+	response.OK(c, tokens)
+}
+
+// ListMySessions 当前用户的登录设备列表
+//
+// GET /api/v1/me/sessions
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	list, err := h.svc.ListSessions(middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// RevokeMySession 登出当前用户的某个会话
+//
+// DELETE /api/v1/me/sessions/:id
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	if err := h.svc.RevokeOwn(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, nil)
+}
+
+// RevokeOtherSessions 登出当前会话以外的所有会话
+//
+// POST /api/v1/me/sessions/revoke-others
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	count, err := h.svc.RevokeOthers(c.Request.Context(), middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, gin.H{"revoked": count})
+}
+
+// ListUserSessions 管理员查看用户的登录会话
+//
+// GET /api/v1/system/user/:id/sessions
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	list, err := h.svc.ListSessions(uint(id), "")
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, list)
+}
+
+// RevokeUserSessions 管理员强制下线用户的所有会话
+//
+// DELETE /api/v1/system/user/:id/sessions
+func (h *SessionHandler) RevokeUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的用户ID")
+		return
+	}
+	count, err := h.svc.RevokeAll(c.Request.Context(), uint(id), model.SessionRevokeByAdmin)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, gin.H{"revoked": count})
+}
@@ -166,6 +166,8 @@ func (h *SrpHandler) ListApplications(c *gin.Context) {
 		}
 	}
 
+	filter.Scope = middleware.GetDataScope(c)
+
 	list, total, err := h.svc.ListApplications(page, size, filter)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
@@ -182,6 +184,10 @@ func (h *SrpHandler) GetApplication(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "无效的 ID")
 		return
 	}
+	if err := h.svc.CheckApplicationScope(uint(id), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
 	app, err := h.svc.GetApplication(uint(id))
 	if err != nil {
 		response.Fail(c, response.CodeNotFound, "申请不存在")
@@ -203,6 +209,10 @@ func (h *SrpHandler) ReviewApplication(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
 		return
 	}
+	if err := h.svc.CheckApplicationScope(uint(id), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
 	reviewerID := middleware.GetUserID(c)
 	app, err := h.svc.ReviewApplication(reviewerID, uint(id), &req)
 	if err != nil {
@@ -225,6 +235,10 @@ func (h *SrpHandler) Payout(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
 		return
 	}
+	if err := h.svc.CheckApplicationScope(uint(id), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
 	payerID := middleware.GetUserID(c)
 	app, err := h.svc.Payout(payerID, uint(id), &req)
 	if err != nil {
@@ -234,6 +248,120 @@ func (h *SrpHandler) Payout(c *gin.Context) {
 	response.OK(c, app)
 }
 
+// RevokeApplication PUT /srp/applications/:id/revoke
+// 撤销已批准的申请，已通过系统钱包发放的金额会被追回
+func (h *SrpHandler) RevokeApplication(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeParamError, "无效的 ID")
+		return
+	}
+	var req service.RevokeApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := h.svc.CheckApplicationScope(uint(id), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
+	app, err := h.svc.RevokeApplication(middleware.GetUserID(c), uint(id), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, app)
+}
+
+// BatchPayFleet POST /srp/fleets/:fleet_id/payout
+// 发放舰队下所有已批准、待发放的申请
+func (h *SrpHandler) BatchPayFleet(c *gin.Context) {
+	result, err := h.svc.BatchPayFleet(middleware.GetUserID(c), c.Param("fleet_id"), middleware.GetDataScope(c))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, result)
+}
+
+// GetPayoutConfig GET /srp/payout-config
+func (h *SrpHandler) GetPayoutConfig(c *gin.Context) {
+	response.OK(c, h.svc.GetPayoutConfig())
+}
+
+// UpdatePayoutConfig PUT /srp/payout-config
+func (h *SrpHandler) UpdatePayoutConfig(c *gin.Context) {
+	var req service.SrpPayoutConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	cfg, err := h.svc.UpdatePayoutConfig(&req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, cfg)
+}
+
+// RepriceApplication PUT /srp/applications/:id/reprice
+// 按当前定价策略重新计算推荐金额（仅限待审批 / 已拒绝的申请）
+func (h *SrpHandler) RepriceApplication(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeParamError, "无效的 ID")
+		return
+	}
+	if err := h.svc.CheckApplicationScope(uint(id), middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
+	app, err := h.svc.RepriceApplication(uint(id))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, app)
+}
+
+// QuoteKillmail POST /srp/killmails/quote
+// 提交前预览推荐金额及明细
+func (h *SrpHandler) QuoteKillmail(c *gin.Context) {
+	var req service.QuoteKillmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	quote, err := h.svc.QuoteKillmail(middleware.GetUserID(c), &req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, quote)
+}
+
+// GetPricingConfig GET /srp/pricing-config
+func (h *SrpHandler) GetPricingConfig(c *gin.Context) {
+	response.OK(c, h.svc.GetPricingConfig())
+}
+
+// UpdatePricingConfig PUT /srp/pricing-config
+func (h *SrpHandler) UpdatePricingConfig(c *gin.Context) {
+	var req service.SrpPricingConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeParamError, "请求参数错误: "+err.Error())
+		return
+	}
+	cfg, err := h.svc.UpdatePricingConfig(&req)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, cfg)
+}
+
 // OpenInfoWindow POST /srp/open-info-window
 // 通过 ESI 在客户端打开角色信息窗口
 func (h *SrpHandler) OpenInfoWindow(c *gin.Context) {
@@ -72,7 +72,7 @@ func (h *SysWalletHandler) AdminListWallets(c *gin.Context) {
 		req.Size = 20
 	}
 
-	wallets, total, err := h.svc.AdminListWallets(req.Current, req.Size)
+	wallets, total, err := h.svc.AdminListWallets(req.Current, req.Size, middleware.GetDataScope(c))
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
@@ -94,6 +94,11 @@ func (h *SysWalletHandler) AdminGetWallet(c *gin.Context) {
 		return
 	}
 
+	if err := service.CheckUserScope(req.UserID, middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
+
 	wallet, err := h.svc.AdminGetWallet(req.UserID)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
@@ -111,6 +116,11 @@ func (h *SysWalletHandler) AdminAdjust(c *gin.Context) {
 		return
 	}
 
+	if err := service.CheckUserScope(req.TargetUID, middleware.GetDataScope(c)); err != nil {
+		response.Fail(c, response.CodeForbidden, err.Error())
+		return
+	}
+
 	operatorID := middleware.GetUserID(c)
 	wallet, err := h.svc.AdminAdjust(operatorID, &req)
 	if err != nil {
@@ -140,6 +150,7 @@ func (h *SysWalletHandler) AdminListTransactions(c *gin.Context) {
 	filter := repository.WalletTransactionFilter{
 		UserID:  req.UserID,
 		RefType: req.RefType,
+		Scope:   middleware.GetDataScope(c),
 	}
 
 	records, total, err := h.svc.AdminListTransactions(req.Current, req.Size, filter)
@@ -172,6 +183,7 @@ func (h *SysWalletHandler) AdminListLogs(c *gin.Context) {
 		OperatorID: req.OperatorID,
 		TargetUID:  req.TargetUID,
 		Action:     req.Action,
+		Scope:      middleware.GetDataScope(c),
 	}
 
 	records, total, err := h.svc.AdminListLogs(req.Current, req.Size, filter)
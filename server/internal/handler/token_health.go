@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"amiya-eden/internal/middleware"
+	"amiya-eden/internal/service"
+	"amiya-eden/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenHealthHandler ESI Token 健康状态处理器
+type TokenHealthHandler struct {
+	svc *service.TokenHealthService
+}
+
+func NewTokenHealthHandler() *TokenHealthHandler {
+	return &TokenHealthHandler{svc: service.NewTokenHealthService()}
+}
+
+// GetCorpReport 按军团查看 Token 已失效的成员角色
+//
+// GET /api/v1/system/token-health?corporation_id=xxx（不传则返回所有军团）
+func (h *TokenHealthHandler) GetCorpReport(c *gin.Context) {
+	var corporationID int64
+	if corpStr := c.Query("corporation_id"); corpStr != "" {
+		id, err := strconv.ParseInt(corpStr, 10, 64)
+		if err != nil {
+			response.Fail(c, response.CodeParamError, "无效的 corporation_id")
+			return
+		}
+		corporationID = id
+	}
+	reports, err := h.svc.GetCorpReport(corporationID)
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, reports)
+}
+
+// Reauth 为 Token 已失效的角色生成重新授权链接（用户点击重新授权时调用）
+//
+// GET /api/v1/sso/eve/reauth/:character_id?redirect=xxx
+func (h *TokenHealthHandler) Reauth(c *gin.Context) {
+	characterID, err := strconv.ParseInt(c.Param("character_id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeParamError, "无效的角色ID")
+		return
+	}
+	url, err := h.svc.GetReauthURL(c.Request.Context(), middleware.GetUserID(c), characterID, c.Query("redirect"))
+	if err != nil {
+		response.Fail(c, response.CodeBizError, err.Error())
+		return
+	}
+	response.OK(c, gin.H{"url": url})
+}
@@ -86,7 +86,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	if req.Status != nil {
 		user.Status = *req.Status
 	}
-	if err := h.svc.UpdateUser(user); err != nil {
+	if err := h.svc.UpdateUser(c.Request.Context(), user); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
@@ -99,27 +99,44 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		response.Fail(c, response.CodeParamError, "无效的用户ID")
 		return
 	}
-	if err := h.svc.DeleteUser(uint(id)); err != nil {
+	if err := h.svc.DeleteUser(c.Request.Context(), uint(id)); err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
 	response.OK(c, nil)
 }
 
-// ImpersonateUser 以指定用户身份签发 JWT（仅超级管理员可用）
+// ImpersonateUser 以指定用户身份签发限时模拟登录 Token（仅超级管理员可用）
+//
+// POST /api/v1/system/user/:id/impersonate  body（可选）: {"read_only": true, "minutes": 15}
 func (h *UserHandler) ImpersonateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		response.Fail(c, response.CodeParamError, "无效的用户ID")
 		return
 	}
-	token, user, err := h.svc.ImpersonateUser(uint(id))
+	if middleware.GetImpersonatorID(c) != 0 {
+		response.Fail(c, response.CodeForbidden, "模拟登录中不能再次发起模拟")
+		return
+	}
+	var req service.ImpersonationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Fail(c, response.CodeParamError, "请求参数错误")
+			return
+		}
+	}
+	tokens, user, err := h.svc.ImpersonateUser(c.Request.Context(),
+		middleware.GetUserID(c), middleware.GetSessionID(c), uint(id), req)
 	if err != nil {
 		response.Fail(c, response.CodeBizError, err.Error())
 		return
 	}
 	response.OK(c, gin.H{
-		"token": token,
-		"user":  user,
+		"token":           tokens.AccessToken,
+		"expires_in":      tokens.ExpiresIn,
+		"impersonator_id": tokens.ImpersonatorID,
+		"read_only":       tokens.ReadOnly,
+		"user":            user,
 	})
 }
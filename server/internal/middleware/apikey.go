@@ -1,27 +1,88 @@
 package middleware
 
 import (
-	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/service"
 	"amiya-eden/pkg/response"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 )
 
-// APIKeyAuth API Key 鉴权中间件
-// 从 Header X-API-Key 或 Query 参数 api_key 中读取。
-func APIKeyAuth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		key := c.GetHeader("X-API-Key")
-		if key == "" {
-			key = c.Query("api_key")
+const (
+	ctxKeyAPIKeyID   = "apiKeyID"
+	ctxKeyAPIKeyFull = "apiKeyFullScope" // Key 拥有 * 权限范围
+)
+
+// apiKeyAuth API Key 鉴权（由 JWTAuth 在请求未携带 Bearer Token 时调用）
+// Key 以所属用户身份访问，权限为 scopes 与用户权限的交集；
+// 非 * 范围的 Key 不能通过 RequireRole，也不享有超级管理员的权限豁免
+func apiKeyAuth(c *gin.Context, keySvc *service.APIKeyService, roleSvc *service.RoleService, raw string) {
+	ident, err := keySvc.Authenticate(c.Request.Context(), raw, c.ClientIP())
+	if err != nil {
+		code := response.CodeUnauthorized
+		if errors.Is(err, service.ErrAPIKeyRateLimited) {
+			code = response.CodeTooMany
 		}
+		response.Fail(c, code, err.Error())
+		c.Abort()
+		return
+	}
+	key := &ident.Key
+	if key.ReadOnly && !isSafeMethod(c.Request.Method) {
+		response.Fail(c, response.CodeForbidden, "只读 API Key，禁止执行写操作")
+		c.Abort()
+		return
+	}
+
+	roles, err := roleSvc.GetUserRoleNames(c.Request.Context(), key.UserID)
+	if err != nil {
+		roles = []string{model.RoleGuest}
+	}
+	userPerms, err := roleSvc.GetUserPermissions(c.Request.Context(), key.UserID)
+	if err != nil {
+		userPerms = []string{}
+	}
+	perms, full := service.EffectivePermissions(key, roles, userPerms)
+	grants, err := roleSvc.GetUserScopedGrants(c.Request.Context(), key.UserID)
+	if err != nil {
+		grants = nil
+	}
 
-		expected := global.Config.SDE.APIKey
-		if expected == "" || key != expected {
-			response.Fail(c, response.CodeUnauthorized, "无效的 API Key")
+	c.Set(ctxKeyUserID, key.UserID)
+	c.Set(ctxKeyCharacterID, ident.CharacterID)
+	c.Set(ctxKeyUserRole, ident.Role)
+	c.Set(ctxKeyAPIKeyID, key.ID)
+	c.Set(ctxKeyAPIKeyFull, full)
+	c.Set(ctxKeyRoles, roles)
+	c.Set(ctxKeyPermissions, perms)
+	c.Set(ctxKeyScopedGrant, service.EffectiveScopedGrants(key, grants))
+	c.Next()
+}
+
+// RequireSession 要求请求来自登录会话（拒绝 API Key），用于 Key 管理、会话管理、模拟登录等敏感接口
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetAPIKeyID(c) != 0 {
+			response.Fail(c, response.CodeForbidden, "该接口不支持 API Key 访问")
 			c.Abort()
 			return
 		}
 		c.Next()
 	}
 }
+
+// GetAPIKeyID 获取当前请求使用的 API Key ID（非 API Key 请求返回 0）
+func GetAPIKeyID(c *gin.Context) uint {
+	return c.GetUint(ctxKeyAPIKeyID)
+}
+
+// isScopedAPIKey 当前请求是否为受权限范围限制（非 *）的 API Key
+func isScopedAPIKey(c *gin.Context) bool {
+	return GetAPIKeyID(c) != 0 && !c.GetBool(ctxKeyAPIKeyFull)
+}
+
+// extractAPIKey 仅从 X-API-Key 请求头读取 API Key；不接受 Query 参数，避免明文 Key 写入访问日志与操作日志
+func extractAPIKey(c *gin.Context) string {
+	return c.GetHeader("X-API-Key")
+}
@@ -16,16 +16,35 @@ const (
 	ctxKeyUserRole    = "userRole" // JWT 中的单角色字段（向后兼容）
 	ctxKeyRoles       = "roles"
 	ctxKeyPermissions = "permissions"
+	ctxKeySessionID   = "sessionID"
+	ctxKeyScopedGrant = "scopedGrants" // 限定作用范围的角色权限
+	ctxKeyDataScope   = "dataScope"    // 本次请求的数据范围（经限定范围权限放行时设置）
+
+	ctxKeyImpersonatorID = "impersonatorID" // 模拟登录的发起管理员 ID
+	ctxKeyReadOnly       = "readOnly"       // 只读模拟登录
 )
 
+// readOnlyAllowedPaths 只读模拟登录下仍允许的写操作（结束模拟 / 登出）
+var readOnlyAllowedPaths = map[string]bool{
+	"/api/v1/auth/impersonation/end": true,
+	"/api/v1/auth/logout":            true,
+}
+
 // JWTAuth JWT 认证中间件
-// 解析 Token，加载用户角色与权限到 context
+// 解析 Token，校验会话未被吊销，加载用户角色与权限到 context；
+// 未携带 Bearer Token 时接受 API Key（仅限请求头 X-API-Key，不接受 Query 参数）
 func JWTAuth() gin.HandlerFunc {
 	roleSvc := service.NewRoleService()
+	sessionSvc := service.NewSessionService()
+	keySvc := service.NewAPIKeyService()
 
 	return func(c *gin.Context) {
 		token := extractToken(c)
 		if token == "" {
+			if key := extractAPIKey(c); key != "" {
+				apiKeyAuth(c, keySvc, roleSvc, key)
+				return
+			}
 			response.Fail(c, response.CodeUnauthorized, "未提供认证令牌")
 			c.Abort()
 			return
@@ -37,8 +56,24 @@ func JWTAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if err := sessionSvc.Validate(c.Request.Context(), claims, c.ClientIP()); err != nil {
+			response.Fail(c, response.CodeUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+
+		if claims.ReadOnly && !isSafeMethod(c.Request.Method) && !readOnlyAllowedPaths[c.FullPath()] {
+			response.Fail(c, response.CodeForbidden, "只读模拟登录，禁止执行写操作")
+			c.Abort()
+			return
+		}
 
 		c.Set(ctxKeyUserID, claims.UserID)
+		c.Set(ctxKeySessionID, claims.SessionID)
+		if claims.ImpersonatorID != 0 {
+			c.Set(ctxKeyImpersonatorID, claims.ImpersonatorID)
+			c.Set(ctxKeyReadOnly, claims.ReadOnly)
+		}
 		c.Set(ctxKeyCharacterID, claims.CharacterID)
 		c.Set(ctxKeyUserRole, claims.Role) // JWT 单角色（向后兼容）
 
@@ -56,13 +91,26 @@ func JWTAuth() gin.HandlerFunc {
 		}
 		c.Set(ctxKeyPermissions, perms)
 
+		// 加载限定作用范围的角色权限（带 Redis 缓存）
+		grants, err := roleSvc.GetUserScopedGrants(c.Request.Context(), claims.UserID)
+		if err == nil {
+			c.Set(ctxKeyScopedGrant, grants)
+		}
+
 		c.Next()
 	}
 }
 
 // RequireRole 要求用户拥有指定角色之一（super_admin 自动通过）
+// 只认全局角色：限定军团/联盟范围的角色不能通过（按数据范围鉴权请使用 RequirePermission）
+// 受权限范围限制的 API Key 一律拒绝（需要 * 范围）
 func RequireRole(codes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if isScopedAPIKey(c) {
+			response.Fail(c, response.CodeForbidden, "API Key 权限范围不足，该接口需要 * 权限范围")
+			c.Abort()
+			return
+		}
 		roles := GetUserRoles(c)
 		for _, code := range codes {
 			if model.HasAnyRoleMatch(roles, code) {
@@ -77,28 +125,52 @@ func RequireRole(codes ...string) gin.HandlerFunc {
 
 // RequirePermission 要求用户拥有指定权限标识之一（super_admin 自动通过）
 // 支持前缀继承：用户拥有 "srp" 时，自动满足 "srp:review"、"srp:price:edit" 等子权限
+// 受权限范围限制的 API Key 只按其 scopes 判断，不享有 super_admin 豁免
+// 全局权限不满足时，按限定军团/联盟范围的角色放行，并在 context 中写入数据范围（见 GetDataScope），
+// 同一请求经多个 RequirePermission 时数据范围取交集
 func RequirePermission(perms ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roles := GetUserRoles(c)
-		if model.IsSuperAdmin(roles) {
+		if model.IsSuperAdmin(roles) && !isScopedAPIKey(c) {
 			c.Next()
 			return
 		}
 		userPerms := GetUserPermissions(c)
 		for _, required := range perms {
-			for _, have := range userPerms {
-				// 精确匹配，或拥有父级权限（前缀 + ":" 匹配）
-				if have == required || strings.HasPrefix(required, have+":") {
-					c.Next()
-					return
-				}
+			// 精确匹配，或拥有父级权限（前缀 + ":" 匹配）
+			if model.HasAnyPermission(userPerms, required) {
+				c.Next()
+				return
 			}
 		}
+		if scope := scopedGrantScope(c, perms); scope != nil {
+			c.Set(ctxKeyDataScope, GetDataScope(c).Intersect(scope))
+			c.Next()
+			return
+		}
 		response.Fail(c, response.CodeForbidden, "权限不足，需要权限: "+strings.Join(perms, "/"))
 		c.Abort()
 	}
 }
 
+// scopedGrantScope 汇总满足任一权限的限定范围角色的军团（无满足的角色返回 nil）
+func scopedGrantScope(c *gin.Context, perms []string) *model.DataScope {
+	var scope *model.DataScope
+	for _, grant := range GetScopedGrants(c) {
+		for _, required := range perms {
+			if !model.HasAnyPermission(grant.Permissions, required) {
+				continue
+			}
+			if scope == nil {
+				scope = &model.DataScope{CorporationIDs: make([]int64, 0)}
+			}
+			scope.CorporationIDs = append(scope.CorporationIDs, grant.CorporationIDs...)
+			break
+		}
+	}
+	return scope
+}
+
 // ─── Context 辅助函数 ───
 
 func GetUserID(c *gin.Context) uint {
@@ -113,6 +185,21 @@ func GetCharacterID(c *gin.Context) int64 {
 	return 0
 }
 
+// GetSessionID 获取当前请求所属的登录会话 ID
+func GetSessionID(c *gin.Context) string {
+	return c.GetString(ctxKeySessionID)
+}
+
+// GetImpersonatorID 获取模拟登录的发起管理员 ID（非模拟登录返回 0）
+func GetImpersonatorID(c *gin.Context) uint {
+	return c.GetUint(ctxKeyImpersonatorID)
+}
+
+// IsReadOnly 当前请求是否为只读模拟登录
+func IsReadOnly(c *gin.Context) bool {
+	return c.GetBool(ctxKeyReadOnly)
+}
+
 // GetUserRole 获取 JWT 中的单角色字段（向后兼容 fleet 等模块）
 func GetUserRole(c *gin.Context) string {
 	return c.GetString(ctxKeyUserRole)
@@ -136,6 +223,30 @@ func GetUserPermissions(c *gin.Context) []string {
 	return perms
 }
 
+// GetScopedGrants 获取用户限定作用范围的角色权限
+func GetScopedGrants(c *gin.Context) []model.ScopedGrant {
+	v, exists := c.Get(ctxKeyScopedGrant)
+	if !exists {
+		return nil
+	}
+	grants, _ := v.([]model.ScopedGrant)
+	return grants
+}
+
+// GetDataScope 获取本次请求的数据范围；nil 表示不限制（全局权限放行）
+func GetDataScope(c *gin.Context) *model.DataScope {
+	v, exists := c.Get(ctxKeyDataScope)
+	if !exists {
+		return nil
+	}
+	scope, _ := v.(*model.DataScope)
+	return scope
+}
+
+func isSafeMethod(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "OPTIONS"
+}
+
 func extractToken(c *gin.Context) string {
 	auth := c.GetHeader("Authorization")
 	if strings.HasPrefix(auth, "Bearer ") {
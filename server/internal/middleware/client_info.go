@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"amiya-eden/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo 将客户端 IP 与 User-Agent 写入请求 context，供登录 / 刷新时记录会话设备信息
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithClientInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
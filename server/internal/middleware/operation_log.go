@@ -3,6 +3,7 @@ package middleware
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +15,31 @@ var opLogSkipPaths = map[string]bool{
 	"/health": true,
 }
 
+// opLogRedactParams 记录前脱敏的 Query 参数（凭据 / 一次性令牌）
+var opLogRedactParams = []string{"api_key", "token", "refresh_token", "login_code", "code"}
+
+// redactQuery 将凭据类 Query 参数替换为 "***"
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+	changed := false
+	for _, key := range opLogRedactParams {
+		if _, ok := values[key]; ok {
+			values[key] = []string{"***"}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return values.Encode()
+}
+
 // CtxKeyBizCode context 中存储业务码的 key（由 ResponseWrapper 写入）
 const CtxKeyBizCode = "biz_code"
 
@@ -38,10 +64,10 @@ func OperationLog() gin.HandlerFunc {
 		latencyMs := time.Since(start).Milliseconds()
 
 		// 操作人信息（由 JWT 中间件写入 context，无鉴权时为零值）
-		userID, _ := c.Get("user_id")
+		// 模拟登录时同时记录被模拟的用户与发起模拟的管理员
+		uid := GetUserID(c)
+		impersonatorID := GetImpersonatorID(c)
 		username, _ := c.Get("username")
-
-		uid, _ := userID.(uint)
 		uname, _ := username.(string)
 
 		// biz_code 由 ResponseWrapper 在其 defer 中写入 context
@@ -49,17 +75,18 @@ func OperationLog() gin.HandlerFunc {
 		biz, _ := bizCode.(int)
 
 		entry := model.OperationLog{
-			RequestID:  c.GetString("request-id"),
-			UserID:     uid,
-			Username:   uname,
-			IP:         c.ClientIP(),
-			Method:     c.Request.Method,
-			Path:       path,
-			Query:      c.Request.URL.RawQuery,
-			StatusCode: c.Writer.Status(),
-			BizCode:    biz,
-			LatencyMs:  latencyMs,
-			UserAgent:  c.Request.UserAgent(),
+			RequestID:      c.GetString("request-id"),
+			UserID:         uid,
+			ImpersonatorID: impersonatorID,
+			Username:       uname,
+			IP:             c.ClientIP(),
+			Method:         c.Request.Method,
+			Path:           path,
+			Query:          redactQuery(c.Request.URL.RawQuery),
+			StatusCode:     c.Writer.Status(),
+			BizCode:        biz,
+			LatencyMs:      latencyMs,
+			UserAgent:      c.Request.UserAgent(),
 		}
 
 		// 异步写入 DB，不阻塞响应
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// ─────────────────────────────────────────────
+//  成员归属历史
+//  affiliation 刷新发现角色军团 / 联盟变化时写入加入、离开事件
+// ─────────────────────────────────────────────
+
+// 归属事件类型
+const (
+	AffiliationEventCorpJoin      = "corp_join"
+	AffiliationEventCorpLeave     = "corp_leave"
+	AffiliationEventAllianceJoin  = "alliance_join"
+	AffiliationEventAllianceLeave = "alliance_leave"
+)
+
+// WatchListHostile 敌对势力观察名单（复用 allowed_entity 表）
+const WatchListHostile = "hostile_watch"
+
+// CharacterAffiliationEvent 角色归属变更事件
+// 一次变更按需拆分为多条事件，共享同一组 from / to（0 表示无军团 / 无联盟）
+type CharacterAffiliationEvent struct {
+	ID                uint      `gorm:"primarykey"                 json:"id"`
+	CharacterID       int64     `gorm:"not null;index"             json:"character_id"`
+	UserID            uint      `gorm:"not null;index"             json:"user_id"`
+	EventType         string    `gorm:"size:16;not null;index"     json:"event_type"` // corp_join | corp_leave | alliance_join | alliance_leave
+	FromCorporationID int64     `gorm:"not null;default:0;index"   json:"from_corporation_id"`
+	FromAllianceID    int64     `gorm:"not null;default:0;index"   json:"from_alliance_id"`
+	ToCorporationID   int64     `gorm:"not null;default:0"         json:"to_corporation_id"`
+	ToAllianceID      int64     `gorm:"not null;default:0"         json:"to_alliance_id"`
+	Initial           bool      `gorm:"not null;default:false"     json:"initial"` // 首次获取到归属（绑定时的已有归属，非真实加入时间）
+	Hostile           bool      `gorm:"not null;default:false"     json:"hostile"` // 新归属命中敌对观察名单
+	OccurredAt        time.Time `gorm:"autoCreateTime;index"       json:"occurred_at"`
+}
+
+func (CharacterAffiliationEvent) TableName() string { return "character_affiliation_event" }
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKeyScopeAll 全权限范围：Key 拥有所属用户的全部权限
+const APIKeyScopeAll = "*"
+
+// APIKey 个人 / 服务 API Key（绑定用户，供外部机器人、表格等调用接口）
+// 明文只在创建时返回一次，库中仅保存 SHA-256
+type APIKey struct {
+	BaseModel
+	UserID     uint       `gorm:"not null;index"         json:"user_id"`
+	Name       string     `gorm:"size:100;not null"      json:"name"`
+	Prefix     string     `gorm:"size:16"                json:"prefix"` // 明文前缀，便于识别
+	KeyHash    string     `gorm:"size:64;uniqueIndex"    json:"-"`
+	Scopes     string     `gorm:"type:text"              json:"scopes"`     // 空格分隔的权限标识（与 RequirePermission 一致），* 表示全部
+	ReadOnly   bool       `gorm:"default:false"          json:"read_only"`  // 只读：禁止写操作
+	RateLimit  int        `gorm:"default:60"             json:"rate_limit"` // 每分钟请求上限
+	ExpiresAt  *time.Time `gorm:"index"                  json:"expires_at"` // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:64"                json:"last_used_ip"`
+	CreatedBy  uint       `gorm:"default:0"              json:"created_by"` // 创建人（管理员为他人创建服务 Key 时与 UserID 不同）
+}
+
+func (APIKey) TableName() string { return "api_key" }
+
+// ScopeList 返回权限范围列表
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
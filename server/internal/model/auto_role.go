@@ -69,6 +69,60 @@ type AutoRoleLog struct {
 
 func (AutoRoleLog) TableName() string { return "auto_role_log" }
 
+// ─── 自动权限规则 ───
+
+// AutoRoleRule 自动权限规则：条件树成立时授予 RoleID 对应的系统角色
+// 与单一映射（ESI 角色 / 头衔 / SeAT 分组）并行生效，同步时取并集
+type AutoRoleRule struct {
+	BaseModel
+	Name        string            `gorm:"size:128;not null"          json:"name"`
+	Description string            `gorm:"size:512"                   json:"description"`
+	RoleID      uint              `gorm:"not null;index"             json:"role_id"`   // 系统角色 ID
+	Enabled     bool              `gorm:"not null"                   json:"enabled"`   // 是否启用
+	Condition   AutoRoleCondition `gorm:"type:text;serializer:json"  json:"condition"` // 条件树
+	CreatedBy   uint              `gorm:"not null;default:0"         json:"created_by"`
+	RoleCode    string            `gorm:"-"                          json:"role_code"` // 系统角色编码（仅展示用，不入库）
+	RoleName    string            `gorm:"-"                          json:"role_name"` // 系统角色名称（仅展示用，不入库）
+}
+
+func (AutoRoleRule) TableName() string { return "auto_role_rule" }
+
+// 规则条件类型
+const (
+	AutoRoleCondAnd          = "and"           // 全部子条件成立
+	AutoRoleCondOr           = "or"            // 任一子条件成立
+	AutoRoleCondCorporation  = "corporation"   // 角色在指定军团之一（ids）
+	AutoRoleCondAlliance     = "alliance"      // 角色在指定联盟之一（ids）
+	AutoRoleCondTitle        = "title"         // 角色拥有指定军团头衔（corporation_id + title_id）
+	AutoRoleCondEsiRole      = "esi_role"      // 角色拥有指定 ESI 军团角色（name）
+	AutoRoleCondSeatGroup    = "seat_group"    // 用户属于指定 SeAT 分组（name）
+	AutoRoleCondSkillPlan    = "skill_plan"    // 角色已完成指定技能规划（skill_plan_id）
+	AutoRoleCondPap          = "pap"           // 用户最近 days 天 PAP 合计 ≥ min
+	AutoRoleCondCharacterAge = "character_age" // 角色创建天数 ≥ min
+)
+
+// AutoRoleCondition 规则条件节点：and / or 为组合节点，其余为叶子条件
+// 角色级叶子条件（军团/联盟/头衔/ESI 角色/技能规划/角色年龄）默认任一已绑定角色满足即成立，
+// only_main 为 true 时仅检测主角色
+type AutoRoleCondition struct {
+	Type          string              `json:"type"`
+	Negate        bool                `json:"negate,omitempty"`         // 结果取反
+	Conditions    []AutoRoleCondition `json:"conditions,omitempty"`     // and / or 的子条件
+	IDs           []int64             `json:"ids,omitempty"`            // corporation / alliance
+	CorporationID int64               `json:"corporation_id,omitempty"` // title
+	TitleID       int                 `json:"title_id,omitempty"`       // title
+	Name          string              `json:"name,omitempty"`           // esi_role / seat_group
+	SkillPlanID   uint                `json:"skill_plan_id,omitempty"`  // skill_plan
+	Days          int                 `json:"days,omitempty"`           // pap：统计天数，默认 30
+	Min           float64             `json:"min,omitempty"`            // pap：PAP 下限；character_age：天数下限
+	OnlyMain      bool                `json:"only_main,omitempty"`      // 角色级条件仅检测主角色
+}
+
+// IsGroup 是否为组合节点
+func (c *AutoRoleCondition) IsGroup() bool {
+	return c.Type == AutoRoleCondAnd || c.Type == AutoRoleCondOr
+}
+
 // ─── ESI 军团角色名常量 ───
 
 var AllEsiCorpRoles = []string{
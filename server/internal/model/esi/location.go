@@ -0,0 +1,17 @@
+package esimodel
+
+import "time"
+
+// EveCharacterLocation 角色位置记录（星系 / 空间站 / 建筑 / 舰船变化时新增一条，未变化时更新 LastSeenAt）
+type EveCharacterLocation struct {
+	ID            uint      `gorm:"primarykey"                                     json:"id"`
+	CharacterID   int64     `gorm:"not null;index:idx_character_location_seen"     json:"character_id"`
+	SolarSystemID int64     `gorm:"not null"                                       json:"solar_system_id"`
+	StationID     int64     `gorm:"default:0"                                      json:"station_id"`
+	StructureID   int64     `gorm:"default:0"                                      json:"structure_id"`
+	ShipTypeID    int64     `gorm:"default:0"                                      json:"ship_type_id"`
+	FirstSeenAt   time.Time `gorm:"not null"                                       json:"first_seen_at"`
+	LastSeenAt    time.Time `gorm:"not null;index:idx_character_location_seen"     json:"last_seen_at"`
+}
+
+func (EveCharacterLocation) TableName() string { return "eve_character_location" }
@@ -15,6 +15,8 @@ type EveCharacterTitle = esimodel.EveCharacterTitle
 type EveCharacterCloneBaseInfo = esimodel.EveCharacterCloneBaseInfo
 type EveCharacterImplants = esimodel.EveCharacterImplants
 
+type EveCharacterLocation = esimodel.EveCharacterLocation
+
 type EveStructure = esimodel.EveStructure
 type CorpStructureInfo = esimodel.CorpStructureInfo
 type CorpStructureService = esimodel.CorpStructureService
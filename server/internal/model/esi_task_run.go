@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// ESI 刷新任务执行结果
+const (
+	EsiTaskRunSuccess = "success" // 执行成功
+	EsiTaskRunFailed  = "failed"  // 执行失败
+)
+
+// EsiTaskRun ESI 刷新任务执行记录（每次执行一行，用于调度判断与历史追溯）
+type EsiTaskRun struct {
+	ID          uint      `gorm:"primarykey"                                   json:"id"`
+	TaskName    string    `gorm:"size:64;not null;index:idx_esi_task_run_key"   json:"task_name"`
+	CharacterID int64     `gorm:"not null;index:idx_esi_task_run_key"           json:"character_id"`
+	IsActive    bool      `gorm:"not null;default:true"                         json:"is_active"` // 执行时角色是否活跃（决定下次刷新间隔）
+	Status      string    `gorm:"size:16;not null;index"                        json:"status"`    // success | failed
+	StartedAt   time.Time `gorm:"not null;index:idx_esi_task_run_key"           json:"started_at"`
+	FinishedAt  time.Time `gorm:"not null"                                      json:"finished_at"`
+	DurationMs  int64     `gorm:"not null;default:0"                            json:"duration_ms"`
+	Error       string    `gorm:"type:text"                                     json:"error,omitempty"`
+	HTTPStatus  int       `gorm:"not null;default:0"                            json:"http_status"`  // 最近一次异常状态码，无异常时为最后一次响应状态码
+	Pages       int       `gorm:"not null;default:0"                            json:"pages"`        // 本次执行收到的 ESI 响应数（含分页）
+	NotModified int       `gorm:"not null;default:0"                            json:"not_modified"` // 其中 304 响应数
+}
+
+func (EsiTaskRun) TableName() string { return "esi_task_run" }
+
+// EsiTaskState ESI 任务最新状态（按 任务 + 角色 一行），随执行记录同步更新，
+// 调度与状态展示直接读取，避免每次对执行记录全表去重
+type EsiTaskState struct {
+	ID                uint       `gorm:"primarykey"                                          json:"id"`
+	TaskName          string     `gorm:"size:64;not null;uniqueIndex:idx_esi_task_state_key" json:"task_name"`
+	CharacterID       int64      `gorm:"not null;uniqueIndex:idx_esi_task_state_key"         json:"character_id"`
+	LastRunID         uint       `gorm:"not null;default:0"                                  json:"last_run_id"`         // 最近一次执行记录
+	LastSuccessAt     *time.Time `gorm:""                                                    json:"last_success_at"`     // 最近一次成功执行的结束时间（不受执行记录清理影响）
+	LastSuccessActive bool       `gorm:"not null;default:true"                               json:"last_success_active"` // 最近一次成功执行时角色是否活跃
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime"                                      json:"updated_at"`
+}
+
+func (EsiTaskState) TableName() string { return "esi_task_state" }
+
+// ESI 任务熔断状态
+const (
+	EsiBreakerClosed       = "closed"        // 正常
+	EsiBreakerOpen         = "open"          // 连续失败，退避中
+	EsiBreakerHalfOpen     = "half_open"     // 退避结束，正在试探执行
+	EsiBreakerScopeMissing = "scope_missing" // 403 缺少 scope，等待角色重新授权
+)
+
+// EsiTaskBreaker ESI 任务熔断器状态（按 任务 + 角色），仅保存存在连续失败的记录，恢复后删除
+type EsiTaskBreaker struct {
+	ID             uint      `gorm:"primarykey"                                      json:"id"`
+	TaskName       string    `gorm:"size:64;not null;uniqueIndex:idx_esi_breaker_key" json:"task_name"`
+	CharacterID    int64     `gorm:"not null;uniqueIndex:idx_esi_breaker_key"         json:"character_id"`
+	State          string    `gorm:"size:16;not null;index"                           json:"state"`
+	Failures       int       `gorm:"not null;default:0"                               json:"failures"` // 连续失败次数
+	LastHTTPStatus int       `gorm:"not null;default:0"                               json:"last_http_status"`
+	LastError      string    `gorm:"type:text"                                        json:"last_error"`
+	Scopes         string    `gorm:"type:text"                                        json:"-"` // 进入 scope_missing 时角色的 scope，变化后自动恢复
+	OpenedAt       time.Time `gorm:"not null"                                         json:"opened_at"`
+	RetryAt        time.Time `gorm:"not null"                                         json:"retry_at"` // 下次允许试探执行的时间
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"                                   json:"updated_at"`
+}
+
+func (EsiTaskBreaker) TableName() string { return "esi_task_breaker" }
@@ -9,16 +9,22 @@ type EveCharacter struct {
 	CharacterName string    `gorm:"size:128;not null"      json:"character_name"`
 	PortraitURL   string    `gorm:"size:512"               json:"portrait_url"`
 	UserID        uint      `gorm:"not null;index"         json:"user_id"`
-	AccessToken   string    `gorm:"type:text"              json:"-"`
-	RefreshToken  string    `gorm:"type:text"              json:"-"`
+	AccessToken   string    `gorm:"type:text;serializer:encrypted" json:"-"`
+	RefreshToken  string    `gorm:"type:text;serializer:encrypted" json:"-"`
 	TokenExpiry   time.Time `gorm:""                       json:"token_expiry"`
 	Scopes        string    `gorm:"type:text"              json:"scopes"` // 空格分隔的 scope 列表
 	TokenInvalid  bool      `gorm:"not null;default:false" json:"token_invalid"`
+	// Token 失效时间与原因（refresh_token 被撤销/过期时记录，重新授权后清除）
+	TokenInvalidAt     *time.Time `gorm:""         json:"token_invalid_at,omitempty"`
+	TokenInvalidReason string     `gorm:"size:512" json:"token_invalid_reason,omitempty"`
 
 	// ESI Affiliation 归属信息
 	CorporationID int64  `gorm:"default:0;index"         json:"corporation_id"`
 	AllianceID    *int64 `gorm:""                         json:"alliance_id,omitempty"`
 	FactionID     *int64 `gorm:""                         json:"faction_id,omitempty"`
+
+	// 角色创建时间（ESI 公开信息，按需拉取）
+	Birthday *time.Time `gorm:"" json:"birthday,omitempty"`
 }
 
 func (EveCharacter) TableName() string {
@@ -30,7 +30,8 @@ type Fleet struct {
 	FCCharacterName  string     `gorm:"size:128"                   json:"fc_character_name"`
 	ESIFleetID       *int64     `gorm:""                           json:"esi_fleet_id,omitempty"`
 	FleetConfigID    *uint      `gorm:""                           json:"fleet_config_id,omitempty"`
-	AutoSrpMode      string     `gorm:"size:32;not null;default:'disabled'" json:"auto_srp_mode"` // disabled/submit_only/auto_approve
+	PapPolicyID      *uint      `gorm:""                           json:"pap_policy_id,omitempty"` // 为空时使用重要等级对应的默认策略
+	AutoSrpMode      string     `gorm:"size:32;not null;default:'disabled'" json:"auto_srp_mode"`  // disabled/submit_only/auto_approve
 	BrUUID           string     `gorm:"column:br_uuid;size:36"                              json:"br_uuid"`
 	BrTeam0Loss      int        `gorm:"column:br_team0_loss;default:0"                      json:"br_team0_loss"`
 	BrTeam0Value     float64    `gorm:"column:br_team0_value;type:decimal(20,2);default:0"  json:"br_team0_value"`
@@ -60,19 +61,88 @@ type FleetMember struct {
 
 func (FleetMember) TableName() string { return "fleet_member" }
 
+// FleetMemberSegment 成员在队时间段（后台持续跟踪 ESI 舰队成员生成）
+// 舰船或星系变化时结束当前时间段并开始新的时间段；EndedAt 为空表示仍在队中
+type FleetMemberSegment struct {
+	ID            uint       `gorm:"primarykey"                                  json:"id"`
+	FleetID       string     `gorm:"size:36;not null;index:idx_fleet_segment"    json:"fleet_id"`
+	CharacterID   int64      `gorm:"not null;index:idx_fleet_segment"            json:"character_id"`
+	CharacterName string     `gorm:"size:128"                                    json:"character_name"`
+	UserID        uint       `gorm:"not null;index"                              json:"user_id"`
+	ShipTypeID    int64      `gorm:"not null"                                    json:"ship_type_id"`
+	SolarSystemID int64      `gorm:"not null"                                    json:"solar_system_id"`
+	StartedAt     time.Time  `gorm:"not null"                                    json:"started_at"`
+	LastSeenAt    time.Time  `gorm:"not null"                                    json:"last_seen_at"` // 最近一次轮询仍在队中的时间
+	EndedAt       *time.Time `gorm:"index"                                       json:"ended_at,omitempty"`
+}
+
+func (FleetMemberSegment) TableName() string { return "fleet_member_segment" }
+
 // FleetPapLog PAP 发放记录
 type FleetPapLog struct {
-	ID          uint      `gorm:"primarykey"                                       json:"id"`
-	FleetID     string    `gorm:"size:36;not null;index"                           json:"fleet_id"`
-	CharacterID int64     `gorm:"not null;index"                                   json:"character_id"`
-	UserID      uint      `gorm:"not null;index"                                   json:"user_id"`
-	PapCount    float64   `gorm:"not null"                                         json:"pap_count"`
-	IssuedBy    uint      `gorm:"not null"                                         json:"issued_by"` // 发放者 user_id
-	IssuedAt    time.Time `gorm:"autoCreateTime"                                   json:"issued_at"`
+	ID          uint          `gorm:"primarykey"                                       json:"id"`
+	FleetID     string        `gorm:"size:36;not null;index"                           json:"fleet_id"`
+	CharacterID int64         `gorm:"not null;index"                                   json:"character_id"`
+	UserID      uint          `gorm:"not null;index"                                   json:"user_id"`
+	PapCount    float64       `gorm:"not null"                                         json:"pap_count"`
+	PolicyName  string        `gorm:"size:128"                                         json:"policy_name"` // 发放时使用的 PAP 策略
+	Breakdown   *PapBreakdown `gorm:"type:text;serializer:json"                        json:"breakdown,omitempty"`
+	IssuedBy    uint          `gorm:"not null"                                         json:"issued_by"` // 发放者 user_id
+	IssuedAt    time.Time     `gorm:"autoCreateTime"                                   json:"issued_at"`
 }
 
 func (FleetPapLog) TableName() string { return "fleet_pap_log" }
 
+// PAP 计算模式
+const (
+	PapModeFlat         = "flat"          // 每个成员发放舰队设定的 PAP
+	PapModeTimeWeighted = "time_weighted" // 按在队时长占跟踪时长的比例折算
+	PapModeManual       = "manual"        // 手动补录（仅出现在发放明细中）
+)
+
+// PAP 加成角色
+const (
+	PapRoleFC      = "fc"      // 舰队 FC 角色
+	PapRoleLogi    = "logi"    // 后勤
+	PapRoleScout   = "scout"   // 侦察
+	PapRoleBooster = "booster" // 指挥（增益）
+)
+
+// PapPolicy PAP 计算策略
+// 舰队可指定策略；未指定时使用 Importance 与舰队重要等级一致的策略，都没有时按固定 PAP 发放
+type PapPolicy struct {
+	BaseModel
+	Name                 string             `gorm:"size:128;not null"          json:"name"`
+	Description          string             `gorm:"size:512"                   json:"description"`
+	Mode                 string             `gorm:"size:32;not null"           json:"mode"`                   // flat / time_weighted
+	MinPresencePercent   float64            `gorm:"not null;default:0"         json:"min_presence_percent"`   // 在队时长低于跟踪时长的该比例时不发放（0 不限制）
+	FullPresencePercent  float64            `gorm:"not null;default:0"         json:"full_presence_percent"`  // 在队比例达到该值即按全程计算（0 表示 100）
+	RoleMultipliers      map[string]float64 `gorm:"type:text;serializer:json"  json:"role_multipliers"`       // 角色 → 倍率（fc / logi / scout / booster）
+	ShipGroupMultipliers map[int64]float64  `gorm:"type:text;serializer:json"  json:"ship_group_multipliers"` // 舰船分组 ID → 倍率
+	Importance           string             `gorm:"size:32;index"              json:"importance"`             // 作为该重要等级舰队的默认策略（为空表示不作为默认）
+	CreatedBy            uint               `gorm:"not null;default:0"         json:"created_by"`
+}
+
+func (PapPolicy) TableName() string { return "pap_policy" }
+
+// PapBreakdown 单个成员的 PAP 计算明细（随 PAP 记录保存，供成员查看）
+type PapBreakdown struct {
+	Policy          string  `json:"policy"`                  // 策略名称
+	Mode            string  `json:"mode"`                    // flat / time_weighted / manual
+	Base            float64 `json:"base"`                    // 舰队设定的 PAP
+	PresentSeconds  int64   `json:"present_seconds"`         // 在队时长
+	TrackedSeconds  int64   `json:"tracked_seconds"`         // 跟踪总时长（0 表示没有跟踪记录，按全程计算）
+	PresenceFactor  float64 `json:"presence_factor"`         // 在队系数（0-1）
+	BelowMinimum    bool    `json:"below_minimum"`           // 在队比例低于策略下限，不发放
+	Untracked       bool    `json:"untracked"`               // 舰队有跟踪记录但没有该成员的记录，按在队时长计算时不发放（需人工核实）
+	Role            string  `json:"role,omitempty"`          // 主要担任的加成角色
+	RoleMultiplier  float64 `json:"role_multiplier"`         // 角色倍率
+	ShipTypeID      int64   `json:"ship_type_id,omitempty"`  // 在队时间最长的舰船
+	ShipGroupID     int64   `json:"ship_group_id,omitempty"` // 该舰船的分组
+	GroupMultiplier float64 `json:"group_multiplier"`        // 舰船分组倍率
+	Multiplier      float64 `json:"multiplier"`              // 按在队时长加权后的最终倍率
+}
+
 // FleetInvite 舰队邀请链接
 type FleetInvite struct {
 	ID        uint      `gorm:"primarykey"                 json:"id"`
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// MarketPriceSourceESI ESI /markets/prices/ 全宇宙均价（adjusted / average）的价格来源标识
+// 交易中心价格的来源标识为配置中的 hub 名称（如 jita、amarr）
+const MarketPriceSourceESI = "esi"
+
+// MarketPrice 物品最新市场价格（按 物品 + 来源 唯一）
+//   - source=esi：仅 AdjustedPrice / AveragePrice 有值
+//   - source=<hub>：交易中心订单簿汇总（最高收单、最低卖单、5% 加权百分位价）
+type MarketPrice struct {
+	TypeID         int64     `gorm:"primaryKey;autoIncrement:false"          json:"type_id"`
+	Source         string    `gorm:"primaryKey;size:32"                      json:"source"`
+	AdjustedPrice  float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"adjusted_price"`
+	AveragePrice   float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"average_price"`
+	BuyMax         float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"buy_max"`
+	SellMin        float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"sell_min"`
+	BuyPercentile  float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"buy_percentile"`
+	SellPercentile float64   `gorm:"type:decimal(20,2);not null;default:0"   json:"sell_percentile"`
+	BuyVolume      int64     `gorm:"not null;default:0"                      json:"buy_volume"`
+	SellVolume     int64     `gorm:"not null;default:0"                      json:"sell_volume"`
+	UpdatedAt      time.Time `gorm:"not null;index"                          json:"updated_at"`
+}
+
+func (MarketPrice) TableName() string { return "market_price" }
+
+// MarketPriceHistory 每日价格快照（同一天多次拉取时覆盖为当天最后一次）
+type MarketPriceHistory struct {
+	ID             uint      `gorm:"primarykey"                                       json:"id"`
+	Date           time.Time `gorm:"type:date;not null;uniqueIndex:idx_market_history" json:"date"`
+	TypeID         int64     `gorm:"not null;uniqueIndex:idx_market_history;index"     json:"type_id"`
+	Source         string    `gorm:"size:32;not null;uniqueIndex:idx_market_history"   json:"source"`
+	AdjustedPrice  float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"adjusted_price"`
+	AveragePrice   float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"average_price"`
+	BuyMax         float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"buy_max"`
+	SellMin        float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"sell_min"`
+	BuyPercentile  float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"buy_percentile"`
+	SellPercentile float64   `gorm:"type:decimal(20,2);not null;default:0"            json:"sell_percentile"`
+	BuyVolume      int64     `gorm:"not null;default:0"                               json:"buy_volume"`
+	SellVolume     int64     `gorm:"not null;default:0"                               json:"sell_volume"`
+}
+
+func (MarketPriceHistory) TableName() string { return "market_price_history" }
@@ -108,11 +108,17 @@ func GetSystemMenuSeeds() []MenuSeed {
 		// ── Operation ──
 		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "Operation", Path: "/operation", Component: "/index/index", Title: "menus.operation.title", Icon: "ri:ship-line", Sort: 90, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "Fleets", Path: "fleets", Component: "/operation/fleets", Title: "menus.operation.fleets", Sort: 100, KeepAlive: true, Status: 1}},
+		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "OperationCalendar", Path: "calendar", Component: "/operation/calendar", Title: "menus.operation.calendar", Sort: 98, KeepAlive: true, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "FleetConfigs", Path: "fleet-configs", Component: "/operation/fleet-configs", Title: "menus.operation.fleetConfigs", Sort: 95, KeepAlive: true, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "FleetDetail", Path: "fleet-detail/:id", Component: "/operation/fleet-detail", Title: "menus.operation.fleetDetail", Sort: 90, IsHide: true, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "MyPap", Path: "pap", Component: "/operation/pap", Title: "menus.operation.pap", Sort: 80, KeepAlive: true, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "JoinFleet", Path: "join", Component: "/operation/join", Title: "menus.operation.join", Sort: 60, IsHide: true, Status: 1}},
 		{ParentName: "Operation", Menu: Menu{Type: MenuTypeMenu, Name: "UserSkillPlan", Path: "skill-plan", Component: "/operation/skill-plan", Title: "menus.operation.skillPlan", Sort: 78, KeepAlive: true, Status: 1}},
+		{ParentName: "Fleets", Menu: Menu{Type: MenuTypeButton, Name: "FleetManage", Permission: "operation:fleet:manage", Title: "管理舰队", Sort: 100, Status: 1}},
+		{ParentName: "Fleets", Menu: Menu{Type: MenuTypeButton, Name: "FleetPapIssue", Permission: "operation:fleet:pap", Title: "发放 PAP", Sort: 90, Status: 1}},
+		{ParentName: "Fleets", Menu: Menu{Type: MenuTypeButton, Name: "PapPolicyManage", Permission: "operation:pap-policy:manage", Title: "管理 PAP 策略", Sort: 80, Status: 1}},
+		{ParentName: "Fleets", Menu: Menu{Type: MenuTypeButton, Name: "AttendanceAnalytics", Permission: "operation:analytics:view", Title: "查看出勤统计", Sort: 70, Status: 1}},
+		{ParentName: "FleetConfigs", Menu: Menu{Type: MenuTypeButton, Name: "FleetConfigManage", Permission: "operation:fleet-config:manage", Title: "管理舰队配置", Sort: 100, Status: 1}},
 
 		// ── Shop ──
 		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "ShopRoot", Path: "/shop", Component: "/index/index", Title: "menus.shop.title", Icon: "ri:shopping-bag-line", Sort: 85, Status: 1}},
@@ -123,6 +129,10 @@ func GetSystemMenuSeeds() []MenuSeed {
 		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopProductEdit", Permission: "system:shop:product:edit", Title: "编辑商品", Sort: 90, Status: 1}},
 		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopProductDelete", Permission: "system:shop:product:delete", Title: "删除商品", Sort: 80, Status: 1}},
 		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopOrderReview", Permission: "system:shop:order:review", Title: "审批订单", Sort: 70, Status: 1}},
+		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopProductList", Permission: "system:shop:product:list", Title: "查看商品", Sort: 65, Status: 1}},
+		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopOrderList", Permission: "system:shop:order:list", Title: "查看订单", Sort: 60, Status: 1}},
+		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopRedeemList", Permission: "system:shop:redeem:list", Title: "查看兑换码", Sort: 50, Status: 1}},
+		{ParentName: "ShopManage", Menu: Menu{Type: MenuTypeButton, Name: "ShopLotteryManage", Permission: "system:shop:lottery:manage", Title: "抽奖管理", Sort: 40, Status: 1}},
 
 		// ── Voice Center ──
 		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "VoiceCenter", Path: "/voice", Component: "/index/index", Title: "menus.voice.title", Icon: "ri:mic-line", Sort: 83, Status: 1}},
@@ -144,44 +154,86 @@ func GetSystemMenuSeeds() []MenuSeed {
 		{ParentName: "SRP", Menu: Menu{Type: MenuTypeMenu, Name: "SrpApply", Path: "srp-apply", Component: "/srp/apply", Title: "menus.srp.srpApply", Sort: 100, KeepAlive: true, Status: 1}},
 		{ParentName: "SRP", Menu: Menu{Type: MenuTypeMenu, Name: "SrpManage", Path: "srp-manage", Component: "/srp/manage", Title: "menus.srp.srpManage", Sort: 90, KeepAlive: true, Status: 1}},
 		{ParentName: "SrpManage", Menu: Menu{Type: MenuTypeButton, Name: "SrpManageReview", Permission: "srp:review", Title: "审批", Sort: 100, Status: 1}},
+		{ParentName: "SrpManage", Menu: Menu{Type: MenuTypeButton, Name: "SrpConfigEdit", Permission: "srp:config:edit", Title: "修改定价 / 发放配置", Sort: 90, Status: 1}},
 		{ParentName: "SRP", Menu: Menu{Type: MenuTypeMenu, Name: "SrpPrices", Path: "srp-prices", Component: "/srp/prices", Title: "menus.srp.srpPrices", Sort: 80, KeepAlive: true, Status: 1}},
 		{ParentName: "SrpPrices", Menu: Menu{Type: MenuTypeButton, Name: "SrpPriceAdd", Permission: "srp:price:add", Title: "新增价格", Sort: 100, Status: 1}},
 		{ParentName: "SrpPrices", Menu: Menu{Type: MenuTypeButton, Name: "SrpPriceDelete", Permission: "srp:price:delete", Title: "删除价格", Sort: 90, Status: 1}},
 
+		// ── Recruit (招新) ──
+		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "Recruit", Path: "/recruit", Component: "/index/index", Title: "menus.recruit.title", Icon: "ri:user-add-line", Sort: 78, Status: 1}},
+		{ParentName: "Recruit", Menu: Menu{Type: MenuTypeMenu, Name: "RecruitApply", Path: "apply", Component: "/recruit/apply", Title: "menus.recruit.apply", Sort: 100, KeepAlive: true, Status: 1}},
+		{ParentName: "Recruit", Menu: Menu{Type: MenuTypeMenu, Name: "RecruitManage", Path: "manage", Component: "/recruit/manage", Title: "menus.recruit.manage", Sort: 90, KeepAlive: true, Status: 1}},
+		{ParentName: "RecruitManage", Menu: Menu{Type: MenuTypeButton, Name: "RecruitView", Permission: "recruit:view", Title: "查看申请 / 留言", Sort: 100, Status: 1}},
+		{ParentName: "RecruitManage", Menu: Menu{Type: MenuTypeButton, Name: "RecruitReview", Permission: "recruit:review", Title: "审核申请", Sort: 90, Status: 1}},
+		{ParentName: "RecruitManage", Menu: Menu{Type: MenuTypeButton, Name: "RecruitConfig", Permission: "recruit:config", Title: "申请表配置", Sort: 80, Status: 1}},
+
 		// ── Corp Management (军团管理) ──
 		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "CorpManage", Path: "/corp-manage", Component: "/index/index", Title: "menus.corpManage.title", Icon: "ri:shield-star-line", Sort: 75, Status: 1}},
 		{ParentName: "CorpManage", Menu: Menu{Type: MenuTypeMenu, Name: "SkillPlanManage", Path: "skill-plan", Component: "/corp-manage/skill-plan", Title: "menus.corpManage.skillPlanManage", Sort: 100, KeepAlive: true, Status: 1}},
 		{ParentName: "CorpManage", Menu: Menu{Type: MenuTypeMenu, Name: "SkillPlanCheck", Path: "skill-plan-check", Component: "/corp-manage/skill-plan/check", Title: "menus.corpManage.skillPlanCheck", Sort: 90, KeepAlive: true, Status: 1}},
 		{ParentName: "CorpManage", Menu: Menu{Type: MenuTypeMenu, Name: "Structures", Path: "structures", Component: "/corp-manage/structures/index", Title: "menus.corpManage.structures", Sort: 80, KeepAlive: true, Status: 1}},
 		{ParentName: "CorpManage", Menu: Menu{Type: MenuTypeMenu, Name: "FleetBattleIncentive", Path: "fleet-incentive", Component: "/corp-manage/fleet-incentive", Title: "menus.corpManage.fleetIncentive", Sort: 70, KeepAlive: true, Status: 1}},
+		{ParentName: "SkillPlanManage", Menu: Menu{Type: MenuTypeButton, Name: "SkillPlanEdit", Permission: "operation:skill-plan:manage", Title: "管理技能规划", Sort: 100, Status: 1}},
+		{ParentName: "SkillPlanCheck", Menu: Menu{Type: MenuTypeButton, Name: "SkillPlanCheckAll", Permission: "operation:skill-plan:check", Title: "检查全部成员", Sort: 100, Status: 1}},
+		{ParentName: "FleetBattleIncentive", Menu: Menu{Type: MenuTypeButton, Name: "IncentiveView", Permission: "corp:incentive:view", Title: "查看激励配置", Sort: 100, Status: 1}},
+		{ParentName: "FleetBattleIncentive", Menu: Menu{Type: MenuTypeButton, Name: "IncentiveEdit", Permission: "corp:incentive:edit", Title: "修改激励配置", Sort: 90, Status: 1}},
+		{ParentName: "FleetBattleIncentive", Menu: Menu{Type: MenuTypeButton, Name: "IncentiveReward", Permission: "corp:incentive:reward", Title: "补发带队奖励", Sort: 80, Status: 1}},
 
 		// ── System ──
 		{ParentName: "", Menu: Menu{Type: MenuTypeDir, Name: "System", Path: "/system", Component: "/index/index", Title: "menus.system.title", Icon: "ri:user-3-line", Sort: 70, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "User", Path: "user", Component: "/system/user", Title: "menus.system.user", Sort: 100, KeepAlive: true, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserList", Permission: "system:user:list", Title: "查看用户", Sort: 110, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserEdit", Permission: "system:user:edit", Title: "编辑用户", Sort: 105, Status: 1}},
 		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserDelete", Permission: "system:user:delete", Title: "删除用户", Sort: 100, Status: 1}},
 		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserSetRole", Permission: "system:user:role", Title: "分配角色", Sort: 90, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserSession", Permission: "system:user:session", Title: "强制下线", Sort: 80, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "UserTokenHealth", Permission: "system:token-health:view", Title: "Token 健康报告", Sort: 70, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "APIKeyManage", Permission: "system:api-key:manage", Title: "API Key 管理", Sort: 60, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "MemberHistory", Permission: "system:member:history", Title: "成员归属历史", Sort: 50, Status: 1}},
+		{ParentName: "User", Menu: Menu{Type: MenuTypeButton, Name: "HostileWatchlist", Permission: "system:member:watchlist", Title: "敌对观察名单", Sort: 40, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "RoleManage", Path: "role", Component: "/system/role", Title: "menus.system.role", Sort: 90, KeepAlive: true, Status: 1}},
+		{ParentName: "RoleManage", Menu: Menu{Type: MenuTypeButton, Name: "RoleList", Permission: "system:role:list", Title: "查看角色", Sort: 110, Status: 1}},
 		{ParentName: "RoleManage", Menu: Menu{Type: MenuTypeButton, Name: "RoleAdd", Permission: "system:role:add", Title: "新增角色", Sort: 100, Status: 1}},
 		{ParentName: "RoleManage", Menu: Menu{Type: MenuTypeButton, Name: "RoleEdit", Permission: "system:role:edit", Title: "编辑角色", Sort: 90, Status: 1}},
 		{ParentName: "RoleManage", Menu: Menu{Type: MenuTypeButton, Name: "RoleDelete", Permission: "system:role:delete", Title: "删除角色", Sort: 80, Status: 1}},
 		{ParentName: "RoleManage", Menu: Menu{Type: MenuTypeButton, Name: "RolePermission", Permission: "system:role:permission", Title: "权限设置", Sort: 70, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "Menus", Path: "menu", Component: "/system/menu", Title: "menus.system.menu", Sort: 80, KeepAlive: true, Status: 1}},
+		{ParentName: "Menus", Menu: Menu{Type: MenuTypeButton, Name: "MenuList", Permission: "system:menu:list", Title: "查看菜单", Sort: 110, Status: 1}},
 		{ParentName: "Menus", Menu: Menu{Type: MenuTypeButton, Name: "MenuAdd", Permission: "system:menu:add", Title: "新增菜单", Sort: 100, Status: 1}},
 		{ParentName: "Menus", Menu: Menu{Type: MenuTypeButton, Name: "MenuEdit", Permission: "system:menu:edit", Title: "编辑菜单", Sort: 90, Status: 1}},
 		{ParentName: "Menus", Menu: Menu{Type: MenuTypeButton, Name: "MenuDelete", Permission: "system:menu:delete", Title: "删除菜单", Sort: 80, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "ESIRefresh", Path: "esi-refresh", Component: "/system/esi-refresh", Title: "menus.system.esiRefresh", Sort: 70, KeepAlive: true, Status: 1}},
+		{ParentName: "ESIRefresh", Menu: Menu{Type: MenuTypeButton, Name: "ESIView", Permission: "system:esi:view", Title: "查看队列", Sort: 110, Status: 1}},
 		{ParentName: "ESIRefresh", Menu: Menu{Type: MenuTypeButton, Name: "ESIRun", Permission: "system:esi:run", Title: "执行任务", Sort: 100, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "SystemWallet", Path: "wallet", Component: "/system/wallet", Title: "menus.system.wallet", Sort: 65, KeepAlive: true, Status: 1}},
 		{ParentName: "SystemWallet", Menu: Menu{Type: MenuTypeButton, Name: "WalletAdjust", Permission: "system:wallet:adjust", Title: "调整余额", Sort: 100, Status: 1}},
+		{ParentName: "SystemWallet", Menu: Menu{Type: MenuTypeButton, Name: "WalletView", Permission: "system:wallet:view", Title: "查看钱包", Sort: 110, Status: 1}},
 		{ParentName: "SystemWallet", Menu: Menu{Type: MenuTypeButton, Name: "WalletViewLog", Permission: "system:wallet:log", Title: "查看日志", Sort: 90, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "AlliancePAP", Path: "pap", Component: "/system/pap", Title: "menus.system.alliancePap", Sort: 63, KeepAlive: true, Status: 1}},
+		{ParentName: "AlliancePAP", Menu: Menu{Type: MenuTypeButton, Name: "AlliancePAPView", Permission: "system:pap:view", Title: "查看联盟 PAP", Sort: 110, Status: 1}},
 		{ParentName: "AlliancePAP", Menu: Menu{Type: MenuTypeButton, Name: "AlliancePAPFetch", Permission: "system:pap:fetch", Title: "手动拉取", Sort: 100, Status: 1}},
+		{ParentName: "AlliancePAP", Menu: Menu{Type: MenuTypeButton, Name: "AlliancePAPImport", Permission: "system:pap:import", Title: "导入", Sort: 90, Status: 1}},
+		{ParentName: "AlliancePAP", Menu: Menu{Type: MenuTypeButton, Name: "AlliancePAPConfig", Permission: "system:pap:config", Title: "兑换配置", Sort: 80, Status: 1}},
+		{ParentName: "AlliancePAP", Menu: Menu{Type: MenuTypeButton, Name: "AlliancePAPSettle", Permission: "system:pap:settle", Title: "月度结算", Sort: 70, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "CorpNpcKillReport", Path: "npc-kills", Component: "/system/npc-kills", Title: "menus.system.npcKills", Sort: 62, KeepAlive: true, Status: 1}},
+		{ParentName: "CorpNpcKillReport", Menu: Menu{Type: MenuTypeButton, Name: "CorpNpcKillView", Permission: "system:npc-kill:view", Title: "查看军团报表", Sort: 100, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "AutoRole", Path: "auto-role", Component: "/system/auto-role", Title: "menus.system.autoRole", Sort: 61, KeepAlive: true, Status: 1}},
+		{ParentName: "AutoRole", Menu: Menu{Type: MenuTypeButton, Name: "AutoRoleView", Permission: "system:auto-role:view", Title: "查看映射", Sort: 100, Status: 1}},
+		{ParentName: "AutoRole", Menu: Menu{Type: MenuTypeButton, Name: "AutoRoleEdit", Permission: "system:auto-role:edit", Title: "修改映射 / 准入名单", Sort: 90, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "UserCenter", Path: "user-center", Component: "/system/user-center", Title: "menus.system.userCenter", Sort: 60, IsHide: true, KeepAlive: true, IsHideTab: true, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "WebhookSettings", Path: "webhook", Component: "/system/webhook", Title: "menus.system.webhook", Sort: 59, KeepAlive: true, Status: 1}},
+		{ParentName: "WebhookSettings", Menu: Menu{Type: MenuTypeButton, Name: "WebhookConfig", Permission: "system:webhook:config", Title: "Webhook 配置", Sort: 100, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "BasicConfig", Path: "basic-config", Component: "/system/basic-config", Title: "menus.system.basicConfig", Sort: 58, KeepAlive: true, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "BasicConfigView", Permission: "system:config:view", Title: "查看配置", Sort: 100, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "BasicConfigEdit", Permission: "system:config:edit", Title: "修改配置", Sort: 90, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "SeatConfig", Permission: "system:seat:config", Title: "SeAT 配置", Sort: 80, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "MumbleConfig", Permission: "system:mumble:config", Title: "Mumble 配置", Sort: 70, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "MarketView", Permission: "system:market:view", Title: "市场价格状态", Sort: 60, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "MarketRefresh", Permission: "system:market:refresh", Title: "拉取市场价格", Sort: 50, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "CronJobView", Permission: "system:cron:view", Title: "定时任务租约", Sort: 40, Status: 1}},
+		{ParentName: "BasicConfig", Menu: Menu{Type: MenuTypeButton, Name: "ServerUpdate", Permission: "system:server:update", Title: "服务器更新", Sort: 30, Status: 1}},
 		{ParentName: "System", Menu: Menu{Type: MenuTypeMenu, Name: "SdeManage", Path: "sde", Component: "/system/sde", Title: "menus.system.sdeManage", Sort: 57, KeepAlive: true, Status: 1}},
+		{ParentName: "SdeManage", Menu: Menu{Type: MenuTypeButton, Name: "SdeView", Permission: "system:sde:view", Title: "查看版本", Sort: 110, Status: 1}},
 		{ParentName: "SdeManage", Menu: Menu{Type: MenuTypeButton, Name: "SdeUpdate", Permission: "system:sde:update", Title: "手动更新", Sort: 100, Status: 1}},
 
 		// ── Result ──
@@ -198,8 +250,8 @@ func DefaultRoleMenuMap() map[string][]string {
 		RoleAdmin: {
 			"Dashboard", "Console", "Characters",
 			"EveInfo", "EveInfoWallet", "EveInfoSkill", "NpcKillReport", "EveInfoShips", "EveInfoImplants", "EveInfoFittings", "EveInfoAssets", "EveInfoContracts",
-			"Operation", "Fleets", "FleetConfigs", "FleetDetail", "MyPap", "JoinFleet", "UserSkillPlan",
-			"CorpManage", "SkillPlanManage", "SkillPlanCheck", "Structures", "FleetBattleIncentive",
+			"Operation", "Fleets", "OperationCalendar", "FleetConfigs", "FleetDetail", "MyPap", "JoinFleet", "UserSkillPlan",
+			"CorpManage", "SkillPlanManage", "SkillPlanCheck", "Structures", "FleetBattleIncentive", "PapPolicyManage", "AttendanceAnalytics",
 			"ShopRoot", "Shop", "Wallet",
 			"VoiceCenter", "MumbleCenter",
 			"SRP", "SrpApply", "SrpManage", "SrpManageReview", "SrpPrices", "SrpPriceAdd", "SrpPriceDelete",
@@ -209,35 +261,41 @@ func DefaultRoleMenuMap() map[string][]string {
 		RoleFC: {
 			"Dashboard", "Console", "Characters",
 			"EveInfo", "EveInfoWallet", "EveInfoSkill", "NpcKillReport", "EveInfoShips", "EveInfoImplants", "EveInfoFittings", "EveInfoAssets", "EveInfoContracts",
-			"Operation", "Fleets", "FleetDetail", "MyPap", "Wallet", "JoinFleet", "UserSkillPlan",
+			"Operation", "Fleets", "OperationCalendar", "FleetDetail", "MyPap", "Wallet", "JoinFleet", "UserSkillPlan",
 			"CorpManage", "SkillPlanManage", "SkillPlanCheck",
 			"ShopRoot", "Shop",
 			"VoiceCenter", "MumbleCenter",
 			"SRP", "SrpApply", "SrpManage", "SrpManageReview",
 			"Result", "ResultSuccess", "ResultFail",
+			// 按钮权限：原 RequireRole(fc, ...) 接口
+			"FleetManage", "FleetPapIssue", "FleetConfigManage", "SkillPlanEdit", "SkillPlanCheckAll",
 		},
 		RoleSRP: {
 			"Dashboard", "Console", "Characters",
 			"EveInfo", "EveInfoWallet", "EveInfoSkill", "NpcKillReport", "EveInfoShips", "EveInfoImplants", "EveInfoFittings", "EveInfoAssets", "EveInfoContracts",
-			"Operation", "MyPap", "Wallet", "JoinFleet",
+			"Operation", "OperationCalendar", "MyPap", "Wallet", "JoinFleet",
 			"ShopRoot", "Shop",
 			"VoiceCenter", "MumbleCenter",
 			"SRP", "SrpApply", "SrpManage", "SrpManageReview", "SrpPrices", "SrpPriceAdd", "SrpPriceDelete",
 			"Result", "ResultSuccess", "ResultFail",
+			// 按钮权限：原 RequireRole(fc, srp) 接口
+			"FleetConfigManage",
 		},
 		RoleUser: {
 			"Dashboard", "Console", "Characters",
 			"EveInfo", "EveInfoWallet", "EveInfoSkill", "NpcKillReport", "EveInfoShips", "EveInfoImplants", "EveInfoFittings", "EveInfoAssets", "EveInfoContracts",
-			"Operation", "Fleets", "FleetDetail", "MyPap", "Wallet", "JoinFleet", "FleetConfigs", "UserSkillPlan",
+			"Operation", "Fleets", "OperationCalendar", "FleetDetail", "MyPap", "Wallet", "JoinFleet", "FleetConfigs", "UserSkillPlan",
 			"ShopRoot", "Shop",
 			"VoiceCenter", "MumbleCenter",
 			"SRP", "SrpApply",
+			"Recruit", "RecruitApply",
 			"Result", "ResultSuccess", "ResultFail",
 			"UserCenter",
 		},
 		RoleGuest: {
 			"Dashboard", "Console", "Characters",
 			"EveInfo", "EveInfoWallet", "EveInfoSkill", "NpcKillReport", "EveInfoShips", "EveInfoImplants", "EveInfoFittings", "EveInfoAssets", "EveInfoContracts",
+			"Recruit", "RecruitApply",
 			"Result", "ResultSuccess", "ResultFail",
 		},
 	}
@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// ─────────────────────────────────────────────
+//  行动日历
+//  FC 预先排期的行动（可由周期模板自动生成），成员报名意向舰船 / 职责，
+//  按设定的提前量发送 Webhook 提醒，并提供个人 iCal 订阅
+// ─────────────────────────────────────────────
+
+// 行动状态
+const (
+	OperationStatusScheduled = "scheduled" // 已排期
+	OperationStatusCancelled = "cancelled" // 已取消
+)
+
+// 报名状态
+const (
+	RSVPYes   = "yes"   // 参加
+	RSVPMaybe = "maybe" // 待定
+	RSVPNo    = "no"    // 不参加
+)
+
+// 报名职责
+const (
+	RSVPRoleDPS    = "dps"
+	RSVPRoleLogi   = "logi"
+	RSVPRoleTackle = "tackle"
+	RSVPRoleEwar   = "ewar"
+	RSVPRoleBoost  = "boost"
+	RSVPRoleScout  = "scout"
+	RSVPRoleOther  = "other"
+)
+
+// OperationTemplate 周期行动模板（按 EVE 时间即 UTC 每周固定时间生成行动）
+type OperationTemplate struct {
+	BaseModel
+	Title           string `gorm:"size:256;not null"          json:"title"`
+	Description     string `gorm:"type:text"                  json:"description"`
+	Importance      string `gorm:"size:32;not null"           json:"importance"` // strat_op / cta / other
+	FleetConfigID   *uint  `gorm:""                           json:"fleet_config_id,omitempty"`
+	Weekdays        []int  `gorm:"type:text;serializer:json"  json:"weekdays"`    // 0=周日 … 6=周六
+	TimeOfDay       string `gorm:"size:5;not null"            json:"time_of_day"` // HH:MM（UTC）
+	DurationMinutes int    `gorm:"not null"                   json:"duration_minutes"`
+	ReminderOffsets []int  `gorm:"type:text;serializer:json"  json:"reminder_offsets"` // 提前提醒的分钟数
+	FCUserID        uint   `gorm:"not null;index"             json:"fc_user_id"`
+	FCCharacterName string `gorm:"size:128"                   json:"fc_character_name"`
+	Enabled         bool   `gorm:"not null;default:true"      json:"enabled"`
+	CreatedBy       uint   `gorm:"not null"                   json:"created_by"`
+}
+
+func (OperationTemplate) TableName() string { return "operation_template" }
+
+// FleetOperation 排期行动（日历条目）；集结后关联实际舰队
+type FleetOperation struct {
+	BaseModel
+	TemplateID      *uint      `gorm:"uniqueIndex:idx_operation_template_occurrence" json:"template_id,omitempty"`
+	OccurrenceAt    *time.Time `gorm:"uniqueIndex:idx_operation_template_occurrence" json:"occurrence_at,omitempty"` // 模板生成时的排期时间（改期后不变，用于去重）
+	Title           string     `gorm:"size:256;not null"                         json:"title"`
+	Description     string     `gorm:"type:text"                                 json:"description"`
+	Importance      string     `gorm:"size:32;not null"                          json:"importance"`
+	FleetConfigID   *uint      `gorm:""                                          json:"fleet_config_id,omitempty"`
+	StartAt         time.Time  `gorm:"not null;index"                            json:"start_at"`
+	EndAt           time.Time  `gorm:"not null"                                  json:"end_at"`
+	ReminderOffsets []int      `gorm:"type:text;serializer:json"                 json:"reminder_offsets"`
+	RemindersSent   []int      `gorm:"type:text;serializer:json"                 json:"reminders_sent"` // 已发送的提醒（分钟数）
+	FCUserID        uint       `gorm:"not null;index"                            json:"fc_user_id"`
+	FCCharacterName string     `gorm:"size:128"                                  json:"fc_character_name"`
+	Status          string     `gorm:"size:16;not null;default:'scheduled';index" json:"status"`
+	FleetID         *string    `gorm:"size:36"                                   json:"fleet_id,omitempty"` // 集结后创建的舰队
+	CreatedBy       uint       `gorm:"not null"                                  json:"created_by"`
+}
+
+func (FleetOperation) TableName() string { return "fleet_operation" }
+
+// OperationRSVP 行动报名（每用户每行动一条）
+type OperationRSVP struct {
+	ID            uint      `gorm:"primarykey"                                 json:"id"`
+	OperationID   uint      `gorm:"not null;uniqueIndex:idx_operation_rsvp"    json:"operation_id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_operation_rsvp"    json:"user_id"`
+	CharacterID   int64     `gorm:"not null"                                   json:"character_id"`
+	CharacterName string    `gorm:"size:128"                                   json:"character_name"`
+	Status        string    `gorm:"size:8;not null"                            json:"status"`                 // yes / maybe / no
+	FittingID     *uint     `gorm:""                                           json:"fitting_id,omitempty"`   // 行动舰队配置中的装配
+	ShipTypeID    *int64    `gorm:""                                           json:"ship_type_id,omitempty"` // 未选装配时的意向舰船
+	Role          string    `gorm:"size:16"                                    json:"role"`                   // dps / logi / tackle …
+	Note          string    `gorm:"size:256"                                   json:"note"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"                             json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"                             json:"updated_at"`
+}
+
+func (OperationRSVP) TableName() string { return "operation_rsvp" }
+
+// CalendarFeedToken 个人 iCal 订阅令牌（仅存哈希，明文只在生成时返回一次）
+type CalendarFeedToken struct {
+	ID        uint      `gorm:"primarykey"             json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex"   json:"user_id"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime"         json:"created_at"`
+}
+
+func (CalendarFeedToken) TableName() string { return "calendar_feed_token" }
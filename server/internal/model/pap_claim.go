@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// ─────────────────────────────────────────────
+//  PAP 补登申请
+//  成员为漏记 PAP 的舰队提交申请，系统按位置记录、击杀邮件与 ESI 舰队快照自动核对，
+//  再由舰队 FC 或管理员审批；通过时按舰队 PAP 策略补发并更新钱包
+// ─────────────────────────────────────────────
+
+// PAP 补登申请状态
+const (
+	PapClaimStatusPending   = "pending"   // 待审批
+	PapClaimStatusApproved  = "approved"  // 已通过并补发
+	PapClaimStatusRejected  = "rejected"  // 已拒绝
+	PapClaimStatusWithdrawn = "withdrawn" // 申请人撤回
+)
+
+// PAP 补登自动核对结论
+const (
+	PapClaimVerdictSupported  = "supported"   // 至少一项证据与舰队吻合
+	PapClaimVerdictNoEvidence = "no_evidence" // 没有找到吻合的证据
+)
+
+// PAP 补登核对来源
+const (
+	PapClaimCheckFleetSnapshot = "fleet_snapshot" // ESI 舰队成员快照 / 在队时间段
+	PapClaimCheckLocation      = "location"       // 角色位置记录
+	PapClaimCheckKillmail      = "killmail"       // 击杀邮件
+)
+
+// PAP 补登操作记录类型
+const (
+	PapClaimActionSubmit   = "submit"
+	PapClaimActionRecheck  = "recheck"
+	PapClaimActionApprove  = "approve"
+	PapClaimActionReject   = "reject"
+	PapClaimActionWithdraw = "withdraw"
+)
+
+// PapClaimCheck 单项自动核对结果
+type PapClaimCheck struct {
+	Source  string `json:"source"`  // fleet_snapshot / location / killmail
+	Matched bool   `json:"matched"` // 是否与舰队吻合
+	Detail  string `json:"detail"`  // 核对说明
+}
+
+// PapClaim PAP 补登申请
+type PapClaim struct {
+	BaseModel
+	FleetID       string `gorm:"size:36;not null;index"                    json:"fleet_id"`
+	UserID        uint   `gorm:"not null;index"                            json:"user_id"`
+	CharacterID   int64  `gorm:"not null;index"                            json:"character_id"`
+	CharacterName string `gorm:"size:128"                                  json:"character_name"`
+	Evidence      string `gorm:"type:text"                                 json:"evidence"` // 申请人提供的说明 / 截图链接
+	Status        string `gorm:"size:16;not null;default:'pending';index"  json:"status"`
+	// 自动核对
+	AutoVerdict string          `gorm:"size:16"                                   json:"auto_verdict"`
+	Checks      []PapClaimCheck `gorm:"type:text;serializer:json"                 json:"checks"`
+	CheckedAt   *time.Time      `gorm:""                                          json:"checked_at,omitempty"`
+	// 审批
+	PapCount       float64    `gorm:"not null;default:0"                        json:"pap_count"` // 通过时补发的 PAP
+	ReviewerID     *uint      `gorm:""                                          json:"reviewer_id,omitempty"`
+	DecidedAt      *time.Time `gorm:""                                          json:"decided_at,omitempty"`
+	DecisionReason string     `gorm:"size:512"                                  json:"decision_reason"`
+	// 展示用
+	FleetTitle string `gorm:"-" json:"fleet_title"`
+}
+
+func (PapClaim) TableName() string { return "pap_claim" }
+
+// PapClaimLog PAP 补登申请操作记录（提交、重新核对、审批、撤回）
+type PapClaimLog struct {
+	ID           uint      `gorm:"primarykey"             json:"id"`
+	ClaimID      uint      `gorm:"not null;index"         json:"claim_id"`
+	OperatorID   uint      `gorm:"not null"               json:"operator_id"`
+	OperatorName string    `gorm:"size:128"               json:"operator_name"`
+	Action       string    `gorm:"size:16;not null"       json:"action"`
+	FromStatus   string    `gorm:"size:16"                json:"from_status,omitempty"`
+	ToStatus     string    `gorm:"size:16"                json:"to_status,omitempty"`
+	Note         string    `gorm:"type:text"              json:"note"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"         json:"created_at"`
+}
+
+func (PapClaimLog) TableName() string { return "pap_claim_log" }
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// ─────────────────────────────────────────────
+//  招新
+//  申请人授权所需 scope 后提交申请表，招新官查看审核视图、留言并流转状态，
+//  通过时按需授予角色 / 加入准入名单，之后拒绝会撤销通过时所做的变更
+// ─────────────────────────────────────────────
+
+// 招新申请状态
+const (
+	RecruitStatusPending   = "pending"   // 待处理
+	RecruitStatusReviewing = "reviewing" // 审核中
+	RecruitStatusAccepted  = "accepted"  // 已通过
+	RecruitStatusRejected  = "rejected"  // 已拒绝
+	RecruitStatusWithdrawn = "withdrawn" // 申请人撤回
+)
+
+// 通过申请时加入 basic_access 准入名单的范围
+const (
+	RecruitAllowListNone        = ""            // 不修改准入名单
+	RecruitAllowListCorporation = "corporation" // 申请人主角色当前所在军团
+	RecruitAllowListAlliance    = "alliance"    // 申请人主角色当前所在联盟
+)
+
+// 招新配置 Key
+const (
+	SysConfigRecruitQuestions = "recruit.questions" // 申请表问题（JSON 字符串数组）
+)
+
+// RecruitAnswer 申请表问答
+type RecruitAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// RecruitApplication 招新申请（角色信息为提交时主角色的快照）
+type RecruitApplication struct {
+	BaseModel
+	UserID        uint            `gorm:"not null;index"                            json:"user_id"`
+	CharacterID   int64           `gorm:"not null;index"                            json:"character_id"`
+	CharacterName string          `gorm:"size:128"                                  json:"character_name"`
+	CorporationID int64           `gorm:"not null;default:0"                        json:"corporation_id"`
+	AllianceID    int64           `gorm:"not null;default:0"                        json:"alliance_id"`
+	Status        string          `gorm:"size:16;not null;default:'pending';index"  json:"status"`
+	Answers       []RecruitAnswer `gorm:"type:text;serializer:json"                 json:"answers"`
+	// 通过时的变更（拒绝已通过的申请时据此撤销）
+	GrantedRoleIDs []uint `gorm:"type:text;serializer:json"                 json:"granted_role_ids"`
+	AllowEntityID  uint   `gorm:"not null;default:0"                        json:"allow_entity_id"` // 通过时新增的准入名单条目，0 表示未新增（拒绝时不会自动移除）
+	// 审核
+	ReviewerID     *uint      `gorm:""                                          json:"reviewer_id,omitempty"`
+	DecidedAt      *time.Time `gorm:""                                          json:"decided_at,omitempty"`
+	DecisionReason string     `gorm:"size:512"                                  json:"decision_reason"`
+}
+
+func (RecruitApplication) TableName() string { return "recruit_application" }
+
+// IsOpen 申请是否仍在处理中（申请人同时只能有一份处理中的申请）
+func (a *RecruitApplication) IsOpen() bool {
+	return a.Status == RecruitStatusPending || a.Status == RecruitStatusReviewing
+}
+
+// RecruitComment 招新申请留言；FromStatus / ToStatus 非空时为状态变更记录
+type RecruitComment struct {
+	ID            uint      `gorm:"primarykey"             json:"id"`
+	ApplicationID uint      `gorm:"not null;index"         json:"application_id"`
+	AuthorID      uint      `gorm:"not null"               json:"author_id"`
+	AuthorName    string    `gorm:"size:128"               json:"author_name"`
+	Content       string    `gorm:"type:text"              json:"content"`
+	FromStatus    string    `gorm:"size:16"                json:"from_status,omitempty"`
+	ToStatus      string    `gorm:"size:16"                json:"to_status,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"         json:"created_at"`
+}
+
+func (RecruitComment) TableName() string { return "recruit_comment" }
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
 // --- 系统角色编码常量 ---
 
 const (
@@ -44,6 +49,96 @@ type UserRole struct {
 
 func (UserRole) TableName() string { return "user_role" }
 
+// --- 限定作用范围的角色（多军团）---
+
+const (
+	RoleScopeCorporation = "corporation"
+	RoleScopeAlliance    = "alliance"
+)
+
+// UserRoleScope 限定作用范围的用户角色：角色权限只对主角色属于指定军团/联盟的用户数据生效
+// 与 UserRole（全局角色）相互独立，不计入 GetUserRoleCodes / RequireRole
+type UserRoleScope struct {
+	ID        uint      `gorm:"primarykey"                                       json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_role_scope"         json:"user_id"`
+	RoleID    uint      `gorm:"not null;uniqueIndex:idx_user_role_scope;index"   json:"role_id"`
+	ScopeType string    `gorm:"size:16;not null;uniqueIndex:idx_user_role_scope" json:"scope_type"` // corporation / alliance
+	ScopeID   int64     `gorm:"not null;uniqueIndex:idx_user_role_scope"         json:"scope_id"`
+	CreatedBy uint      `gorm:"not null;default:0"                               json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	RoleCode string `gorm:"-" json:"role_code,omitempty"`
+	RoleName string `gorm:"-" json:"role_name,omitempty"`
+}
+
+func (UserRoleScope) TableName() string { return "user_role_scope" }
+
+// ScopablePermissions 支持限定作用范围的权限标识：对应接口按 DataScope 过滤数据
+// 限定范围的角色只授予其中被角色权限覆盖的部分，其余权限（系统配置、角色管理等）不生效
+var ScopablePermissions = []string{
+	"srp:review",
+	"operation:fleet:manage",
+	"operation:fleet:pap",
+	"operation:analytics:view",
+	"system:wallet:view",
+	"system:wallet:adjust",
+	"system:wallet:log",
+	"system:pap:view",
+	"system:pap:settle",
+}
+
+// IsScopablePermission 检查权限标识是否支持限定作用范围
+func IsScopablePermission(code string) bool {
+	for _, p := range ScopablePermissions {
+		if p == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopedGrant 用户通过限定范围角色获得的权限（已展开为军团 ID）
+type ScopedGrant struct {
+	RoleCode       string   `json:"role_code"`
+	Permissions    []string `json:"permissions"`
+	CorporationIDs []int64  `json:"corporation_ids"`
+}
+
+// DataScope 数据范围：nil 表示不限制；否则只能访问主角色属于 CorporationIDs 的用户数据
+type DataScope struct {
+	CorporationIDs []int64 `json:"corporation_ids"`
+}
+
+// ContainsCorporation 检查军团是否在数据范围内
+func (d *DataScope) ContainsCorporation(corpID int64) bool {
+	if d == nil {
+		return true
+	}
+	for _, id := range d.CorporationIDs {
+		if id == corpID {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersect 取两个数据范围的交集（nil 表示不限制）
+func (d *DataScope) Intersect(other *DataScope) *DataScope {
+	if d == nil {
+		return other
+	}
+	if other == nil {
+		return d
+	}
+	result := &DataScope{CorporationIDs: make([]int64, 0)}
+	for _, id := range d.CorporationIDs {
+		if other.ContainsCorporation(id) {
+			result.CorporationIDs = append(result.CorporationIDs, id)
+		}
+	}
+	return result
+}
+
 // --- 角色检查辅助函数 ---
 
 // IsSuperAdmin 检查角色列表中是否包含超级管理员
@@ -89,6 +184,22 @@ func HasAnyRoleMatch(userRoles []string, requiredRole string) bool {
 	return ContainsRole(userRoles, requiredRole)
 }
 
+// PermissionCovers 检查已拥有的权限 have 是否满足 required
+// 精确匹配，或拥有父级权限（如 "srp" 满足 "srp:review"、"srp:price:edit"）
+func PermissionCovers(have, required string) bool {
+	return have == required || strings.HasPrefix(required, have+":")
+}
+
+// HasAnyPermission 检查权限列表中是否有任一权限满足 required
+func HasAnyPermission(perms []string, required string) bool {
+	for _, have := range perms {
+		if PermissionCovers(have, required) {
+			return true
+		}
+	}
+	return false
+}
+
 // HasRole 兼容接口
 func HasRole(userRole, requiredRole string) bool {
 	if userRole == RoleSuperAdmin {
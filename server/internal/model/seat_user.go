@@ -9,8 +9,8 @@ type SeatUser struct {
 	SeatUsername string    `gorm:"size:128;not null"            json:"seat_username"` // SeAT 用户名 (nam)
 	UserID       uint      `gorm:"uniqueIndex;not null"         json:"user_id"`       // 本系统用户 ID
 	MainCharID   int64     `gorm:"default:0"                    json:"main_char_id"`  // SeAT 主角色 character_id (uid)
-	AccessToken  string    `gorm:"type:text"                    json:"-"`
-	RefreshToken string    `gorm:"type:text"                    json:"-"`
+	AccessToken  string    `gorm:"type:text;serializer:encrypted" json:"-"`
+	RefreshToken string    `gorm:"type:text;serializer:encrypted" json:"-"`
 	TokenExpiry  time.Time `gorm:""                             json:"token_expiry"`
 	Groups       string    `gorm:"type:text"                    json:"groups"` // JSON 数组字符串
 }
@@ -11,8 +11,26 @@ const (
 
 // SRP 发放状态
 const (
-	SrpPayoutPending = "pending" // 待发放
-	SrpPayoutPaid    = "paid"    // 已发放
+	SrpPayoutPending  = "pending"  // 待发放
+	SrpPayoutPaid     = "paid"     // 已发放
+	SrpPayoutReversed = "reversed" // 已追回（发放后被撤销）
+)
+
+// SRP 定价策略
+const (
+	SrpPolicyFlat        = "flat"         // 固定价格表（舰队配置装配金额 > 舰船价格表）
+	SrpPolicyFittedValue = "fitted_value" // 船体 + 装配槽位装备的市场价值
+	SrpPolicyLossPercent = "loss_percent" // 损失价值（船体 + 装配）按比例补损，可设上限
+	SrpPolicyDoctrineCap = "doctrine_cap" // 损失价值，不超过舰队配置装配金额（无配置时为价格表金额）
+)
+
+// SRP 金额明细行类型
+const (
+	SrpLineFlat       = "flat"       // 固定金额
+	SrpLineHull       = "hull"       // 船体
+	SrpLineModule     = "module"     // 装配槽位装备（摧毁或掉落）
+	SrpLineExcluded   = "excluded"   // 不计入补损的物品（货柜舱、无人机舱等），仅作展示
+	SrpLineAdjustment = "adjustment" // 比例 / 上限调整（金额为负）
 )
 
 // SrpShipPrice 舰船标准补损金额表（可由 srp/admin 编辑）
@@ -51,6 +69,12 @@ type SrpApplication struct {
 	// 金额
 	RecommendedAmount float64 `gorm:"not null;default:0"                     json:"recommended_amount"`
 	FinalAmount       float64 `gorm:"not null;default:0"                     json:"final_amount"`
+	// 定价（推荐金额的计算依据，明细见 SrpApplicationLine）
+	PricingPolicy string  `gorm:"size:32"                                json:"pricing_policy"`
+	PricingBasis  string  `gorm:"size:64"                                json:"pricing_basis"`
+	LossValue     float64 `gorm:"type:decimal(20,2);not null;default:0"  json:"loss_value"`
+	// NeedsManualReview 推荐金额为回退结果（如船体缺少市场价格），审批前需人工核对
+	NeedsManualReview bool `gorm:"not null;default:false" json:"needs_manual_review"`
 	// 审批
 	ReviewStatus string     `gorm:"size:32;not null;default:'pending';index" json:"review_status"`
 	ReviewedBy   *uint      `gorm:""                                         json:"reviewed_by,omitempty"`
@@ -66,3 +90,19 @@ type SrpApplication struct {
 }
 
 func (SrpApplication) TableName() string { return "srp_application" }
+
+// SrpApplicationLine 补损推荐金额明细（提交 / 重新定价时按当前定价策略生成）
+type SrpApplicationLine struct {
+	ID            uint    `gorm:"primarykey"                             json:"id"`
+	ApplicationID uint    `gorm:"not null;index"                         json:"application_id"`
+	Kind          string  `gorm:"size:32;not null"                       json:"kind"`
+	TypeID        int64   `gorm:"default:0"                              json:"type_id"`
+	Quantity      int64   `gorm:"default:0"                              json:"quantity"`
+	Flag          string  `gorm:"size:64"                                json:"flag"`
+	Dropped       bool    `gorm:"default:false"                          json:"dropped"`
+	UnitPrice     float64 `gorm:"type:decimal(20,2);not null;default:0"  json:"unit_price"`
+	Amount        float64 `gorm:"type:decimal(20,2);not null;default:0"  json:"amount"` // 计入推荐金额的部分
+	Note          string  `gorm:"size:256"                               json:"note"`
+}
+
+func (SrpApplicationLine) TableName() string { return "srp_application_line" }
@@ -67,6 +67,19 @@ const (
 	SysConfigAutoRoleAllowOnlyMainChar    = "allow.auto_role.only_main_char"    // 自动权限准入（bool）
 	SysConfigBasicAccessAllowOnlyMainChar = "allow.basic_access.only_main_char" // 基础访问准入（bool）
 
+	// 批量权限同步保护：单轮将被移除角色的用户比例超过该值（%）时中止并告警，0 关闭
+	SysConfigRoleSyncMaxRemovePercent = "role_sync.max_remove_percent"
+
+	// 舰队持续跟踪：轮询 ESI 舰队成员的间隔（秒），0 关闭
+	SysConfigFleetTrackingInterval = "fleet.tracking_interval"
+
+	// SRP 定价策略
+	SysConfigSrpPricingPolicy = "srp.pricing_policy" // flat | fitted_value | loss_percent | doctrine_cap
+	SysConfigSrpPriceBasis    = "srp.price_basis"    // 市场估价基准，如 jita_sell_percentile、adjusted
+	SysConfigSrpLossPercent   = "srp.loss_percent"   // loss_percent 策略的补损比例（0-100，float）
+	SysConfigSrpLossCap       = "srp.loss_cap"       // loss_percent 策略的单笔上限（float，0 表示不限）
+	SysConfigSrpWalletPayout  = "srp.wallet_payout"  // 发放时是否计入系统钱包（bool）
+
 	SysConfigDefaultCorpID    int64  = 1
 	SysConfigDefaultSiteTitle string = "Amiya eden"
 
@@ -74,6 +74,7 @@ const (
 	WalletRefRedeem       = "redeem"         // 兑换消费
 	WalletRefAdminAdjust  = "admin_adjust"   // 管理员调整
 	WalletRefSrpPayout    = "srp_payout"     // SRP 补损发放
+	WalletRefSrpReversal  = "srp_reversal"   // SRP 补损追回（已发放的申请被撤销）
 	WalletRefShopBuy      = "shop_purchase"  // 商城购买
 	WalletRefShopRefund   = "shop_refund"    // 商城退款（审批拒绝）
 	WalletRefLotteryDraw  = "lottery_draw"   // 抽奖消费
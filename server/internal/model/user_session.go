@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 会话吊销原因
+const (
+	SessionRevokeLogout           = "logout"              // 用户主动登出
+	SessionRevokeByUser           = "revoked_by_user"     // 用户在设备列表中登出其它会话
+	SessionRevokeByAdmin          = "revoked_by_admin"    // 管理员强制下线
+	SessionRevokeDisabled         = "user_disabled"       // 用户被禁用 / 删除
+	SessionRevokeTokenReuse       = "token_reuse"         // 已轮换的 Refresh Token 被再次使用（疑似泄露）
+	SessionRevokeImpersonationEnd = "impersonation_ended" // 管理员结束模拟登录
+)
+
+// UserSession 登录会话（每次登录生成一条，Access Token 通过 sid 关联）
+type UserSession struct {
+	ID               string     `gorm:"primaryKey;size:36"       json:"id"`
+	UserID           uint       `gorm:"not null;index"           json:"user_id"`
+	CharacterID      int64      `gorm:"default:0"                json:"character_id"`
+	RefreshTokenHash string     `gorm:"size:64;uniqueIndex"      json:"-"` // 当前 Refresh Token 的 SHA-256
+	PrevTokenHash    string     `gorm:"size:64;index"            json:"-"` // 上一个 Refresh Token 的 SHA-256（用于检测重放）
+	UserAgent        string     `gorm:"size:512"                 json:"user_agent"`
+	Device           string     `gorm:"size:128"                 json:"device"`
+	IP               string     `gorm:"size:64"                  json:"ip"`
+	LastSeenAt       time.Time  `gorm:"not null"                 json:"last_seen_at"`
+	ExpiresAt        time.Time  `gorm:"not null;index"           json:"expires_at"`
+	RevokedAt        *time.Time `gorm:"index"                    json:"revoked_at,omitempty"`
+	RevokedReason    string     `gorm:"size:64"                  json:"revoked_reason,omitempty"`
+	ImpersonatorID   uint       `gorm:"default:0;index"          json:"impersonator_id,omitempty"` // 模拟登录：发起模拟的管理员用户 ID
+	ParentSessionID  string     `gorm:"size:36"                  json:"-"`                         // 模拟登录：管理员自己的会话，结束模拟后回到该会话
+	ReadOnly         bool       `gorm:"default:false"            json:"read_only,omitempty"`       // 模拟登录：只读（禁止写操作）
+	CreatedAt        time.Time  `gorm:"autoCreateTime"           json:"created_at"`
+}
+
+func (UserSession) TableName() string { return "user_session" }
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+)
+
+// AffiliationHistoryRepository 角色归属历史数据访问层
+type AffiliationHistoryRepository struct{}
+
+func NewAffiliationHistoryRepository() *AffiliationHistoryRepository {
+	return &AffiliationHistoryRepository{}
+}
+
+// CreateEvents 批量写入归属事件
+func (r *AffiliationHistoryRepository) CreateEvents(events []model.CharacterAffiliationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return global.DB.Create(&events).Error
+}
+
+// ListByUser 查询用户所有角色的归属事件（按时间倒序）
+func (r *AffiliationHistoryRepository) ListByUser(userID uint) ([]model.CharacterAffiliationEvent, error) {
+	var events []model.CharacterAffiliationEvent
+	err := global.DB.Where("user_id = ?", userID).
+		Order("occurred_at DESC, id DESC").
+		Find(&events).Error
+	return events, err
+}
+
+// DepartureFilter 离开事件查询条件；军团与联盟均为空时返回全部离开军团事件
+type DepartureFilter struct {
+	Since          time.Time
+	CorporationIDs []int64
+	AllianceIDs    []int64
+}
+
+// ListDepartures 分页查询离开指定军团 / 联盟的事件（按时间倒序）
+func (r *AffiliationHistoryRepository) ListDepartures(page, pageSize int, filter DepartureFilter) ([]model.CharacterAffiliationEvent, int64, error) {
+	var events []model.CharacterAffiliationEvent
+	var total int64
+
+	db := global.DB.Model(&model.CharacterAffiliationEvent{}).Where("occurred_at >= ?", filter.Since)
+	switch {
+	case len(filter.CorporationIDs) > 0 && len(filter.AllianceIDs) > 0:
+		db = db.Where(global.DB.
+			Where("event_type = ? AND from_corporation_id IN ?", model.AffiliationEventCorpLeave, filter.CorporationIDs).
+			Or("event_type = ? AND from_alliance_id IN ?", model.AffiliationEventAllianceLeave, filter.AllianceIDs))
+	case len(filter.CorporationIDs) > 0:
+		db = db.Where("event_type = ? AND from_corporation_id IN ?", model.AffiliationEventCorpLeave, filter.CorporationIDs)
+	case len(filter.AllianceIDs) > 0:
+		db = db.Where("event_type = ? AND from_alliance_id IN ?", model.AffiliationEventAllianceLeave, filter.AllianceIDs)
+	default:
+		db = db.Where("event_type = ?", model.AffiliationEventCorpLeave)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := db.Order("occurred_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&events).Error
+	return events, total, err
+}
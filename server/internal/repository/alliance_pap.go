@@ -114,18 +114,26 @@ func (r *AlliancePAPRepository) ListAllMainCharacters() ([]string, error) {
 	return names, err
 }
 
-// MarkArchived 将某月所有记录和汇总标记为已归档
-func (r *AlliancePAPRepository) MarkArchived(year, month int) error {
-	if err := global.DB.
-		Model(&model.AlliancePAPRecord{}).
-		Where("year = ? AND month = ?", year, month).
-		Update("is_archived", true).Error; err != nil {
+// MarkArchived 将某月记录和汇总标记为已归档
+// corporationIDs 非空时只归档这些军团的数据（明细按主角色关联汇总的军团）
+func (r *AlliancePAPRepository) MarkArchived(year, month int, corporationIDs []int64) error {
+	records := global.DB.Model(&model.AlliancePAPRecord{}).Where("year = ? AND month = ?", year, month)
+	summaries := global.DB.Model(&model.AlliancePAPSummary{}).Where("year = ? AND month = ?", year, month)
+	if len(corporationIDs) > 0 {
+		strIDs := make([]string, len(corporationIDs))
+		for i, id := range corporationIDs {
+			strIDs[i] = fmt.Sprintf("%d", id)
+		}
+		mainChars := global.DB.Model(&model.AlliancePAPSummary{}).
+			Select("main_character").
+			Where("year = ? AND month = ? AND corporation_id IN ?", year, month, strIDs)
+		records = records.Where("main_character IN (?)", mainChars)
+		summaries = summaries.Where("corporation_id IN ?", strIDs)
+	}
+	if err := records.Update("is_archived", true).Error; err != nil {
 		return err
 	}
-	return global.DB.
-		Model(&model.AlliancePAPSummary{}).
-		Where("year = ? AND month = ?", year, month).
-		Update("is_archived", true).Error
+	return summaries.Update("is_archived", true).Error
 }
 
 // ListSummariesByMainChar 查询指定主角色的月度汇总（最近 N 条）
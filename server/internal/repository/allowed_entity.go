@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 const (
@@ -39,10 +41,19 @@ func (r *AllowedEntityRepository) Add(e *model.AllowedEntity) error {
 	if result.Error != nil {
 		return result.Error
 	}
-	r.invalidateCache(e.ListType)
+	r.InvalidateCache(e.ListType)
 	return nil
 }
 
+// AddTx 在事务中添加实体到名单（已存在则忽略），返回是否新增；
+// 提交后须由调用方调用 InvalidateCache，避免事务未提交时缓存被旧数据回填
+func (r *AllowedEntityRepository) AddTx(tx *gorm.DB, e *model.AllowedEntity) (bool, error) {
+	result := tx.
+		Where("list_type = ? AND entity_id = ?", e.ListType, e.EntityID).
+		FirstOrCreate(e)
+	return result.RowsAffected > 0, result.Error
+}
+
 // Remove 从名单中删除实体
 func (r *AllowedEntityRepository) Remove(id uint) error {
 	var e model.AllowedEntity
@@ -52,7 +63,7 @@ func (r *AllowedEntityRepository) Remove(id uint) error {
 	if err := global.DB.Delete(&model.AllowedEntity{}, id).Error; err != nil {
 		return err
 	}
-	r.invalidateCache(e.ListType)
+	r.InvalidateCache(e.ListType)
 	return nil
 }
 
@@ -111,8 +122,8 @@ func (r *AllowedEntityRepository) IsNonEmpty(listType string) (bool, error) {
 	return count > 0, err
 }
 
-// invalidateCache 使对应名单的缓存失效
-func (r *AllowedEntityRepository) invalidateCache(listType string) {
+// InvalidateCache 使对应名单的缓存失效
+func (r *AllowedEntityRepository) InvalidateCache(listType string) {
 	ctx := context.Background()
 	_ = cache.Del(ctx, allowEntityCachePrefix+listType+":ids")
 }
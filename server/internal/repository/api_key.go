@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+)
+
+// APIKeyRepository API Key 数据访问层
+type APIKeyRepository struct{}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+// Create 创建 API Key
+func (r *APIKeyRepository) Create(key *model.APIKey) error {
+	return global.DB.Create(key).Error
+}
+
+// GetByID 按 ID 查询
+func (r *APIKeyRepository) GetByID(id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := global.DB.First(&key, id).Error
+	return &key, err
+}
+
+// GetByHash 按 Key 哈希查询
+func (r *APIKeyRepository) GetByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := global.DB.Where("key_hash = ?", hash).First(&key).Error
+	return &key, err
+}
+
+// ListByUser 查询用户的 API Key
+func (r *APIKeyRepository) ListByUser(userID uint) ([]model.APIKey, error) {
+	var list []model.APIKey
+	err := global.DB.Where("user_id = ?", userID).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// List 分页查询所有 API Key（userID 为 0 时不过滤）
+func (r *APIKeyRepository) List(page, pageSize int, userID uint) ([]model.APIKey, int64, error) {
+	var list []model.APIKey
+	var total int64
+	db := global.DB.Model(&model.APIKey{})
+	if userID > 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&list).Error
+	return list, total, err
+}
+
+// TouchLastUsed 更新最近使用时间与 IP
+func (r *APIKeyRepository) TouchLastUsed(id uint, ip string, at time.Time) error {
+	return global.DB.Model(&model.APIKey{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
+
+// Delete 吊销（软删除）API Key
+func (r *APIKeyRepository) Delete(id uint) error {
+	return global.DB.Delete(&model.APIKey{}, id).Error
+}
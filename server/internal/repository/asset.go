@@ -46,3 +46,23 @@ func (r *AssetRepository) GetStationByID(stationID int64) (*model.EveStation, er
 func (r *AssetRepository) UpsertStation(s *model.EveStation) error {
 	return global.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(s).Error
 }
+
+// GetAssetsByTypeIDs 批量获取多个角色指定物品类型的资产
+func (r *AssetRepository) GetAssetsByTypeIDs(characterIDs []int64, typeIDs []int) ([]model.EveCharacterAsset, error) {
+	var assets []model.EveCharacterAsset
+	if len(characterIDs) == 0 || len(typeIDs) == 0 {
+		return assets, nil
+	}
+	err := global.DB.Where("character_id IN ? AND type_id IN ?", characterIDs, typeIDs).Find(&assets).Error
+	return assets, err
+}
+
+// GetAssetsByItemIDs 按 item_id 批量获取资产（用于向上查找容器所在位置）
+func (r *AssetRepository) GetAssetsByItemIDs(itemIDs []int64) ([]model.EveCharacterAsset, error) {
+	var assets []model.EveCharacterAsset
+	if len(itemIDs) == 0 {
+		return assets, nil
+	}
+	err := global.DB.Where("item_id IN ?", itemIDs).Find(&assets).Error
+	return assets, err
+}
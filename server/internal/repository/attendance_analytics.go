@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+)
+
+// AttendanceAnalyticsRepository 出勤统计数据访问层
+type AttendanceAnalyticsRepository struct{}
+
+func NewAttendanceAnalyticsRepository() *AttendanceAnalyticsRepository {
+	return &AttendanceAnalyticsRepository{}
+}
+
+// AttendanceRow 单条出勤记录（PAP 记录 + 舰队时间 + 用户主角色军团 + 成员快照舰船）
+type AttendanceRow struct {
+	FleetID       string    `json:"fleet_id"`
+	FleetStartAt  time.Time `json:"fleet_start_at"`
+	CharacterID   int64     `json:"character_id"`
+	UserID        uint      `json:"user_id"`
+	PapCount      float64   `json:"pap_count"`
+	CorporationID int64     `json:"corporation_id"`
+	ShipTypeID    *int64    `json:"ship_type_id"`
+}
+
+// ListAttendance 查询自 since 起开始的舰队中获得 PAP 的记录
+// corporationIDs 非 nil 时只统计主角色属于这些军团的用户
+func (r *AttendanceAnalyticsRepository) ListAttendance(since time.Time, corporationIDs []int64) ([]AttendanceRow, error) {
+	var rows []AttendanceRow
+	db := global.DB.Table("fleet_pap_log p").
+		Select(`p.fleet_id,
+			f.start_at AS fleet_start_at,
+			p.character_id,
+			p.user_id,
+			p.pap_count,
+			COALESCE(mc.corporation_id, 0) AS corporation_id,
+			fm.ship_type_id`).
+		Joins("JOIN fleet f ON f.id = p.fleet_id AND f.deleted_at IS NULL").
+		Joins(`LEFT JOIN "user" u ON u.id = p.user_id`).
+		Joins("LEFT JOIN eve_character mc ON mc.character_id = u.primary_character_id").
+		Joins("LEFT JOIN fleet_member fm ON fm.fleet_id = p.fleet_id AND fm.character_id = p.character_id").
+		Where("f.start_at >= ? AND p.pap_count > 0", since)
+	if corporationIDs != nil {
+		db = db.Where("mc.corporation_id IN ?", corporationIDs)
+	}
+	err := db.Scan(&rows).Error
+	return rows, err
+}
+
+// FleetTurnoutRow 舰队出勤人数
+type FleetTurnoutRow struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	StartAt         time.Time `json:"start_at"`
+	Importance      string    `json:"importance"`
+	FCUserID        uint      `json:"fc_user_id"`
+	FCCharacterName string    `json:"fc_character_name"`
+	Pilots          int       `json:"pilots"` // 获得 PAP 的角色数
+	Users           int       `json:"users"`  // 获得 PAP 的用户数
+	TotalPap        float64   `json:"total_pap"`
+}
+
+// ListFleetTurnout 查询自 since 起开始的舰队及其出勤人数
+// corporationIDs 非 nil 时只统计 FC 主角色属于这些军团的舰队
+func (r *AttendanceAnalyticsRepository) ListFleetTurnout(since time.Time, corporationIDs []int64) ([]FleetTurnoutRow, error) {
+	var rows []FleetTurnoutRow
+	db := global.DB.Table("fleet f").
+		Select(`f.id, f.title, f.start_at, f.importance, f.fc_user_id, f.fc_character_name,
+			COUNT(DISTINCT p.character_id) AS pilots,
+			COUNT(DISTINCT p.user_id) AS users,
+			COALESCE(SUM(p.pap_count), 0) AS total_pap`).
+		Joins("LEFT JOIN fleet_pap_log p ON p.fleet_id = f.id AND p.pap_count > 0").
+		Where("f.start_at >= ? AND f.start_at <= ? AND f.deleted_at IS NULL", since, time.Now())
+	if corporationIDs != nil {
+		db = db.Joins(`LEFT JOIN "user" fu ON fu.id = f.fc_user_id`).
+			Joins("LEFT JOIN eve_character fc ON fc.character_id = fu.primary_character_id").
+			Where("fc.corporation_id IN ?", corporationIDs)
+	}
+	err := db.Group("f.id").Order("f.start_at ASC").Scan(&rows).Error
+	return rows, err
+}
+
+// UserSummary 用户及其主角色
+type UserSummary struct {
+	UserID            uint       `json:"user_id"`
+	Nickname          string     `json:"nickname"`
+	MainCharacterID   int64      `json:"main_character_id"`
+	MainCharacterName string     `json:"main_character_name"`
+	CorporationID     int64      `json:"corporation_id"`
+	LastLoginAt       *time.Time `json:"last_login_at"`
+}
+
+// ListUserSummaries 批量查询用户及其主角色
+func (r *AttendanceAnalyticsRepository) ListUserSummaries(userIDs []uint) ([]UserSummary, error) {
+	var rows []UserSummary
+	if len(userIDs) == 0 {
+		return rows, nil
+	}
+	err := global.DB.Table(`"user" u`).
+		Select(`u.id AS user_id, u.nickname, u.primary_character_id AS main_character_id,
+			COALESCE(ec.character_name, '') AS main_character_name,
+			COALESCE(ec.corporation_id, 0) AS corporation_id,
+			u.last_login_at`).
+		Joins("LEFT JOIN eve_character ec ON ec.character_id = u.primary_character_id").
+		Where("u.id IN ? AND u.deleted_at IS NULL", userIDs).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ListRoleHolders 查询拥有 exclude 以外角色的用户，返回 map[userID][]角色 code
+func (r *AttendanceAnalyticsRepository) ListRoleHolders(exclude []string) (map[uint][]string, error) {
+	var rows []userRoleRow
+	err := global.DB.Table("user_role").
+		Select("user_role.user_id, role.code").
+		Joins("JOIN role ON role.id = user_role.role_id").
+		Where("role.code NOT IN ?", exclude).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]string)
+	for _, row := range rows {
+		result[row.UserID] = append(result[row.UserID], row.Code)
+	}
+	return result, nil
+}
+
+// LastAttendanceByUser 每个用户最近一次获得 PAP 的舰队开始时间
+func (r *AttendanceAnalyticsRepository) LastAttendanceByUser(userIDs []uint) (map[uint]time.Time, error) {
+	result := make(map[uint]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	var rows []struct {
+		UserID uint
+		LastAt time.Time
+	}
+	err := global.DB.Table("fleet_pap_log p").
+		Select("p.user_id, MAX(f.start_at) AS last_at").
+		Joins("JOIN fleet f ON f.id = p.fleet_id AND f.deleted_at IS NULL").
+		Where("p.user_id IN ? AND p.pap_count > 0", userIDs).
+		Group("p.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.UserID] = row.LastAt
+	}
+	return result, nil
+}
+
+// SumAlliancePapByMain 按主角色名汇总自 since 起的联盟 PAP
+func (r *AttendanceAnalyticsRepository) SumAlliancePapByMain(since time.Time) (map[string]float64, error) {
+	var rows []struct {
+		MainCharacter string
+		Total         float64
+	}
+	err := global.DB.Model(&model.AlliancePAPRecord{}).
+		Select("main_character, COALESCE(SUM(pap), 0) AS total").
+		Where("start_at >= ?", since).
+		Group("main_character").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		result[row.MainCharacter] = row.Total
+	}
+	return result, nil
+}
@@ -183,6 +183,44 @@ func (r *AutoRoleRepository) ListAllCharacterCorpRoles() ([]model.EveCharacterCo
 	return roles, err
 }
 
+// ─── Auto Role Rule ───
+
+// ListAutoRoleRules 获取所有自动权限规则
+func (r *AutoRoleRepository) ListAutoRoleRules() ([]model.AutoRoleRule, error) {
+	var rules []model.AutoRoleRule
+	err := global.DB.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// ListEnabledAutoRoleRules 获取所有已启用的自动权限规则
+func (r *AutoRoleRepository) ListEnabledAutoRoleRules() ([]model.AutoRoleRule, error) {
+	var rules []model.AutoRoleRule
+	err := global.DB.Where("enabled = ?", true).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetAutoRoleRule 根据 ID 获取自动权限规则
+func (r *AutoRoleRepository) GetAutoRoleRule(id uint) (*model.AutoRoleRule, error) {
+	var rule model.AutoRoleRule
+	err := global.DB.First(&rule, id).Error
+	return &rule, err
+}
+
+// CreateAutoRoleRule 创建自动权限规则
+func (r *AutoRoleRepository) CreateAutoRoleRule(rule *model.AutoRoleRule) error {
+	return global.DB.Create(rule).Error
+}
+
+// UpdateAutoRoleRule 更新自动权限规则
+func (r *AutoRoleRepository) UpdateAutoRoleRule(rule *model.AutoRoleRule) error {
+	return global.DB.Save(rule).Error
+}
+
+// DeleteAutoRoleRule 删除自动权限规则
+func (r *AutoRoleRepository) DeleteAutoRoleRule(id uint) error {
+	return global.DB.Delete(&model.AutoRoleRule{}, id).Error
+}
+
 // ─── Auto Role Log ───
 
 // CreateAutoRoleLog 写入一条自动权限操作日志
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ─────────────────────────────────────────────
+//  数据范围（限定作用范围的角色）
+//  用户归属以主角色所在军团为准
+// ─────────────────────────────────────────────
+
+// scopedUserIDs 数据范围内的用户 ID 子查询
+func scopedUserIDs(scope *model.DataScope) *gorm.DB {
+	return global.DB.Table(`"user" scope_u`).
+		Select("scope_u.id").
+		Joins("JOIN eve_character scope_ec ON scope_ec.character_id = scope_u.primary_character_id").
+		Where("scope_ec.corporation_id IN ?", scope.CorporationIDs)
+}
+
+// ApplyUserScope 按数据范围过滤 column（用户 ID 列）；scope 为 nil 时不过滤
+func ApplyUserScope(db *gorm.DB, column string, scope *model.DataScope) *gorm.DB {
+	if scope == nil {
+		return db
+	}
+	return db.Where(column+" IN (?)", scopedUserIDs(scope))
+}
+
+// ManagedFleetFilter 可管理舰队的过滤条件：FC 为 UserID 本人，或 FC 在数据范围 Scope 内（Scope 为 nil 时仅限本人）
+type ManagedFleetFilter struct {
+	UserID uint
+	Scope  *model.DataScope
+}
+
+// ApplyManagedFleets 按可管理舰队过滤 column（舰队 ID 列）；filter 为 nil 时不过滤
+func ApplyManagedFleets(db *gorm.DB, column string, filter *ManagedFleetFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	fleets := global.DB.Model(&model.Fleet{}).Select("id")
+	if filter.Scope != nil {
+		fleets = fleets.Where("fc_user_id = ? OR fc_user_id IN (?)", filter.UserID, scopedUserIDs(filter.Scope))
+	} else {
+		fleets = fleets.Where("fc_user_id = ?", filter.UserID)
+	}
+	return db.Where(column+" IN (?)", fleets)
+}
+
+// UserInScope 检查用户是否在数据范围内；scope 为 nil 时恒为 true
+func UserInScope(userID uint, scope *model.DataScope) (bool, error) {
+	if scope == nil {
+		return true, nil
+	}
+	var count int64
+	err := scopedUserIDs(scope).Where("scope_u.id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// ListCorporationIDsByAlliance 查询联盟下已知的军团 ID（来源于已绑定角色的归属信息）
+func ListCorporationIDsByAlliance(allianceIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(allianceIDs) == 0 {
+		return ids, nil
+	}
+	err := global.DB.Model(&model.EveCharacter{}).
+		Where("alliance_id IN ? AND corporation_id > 0", allianceIDs).
+		Distinct("corporation_id").
+		Pluck("corporation_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/pkg/envelope"
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// ─────────────────────────────────────────────
+//  敏感字段加密存储
+//  模型字段声明 `gorm:"serializer:encrypted"` 后，写入时自动加密、读取时自动解密
+// ─────────────────────────────────────────────
+
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+}
+
+// encryptedSerializer 基于 envelope 的 GORM 字段序列化器（仅用于 string 字段）
+type encryptedSerializer struct{}
+
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var raw string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("encrypted 字段 %s 不支持的数据类型 %T", field.Name, dbValue)
+	}
+	plain, err := envelope.Decrypt(raw)
+	if err != nil {
+		return fmt.Errorf("解密字段 %s 失败: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plain)
+}
+
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plain, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted 字段 %s 必须为 string 类型", field.Name)
+	}
+	return envelope.Encrypt(plain)
+}
+
+// encryptedConfigKeys 需要加密存储的系统配置项
+var encryptedConfigKeys = map[string]struct{}{
+	model.SysConfigSeatClientSecret: {},
+	model.SysConfigMumbleAuthSecret: {},
+	model.SysConfigWebhookURL:       {},
+	model.SysConfigWebhookOBToken:   {},
+}
+
+func isEncryptedConfigKey(key string) bool {
+	_, ok := encryptedConfigKeys[key]
+	return ok
+}
+
+// encryptedColumns 需要加密存储的表字段（表名 → 列名）
+var encryptedColumns = []struct {
+	Table   string
+	Columns []string
+}{
+	{Table: "eve_character", Columns: []string{"access_token", "refresh_token"}},
+	{Table: "seat_user", Columns: []string{"access_token", "refresh_token"}},
+}
+
+// SecretMigrationResult 加密迁移 / 密钥轮换结果
+type SecretMigrationResult struct {
+	Rows    map[string]int64 `json:"rows"`    // 表名 → 更新行数
+	Configs int64            `json:"configs"` // 更新的系统配置项数
+}
+
+// ReencryptSecrets 将所有敏感字段迁移到当前版本主密钥
+//
+//	rotate=false：仅加密历史明文（一次性迁移，启动时执行）
+//	rotate=true ：同时将旧版本密钥加密的值重新加密为当前版本（密钥轮换）
+func ReencryptSecrets(rotate bool) (*SecretMigrationResult, error) {
+	result := &SecretMigrationResult{Rows: make(map[string]int64)}
+	if !envelope.Enabled() {
+		return result, nil
+	}
+
+	// 已是当前版本的密文无需处理
+	current := fmt.Sprintf("enc:v%d:%%", envelope.CurrentVersion())
+
+	for _, t := range encryptedColumns {
+		for _, col := range t.Columns {
+			type row struct {
+				ID    uint
+				Value string
+			}
+			var rows []row
+			q := global.DB.Table(t.Table).
+				Select(fmt.Sprintf("id, %s AS value", col)).
+				Where(fmt.Sprintf("%s IS NOT NULL AND %s != ''", col, col))
+			if rotate {
+				q = q.Where(fmt.Sprintf("%s NOT LIKE ?", col), current)
+			} else {
+				q = q.Where(fmt.Sprintf("%s NOT LIKE ?", col), "enc:%")
+			}
+			if err := q.Scan(&rows).Error; err != nil {
+				return result, fmt.Errorf("查询 %s.%s 失败: %w", t.Table, col, err)
+			}
+			for _, r := range rows {
+				value, changed, err := envelope.Rewrap(r.Value)
+				if err != nil {
+					return result, fmt.Errorf("加密 %s.%s (id=%d) 失败: %w", t.Table, col, r.ID, err)
+				}
+				if !changed {
+					continue
+				}
+				// 使用 Table + map 直接写列，绕过模型序列化器（value 已是密文）
+				if err := global.DB.Table(t.Table).Where("id = ?", r.ID).UpdateColumn(col, value).Error; err != nil {
+					return result, fmt.Errorf("写入 %s.%s (id=%d) 失败: %w", t.Table, col, r.ID, err)
+				}
+				result.Rows[t.Table]++
+			}
+		}
+	}
+
+	cfgRepo := NewSysConfigRepository()
+	for key := range encryptedConfigKeys {
+		var cfg model.SystemConfig
+		if err := global.DB.Where("key = ?", key).Limit(1).Find(&cfg).Error; err != nil {
+			return result, err
+		}
+		if cfg.Key == "" || cfg.Value == "" {
+			continue
+		}
+		if !rotate && envelope.IsEncrypted(cfg.Value) {
+			continue
+		}
+		value, changed, err := envelope.Rewrap(cfg.Value)
+		if err != nil {
+			return result, fmt.Errorf("加密配置 %s 失败: %w", key, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := global.DB.Model(&model.SystemConfig{}).Where("key = ?", key).Update("value", value).Error; err != nil {
+			return result, err
+		}
+		cfgRepo.Invalidate(key)
+		result.Configs++
+	}
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EsiTaskRunRepository ESI 刷新任务执行记录数据访问层
+type EsiTaskRunRepository struct{}
+
+func NewEsiTaskRunRepository() *EsiTaskRunRepository {
+	return &EsiTaskRunRepository{}
+}
+
+// Create 写入一条执行记录，并在同一事务中更新 (任务, 角色) 的最新状态
+func (r *EsiTaskRunRepository) Create(run *model.EsiTaskRun) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		state := &model.EsiTaskState{
+			TaskName:    run.TaskName,
+			CharacterID: run.CharacterID,
+			LastRunID:   run.ID,
+		}
+		columns := []string{"last_run_id", "updated_at"}
+		if run.Status == model.EsiTaskRunSuccess {
+			finishedAt := run.FinishedAt
+			state.LastSuccessAt = &finishedAt
+			state.LastSuccessActive = run.IsActive
+			columns = append(columns, "last_success_at", "last_success_active")
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "task_name"}, {Name: "character_id"}},
+			DoUpdates: clause.AssignmentColumns(columns),
+		}).Create(state).Error
+	})
+}
+
+// EsiTaskRunFilter 执行记录筛选条件
+type EsiTaskRunFilter struct {
+	TaskName    string
+	CharacterID *int64
+	Status      string
+	StartTime   *time.Time
+	EndTime     *time.Time
+}
+
+// List 分页查询执行记录（按开始时间倒序）
+func (r *EsiTaskRunRepository) List(page, pageSize int, filter EsiTaskRunFilter) ([]model.EsiTaskRun, int64, error) {
+	var list []model.EsiTaskRun
+	var total int64
+
+	db := global.DB.Model(&model.EsiTaskRun{})
+	if filter.TaskName != "" {
+		db = db.Where("task_name = ?", filter.TaskName)
+	}
+	if filter.CharacterID != nil {
+		db = db.Where("character_id = ?", *filter.CharacterID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.StartTime != nil {
+		db = db.Where("started_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		db = db.Where("started_at <= ?", *filter.EndTime)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := db.Order("started_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&list).Error
+	return list, total, err
+}
+
+// ListLatest 查询每个 (任务, 角色) 最近一次执行记录（按最新状态表关联，执行记录已被清理的不返回）
+func (r *EsiTaskRunRepository) ListLatest() ([]model.EsiTaskRun, error) {
+	var list []model.EsiTaskRun
+	err := global.DB.Table("esi_task_state AS s").
+		Select("r.*").
+		Joins("JOIN esi_task_run AS r ON r.id = s.last_run_id").
+		Order("s.task_name, s.character_id").
+		Scan(&list).Error
+	return list, err
+}
+
+// EsiTaskLastSuccess 某 (任务, 角色) 最近一次成功执行
+type EsiTaskLastSuccess struct {
+	TaskName    string
+	CharacterID int64
+	IsActive    bool
+	FinishedAt  time.Time
+}
+
+// ListLastSuccess 查询每个 (任务, 角色) 最近一次成功执行（供调度判断）
+func (r *EsiTaskRunRepository) ListLastSuccess() ([]EsiTaskLastSuccess, error) {
+	var list []EsiTaskLastSuccess
+	err := global.DB.Model(&model.EsiTaskState{}).
+		Select("task_name, character_id, last_success_active AS is_active, last_success_at AS finished_at").
+		Where("last_success_at IS NOT NULL").
+		Scan(&list).Error
+	return list, err
+}
+
+// DeleteBefore 删除指定时间之前的执行记录，返回删除条数
+func (r *EsiTaskRunRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := global.DB.Where("started_at < ?", t).Delete(&model.EsiTaskRun{})
+	return result.RowsAffected, result.Error
+}
+
+// ListBreakers 查询所有熔断记录
+func (r *EsiTaskRunRepository) ListBreakers() ([]model.EsiTaskBreaker, error) {
+	var list []model.EsiTaskBreaker
+	err := global.DB.Order("task_name, character_id").Find(&list).Error
+	return list, err
+}
+
+// SaveBreaker 按 (任务, 角色) 写入或更新熔断记录
+func (r *EsiTaskRunRepository) SaveBreaker(b *model.EsiTaskBreaker) error {
+	return global.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_name"}, {Name: "character_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "failures", "last_http_status", "last_error", "scopes", "opened_at", "retry_at", "updated_at"}),
+	}).Create(b).Error
+}
+
+// DeleteBreaker 删除熔断记录（恢复为 closed）
+func (r *EsiTaskRunRepository) DeleteBreaker(taskName string, characterID int64) error {
+	return global.DB.Where("task_name = ? AND character_id = ?", taskName, characterID).
+		Delete(&model.EsiTaskBreaker{}).Error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
+	"time"
 )
 
 // EveCharacterRepository EVE 角色数据访问层
@@ -51,6 +52,13 @@ func (r *EveCharacterRepository) Update(char *model.EveCharacter) error {
 	return global.DB.Save(char).Error
 }
 
+// UpdateBirthday 回写角色创建时间
+func (r *EveCharacterRepository) UpdateBirthday(characterID int64, birthday time.Time) error {
+	return global.DB.Model(&model.EveCharacter{}).
+		Where("character_id = ?", characterID).
+		Update("birthday", birthday).Error
+}
+
 // ListAllWithToken 查询所有有 refresh_token 且 token 未失效的角色，
 // 以及通过 SeAT passthrough 已获取过 scopes 的 SeAT-only 角色（用于 ESI 数据刷新队列）
 func (r *EveCharacterRepository) ListAllWithToken() ([]model.EveCharacter, error) {
@@ -61,6 +69,44 @@ func (r *EveCharacterRepository) ListAllWithToken() ([]model.EveCharacter, error
 	return chars, err
 }
 
+// ListMemberCharsWithToken 查询通过基础准入的成员（持有 guest 以外角色的用户）拥有有效 Token 的角色
+// scope 非 nil 时只返回数据范围内用户的角色
+func (r *EveCharacterRepository) ListMemberCharsWithToken(scope *model.DataScope) ([]model.EveCharacter, error) {
+	var chars []model.EveCharacter
+	members := global.DB.Table("user_role").
+		Select("user_role.user_id").
+		Joins("JOIN role ON role.id = user_role.role_id").
+		Where("role.code <> ?", model.RoleGuest)
+	db := global.DB.Where(
+		"(refresh_token != '' AND refresh_token IS NOT NULL AND token_invalid = false) OR (scopes != '' AND scopes IS NOT NULL AND (refresh_token = '' OR refresh_token IS NULL))",
+	).Where("user_id IN (?)", members)
+	err := ApplyUserScope(db, "user_id", scope).Find(&chars).Error
+	return chars, err
+}
+
+// MarkTokenInvalid 将角色 Token 标记为失效；仅当此前未标记时更新，返回是否为本次新标记
+func (r *EveCharacterRepository) MarkTokenInvalid(characterID int64, reason string, at time.Time) (bool, error) {
+	result := global.DB.Model(&model.EveCharacter{}).
+		Where("character_id = ? AND token_invalid = false", characterID).
+		Updates(map[string]interface{}{
+			"token_invalid":        true,
+			"token_invalid_at":     at,
+			"token_invalid_reason": reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListTokenInvalid 查询 Token 已失效的角色（corporationID 为 0 时不限军团），按军团、失效时间排序
+func (r *EveCharacterRepository) ListTokenInvalid(corporationID int64) ([]model.EveCharacter, error) {
+	var chars []model.EveCharacter
+	db := global.DB.Where("token_invalid = true")
+	if corporationID > 0 {
+		db = db.Where("corporation_id = ?", corporationID)
+	}
+	err := db.Order("corporation_id ASC, token_invalid_at DESC").Find(&chars).Error
+	return chars, err
+}
+
 // Delete 删除角色记录（硬删除）
 func (r *EveCharacterRepository) Delete(id uint) error {
 	return global.DB.Unscoped().Delete(&model.EveCharacter{}, id).Error
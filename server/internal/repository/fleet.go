@@ -4,6 +4,8 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // FleetRepository 舰队数据访问层
@@ -92,6 +94,12 @@ func (r *FleetRepository) AddMember(member *model.FleetMember) error {
 		Assign(member).FirstOrCreate(member).Error
 }
 
+// AddMemberTx 在事务中添加舰队成员（已存在则更新）
+func (r *FleetRepository) AddMemberTx(tx *gorm.DB, member *model.FleetMember) error {
+	return tx.Where("fleet_id = ? AND character_id = ?", member.FleetID, member.CharacterID).
+		Assign(member).FirstOrCreate(member).Error
+}
+
 // ListMembers 查询舰队成员列表
 func (r *FleetRepository) ListMembers(fleetID string) ([]model.FleetMember, error) {
 	var members []model.FleetMember
@@ -102,8 +110,10 @@ func (r *FleetRepository) ListMembers(fleetID string) ([]model.FleetMember, erro
 // MemberWithPap 舰队成员 + PAP 信息
 type MemberWithPap struct {
 	model.FleetMember
-	PapCount *float64   `json:"pap_count"`
-	IssuedAt *time.Time `json:"issued_at"`
+	PapCount   *float64            `json:"pap_count"`
+	PolicyName *string             `json:"policy_name"`
+	Breakdown  *model.PapBreakdown `gorm:"serializer:json" json:"breakdown,omitempty"`
+	IssuedAt   *time.Time          `json:"issued_at"`
 }
 
 // ListMembersWithPap 分页查询舰队成员（左连接 PAP 记录）
@@ -118,7 +128,7 @@ func (r *FleetRepository) ListMembersWithPap(fleetID string, page, pageSize int)
 	}
 
 	err := global.DB.Table("fleet_member").
-		Select("fleet_member.*, fleet_pap_log.pap_count, fleet_pap_log.issued_at").
+		Select("fleet_member.*, fleet_pap_log.pap_count, fleet_pap_log.policy_name, fleet_pap_log.breakdown, fleet_pap_log.issued_at").
 		Joins("LEFT JOIN fleet_pap_log ON fleet_pap_log.fleet_id = fleet_member.fleet_id AND fleet_pap_log.character_id = fleet_member.character_id").
 		Where("fleet_member.fleet_id = ?", fleetID).
 		Order("fleet_member.joined_at ASC").
@@ -182,6 +192,15 @@ func (r *FleetRepository) ListPapLogsByFleet(fleetID string) ([]model.FleetPapLo
 	return logs, err
 }
 
+// HasPapLog 某角色是否已有该舰队的 PAP 记录
+func (r *FleetRepository) HasPapLog(fleetID string, characterID int64) (bool, error) {
+	var n int64
+	err := global.DB.Model(&model.FleetPapLog{}).
+		Where("fleet_id = ? AND character_id = ?", fleetID, characterID).
+		Count(&n).Error
+	return n > 0, err
+}
+
 // ListPapLogsByUser 查询某用户的所有 PAP 记录
 func (r *FleetRepository) ListPapLogsByUser(userID uint) ([]model.FleetPapLog, error) {
 	var logs []model.FleetPapLog
@@ -249,6 +268,16 @@ func (r *FleetRepository) SumPapByUserGroupedByMonth(userID uint) ([]MonthlyPapS
 	return stats, err
 }
 
+// SumPapByUserSince 汇总用户自 since 起获得的 PAP
+func (r *FleetRepository) SumPapByUserSince(userID uint, since time.Time) (float64, error) {
+	var total float64
+	err := global.DB.Model(&model.FleetPapLog{}).
+		Select("COALESCE(SUM(pap_count), 0)").
+		Where("user_id = ? AND issued_at >= ?", userID, since).
+		Scan(&total).Error
+	return total, err
+}
+
 // ─────────────────────────────────────────────
 //  Fleet Invite
 // ─────────────────────────────────────────────
@@ -265,6 +294,13 @@ func (r *FleetRepository) GetInviteByCode(code string) (*model.FleetInvite, erro
 	return &invite, err
 }
 
+// GetInviteByID 按 ID 查询邀请链接
+func (r *FleetRepository) GetInviteByID(id uint) (*model.FleetInvite, error) {
+	var invite model.FleetInvite
+	err := global.DB.First(&invite, id).Error
+	return &invite, err
+}
+
 // DeactivateInvite 禁用邀请链接
 func (r *FleetRepository) DeactivateInvite(id uint) error {
 	return global.DB.Model(&model.FleetInvite{}).Where("id = ?", id).Update("active", false).Error
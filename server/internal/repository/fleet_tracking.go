@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+)
+
+// ─────────────────────────────────────────────
+//  舰队持续跟踪（成员在队时间段）
+// ─────────────────────────────────────────────
+
+// ListTrackableFleets 查询进行中且已关联 ESI 舰队的舰队
+func (r *FleetRepository) ListTrackableFleets(now time.Time) ([]model.Fleet, error) {
+	var fleets []model.Fleet
+	err := global.DB.Where("deleted_at IS NULL AND esi_fleet_id IS NOT NULL AND start_at <= ? AND end_at >= ?", now, now).
+		Find(&fleets).Error
+	return fleets, err
+}
+
+// ListOpenSegments 查询舰队中仍在进行的时间段
+func (r *FleetRepository) ListOpenSegments(fleetID string) ([]model.FleetMemberSegment, error) {
+	var list []model.FleetMemberSegment
+	err := global.DB.Where("fleet_id = ? AND ended_at IS NULL", fleetID).Find(&list).Error
+	return list, err
+}
+
+// ListSegments 查询舰队的全部时间段（按角色、开始时间排序）
+func (r *FleetRepository) ListSegments(fleetID string) ([]model.FleetMemberSegment, error) {
+	var list []model.FleetMemberSegment
+	err := global.DB.Where("fleet_id = ?", fleetID).Order("character_id ASC, started_at ASC").Find(&list).Error
+	return list, err
+}
+
+// CreateSegments 批量创建时间段
+func (r *FleetRepository) CreateSegments(segments []model.FleetMemberSegment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	return global.DB.Create(&segments).Error
+}
+
+// TouchSegments 更新时间段的最近在队时间
+func (r *FleetRepository) TouchSegments(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return global.DB.Model(&model.FleetMemberSegment{}).Where("id IN ?", ids).
+		Update("last_seen_at", at).Error
+}
+
+// CloseSegmentsAt 在指定时间结束时间段（成员换船 / 跳星系）
+func (r *FleetRepository) CloseSegmentsAt(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return global.DB.Model(&model.FleetMemberSegment{}).Where("id IN ?", ids).
+		Updates(map[string]any{"last_seen_at": at, "ended_at": at}).Error
+}
+
+// CloseSegmentsAtLastSeen 以最近在队时间结束时间段（成员离队）
+func (r *FleetRepository) CloseSegmentsAtLastSeen(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return global.DB.Exec(`UPDATE fleet_member_segment SET ended_at = last_seen_at WHERE id IN ?`, ids).Error
+}
+
+// CloseSegmentsOfEndedFleets 结束已结束或已删除舰队中仍在进行的时间段
+func (r *FleetRepository) CloseSegmentsOfEndedFleets(now time.Time) (int64, error) {
+	result := global.DB.Exec(`
+UPDATE fleet_member_segment SET ended_at = last_seen_at
+WHERE ended_at IS NULL
+  AND fleet_id IN (SELECT id FROM fleet WHERE end_at < ? OR deleted_at IS NOT NULL)`, now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MarketRepository 市场价格数据访问层
+type MarketRepository struct{}
+
+func NewMarketRepository() *MarketRepository {
+	return &MarketRepository{}
+}
+
+// marketPriceColumns 价格快照中需要覆盖更新的列
+var marketPriceColumns = []string{
+	"adjusted_price", "average_price",
+	"buy_max", "sell_min", "buy_percentile", "sell_percentile",
+	"buy_volume", "sell_volume",
+}
+
+// ReplacePrices 写入某来源的最新价格快照并记录当天历史
+// 本次快照中不存在的物品（已无挂单）会从最新价格表中移除，历史保留
+func (r *MarketRepository) ReplacePrices(source string, prices []model.MarketPrice, at time.Time) error {
+	date := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	history := make([]model.MarketPriceHistory, 0, len(prices))
+	for i := range prices {
+		prices[i].Source = source
+		prices[i].UpdatedAt = at
+		p := prices[i]
+		history = append(history, model.MarketPriceHistory{
+			Date:           date,
+			TypeID:         p.TypeID,
+			Source:         source,
+			AdjustedPrice:  p.AdjustedPrice,
+			AveragePrice:   p.AveragePrice,
+			BuyMax:         p.BuyMax,
+			SellMin:        p.SellMin,
+			BuyPercentile:  p.BuyPercentile,
+			SellPercentile: p.SellPercentile,
+			BuyVolume:      p.BuyVolume,
+			SellVolume:     p.SellVolume,
+		})
+	}
+
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if len(prices) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "type_id"}, {Name: "source"}},
+				DoUpdates: clause.AssignmentColumns(append(marketPriceColumns, "updated_at")),
+			}).CreateInBatches(prices, 1000).Error; err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "date"}, {Name: "type_id"}, {Name: "source"}},
+				DoUpdates: clause.AssignmentColumns(marketPriceColumns),
+			}).CreateInBatches(history, 1000).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("source = ? AND updated_at < ?", source, at).Delete(&model.MarketPrice{}).Error
+	})
+}
+
+// GetPrices 批量查询最新价格（source 为空时返回所有来源）
+func (r *MarketRepository) GetPrices(typeIDs []int64, source string) ([]model.MarketPrice, error) {
+	var list []model.MarketPrice
+	if len(typeIDs) == 0 {
+		return list, nil
+	}
+	db := global.DB.Where("type_id IN ?", typeIDs)
+	if source != "" {
+		db = db.Where("source = ?", source)
+	}
+	err := db.Order("type_id ASC, source ASC").Find(&list).Error
+	return list, err
+}
+
+// GetLastUpdated 查询各来源最近一次更新时间
+func (r *MarketRepository) GetLastUpdated() (map[string]time.Time, error) {
+	type row struct {
+		Source    string
+		UpdatedAt time.Time
+	}
+	var rows []row
+	if err := global.DB.Model(&model.MarketPrice{}).
+		Select("source, MAX(updated_at) AS updated_at").
+		Group("source").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(rows))
+	for _, r := range rows {
+		result[r.Source] = r.UpdatedAt
+	}
+	return result, nil
+}
+
+// ListHistory 查询某物品的价格历史（source 为空时返回所有来源）
+func (r *MarketRepository) ListHistory(typeID int64, source string, start, end *time.Time) ([]model.MarketPriceHistory, error) {
+	var list []model.MarketPriceHistory
+	db := global.DB.Where("type_id = ?", typeID)
+	if source != "" {
+		db = db.Where("source = ?", source)
+	}
+	if start != nil {
+		db = db.Where("date >= ?", *start)
+	}
+	if end != nil {
+		db = db.Where("date <= ?", *end)
+	}
+	err := db.Order("date ASC, source ASC").Find(&list).Error
+	return list, err
+}
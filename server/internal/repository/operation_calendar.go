@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OperationCalendarRepository 行动日历数据访问层
+type OperationCalendarRepository struct{}
+
+func NewOperationCalendarRepository() *OperationCalendarRepository {
+	return &OperationCalendarRepository{}
+}
+
+// ─────────────────────────────────────────────
+//  周期模板
+// ─────────────────────────────────────────────
+
+// ListTemplates 查询全部模板
+func (r *OperationCalendarRepository) ListTemplates() ([]model.OperationTemplate, error) {
+	var list []model.OperationTemplate
+	err := global.DB.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// ListEnabledTemplates 查询启用的模板
+func (r *OperationCalendarRepository) ListEnabledTemplates() ([]model.OperationTemplate, error) {
+	var list []model.OperationTemplate
+	err := global.DB.Where("enabled = ?", true).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// GetTemplate 按 ID 查询模板
+func (r *OperationCalendarRepository) GetTemplate(id uint) (*model.OperationTemplate, error) {
+	var t model.OperationTemplate
+	if err := global.DB.First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CreateTemplate 创建模板
+func (r *OperationCalendarRepository) CreateTemplate(t *model.OperationTemplate) error {
+	return global.DB.Create(t).Error
+}
+
+// UpdateTemplate 更新模板
+func (r *OperationCalendarRepository) UpdateTemplate(t *model.OperationTemplate) error {
+	return global.DB.Save(t).Error
+}
+
+// DeleteTemplate 删除模板
+func (r *OperationCalendarRepository) DeleteTemplate(id uint) error {
+	return global.DB.Delete(&model.OperationTemplate{}, id).Error
+}
+
+// ─────────────────────────────────────────────
+//  排期行动
+// ─────────────────────────────────────────────
+
+// CreateOperation 创建行动
+func (r *OperationCalendarRepository) CreateOperation(op *model.FleetOperation) error {
+	return global.DB.Create(op).Error
+}
+
+// UpdateOperation 更新行动
+func (r *OperationCalendarRepository) UpdateOperation(op *model.FleetOperation) error {
+	return global.DB.Save(op).Error
+}
+
+// UpdateOperationTx 在事务中更新行动
+func (r *OperationCalendarRepository) UpdateOperationTx(tx *gorm.DB, op *model.FleetOperation) error {
+	return tx.Save(op).Error
+}
+
+// GetOperationForUpdateTx 在事务中按 ID 查询行动并加行锁（集结舰队时防止并发重复创建）
+func (r *OperationCalendarRepository) GetOperationForUpdateTx(tx *gorm.DB, id uint) (*model.FleetOperation, error) {
+	var op model.FleetOperation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&op, id).Error; err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// GetOperation 按 ID 查询行动
+func (r *OperationCalendarRepository) GetOperation(id uint) (*model.FleetOperation, error) {
+	var op model.FleetOperation
+	if err := global.DB.First(&op, id).Error; err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// ListOperations 查询时间范围内的行动（按开始时间正序）；includeCancelled 为 false 时不含已取消
+func (r *OperationCalendarRepository) ListOperations(from, to time.Time, includeCancelled bool) ([]model.FleetOperation, error) {
+	var list []model.FleetOperation
+	db := global.DB.Where("start_at >= ? AND start_at < ?", from, to)
+	if !includeCancelled {
+		db = db.Where("status = ?", model.OperationStatusScheduled)
+	}
+	err := db.Order("start_at ASC, id ASC").Find(&list).Error
+	return list, err
+}
+
+// ListTemplateOperationsAfter 查询模板在某排期时间之后生成的行动（含已取消）
+func (r *OperationCalendarRepository) ListTemplateOperationsAfter(templateID uint, after time.Time) ([]model.FleetOperation, error) {
+	var list []model.FleetOperation
+	err := global.DB.Where("template_id = ? AND occurrence_at > ?", templateID, after).
+		Order("occurrence_at ASC").Find(&list).Error
+	return list, err
+}
+
+// TemplateOperationExists 模板在该排期时间是否已生成过行动（按生成时的排期时间判断，改期不影响）
+func (r *OperationCalendarRepository) TemplateOperationExists(templateID uint, occurrenceAt time.Time) (bool, error) {
+	var count int64
+	err := global.DB.Unscoped().Model(&model.FleetOperation{}).
+		Where("template_id = ? AND occurrence_at = ?", templateID, occurrenceAt).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PurgeOperation 彻底删除行动及其报名（仅用于模板变更时清理无人报名的未来行动）
+func (r *OperationCalendarRepository) PurgeOperation(id uint) error {
+	if err := global.DB.Where("operation_id = ?", id).Delete(&model.OperationRSVP{}).Error; err != nil {
+		return err
+	}
+	return global.DB.Unscoped().Delete(&model.FleetOperation{}, id).Error
+}
+
+// ListUpcoming 查询即将开始的行动：已排期且在 [now, until) 内开始
+func (r *OperationCalendarRepository) ListUpcoming(now, until time.Time) ([]model.FleetOperation, error) {
+	var list []model.FleetOperation
+	err := global.DB.Where("status = ? AND start_at >= ? AND start_at < ?", model.OperationStatusScheduled, now, until).
+		Order("start_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ─────────────────────────────────────────────
+//  报名
+// ─────────────────────────────────────────────
+
+// UpsertRSVP 创建或更新报名（按 operation_id + user_id 唯一）
+func (r *OperationCalendarRepository) UpsertRSVP(rsvp *model.OperationRSVP) error {
+	var existing model.OperationRSVP
+	err := global.DB.Where("operation_id = ? AND user_id = ?", rsvp.OperationID, rsvp.UserID).First(&existing).Error
+	if err == nil {
+		rsvp.ID = existing.ID
+		rsvp.CreatedAt = existing.CreatedAt
+		return global.DB.Save(rsvp).Error
+	}
+	return global.DB.Create(rsvp).Error
+}
+
+// DeleteRSVP 取消报名
+func (r *OperationCalendarRepository) DeleteRSVP(operationID, userID uint) error {
+	return global.DB.Where("operation_id = ? AND user_id = ?", operationID, userID).
+		Delete(&model.OperationRSVP{}).Error
+}
+
+// ListRSVPs 查询行动的全部报名
+func (r *OperationCalendarRepository) ListRSVPs(operationID uint) ([]model.OperationRSVP, error) {
+	var list []model.OperationRSVP
+	err := global.DB.Where("operation_id = ?", operationID).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// CountRSVPs 统计行动的报名数
+func (r *OperationCalendarRepository) CountRSVPs(operationID uint) (int64, error) {
+	var count int64
+	err := global.DB.Model(&model.OperationRSVP{}).Where("operation_id = ?", operationID).Count(&count).Error
+	return count, err
+}
+
+// ListUserRSVPs 查询用户在若干行动中的报名
+func (r *OperationCalendarRepository) ListUserRSVPs(userID uint, operationIDs []uint) ([]model.OperationRSVP, error) {
+	var list []model.OperationRSVP
+	if len(operationIDs) == 0 {
+		return list, nil
+	}
+	err := global.DB.Where("user_id = ? AND operation_id IN ?", userID, operationIDs).Find(&list).Error
+	return list, err
+}
+
+// RSVPStatusCount 行动报名状态统计
+type RSVPStatusCount struct {
+	OperationID uint   `gorm:"column:operation_id"`
+	Status      string `gorm:"column:status"`
+	Count       int    `gorm:"column:count"`
+}
+
+// CountRSVPsByStatus 按行动、状态统计报名数
+func (r *OperationCalendarRepository) CountRSVPsByStatus(operationIDs []uint) ([]RSVPStatusCount, error) {
+	var rows []RSVPStatusCount
+	if len(operationIDs) == 0 {
+		return rows, nil
+	}
+	err := global.DB.Model(&model.OperationRSVP{}).
+		Select("operation_id, status, COUNT(*) AS count").
+		Where("operation_id IN ?", operationIDs).
+		Group("operation_id, status").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ─────────────────────────────────────────────
+//  iCal 订阅令牌
+// ─────────────────────────────────────────────
+
+// SetFeedToken 设置用户的订阅令牌（覆盖旧令牌）
+func (r *OperationCalendarRepository) SetFeedToken(userID uint, tokenHash string) error {
+	if err := global.DB.Where("user_id = ?", userID).Delete(&model.CalendarFeedToken{}).Error; err != nil {
+		return err
+	}
+	return global.DB.Create(&model.CalendarFeedToken{UserID: userID, TokenHash: tokenHash}).Error
+}
+
+// GetFeedToken 查询用户的订阅令牌
+func (r *OperationCalendarRepository) GetFeedToken(userID uint) (*model.CalendarFeedToken, error) {
+	var t model.CalendarFeedToken
+	if err := global.DB.Where("user_id = ?", userID).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetFeedTokenByHash 按令牌哈希查询
+func (r *OperationCalendarRepository) GetFeedTokenByHash(tokenHash string) (*model.CalendarFeedToken, error) {
+	var t model.CalendarFeedToken
+	if err := global.DB.Where("token_hash = ?", tokenHash).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteFeedToken 删除用户的订阅令牌
+func (r *OperationCalendarRepository) DeleteFeedToken(userID uint) error {
+	return global.DB.Where("user_id = ?", userID).Delete(&model.CalendarFeedToken{}).Error
+}
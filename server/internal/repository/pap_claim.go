@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PapClaimRepository PAP 补登申请数据访问层
+type PapClaimRepository struct{}
+
+func NewPapClaimRepository() *PapClaimRepository {
+	return &PapClaimRepository{}
+}
+
+// Create 创建申请
+func (r *PapClaimRepository) Create(claim *model.PapClaim) error {
+	return global.DB.Create(claim).Error
+}
+
+// GetByID 按 ID 查询申请
+func (r *PapClaimRepository) GetByID(id uint) (*model.PapClaim, error) {
+	var claim model.PapClaim
+	if err := global.DB.First(&claim, id).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// Update 更新申请
+func (r *PapClaimRepository) Update(claim *model.PapClaim) error {
+	return global.DB.Save(claim).Error
+}
+
+// GetForUpdateTx 在事务中按 ID 查询并加行锁（审批时防止并发重复补发）
+func (r *PapClaimRepository) GetForUpdateTx(tx *gorm.DB, id uint) (*model.PapClaim, error) {
+	var claim model.PapClaim
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, id).Error
+	return &claim, err
+}
+
+// UpdateTx 在事务中更新申请
+func (r *PapClaimRepository) UpdateTx(tx *gorm.DB, claim *model.PapClaim) error {
+	return tx.Save(claim).Error
+}
+
+// HasPending 某舰队某角色是否已有待审批的申请
+func (r *PapClaimRepository) HasPending(fleetID string, characterID int64) (bool, error) {
+	var n int64
+	err := global.DB.Model(&model.PapClaim{}).
+		Where("fleet_id = ? AND character_id = ? AND status = ?", fleetID, characterID, model.PapClaimStatusPending).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// ListByUser 查询用户的申请
+func (r *PapClaimRepository) ListByUser(userID uint) ([]model.PapClaim, error) {
+	var claims []model.PapClaim
+	err := global.DB.Where("user_id = ?", userID).Order("id DESC").Find(&claims).Error
+	return claims, err
+}
+
+// List 分页查询申请；managed 非 nil 时只返回可管理舰队的申请
+func (r *PapClaimRepository) List(page, pageSize int, status, fleetID string, managed *ManagedFleetFilter) ([]model.PapClaim, int64, error) {
+	var claims []model.PapClaim
+	var total int64
+
+	db := ApplyManagedFleets(global.DB.Model(&model.PapClaim{}), "fleet_id", managed)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if fleetID != "" {
+		db = db.Where("fleet_id = ?", fleetID)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&claims).Error
+	return claims, total, err
+}
+
+// CreateLog 写入操作记录
+func (r *PapClaimRepository) CreateLog(log *model.PapClaimLog) error {
+	return global.DB.Create(log).Error
+}
+
+// CreateLogTx 在事务中写入操作记录
+func (r *PapClaimRepository) CreateLogTx(tx *gorm.DB, log *model.PapClaimLog) error {
+	return tx.Create(log).Error
+}
+
+// ListLogs 查询申请的操作记录
+func (r *PapClaimRepository) ListLogs(claimID uint) ([]model.PapClaimLog, error) {
+	var logs []model.PapClaimLog
+	err := global.DB.Where("claim_id = ?", claimID).Order("id ASC").Find(&logs).Error
+	return logs, err
+}
+
+// ─────────────────────────────────────────────
+//  自动核对数据
+// ─────────────────────────────────────────────
+
+// ListLocationsBetween 查询角色在时间范围内的位置记录
+func (r *PapClaimRepository) ListLocationsBetween(characterID int64, from, to time.Time) ([]model.EveCharacterLocation, error) {
+	var locs []model.EveCharacterLocation
+	err := global.DB.Where("character_id = ? AND first_seen_at <= ? AND last_seen_at >= ?", characterID, to, from).
+		Order("first_seen_at ASC").Find(&locs).Error
+	return locs, err
+}
+
+// ListCoInvolvedKillmails 在给定击杀邮件中，查询同样出现了指定角色（舰队成员）的击杀邮件 ID
+func (r *PapClaimRepository) ListCoInvolvedKillmails(killmailIDs, characterIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(killmailIDs) == 0 || len(characterIDs) == 0 {
+		return ids, nil
+	}
+	err := global.DB.Model(&model.EveCharacterKillmail{}).
+		Distinct("killmail_id").
+		Where("killmail_id IN ? AND character_id IN ?", killmailIDs, characterIDs).
+		Pluck("killmail_id", &ids).Error
+	return ids, err
+}
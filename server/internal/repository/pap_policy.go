@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+)
+
+// PapPolicyRepository PAP 计算策略数据访问层
+type PapPolicyRepository struct{}
+
+func NewPapPolicyRepository() *PapPolicyRepository {
+	return &PapPolicyRepository{}
+}
+
+// List 查询全部策略
+func (r *PapPolicyRepository) List() ([]model.PapPolicy, error) {
+	var list []model.PapPolicy
+	err := global.DB.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// GetByID 按 ID 查询策略
+func (r *PapPolicyRepository) GetByID(id uint) (*model.PapPolicy, error) {
+	var p model.PapPolicy
+	if err := global.DB.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByImportance 查询作为某重要等级默认策略的策略
+func (r *PapPolicyRepository) GetByImportance(importance string) (*model.PapPolicy, error) {
+	var p model.PapPolicy
+	if err := global.DB.Where("importance = ?", importance).Order("id ASC").First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Create 创建策略
+func (r *PapPolicyRepository) Create(p *model.PapPolicy) error {
+	return global.DB.Create(p).Error
+}
+
+// Update 更新策略
+func (r *PapPolicyRepository) Update(p *model.PapPolicy) error {
+	return global.DB.Save(p).Error
+}
+
+// Delete 删除策略
+func (r *PapPolicyRepository) Delete(id uint) error {
+	return global.DB.Delete(&model.PapPolicy{}, id).Error
+}
+
+// ClearImportance 取消其他策略的默认重要等级（同一重要等级只保留一个默认策略）
+func (r *PapPolicyRepository) ClearImportance(importance string, exceptID uint) error {
+	return global.DB.Model(&model.PapPolicy{}).
+		Where("importance = ? AND id <> ?", importance, exceptID).
+		Update("importance", "").Error
+}
+
+// CountFleetsUsing 统计指定了该策略的舰队数
+func (r *PapPolicyRepository) CountFleetsUsing(id uint) (int64, error) {
+	var n int64
+	err := global.DB.Model(&model.Fleet{}).Where("pap_policy_id = ? AND deleted_at IS NULL", id).Count(&n).Error
+	return n, err
+}
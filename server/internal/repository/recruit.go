@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecruitRepository 招新申请数据访问层
+type RecruitRepository struct{}
+
+func NewRecruitRepository() *RecruitRepository { return &RecruitRepository{} }
+
+// Create 创建申请
+func (r *RecruitRepository) Create(app *model.RecruitApplication) error {
+	return global.DB.Create(app).Error
+}
+
+// Update 更新申请
+func (r *RecruitRepository) Update(app *model.RecruitApplication) error {
+	return global.DB.Save(app).Error
+}
+
+// UpdateTx 在事务中更新申请
+func (r *RecruitRepository) UpdateTx(tx *gorm.DB, app *model.RecruitApplication) error {
+	return tx.Save(app).Error
+}
+
+// GetForUpdateTx 在事务中按 ID 查询申请并加行锁（通过 / 拒绝时防止并发重复处理）
+func (r *RecruitRepository) GetForUpdateTx(tx *gorm.DB, id uint) (*model.RecruitApplication, error) {
+	var app model.RecruitApplication
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&app, id).Error; err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// GetByID 按 ID 查询申请
+func (r *RecruitRepository) GetByID(id uint) (*model.RecruitApplication, error) {
+	var app model.RecruitApplication
+	if err := global.DB.First(&app, id).Error; err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// ListByUser 查询用户的全部申请（按时间倒序）
+func (r *RecruitRepository) ListByUser(userID uint) ([]model.RecruitApplication, error) {
+	var apps []model.RecruitApplication
+	err := global.DB.Where("user_id = ?", userID).Order("id DESC").Find(&apps).Error
+	return apps, err
+}
+
+// HasOpenByUser 用户是否有处理中的申请
+func (r *RecruitRepository) HasOpenByUser(userID uint) (bool, error) {
+	var count int64
+	err := global.DB.Model(&model.RecruitApplication{}).
+		Where("user_id = ? AND status IN ?", userID, []string{model.RecruitStatusPending, model.RecruitStatusReviewing}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List 分页查询申请；status 为空时返回全部
+func (r *RecruitRepository) List(page, pageSize int, status string) ([]model.RecruitApplication, int64, error) {
+	var apps []model.RecruitApplication
+	var total int64
+
+	db := global.DB.Model(&model.RecruitApplication{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&apps).Error
+	return apps, total, err
+}
+
+// CreateComment 写入留言 / 状态变更记录
+func (r *RecruitRepository) CreateComment(comment *model.RecruitComment) error {
+	return global.DB.Create(comment).Error
+}
+
+// CreateCommentTx 在事务中写入留言 / 状态变更记录
+func (r *RecruitRepository) CreateCommentTx(tx *gorm.DB, comment *model.RecruitComment) error {
+	return tx.Create(comment).Error
+}
+
+// ListComments 查询申请的留言（按时间正序）
+func (r *RecruitRepository) ListComments(applicationID uint) ([]model.RecruitComment, error) {
+	var comments []model.RecruitComment
+	err := global.DB.Where("application_id = ?", applicationID).Order("id ASC").Find(&comments).Error
+	return comments, err
+}
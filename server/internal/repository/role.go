@@ -3,6 +3,8 @@ package repository
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
+
+	"gorm.io/gorm"
 )
 
 type RoleRepository struct{}
@@ -43,6 +45,14 @@ func (r *RoleRepository) Delete(id uint) error {
 		tx.Rollback()
 		return err
 	}
+	if err := tx.Where("role_id = ?", id).Delete(&model.UserRoleScope{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("role_id = ?", id).Delete(&model.AutoRoleRule{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
 	if err := tx.Delete(&model.Role{}, id).Error; err != nil {
 		tx.Rollback()
 		return err
@@ -148,6 +158,11 @@ func (r *RoleRepository) AddUserRole(userID, roleID uint) error {
 	return global.DB.Create(&model.UserRole{UserID: userID, RoleID: roleID, IsAuto: false}).Error
 }
 
+// AddUserRoleTx 在事务中为用户添加手动分配的角色
+func (r *RoleRepository) AddUserRoleTx(tx *gorm.DB, userID, roleID uint) error {
+	return tx.Create(&model.UserRole{UserID: userID, RoleID: roleID, IsAuto: false}).Error
+}
+
 // AddAutoUserRole 由自动权限系统分配角色（标记 is_auto=true），若已存在则跳过
 func (r *RoleRepository) AddAutoUserRole(userID, roleID uint) error {
 	record := model.UserRole{UserID: userID, RoleID: roleID, IsAuto: true}
@@ -168,9 +183,61 @@ func (r *RoleRepository) RemoveUserRole(userID, roleID uint) error {
 	return global.DB.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&model.UserRole{}).Error
 }
 
-// GetRoleUsers 获取拥有某角色的所有用户ID
+// RemoveUserRoleTx 在事务中移除用户的角色
+func (r *RoleRepository) RemoveUserRoleTx(tx *gorm.DB, userID, roleID uint) error {
+	return tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&model.UserRole{}).Error
+}
+
+// GetRoleUsers 获取拥有某角色的所有用户ID（含限定作用范围的分配）
 func (r *RoleRepository) GetRoleUserIDs(roleID uint) ([]uint, error) {
 	var ids []uint
-	err := global.DB.Model(&model.UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &ids).Error
+	err := global.DB.Raw(`SELECT user_id FROM user_role WHERE role_id = ?
+		UNION SELECT user_id FROM user_role_scope WHERE role_id = ?`, roleID, roleID).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// ─── UserRoleScope ───
+
+// ListUserRoleScopes 查询用户所有限定作用范围的角色（附带角色编码与名称，仅启用的角色）
+func (r *RoleRepository) ListUserRoleScopes(userID uint) ([]model.UserRoleScope, error) {
+	var list []model.UserRoleScope
+	err := global.DB.Table("user_role_scope urs").
+		Select("urs.*, role.code AS role_code, role.name AS role_name").
+		Joins("JOIN role ON role.id = urs.role_id").
+		Where("urs.user_id = ? AND role.status = 1", userID).
+		Order("urs.id ASC").
+		Scan(&list).Error
+	return list, err
+}
+
+// GetUserScopedRoleIDs 获取用户限定作用范围的角色 ID（去重）
+func (r *RoleRepository) GetUserScopedRoleIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := global.DB.Model(&model.UserRoleScope{}).Where("user_id = ?", userID).
+		Distinct("role_id").Pluck("role_id", &ids).Error
 	return ids, err
 }
+
+func (r *RoleRepository) CreateUserRoleScope(scope *model.UserRoleScope) error {
+	return global.DB.Create(scope).Error
+}
+
+func (r *RoleRepository) GetUserRoleScope(id uint) (*model.UserRoleScope, error) {
+	var scope model.UserRoleScope
+	err := global.DB.First(&scope, id).Error
+	return &scope, err
+}
+
+func (r *RoleRepository) DeleteUserRoleScope(id uint) error {
+	return global.DB.Delete(&model.UserRoleScope{}, id).Error
+}
+
+// ExistsUserRoleScope 检查是否已存在相同的限定范围分配
+func (r *RoleRepository) ExistsUserRoleScope(userID, roleID uint, scopeType string, scopeID int64) (bool, error) {
+	var count int64
+	err := global.DB.Model(&model.UserRoleScope{}).
+		Where("user_id = ? AND role_id = ? AND scope_type = ? AND scope_id = ?", userID, roleID, scopeType, scopeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -4,6 +4,9 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SrpRepository SRP 数据访问层
@@ -54,6 +57,47 @@ func (r *SrpRepository) CreateApplication(app *model.SrpApplication) error {
 	return global.DB.Create(app).Error
 }
 
+// CreateApplicationWithLines 创建补损申请及推荐金额明细
+func (r *SrpRepository) CreateApplicationWithLines(app *model.SrpApplication, lines []model.SrpApplicationLine) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(app).Error; err != nil {
+			return err
+		}
+		return createApplicationLines(tx, app.ID, lines)
+	})
+}
+
+// UpdateApplicationPricing 更新申请的定价结果并替换推荐金额明细
+func (r *SrpRepository) UpdateApplicationPricing(app *model.SrpApplication, lines []model.SrpApplicationLine) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(app).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("application_id = ?", app.ID).Delete(&model.SrpApplicationLine{}).Error; err != nil {
+			return err
+		}
+		return createApplicationLines(tx, app.ID, lines)
+	})
+}
+
+func createApplicationLines(tx *gorm.DB, appID uint, lines []model.SrpApplicationLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].ID = 0
+		lines[i].ApplicationID = appID
+	}
+	return tx.Create(&lines).Error
+}
+
+// ListApplicationLines 查询申请的推荐金额明细
+func (r *SrpRepository) ListApplicationLines(appID uint) ([]model.SrpApplicationLine, error) {
+	var lines []model.SrpApplicationLine
+	err := global.DB.Where("application_id = ?", appID).Order("id ASC").Find(&lines).Error
+	return lines, err
+}
+
 // GetApplicationByID 按 ID 查询
 func (r *SrpRepository) GetApplicationByID(id uint) (*model.SrpApplication, error) {
 	var app model.SrpApplication
@@ -61,6 +105,29 @@ func (r *SrpRepository) GetApplicationByID(id uint) (*model.SrpApplication, erro
 	return &app, err
 }
 
+// GetApplicationForUpdateTx 在事务中按 ID 查询并加行锁（发放 / 追回时防止并发重复记账）
+func (r *SrpRepository) GetApplicationForUpdateTx(tx *gorm.DB, id uint) (*model.SrpApplication, error) {
+	var app model.SrpApplication
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&app, id).Error
+	return &app, err
+}
+
+// UpdateApplicationTx 在事务中更新申请
+func (r *SrpRepository) UpdateApplicationTx(tx *gorm.DB, app *model.SrpApplication) error {
+	return tx.Save(app).Error
+}
+
+// ListPayableApplicationIDsByFleet 查询舰队下已批准、待发放的申请 ID（scope 非空时只返回范围内申请人的申请）
+func (r *SrpRepository) ListPayableApplicationIDsByFleet(fleetID string, scope *model.DataScope) ([]uint, error) {
+	var ids []uint
+	db := global.DB.Model(&model.SrpApplication{}).
+		Where("fleet_id = ? AND review_status = ? AND payout_status = ?", fleetID, model.SrpReviewApproved, model.SrpPayoutPending)
+	err := ApplyUserScope(db, "user_id", scope).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 // ExistsApplicationByKillmail 检查该 killmail 是否已被该角色提交过申请
 func (r *SrpRepository) ExistsApplicationByKillmail(killmailID int64, characterID int64) bool {
 	var count int64
@@ -84,6 +151,7 @@ type SrpApplicationFilter struct {
 	PayoutStatus string
 	StartTime    *time.Time
 	EndTime      *time.Time
+	Scope        *model.DataScope // 数据范围（限定军团/联盟的审批人），nil 不限制
 }
 
 // ListApplications 分页查询申请列表
@@ -113,6 +181,7 @@ func (r *SrpRepository) ListApplications(page, pageSize int, filter SrpApplicati
 	if filter.EndTime != nil {
 		db = db.Where("killmail_time <= ?", *filter.EndTime)
 	}
+	db = ApplyUserScope(db, "user_id", filter.Scope)
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
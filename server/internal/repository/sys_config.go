@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/pkg/cache"
+	"amiya-eden/pkg/envelope"
 	"context"
 	"errors"
 	"fmt"
@@ -28,12 +29,13 @@ func NewSysConfigRepository() *SysConfigRepository {
 func cacheKey(key string) string { return sysConfigCachePrefix + key }
 
 // Get 获取配置值字符串；若不存在返回 defaultVal
+// 敏感配置项（见 encryptedConfigKeys）在数据库与缓存中均为密文，此处自动解密
 func (r *SysConfigRepository) Get(key, defaultVal string) (string, error) {
 	ctx := context.Background()
 
 	// 1. 先查缓存
 	if val, err := cache.GetString(ctx, cacheKey(key)); err == nil {
-		return val, nil
+		return envelope.Decrypt(val)
 	}
 
 	// 2. 查数据库
@@ -52,11 +54,19 @@ func (r *SysConfigRepository) Get(key, defaultVal string) (string, error) {
 
 	// 3. 回写缓存
 	_ = cache.SetString(ctx, cacheKey(key), cfg.Value, sysConfigCacheTTL)
-	return cfg.Value, nil
+	return envelope.Decrypt(cfg.Value)
 }
 
-// Set 设置配置值并使缓存失效
+// Set 设置配置值并使缓存失效；敏感配置项自动加密存储
 func (r *SysConfigRepository) Set(key, value, desc string) error {
+	if isEncryptedConfigKey(key) {
+		enc, err := envelope.Encrypt(value)
+		if err != nil {
+			return err
+		}
+		value = enc
+	}
+
 	var cfg model.SystemConfig
 	err := global.DB.Where("key = ?", key).First(&cfg).Error
 	if err != nil {
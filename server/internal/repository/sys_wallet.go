@@ -5,6 +5,7 @@ import (
 	"amiya-eden/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SysWalletRepository 系统钱包数据访问层
@@ -31,6 +32,19 @@ func (r *SysWalletRepository) GetOrCreateWalletTx(tx *gorm.DB, userID uint) (*mo
 	return &wallet, nil
 }
 
+// GetOrCreateWalletForUpdateTx 在事务内获取（加行锁）或创建用户钱包
+func (r *SysWalletRepository) GetOrCreateWalletForUpdateTx(tx *gorm.DB, userID uint) (*model.SystemWallet, error) {
+	var wallet model.SystemWallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&wallet).Error
+	if err != nil {
+		wallet = model.SystemWallet{UserID: userID, Balance: 0}
+		if err := tx.Create(&wallet).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &wallet, nil
+}
+
 // GetOrCreateWallet 获取或创建用户钱包
 func (r *SysWalletRepository) GetOrCreateWallet(userID uint) (*model.SystemWallet, error) {
 	var wallet model.SystemWallet
@@ -87,10 +101,22 @@ func (r *SysWalletRepository) ExistsTransactionByRefID(refID string) (bool, erro
 	return count > 0, err
 }
 
+// SumTransactionsByRefTx 在事务中汇总指定 RefID 的流水金额（可限定 RefType）
+func (r *SysWalletRepository) SumTransactionsByRefTx(tx *gorm.DB, refID string, refTypes ...string) (float64, error) {
+	var sum float64
+	db := tx.Model(&model.WalletTransaction{}).Where("ref_id = ?", refID)
+	if len(refTypes) > 0 {
+		db = db.Where("ref_type IN ?", refTypes)
+	}
+	err := db.Select("COALESCE(SUM(amount), 0)").Scan(&sum).Error
+	return sum, err
+}
+
 // WalletTransactionFilter 流水查询筛选条件
 type WalletTransactionFilter struct {
 	UserID  *uint
 	RefType string
+	Scope   *model.DataScope // 数据范围，nil 不限制
 }
 
 // ListTransactions 分页查询钱包流水
@@ -129,6 +155,7 @@ func (r *SysWalletRepository) ListTransactionsWithCharacter(page, pageSize int,
 	if filter.RefType != "" {
 		countDB = countDB.Where("ref_type = ?", filter.RefType)
 	}
+	countDB = ApplyUserScope(countDB, "user_id", filter.Scope)
 	if err := countDB.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -143,6 +170,7 @@ func (r *SysWalletRepository) ListTransactionsWithCharacter(page, pageSize int,
 	if filter.RefType != "" {
 		queryDB = queryDB.Where("wt.ref_type = ?", filter.RefType)
 	}
+	queryDB = ApplyUserScope(queryDB, "wt.user_id", filter.Scope)
 	if err := queryDB.Order("wt.created_at DESC").Offset(offset).Limit(pageSize).Scan(&results).Error; err != nil {
 		return nil, 0, err
 	}
@@ -168,6 +196,7 @@ type WalletLogFilter struct {
 	OperatorID *uint
 	TargetUID  *uint
 	Action     string
+	Scope      *model.DataScope // 数据范围（按目标用户），nil 不限制
 }
 
 // ListLogs 分页查询操作日志
@@ -212,6 +241,7 @@ func (r *SysWalletRepository) ListLogsWithCharacter(page, pageSize int, filter W
 	if filter.Action != "" {
 		countDB = countDB.Where("action = ?", filter.Action)
 	}
+	countDB = ApplyUserScope(countDB, "target_uid", filter.Scope)
 	if err := countDB.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -233,6 +263,7 @@ func (r *SysWalletRepository) ListLogsWithCharacter(page, pageSize int, filter W
 	if filter.Action != "" {
 		queryDB = queryDB.Where("wl.action = ?", filter.Action)
 	}
+	queryDB = ApplyUserScope(queryDB, "wl.target_uid", filter.Scope)
 	if err := queryDB.Order("wl.created_at DESC").Offset(offset).Limit(pageSize).Scan(&results).Error; err != nil {
 		return nil, 0, err
 	}
@@ -259,21 +290,22 @@ func (r *SysWalletRepository) ListWallets(page, pageSize int) ([]model.SystemWal
 	return wallets, total, nil
 }
 
-// ListWalletsWithCharacter 分页查询所有用户钱包（附带主角色名）
-func (r *SysWalletRepository) ListWalletsWithCharacter(page, pageSize int) ([]model.WalletWithCharacter, int64, error) {
+// ListWalletsWithCharacter 分页查询所有用户钱包（附带主角色名，scope 非空时只返回范围内用户）
+func (r *SysWalletRepository) ListWalletsWithCharacter(page, pageSize int, scope *model.DataScope) ([]model.WalletWithCharacter, int64, error) {
 	var results []model.WalletWithCharacter
 	var total int64
 	offset := (page - 1) * pageSize
 
-	db := global.DB.Model(&model.SystemWallet{})
+	db := ApplyUserScope(global.DB.Model(&model.SystemWallet{}), "user_id", scope)
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := global.DB.Table("system_wallet sw").
+	queryDB := global.DB.Table("system_wallet sw").
 		Select("sw.*, COALESCE(ec.character_name, '') AS character_name").
 		Joins(`LEFT JOIN "user" u ON sw.user_id = u.id`).
-		Joins("LEFT JOIN eve_character ec ON u.primary_character_id = ec.character_id").
+		Joins("LEFT JOIN eve_character ec ON u.primary_character_id = ec.character_id")
+	err := ApplyUserScope(queryDB, "sw.user_id", scope).
 		Order("sw.updated_at DESC").
 		Offset(offset).Limit(pageSize).
 		Scan(&results).Error
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"time"
+)
+
+// UserSessionRepository 登录会话数据访问层
+type UserSessionRepository struct{}
+
+func NewUserSessionRepository() *UserSessionRepository {
+	return &UserSessionRepository{}
+}
+
+// Create 创建会话
+func (r *UserSessionRepository) Create(s *model.UserSession) error {
+	return global.DB.Create(s).Error
+}
+
+// GetByID 按 ID 查询会话
+func (r *UserSessionRepository) GetByID(id string) (*model.UserSession, error) {
+	var s model.UserSession
+	err := global.DB.Where("id = ?", id).First(&s).Error
+	return &s, err
+}
+
+// GetByRefreshHash 按当前 Refresh Token 哈希查询会话
+func (r *UserSessionRepository) GetByRefreshHash(hash string) (*model.UserSession, error) {
+	var s model.UserSession
+	err := global.DB.Where("refresh_token_hash = ?", hash).First(&s).Error
+	return &s, err
+}
+
+// GetByPrevHash 按上一个 Refresh Token 哈希查询会话
+func (r *UserSessionRepository) GetByPrevHash(hash string) (*model.UserSession, error) {
+	var s model.UserSession
+	err := global.DB.Where("prev_token_hash = ?", hash).First(&s).Error
+	return &s, err
+}
+
+// Rotate 轮换 Refresh Token（仅当当前哈希仍为 oldHash 且未吊销时成功，防止并发重复刷新）
+func (r *UserSessionRepository) Rotate(id, oldHash, newHash, ip string, expiresAt, now time.Time) (bool, error) {
+	res := global.DB.Model(&model.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"prev_token_hash":    oldHash,
+			"ip":                 ip,
+			"last_seen_at":       now,
+			"expires_at":         expiresAt,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ResetRefreshToken 为会话重新签发 Refresh Token（结束模拟登录回到管理员会话时使用）
+func (r *UserSessionRepository) ResetRefreshToken(id, newHash, ip string, now time.Time) error {
+	return global.DB.Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"ip":                 ip,
+			"last_seen_at":       now,
+		}).Error
+}
+
+// TouchLastSeen 更新会话最近活跃时间与 IP
+func (r *UserSessionRepository) TouchLastSeen(id, ip string, at time.Time) error {
+	updates := map[string]interface{}{"last_seen_at": at}
+	if ip != "" {
+		updates["ip"] = ip
+	}
+	return global.DB.Model(&model.UserSession{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListActiveByUser 查询用户未吊销、未过期的会话（按最近活跃倒序）
+func (r *UserSessionRepository) ListActiveByUser(userID uint, now time.Time) ([]model.UserSession, error) {
+	var list []model.UserSession
+	err := global.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// Revoke 吊销单个会话
+func (r *UserSessionRepository) Revoke(id, reason string, at time.Time) error {
+	return global.DB.Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+}
+
+// RevokeByUser 吊销用户的所有有效会话（exceptID 非空时保留该会话），返回被吊销的会话 ID
+func (r *UserSessionRepository) RevokeByUser(userID uint, exceptID, reason string, at time.Time) ([]string, error) {
+	var ids []string
+	db := global.DB.Model(&model.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		db = db.Where("id <> ?", exceptID)
+	}
+	if err := db.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	err := global.DB.Model(&model.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+	return ids, err
+}
+
+// DeleteExpiredBefore 删除在指定时间之前已过期或已吊销的会话
+func (r *UserSessionRepository) DeleteExpiredBefore(before time.Time) (int64, error) {
+	res := global.DB.Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&model.UserSession{})
+	return res.RowsAffected, res.Error
+}
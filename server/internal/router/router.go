@@ -33,9 +33,9 @@ func RegisterRoutes(r *gin.Engine) {
 		seatSSO.GET("/callback", seatH.Callback)
 	}
 
-	// ─── SDE 公开查询（API Key 鉴权）───
+	// ─── SDE 查询（登录会话或 API Key）───
 	sdeH := handler.NewSdeHandler()
-	sde := api.Group("/sde")
+	sde := api.Group("/sde", middleware.JWTAuth())
 	{
 		sde.GET("/version", sdeH.GetVersion)
 		sde.POST("/types", sdeH.GetTypes)
@@ -43,6 +43,11 @@ func RegisterRoutes(r *gin.Engine) {
 		sde.POST("/search", sdeH.FuzzySearch)
 	}
 
+	// ─── 登录会话：刷新 Token（Refresh Token 鉴权）───
+	sessionH := handler.NewSessionHandler()
+	api.POST("/auth/refresh", sessionH.Refresh)
+	api.POST("/auth/exchange", sessionH.Exchange)
+
 	// ─── 需要登录 ───
 	auth := api.Group("", middleware.JWTAuth())
 
@@ -50,12 +55,14 @@ func RegisterRoutes(r *gin.Engine) {
 	mumbleH := handler.NewMumbleHandler()
 	api.POST("/voice/mumble/ice-auth", mumbleH.ICEAuthenticate)
 
-	// SSO 角色管理（绑定/解绑/设主角色）
+	// SSO 角色管理（绑定/解绑/设主角色/失效 Token 重新授权）
+	tokenHealthH := handler.NewTokenHealthHandler()
 	ssoAuth := auth.Group("/sso/eve")
 	{
 		// ssoAuth.GET("/scopes", ssoH.GetScopes)
 		ssoAuth.GET("/characters", ssoH.GetMyCharacters)
 		ssoAuth.GET("/bind", ssoH.BindLogin)
+		ssoAuth.GET("/reauth/:character_id", tokenHealthH.Reauth)
 		ssoAuth.POST("/transfer-confirm", ssoH.TransferConfirm)
 		ssoAuth.PUT("/primary/:character_id", ssoH.SetPrimary)
 		ssoAuth.DELETE("/characters/:character_id", ssoH.Unbind)
@@ -73,6 +80,25 @@ func RegisterRoutes(r *gin.Engine) {
 	meH := handler.NewMeHandler()
 	auth.GET("/me", meH.GetMe)
 
+	// 登录会话（登出 / 设备列表）与 API Key 管理：仅限登录会话，不接受 API Key
+	sessionOnly := auth.Group("", middleware.RequireSession())
+	sessionOnly.POST("/auth/logout", sessionH.Logout)
+	sessionOnly.POST("/auth/impersonation/end", sessionH.EndImpersonation)
+	mySessions := sessionOnly.Group("/me/sessions")
+	{
+		mySessions.GET("", sessionH.ListMySessions)
+		mySessions.DELETE("/:id", sessionH.RevokeMySession)
+		mySessions.POST("/revoke-others", sessionH.RevokeOtherSessions)
+	}
+	apiKeyH := handler.NewAPIKeyHandler()
+	myAPIKeys := sessionOnly.Group("/me/api-keys")
+	{
+		myAPIKeys.GET("", apiKeyH.ListMine)
+		myAPIKeys.GET("/scopes", apiKeyH.GetScopes)
+		myAPIKeys.POST("", apiKeyH.CreateMine)
+		myAPIKeys.DELETE("/:id", apiKeyH.RevokeMine)
+	}
+
 	dashboardH := handler.NewDashboardHandler()
 	auth.POST("/dashboard", dashboardH.GetDashboard)
 
@@ -110,21 +136,94 @@ func RegisterRoutes(r *gin.Engine) {
 		alliancePAPH := handler.NewAlliancePAPHandler()
 		fleet.GET("/pap/alliance", alliancePAPH.GetMyAlliancePAP)
 
-		// ─── 仅 FC / 管理员可操作 ───
-		fleetFC := fleet.Group("", middleware.RequireRole(model.RoleFC, model.RoleAdmin))
+		// ─── 舰队管理（FC / 管理员）───
+		fleetFC := fleet.Group("", middleware.RequirePermission("operation:fleet:manage"))
 		{
 			fleetFC.POST("", fleetH.CreateFleet)
 			fleetFC.PUT("/:id", fleetH.UpdateFleet)
 			fleetFC.DELETE("/:id", fleetH.DeleteFleet)
 			fleetFC.POST("/:id/refresh-esi", fleetH.RefreshFleetESI)
 			fleetFC.POST("/:id/members/sync", fleetH.SyncESIMembers)
-			fleetFC.POST("/:id/pap", fleetH.IssuePap)
-			fleetFC.POST("/:id/manual-pap", fleetH.ManualPap)
+			fleetFC.GET("/:id/pap/preview", middleware.RequirePermission("operation:fleet:pap"), fleetH.PreviewPap)
+			fleetFC.POST("/:id/pap", middleware.RequirePermission("operation:fleet:pap"), fleetH.IssuePap)
+			fleetFC.POST("/:id/manual-pap", middleware.RequirePermission("operation:fleet:pap"), fleetH.ManualPap)
 			fleetFC.POST("/:id/br", fleetH.GenerateBattleReport)
 			fleetFC.POST("/:id/invites", fleetH.CreateInvite)
 			fleetFC.GET("/:id/invites", fleetH.GetInvites)
 			fleetFC.DELETE("/invites/:invite_id", fleetH.DeactivateInvite)
 			fleetFC.POST("/:id/ping", fleetH.PingFleet)
+			fleetFC.GET("/:id/tracking", fleetH.GetTracking)
+		}
+	}
+
+	// ─── PAP 策略 ───
+	papPolicyH := handler.NewPapPolicyHandler()
+	papPolicy := operation.Group("/pap-policies")
+	{
+		papPolicy.GET("", papPolicyH.ListPolicies)
+		papPolicy.POST("", middleware.RequirePermission("operation:pap-policy:manage"), papPolicyH.CreatePolicy)
+		papPolicy.PUT("/:id", middleware.RequirePermission("operation:pap-policy:manage"), papPolicyH.UpdatePolicy)
+		papPolicy.DELETE("/:id", middleware.RequirePermission("operation:pap-policy:manage"), papPolicyH.DeletePolicy)
+	}
+
+	// ─── PAP 补登申请 ───
+	papClaimH := handler.NewPapClaimHandler()
+	papClaim := operation.Group("/pap-claims")
+	{
+		papClaim.POST("", papClaimH.SubmitClaim)
+		papClaim.GET("/me", papClaimH.ListMyClaims)
+		papClaim.POST("/me/:id/withdraw", papClaimH.WithdrawClaim)
+
+		papClaimReview := papClaim.Group("", middleware.RequirePermission("operation:fleet:pap"))
+		{
+			papClaimReview.GET("", papClaimH.ListClaims)
+			papClaimReview.GET("/:id", papClaimH.GetClaim)
+			papClaimReview.POST("/:id/recheck", papClaimH.RecheckClaim)
+			papClaimReview.POST("/:id/approve", papClaimH.ApproveClaim)
+			papClaimReview.POST("/:id/reject", papClaimH.RejectClaim)
+		}
+	}
+
+	// ─── 出勤统计 ───
+	analyticsH := handler.NewAttendanceAnalyticsHandler()
+	analytics := operation.Group("/analytics", middleware.RequirePermission("operation:analytics:view"))
+	{
+		analytics.GET("/participation", analyticsH.GetParticipation)
+		analytics.GET("/participation/export", analyticsH.ExportParticipation)
+		analytics.GET("/streaks", analyticsH.GetStreaks)
+		analytics.GET("/streaks/export", analyticsH.ExportStreaks)
+		analytics.GET("/inactive-roles", analyticsH.ListInactiveRoleHolders)
+		analytics.GET("/inactive-roles/export", analyticsH.ExportInactiveRoleHolders)
+		analytics.GET("/fc-leaderboard", analyticsH.GetFCLeaderboard)
+		analytics.GET("/fc-leaderboard/export", analyticsH.ExportFCLeaderboard)
+		analytics.GET("/heatmap", analyticsH.GetFleetHeatmap)
+		analytics.GET("/heatmap/export", analyticsH.ExportFleetHeatmap)
+	}
+
+	// ─── 行动日历 ───
+	opCalendarH := handler.NewOperationCalendarHandler()
+	api.GET("/calendar/feed.ics", opCalendarH.Feed) // iCal 订阅（令牌鉴权）
+	ops := operation.Group("/ops")
+	{
+		ops.GET("", opCalendarH.ListOperations)
+		ops.GET("/calendar-feed", opCalendarH.GetFeedStatus)
+		ops.POST("/calendar-feed", opCalendarH.ResetFeedToken)
+		ops.DELETE("/calendar-feed", opCalendarH.RevokeFeedToken)
+		ops.GET("/:id", opCalendarH.GetOperation)
+		ops.PUT("/:id/rsvp", opCalendarH.SetRSVP)
+		ops.DELETE("/:id/rsvp", opCalendarH.DeleteRSVP)
+
+		opsFC := ops.Group("", middleware.RequirePermission("operation:fleet:manage"))
+		{
+			opsFC.POST("", opCalendarH.CreateOperation)
+			opsFC.PUT("/:id", opCalendarH.UpdateOperation)
+			opsFC.POST("/:id/cancel", opCalendarH.CancelOperation)
+			opsFC.POST("/:id/form-up", opCalendarH.FormUp)
+			opsFC.GET("/:id/turnout", opCalendarH.GetTurnout)
+			opsFC.GET("/templates", opCalendarH.ListTemplates)
+			opsFC.POST("/templates", opCalendarH.CreateTemplate)
+			opsFC.PUT("/templates/:id", opCalendarH.UpdateTemplate)
+			opsFC.DELETE("/templates/:id", opCalendarH.DeleteTemplate)
 		}
 	}
 
@@ -135,13 +234,14 @@ func RegisterRoutes(r *gin.Engine) {
 		fleetConfig.GET("", fleetConfigH.ListFleetConfigs)
 		fleetConfig.GET("/:id", fleetConfigH.GetFleetConfig)
 		fleetConfig.GET("/:id/eft", fleetConfigH.GetFittingEFT)
-		fleetConfig.POST("", middleware.RequireRole(model.RoleFC, model.RoleSRP), fleetConfigH.CreateFleetConfig)
-		fleetConfig.PUT("/:id", middleware.RequireRole(model.RoleFC, model.RoleSRP), fleetConfigH.UpdateFleetConfig)
-		fleetConfig.DELETE("/:id", middleware.RequireRole(model.RoleFC, model.RoleSRP), fleetConfigH.DeleteFleetConfig)
+		fleetConfig.POST("", middleware.RequirePermission("operation:fleet-config:manage"), fleetConfigH.CreateFleetConfig)
+		fleetConfig.PUT("/:id", middleware.RequirePermission("operation:fleet-config:manage"), fleetConfigH.UpdateFleetConfig)
+		fleetConfig.DELETE("/:id", middleware.RequirePermission("operation:fleet-config:manage"), fleetConfigH.DeleteFleetConfig)
 		fleetConfig.POST("/import-fitting", fleetConfigH.ImportFromUserFitting)
 		fleetConfig.POST("/export-esi", fleetConfigH.ExportToESI)
 		fleetConfig.GET("/:id/fittings/:fitting_id/items", fleetConfigH.GetFittingItems)
-		fleetConfig.PUT("/:id/fittings/:fitting_id/items/settings", middleware.RequireRole(model.RoleFC, model.RoleSRP), fleetConfigH.UpdateFittingItemsSettings)
+		fleetConfig.GET("/:id/readiness", middleware.RequirePermission("operation:fleet:manage", "operation:fleet-config:manage"), fleetConfigH.GetReadiness)
+		fleetConfig.PUT("/:id/fittings/:fitting_id/items/settings", middleware.RequirePermission("operation:fleet-config:manage"), fleetConfigH.UpdateFittingItemsSettings)
 	}
 
 	// ─── 技能规划 ───
@@ -159,12 +259,12 @@ func RegisterRoutes(r *gin.Engine) {
 		skillPlan.GET("/all", skillPlanH.ListAllSkillPlans)
 		skillPlan.GET("/:id", skillPlanH.GetSkillPlan)
 		skillPlan.GET("/:id/check/me", skillPlanH.CheckUserCharacters)
-		// 管理操作（需要 FC 或 admin）
-		skillPlan.GET("", middleware.RequireRole(model.RoleFC, model.RoleAdmin), skillPlanH.ListSkillPlans)
-		skillPlan.POST("", middleware.RequireRole(model.RoleFC, model.RoleAdmin), skillPlanH.CreateSkillPlan)
-		skillPlan.PUT("/:id", middleware.RequireRole(model.RoleFC, model.RoleAdmin), skillPlanH.UpdateSkillPlan)
-		skillPlan.DELETE("/:id", middleware.RequireRole(model.RoleFC, model.RoleAdmin), skillPlanH.DeleteSkillPlan)
-		skillPlan.GET("/:id/check", middleware.RequireRole(model.RoleFC, model.RoleAdmin), skillPlanH.CheckAllCharacters)
+		// 管理操作（FC / 管理员）
+		skillPlan.GET("", middleware.RequirePermission("operation:skill-plan:manage"), skillPlanH.ListSkillPlans)
+		skillPlan.POST("", middleware.RequirePermission("operation:skill-plan:manage"), skillPlanH.CreateSkillPlan)
+		skillPlan.PUT("/:id", middleware.RequirePermission("operation:skill-plan:manage"), skillPlanH.UpdateSkillPlan)
+		skillPlan.DELETE("/:id", middleware.RequirePermission("operation:skill-plan:manage"), skillPlanH.DeleteSkillPlan)
+		skillPlan.GET("/:id/check", middleware.RequirePermission("operation:skill-plan:check"), skillPlanH.CheckAllCharacters)
 	}
 
 	// ─── EVE 角色信息 ───
@@ -244,6 +344,7 @@ func RegisterRoutes(r *gin.Engine) {
 		srp.GET("/killmails/me", srpH.GetMyKillmails)
 		srp.GET("/killmails/fleet/:fleet_id", srpH.GetFleetKillmails)
 		srp.POST("/killmails/detail", srpH.GetKillmailDetail)
+		srp.POST("/killmails/quote", srpH.QuoteKillmail)
 		srp.POST("/open-info-window", srpH.OpenInfoWindow)
 
 		// 审核（需权限）
@@ -253,141 +354,232 @@ func RegisterRoutes(r *gin.Engine) {
 			srpAdmin.GET("/applications/:id", srpH.GetApplication)
 			srpAdmin.PUT("/applications/:id/review", srpH.ReviewApplication)
 			srpAdmin.PUT("/applications/:id/payout", srpH.Payout)
+			srpAdmin.PUT("/applications/:id/reprice", srpH.RepriceApplication)
+			srpAdmin.PUT("/applications/:id/revoke", srpH.RevokeApplication)
+			srpAdmin.POST("/fleets/:fleet_id/payout", srpH.BatchPayFleet)
+			srpAdmin.GET("/pricing-config", srpH.GetPricingConfig)
+			srpAdmin.PUT("/pricing-config", middleware.RequirePermission("srp:config:edit"), srpH.UpdatePricingConfig)
+			srpAdmin.GET("/payout-config", srpH.GetPayoutConfig)
+			srpAdmin.PUT("/payout-config", middleware.RequirePermission("srp:config:edit"), srpH.UpdatePayoutConfig)
 		}
 	}
 
+	// ─── 招新 ───
+	recruitH := handler.NewRecruitHandler()
+	recruit := auth.Group("/recruit")
+	{
+		// 申请人（guest 即可访问）
+		recruit.GET("/form", recruitH.GetForm)
+		recruit.POST("/applications", recruitH.SubmitApplication)
+		recruit.GET("/applications/me", recruitH.ListMyApplications)
+		recruit.POST("/applications/me/:id/withdraw", recruitH.WithdrawApplication)
+
+		// 招新官
+		recruitView := middleware.RequirePermission("recruit:view", "recruit:review")
+		recruitReview := middleware.RequirePermission("recruit:review")
+		recruit.GET("/applications", recruitView, recruitH.ListApplications)
+		recruit.GET("/applications/:id", recruitView, recruitH.GetApplication)
+		recruit.GET("/applications/:id/vetting", recruitView, recruitH.GetVetting)
+		recruit.POST("/applications/:id/comments", recruitView, recruitH.AddComment)
+		recruit.PUT("/applications/:id/status", recruitReview, recruitH.UpdateStatus)
+		recruit.PUT("/applications/:id/accept", recruitReview, recruitH.AcceptApplication)
+		recruit.PUT("/applications/:id/reject", recruitReview, recruitH.RejectApplication)
+		recruit.GET("/config", recruitView, recruitH.GetConfig)
+		recruit.PUT("/config", middleware.RequirePermission("recruit:config"), recruitH.UpdateConfig)
+	}
+
+	// ─── 市场价格 / 估值 ───
+	marketH := handler.NewMarketHandler()
+	market := auth.Group("/market")
+	{
+		market.GET("/prices", marketH.GetPrices)
+		market.POST("/appraise", marketH.Appraise)
+		market.GET("/killmails/:killmail_id", marketH.ValueKillmail)
+		market.GET("/history/:type_id", marketH.GetHistory)
+	}
+
 	// ─── ESI 刷新队列 ───
 	esiH := handler.NewESIRefreshHandler()
-	esiRefresh := auth.Group("/esi/refresh", middleware.RequireRole(model.RoleAdmin))
+	esiRefresh := auth.Group("/esi/refresh")
 	{
-		esiRefresh.GET("/tasks", esiH.GetTasks)
-		esiRefresh.GET("/statuses", esiH.GetStatuses)
-		esiRefresh.POST("/run", esiH.RunTask)
-		esiRefresh.POST("/run-task", esiH.RunTaskByName)
-		esiRefresh.POST("/run-all", esiH.RunAll)
+		esiView := middleware.RequirePermission("system:esi:view")
+		esiRun := middleware.RequirePermission("system:esi:run")
+		esiRefresh.GET("/tasks", esiView, esiH.GetTasks)
+		esiRefresh.GET("/statuses", esiView, esiH.GetStatuses)
+		esiRefresh.GET("/runs", esiView, esiH.GetRuns)
+		esiRefresh.GET("/breakers", esiView, esiH.GetBreakers)
+		esiRefresh.POST("/breakers/reset", esiRun, esiH.ResetBreaker)
+		esiRefresh.POST("/run", esiRun, esiH.RunTask)
+		esiRefresh.POST("/run-task", esiRun, esiH.RunTaskByName)
+		esiRefresh.POST("/run-all", esiRun, esiH.RunAll)
 	}
 
-	// ─── 系统管理（需要 admin 角色）───
-	admin := auth.Group("/system", middleware.RequireRole(model.RoleAdmin))
+	// ─── 系统管理 ───
+	// 每个接口按权限标识鉴权（菜单按钮权限，见 model.GetSystemMenuSeeds），
+	// admin 角色默认拥有全部按钮权限，自定义角色可按需分配
+	admin := auth.Group("/system")
 
 	// 系统基础配置
 	sysConfigH := handler.NewSysConfigHandler()
-	admin.GET("/basic-config", sysConfigH.GetBasicConfig)
-	admin.PUT("/basic-config", sysConfigH.UpdateBasicConfig)
+	admin.GET("/basic-config", middleware.RequirePermission("system:config:view"), sysConfigH.GetBasicConfig)
+	admin.PUT("/basic-config", middleware.RequirePermission("system:config:edit"), sysConfigH.UpdateBasicConfig)
+	admin.GET("/fleet-tracking-config", middleware.RequirePermission("system:config:view"), fleetH.GetTrackingConfig)
+	admin.PUT("/fleet-tracking-config", middleware.RequirePermission("system:config:edit"), fleetH.UpdateTrackingConfig)
 
 	// 服务器更新（管理员）
 	serverUpdateH := handler.NewServerUpdateHandler()
-	serverUpdate := admin.Group("/server-update")
+	serverUpdate := admin.Group("/server-update", middleware.RequirePermission("system:server:update"))
 	{
 		serverUpdate.GET("/check", serverUpdateH.CheckUpdate)
 		serverUpdate.POST("/upgrade", serverUpdateH.PerformUpgrade)
 		serverUpdate.POST("/upgrade-frontend", serverUpdateH.PerformFrontendUpgrade)
 	}
 
+	// 定时任务租约（多实例部署时查看各任务由哪个实例持有）
+	cronJobH := handler.NewCronJobHandler()
+	admin.GET("/cron-jobs", middleware.RequirePermission("system:cron:view"), cronJobH.GetLeases)
+
+	// 成员生命周期：离队报表 + 敌对观察名单
+	memberH := handler.NewMemberLifecycleHandler()
+	adminMember := admin.Group("/member")
+	{
+		adminMember.GET("/departures", middleware.RequirePermission("system:member:history"), memberH.ListDepartures)
+		adminMember.GET("/hostile-watchlist", middleware.RequirePermission("system:member:history", "system:member:watchlist"), memberH.ListHostileWatchlist)
+		adminMember.POST("/hostile-watchlist", middleware.RequirePermission("system:member:watchlist"), memberH.AddHostileEntity)
+		adminMember.DELETE("/hostile-watchlist/:id", middleware.RequirePermission("system:member:watchlist"), memberH.RemoveHostileEntity)
+	}
+
+	// ESI Token 健康报告（按军团列出失效 Token 的成员）
+	admin.GET("/token-health", middleware.RequirePermission("system:token-health:view"), tokenHealthH.GetCorpReport)
+
 	// SeAT 配置（管理员）
-	admin.GET("/seat-config", seatH.GetSeatConfig)
-	admin.PUT("/seat-config", seatH.UpdateSeatConfig)
+	admin.GET("/seat-config", middleware.RequirePermission("system:seat:config"), seatH.GetSeatConfig)
+	admin.PUT("/seat-config", middleware.RequirePermission("system:seat:config"), seatH.UpdateSeatConfig)
 
 	// Mumble 配置（管理员）
-	admin.GET("/mumble-config", mumbleH.GetConfig)
-	admin.PUT("/mumble-config", mumbleH.UpdateConfig)
-	admin.GET("/mumble-role-groups", mumbleH.ListRoleGroups)
-	admin.PUT("/mumble-role-groups", mumbleH.UpdateRoleGroups)
+	mumbleAdmin := admin.Group("", middleware.RequirePermission("system:mumble:config"))
+	mumbleAdmin.GET("/mumble-config", mumbleH.GetConfig)
+	mumbleAdmin.PUT("/mumble-config", mumbleH.UpdateConfig)
+	mumbleAdmin.GET("/mumble-role-groups", mumbleH.ListRoleGroups)
+	mumbleAdmin.PUT("/mumble-role-groups", mumbleH.UpdateRoleGroups)
 
 	// NPC 刷怪报表（管理员 — 公司级）
-	admin.POST("/npc-kills", npcKillH.GetCorpNpcKills)
+	admin.POST("/npc-kills", middleware.RequirePermission("system:npc-kill:view"), npcKillH.GetCorpNpcKills)
 
 	// 联盟 PAP 管理（管理员）
 	alliancePAPAdminH := handler.NewAlliancePAPHandler()
 	alliancePAPAdmin := admin.Group("/pap")
 	{
-		alliancePAPAdmin.GET("", alliancePAPAdminH.GetAllAlliancePAP)
-		alliancePAPAdmin.POST("/fetch", alliancePAPAdminH.TriggerFetch)
-		alliancePAPAdmin.POST("/import", alliancePAPAdminH.ImportAlliancePAP)
+		alliancePAPAdmin.GET("", middleware.RequirePermission("system:pap:view"), alliancePAPAdminH.GetAllAlliancePAP)
+		alliancePAPAdmin.POST("/fetch", middleware.RequirePermission("system:pap:fetch"), alliancePAPAdminH.TriggerFetch)
+		alliancePAPAdmin.POST("/import", middleware.RequirePermission("system:pap:import"), alliancePAPAdminH.ImportAlliancePAP)
 		// PAP 兑换配置
-		alliancePAPAdmin.GET("/config", alliancePAPAdminH.GetExchangeConfig)
-		alliancePAPAdmin.PUT("/config", alliancePAPAdminH.SetExchangeConfig)
+		alliancePAPAdmin.GET("/config", middleware.RequirePermission("system:pap:config"), alliancePAPAdminH.GetExchangeConfig)
+		alliancePAPAdmin.PUT("/config", middleware.RequirePermission("system:pap:config"), alliancePAPAdminH.SetExchangeConfig)
 		// 月度归档 + 兑换系统钱包
-		alliancePAPAdmin.POST("/settle", alliancePAPAdminH.SettleMonth)
+		alliancePAPAdmin.POST("/settle", middleware.RequirePermission("system:pap:settle"), alliancePAPAdminH.SettleMonth)
 	}
 
 	// 菜单管理
 	adminMenu := admin.Group("/menu")
 	{
-		adminMenu.GET("/tree", menuH.GetMenuTree)
-		adminMenu.POST("", menuH.CreateMenu)
-		adminMenu.PUT("/:id", menuH.UpdateMenu)
-		adminMenu.DELETE("/:id", menuH.DeleteMenu)
+		adminMenu.GET("/tree", middleware.RequirePermission("system:menu:list"), menuH.GetMenuTree)
+		adminMenu.POST("", middleware.RequirePermission("system:menu:add"), menuH.CreateMenu)
+		adminMenu.PUT("/:id", middleware.RequirePermission("system:menu:edit"), menuH.UpdateMenu)
+		adminMenu.DELETE("/:id", middleware.RequirePermission("system:menu:delete"), menuH.DeleteMenu)
 	}
 
 	// 角色管理
 	roleH := handler.NewRoleHandler()
 	adminRole := admin.Group("/role")
 	{
-		adminRole.GET("", roleH.ListRoles)
-		adminRole.GET("/all", roleH.ListAllRoles)
-		adminRole.GET("/:id", roleH.GetRole)
-		adminRole.POST("", roleH.CreateRole)
-		adminRole.PUT("/:id", roleH.UpdateRole)
-		adminRole.DELETE("/:id", roleH.DeleteRole)
+		adminRole.GET("", middleware.RequirePermission("system:role:list"), roleH.ListRoles)
+		adminRole.GET("/all", middleware.RequirePermission("system:role:list", "system:user:role"), roleH.ListAllRoles)
+		adminRole.GET("/:id", middleware.RequirePermission("system:role:list"), roleH.GetRole)
+		adminRole.POST("", middleware.RequirePermission("system:role:add"), roleH.CreateRole)
+		adminRole.PUT("/:id", middleware.RequirePermission("system:role:edit"), roleH.UpdateRole)
+		adminRole.DELETE("/:id", middleware.RequirePermission("system:role:delete"), roleH.DeleteRole)
 
 		// 角色权限
-		adminRole.GET("/:id/menus", roleH.GetRoleMenus)
-		adminRole.PUT("/:id/menus", roleH.SetRoleMenus)
+		adminRole.GET("/:id/menus", middleware.RequirePermission("system:role:list"), roleH.GetRoleMenus)
+		adminRole.PUT("/:id/menus", middleware.RequirePermission("system:role:permission"), roleH.SetRoleMenus)
 	}
 
+	// 权限标识列表（供角色分配权限时选择）
+	admin.GET("/permissions", middleware.RequirePermission("system:role:list"), menuH.ListPermissions)
+
 	// 用户管理
 	userH := handler.NewUserHandler()
 	adminUser := admin.Group("/user")
 	{
-		adminUser.GET("", userH.ListUsers)
-		adminUser.GET("/:id", userH.GetUser)
-		adminUser.PUT("/:id", userH.UpdateUser)
-		adminUser.DELETE("/:id", userH.DeleteUser)
+		adminUser.GET("", middleware.RequirePermission("system:user:list"), userH.ListUsers)
+		adminUser.GET("/:id", middleware.RequirePermission("system:user:list"), userH.GetUser)
+		adminUser.PUT("/:id", middleware.RequirePermission("system:user:edit"), userH.UpdateUser)
+		adminUser.DELETE("/:id", middleware.RequirePermission("system:user:delete"), userH.DeleteUser)
 
 		// 用户角色分配
-		adminUser.GET("/:id/roles", roleH.GetUserRoles)
-		adminUser.PUT("/:id/roles", roleH.SetUserRoles)
+		adminUser.GET("/:id/roles", middleware.RequirePermission("system:user:list"), roleH.GetUserRoles)
+		adminUser.PUT("/:id/roles", middleware.RequirePermission("system:user:role"), roleH.SetUserRoles)
+
+		// 限定军团/联盟范围的角色
+		adminUser.GET("/:id/role-scopes", middleware.RequirePermission("system:user:list"), roleH.ListUserRoleScopes)
+		adminUser.POST("/:id/role-scopes", middleware.RequirePermission("system:user:role"), roleH.AddUserRoleScope)
+		adminUser.DELETE("/:id/role-scopes/:scope_id", middleware.RequirePermission("system:user:role"), roleH.RemoveUserRoleScope)
+
+		// 登录会话（查看 / 强制下线）
+		// 军团 / 联盟归属时间线
+		adminUser.GET("/:id/affiliation-history", middleware.RequirePermission("system:member:history"), memberH.GetUserTimeline)
+
+		adminUser.GET("/:id/sessions", middleware.RequirePermission("system:user:list"), sessionH.ListUserSessions)
+		adminUser.DELETE("/:id/sessions", middleware.RequirePermission("system:user:session"), sessionH.RevokeUserSessions)
 
 		// 模拟登录（仅超级管理员）
-		adminUser.POST("/:id/impersonate", middleware.RequireRole(model.RoleSuperAdmin), userH.ImpersonateUser)
+		adminUser.POST("/:id/impersonate", middleware.RequireSession(), middleware.RequireRole(model.RoleSuperAdmin), userH.ImpersonateUser)
+	}
+
+	// API Key 管理（管理员，仅限登录会话）
+	adminAPIKey := admin.Group("/api-keys", middleware.RequireSession(), middleware.RequirePermission("system:api-key:manage"))
+	{
+		adminAPIKey.GET("", apiKeyH.List)
+		adminAPIKey.POST("", apiKeyH.Create)
+		adminAPIKey.DELETE("/:id", apiKeyH.Revoke)
 	}
 
 	// 系统钱包管理（管理员）
 	adminWalletH := handler.NewSysWalletHandler()
 	adminWallet := admin.Group("/wallet")
 	{
-		adminWallet.POST("/list", adminWalletH.AdminListWallets)
-		adminWallet.POST("/detail", adminWalletH.AdminGetWallet)
-		adminWallet.POST("/adjust", adminWalletH.AdminAdjust)
-		adminWallet.POST("/transactions", adminWalletH.AdminListTransactions)
-		adminWallet.POST("/logs", adminWalletH.AdminListLogs)
+		adminWallet.POST("/list", middleware.RequirePermission("system:wallet:view"), adminWalletH.AdminListWallets)
+		adminWallet.POST("/detail", middleware.RequirePermission("system:wallet:view"), adminWalletH.AdminGetWallet)
+		adminWallet.POST("/adjust", middleware.RequirePermission("system:wallet:adjust"), adminWalletH.AdminAdjust)
+		adminWallet.POST("/transactions", middleware.RequirePermission("system:wallet:view"), adminWalletH.AdminListTransactions)
+		adminWallet.POST("/logs", middleware.RequirePermission("system:wallet:log"), adminWalletH.AdminListLogs)
 	}
 
 	// 商店管理（管理员）
 	adminShopH := handler.NewShopHandler()
 	adminShopProduct := admin.Group("/shop/product")
 	{
-		adminShopProduct.POST("/list", adminShopH.AdminListProducts)
-		adminShopProduct.POST("/add", adminShopH.AdminCreateProduct)
-		adminShopProduct.POST("/edit", adminShopH.AdminUpdateProduct)
-		adminShopProduct.POST("/delete", adminShopH.AdminDeleteProduct)
+		adminShopProduct.POST("/list", middleware.RequirePermission("system:shop:product:list"), adminShopH.AdminListProducts)
+		adminShopProduct.POST("/add", middleware.RequirePermission("system:shop:product:add"), adminShopH.AdminCreateProduct)
+		adminShopProduct.POST("/edit", middleware.RequirePermission("system:shop:product:edit"), adminShopH.AdminUpdateProduct)
+		adminShopProduct.POST("/delete", middleware.RequirePermission("system:shop:product:delete"), adminShopH.AdminDeleteProduct)
 	}
 	adminShopOrder := admin.Group("/shop/order")
 	{
-		adminShopOrder.POST("/list", adminShopH.AdminListOrders)
-		adminShopOrder.POST("/approve", adminShopH.AdminApproveOrder)
-		adminShopOrder.POST("/reject", adminShopH.AdminRejectOrder)
-		adminShopOrder.POST("/ship", adminShopH.AdminShipOrder)
+		adminShopOrder.POST("/list", middleware.RequirePermission("system:shop:order:list"), adminShopH.AdminListOrders)
+		adminShopOrder.POST("/approve", middleware.RequirePermission("system:shop:order:review"), adminShopH.AdminApproveOrder)
+		adminShopOrder.POST("/reject", middleware.RequirePermission("system:shop:order:review"), adminShopH.AdminRejectOrder)
+		adminShopOrder.POST("/ship", middleware.RequirePermission("system:shop:order:review"), adminShopH.AdminShipOrder)
 	}
-	adminShopRedeem := admin.Group("/shop/redeem")
+	adminShopRedeem := admin.Group("/shop/redeem", middleware.RequirePermission("system:shop:redeem:list"))
 	{
 		adminShopRedeem.POST("/list", adminShopH.AdminListRedeemCodes)
 	}
 
 	// 抽奖管理（管理员）
 	adminLotteryH := handler.NewLotteryHandler()
-	adminLottery := admin.Group("/shop/lottery")
+	adminLottery := admin.Group("/shop/lottery", middleware.RequirePermission("system:shop:lottery:manage"))
 	{
 		adminLottery.POST("/list", adminLotteryH.AdminListActivities)
 		adminLottery.POST("/add", adminLotteryH.AdminCreateActivity)
@@ -404,69 +596,89 @@ func RegisterRoutes(r *gin.Engine) {
 	autoRoleH := handler.NewAutoRoleHandler()
 	adminAutoRole := admin.Group("/auto-role")
 	{
+		autoRoleView := middleware.RequirePermission("system:auto-role:view")
+		autoRoleEdit := middleware.RequirePermission("system:auto-role:edit")
+
 		// ESI 军团角色映射
-		adminAutoRole.GET("/esi-roles", autoRoleH.GetAllEsiRoles)
-		adminAutoRole.GET("/esi-role-mappings", autoRoleH.ListEsiRoleMappings)
-		adminAutoRole.POST("/esi-role-mappings", autoRoleH.CreateEsiRoleMapping)
-		adminAutoRole.DELETE("/esi-role-mappings/:id", autoRoleH.DeleteEsiRoleMapping)
+		adminAutoRole.GET("/esi-roles", autoRoleView, autoRoleH.GetAllEsiRoles)
+		adminAutoRole.GET("/esi-role-mappings", autoRoleView, autoRoleH.ListEsiRoleMappings)
+		adminAutoRole.POST("/esi-role-mappings", autoRoleEdit, autoRoleH.CreateEsiRoleMapping)
+		adminAutoRole.DELETE("/esi-role-mappings/:id", autoRoleEdit, autoRoleH.DeleteEsiRoleMapping)
 
 		// ESI 头衔映射
-		adminAutoRole.GET("/corp-titles", autoRoleH.ListCorpTitles)
-		adminAutoRole.GET("/esi-title-mappings", autoRoleH.ListEsiTitleMappings)
-		adminAutoRole.POST("/esi-title-mappings", autoRoleH.CreateEsiTitleMapping)
-		adminAutoRole.DELETE("/esi-title-mappings/:id", autoRoleH.DeleteEsiTitleMapping)
+		adminAutoRole.GET("/corp-titles", autoRoleView, autoRoleH.ListCorpTitles)
+		adminAutoRole.GET("/esi-title-mappings", autoRoleView, autoRoleH.ListEsiTitleMappings)
+		adminAutoRole.POST("/esi-title-mappings", autoRoleEdit, autoRoleH.CreateEsiTitleMapping)
+		adminAutoRole.DELETE("/esi-title-mappings/:id", autoRoleEdit, autoRoleH.DeleteEsiTitleMapping)
 
 		// SeAT 分组映射
-		adminAutoRole.GET("/seat-roles", autoRoleH.GetAllSeatRoles)
-		adminAutoRole.GET("/seat-role-mappings", autoRoleH.ListSeatRoleMappings)
-		adminAutoRole.POST("/seat-role-mappings", autoRoleH.CreateSeatRoleMapping)
-		adminAutoRole.DELETE("/seat-role-mappings/:id", autoRoleH.DeleteSeatRoleMapping)
+		adminAutoRole.GET("/seat-roles", autoRoleView, autoRoleH.GetAllSeatRoles)
+		adminAutoRole.GET("/seat-role-mappings", autoRoleView, autoRoleH.ListSeatRoleMappings)
+		adminAutoRole.POST("/seat-role-mappings", autoRoleEdit, autoRoleH.CreateSeatRoleMapping)
+		adminAutoRole.DELETE("/seat-role-mappings/:id", autoRoleEdit, autoRoleH.DeleteSeatRoleMapping)
+
+		// 自动权限规则（条件树）
+		adminAutoRole.GET("/rules", autoRoleView, autoRoleH.ListAutoRoleRules)
+		adminAutoRole.POST("/rules", autoRoleEdit, autoRoleH.CreateAutoRoleRule)
+		adminAutoRole.POST("/rules/dry-run", autoRoleView, autoRoleH.DryRunAutoRoleRule)
+		adminAutoRole.PUT("/rules/:id", autoRoleEdit, autoRoleH.UpdateAutoRoleRule)
+		adminAutoRole.DELETE("/rules/:id", autoRoleEdit, autoRoleH.DeleteAutoRoleRule)
 
 		// 手动触发同步
-		adminAutoRole.POST("/sync", autoRoleH.TriggerSync)
+		adminAutoRole.POST("/sync", autoRoleEdit, autoRoleH.TriggerSync)
+		adminAutoRole.GET("/sync/preview", autoRoleView, autoRoleH.PreviewSync)
+		adminAutoRole.GET("/sync-guard", autoRoleView, autoRoleH.GetSyncGuardConfig)
+		adminAutoRole.PUT("/sync-guard", autoRoleEdit, autoRoleH.UpdateSyncGuardConfig)
 
 		// 操作日志
-		adminAutoRole.GET("/logs", autoRoleH.ListAutoRoleLogs)
+		adminAutoRole.GET("/logs", autoRoleView, autoRoleH.ListAutoRoleLogs)
 
 		// 准入名单管理
-		adminAutoRole.GET("/allow-list/:type", autoRoleH.ListAllowedEntities)
-		adminAutoRole.POST("/allow-list/:type", autoRoleH.AddAllowedEntity)
-		adminAutoRole.DELETE("/allow-list/:type/:id", autoRoleH.RemoveAllowedEntity)
+		adminAutoRole.GET("/allow-list/:type", autoRoleView, autoRoleH.ListAllowedEntities)
+		adminAutoRole.POST("/allow-list/:type", autoRoleEdit, autoRoleH.AddAllowedEntity)
+		adminAutoRole.DELETE("/allow-list/:type/:id", autoRoleEdit, autoRoleH.RemoveAllowedEntity)
 
 		// EVE 实体模糊搜索（zkillboard autocomplete 代理）
-		adminAutoRole.GET("/eve-search", autoRoleH.SearchEveEntities)
+		adminAutoRole.GET("/eve-search", autoRoleView, autoRoleH.SearchEveEntities)
 
 		// 准入名单"仅主角色"开关配置
-		adminAutoRole.GET("/allow-config", autoRoleH.GetAllowListOnlyMainCharConfig)
-		adminAutoRole.PUT("/allow-config", autoRoleH.UpdateAllowListOnlyMainCharConfig)
+		adminAutoRole.GET("/allow-config", autoRoleView, autoRoleH.GetAllowListOnlyMainCharConfig)
+		adminAutoRole.PUT("/allow-config", autoRoleEdit, autoRoleH.UpdateAllowListOnlyMainCharConfig)
 	}
 
 	// Webhook 配置（管理员）
 	webhookH := handler.NewWebhookHandler()
-	adminWebhook := admin.Group("/webhook")
+	adminWebhook := admin.Group("/webhook", middleware.RequirePermission("system:webhook:config"))
 	{
 		adminWebhook.GET("/config", webhookH.GetConfig)
 		adminWebhook.PUT("/config", webhookH.SetConfig)
 		adminWebhook.POST("/test", webhookH.TestWebhook)
 	}
 
+	// 市场价格拉取（管理员）
+	adminMarket := admin.Group("/market")
+	{
+		adminMarket.GET("/status", middleware.RequirePermission("system:market:view"), marketH.GetStatus)
+		adminMarket.POST("/refresh", middleware.RequirePermission("system:market:refresh"), marketH.Refresh)
+	}
+
 	// SDE 数据管理（管理员）
 	adminSde := admin.Group("/sde")
 	{
-		adminSde.GET("/version", sdeH.GetVersion)
-		adminSde.POST("/update", sdeH.TriggerUpdate)
+		adminSde.GET("/version", middleware.RequirePermission("system:sde:view"), sdeH.GetVersion)
+		adminSde.POST("/update", middleware.RequirePermission("system:sde:update"), sdeH.TriggerUpdate)
 	}
 
 	// ─── 军团管理（管理员） ───
-	corpAdmin := auth.Group("/corp", middleware.RequireRole(model.RoleAdmin, model.RoleSuperAdmin))
+	corpAdmin := auth.Group("/corp")
 	{
 		incentiveH := handler.NewFleetBattleIncentiveHandler()
 		corpIncentive := corpAdmin.Group("/battle-incentives")
 		{
-			corpIncentive.GET("", incentiveH.ListAll)
-			corpIncentive.PUT("/:fleet_type", incentiveH.Update)
+			corpIncentive.GET("", middleware.RequirePermission("corp:incentive:view"), incentiveH.ListAll)
+			corpIncentive.PUT("/:fleet_type", middleware.RequirePermission("corp:incentive:edit"), incentiveH.Update)
 		}
 		// 手动补发 FC 带队奖励
-		corpAdmin.POST("/fleets/:id/lead-reward", incentiveH.IssueFCLeadReward)
+		corpAdmin.POST("/fleets/:id/lead-reward", middleware.RequirePermission("corp:incentive:reward"), incentiveH.IssueFCLeadReward)
 	}
 }
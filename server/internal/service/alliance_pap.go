@@ -390,7 +390,7 @@ type SettleMonthResult struct {
 // corporationIDs 非空时只结算这些军团的数据
 func (s *AlliancePAPService) SettleMonth(year, month int, walletConvert bool, operatorID uint, corporationIDs []int64) (*SettleMonthResult, error) {
 	// 1. 归档
-	if err := s.repo.MarkArchived(year, month); err != nil {
+	if err := s.repo.MarkArchived(year, month, corporationIDs); err != nil {
 		return nil, fmt.Errorf("归档失败: %w", err)
 	}
 
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/cache"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  API Key
+//  Key 绑定用户，以该用户身份访问任意接口；权限范围（scopes）沿用 RequirePermission 的权限标识，
+//  实际权限为 scopes 与用户自身权限的交集。Key 元数据在 Redis 缓存 apiKeyCacheTTL，
+//  每个 Key 按分钟计数限流，最近使用时间按分钟粒度回写数据库
+// ─────────────────────────────────────────────
+
+const (
+	apiKeyPrefix        = "ae_"
+	apiKeyCachePrefix   = "apikey:hash:"
+	apiKeyRatePrefix    = "apikey:rate:"
+	apiKeySeenPrefix    = "apikey:seen:"
+	apiKeyCacheTTL      = time.Minute
+	defaultAPIKeyRate   = 60
+	maxAPIKeyRate       = 600
+	maxAPIKeysPerUser   = 20
+	apiKeyDisplayPrefix = 10
+)
+
+var (
+	ErrAPIKeyInvalid     = errors.New("无效的 API Key")
+	ErrAPIKeyExpired     = errors.New("API Key 已过期")
+	ErrAPIKeyRateLimited = errors.New("API Key 请求过于频繁，请稍后再试")
+)
+
+// APIKeyService API Key 业务逻辑层
+type APIKeyService struct {
+	repo     *repository.APIKeyRepository
+	userRepo *repository.UserRepository
+	roleSvc  *RoleService
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{
+		repo:     repository.NewAPIKeyRepository(),
+		userRepo: repository.NewUserRepository(),
+		roleSvc:  NewRoleService(),
+	}
+}
+
+// CreateAPIKeyRequest 创建 API Key 参数
+type CreateAPIKeyRequest struct {
+	UserID    uint       `json:"user_id"` // 仅管理员接口使用：Key 绑定的用户
+	Name      string     `json:"name"       binding:"required,max=100"`
+	Scopes    []string   `json:"scopes"     binding:"required,min=1"`
+	ReadOnly  bool       `json:"read_only"`
+	RateLimit int        `json:"rate_limit" binding:"gte=0"` // 每分钟请求上限，0 取默认值
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey 创建结果（明文 Key 仅返回这一次）
+type CreatedAPIKey struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"api_key"`
+}
+
+// Create 为用户创建 API Key，权限范围不得超出用户自身权限；
+// 为他人创建时，权限范围与该用户持有的角色均不得超出创建人可授予的范围
+func (s *APIKeyService) Create(ctx context.Context, ownerID, creatorID uint, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	owner, err := s.userRepo.GetByID(ownerID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if owner.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("过期时间必须晚于当前时间")
+	}
+	rate := req.RateLimit
+	if rate == 0 {
+		rate = defaultAPIKeyRate
+	}
+	if rate > maxAPIKeyRate {
+		return nil, fmt.Errorf("每分钟请求上限不能超过 %d", maxAPIKeyRate)
+	}
+	existing, err := s.repo.ListByUser(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return nil, fmt.Errorf("每个用户最多创建 %d 个 API Key", maxAPIKeysPerUser)
+	}
+
+	scopes, err := s.validateScopes(ctx, ownerID, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDelegation(ctx, creatorID, ownerID, scopes); err != nil {
+		return nil, err
+	}
+
+	raw, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key := &model.APIKey{
+		UserID:    ownerID,
+		Name:      req.Name,
+		Prefix:    raw[:apiKeyDisplayPrefix],
+		KeyHash:   hashToken(raw),
+		Scopes:    strings.Join(scopes, " "),
+		ReadOnly:  req.ReadOnly,
+		RateLimit: rate,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: creatorID,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, err
+	}
+	global.Logger.Info("[APIKey] 创建 API Key",
+		zap.Uint("key_id", key.ID), zap.Uint("user_id", ownerID),
+		zap.Uint("created_by", creatorID), zap.String("scopes", key.Scopes))
+	return &CreatedAPIKey{Key: raw, APIKey: key}, nil
+}
+
+// checkDelegation 为他人创建 Key 时校验创建人：
+// 用户持有的角色（含限定范围的角色）须全部可由创建人授予，权限范围须被创建人自身权限覆盖，
+// 防止借高权限用户的 Key 越权（如管理员为超级管理员创建 * Key）
+func (s *APIKeyService) checkDelegation(ctx context.Context, creatorID, ownerID uint, scopes []string) error {
+	if creatorID == ownerID {
+		return nil
+	}
+	guard, err := s.roleSvc.newGrantGuard(ctx, creatorID)
+	if err != nil {
+		return err
+	}
+	roleIDs, err := s.roleSvc.repo.GetUserRoleIDs(ownerID)
+	if err != nil {
+		return err
+	}
+	scopedIDs, err := s.roleSvc.repo.GetUserScopedRoleIDs(ownerID)
+	if err != nil {
+		return err
+	}
+	for _, rid := range append(roleIDs, scopedIDs...) {
+		role, err := s.roleSvc.repo.GetByID(rid)
+		if err != nil {
+			continue
+		}
+		if err := guard.checkRole(role); err != nil {
+			return fmt.Errorf("不能为该用户创建 API Key：%w", err)
+		}
+	}
+	for _, scope := range scopes {
+		if scope != model.APIKeyScopeAll && !guard.covers(scope) {
+			return fmt.Errorf("您不具备权限 %s，不能授予 API Key", scope)
+		}
+	}
+	return nil
+}
+
+// validateScopes 去重并校验权限范围：* 或用户自身拥有的权限（含父级权限覆盖的子权限）
+func (s *APIKeyService) validateScopes(ctx context.Context, userID uint, scopes []string) ([]string, error) {
+	roles, err := s.roleSvc.GetUserRoleNames(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.roleSvc.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	superAdmin := model.IsSuperAdmin(roles)
+
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if strings.ContainsAny(scope, " \t\n") {
+			return nil, fmt.Errorf("无效的权限范围: %q", scope)
+		}
+		if scope != model.APIKeyScopeAll && !superAdmin && !model.HasAnyPermission(perms, scope) {
+			return nil, fmt.Errorf("用户不具备权限 %s，不能授予 API Key", scope)
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("至少需要一个权限范围")
+	}
+	return result, nil
+}
+
+// GrantableScopes 用户可授予 API Key 的权限范围
+func (s *APIKeyService) GrantableScopes(ctx context.Context, userID uint) ([]string, error) {
+	perms, err := s.roleSvc.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{model.APIKeyScopeAll}, perms...), nil
+}
+
+// ListMine 查询用户自己的 API Key
+func (s *APIKeyService) ListMine(userID uint) ([]model.APIKey, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// List 管理员分页查询 API Key
+func (s *APIKeyService) List(page, pageSize int, userID uint) ([]model.APIKey, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.repo.List(page, pageSize, userID)
+}
+
+// Revoke 吊销 API Key；ownerID 非 0 时只能吊销该用户自己的 Key
+func (s *APIKeyService) Revoke(ctx context.Context, id, ownerID uint) error {
+	key, err := s.repo.GetByID(id)
+	if err != nil || (ownerID != 0 && key.UserID != ownerID) {
+		return errors.New("API Key 不存在")
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	_ = cache.Del(ctx, apiKeyCachePrefix+key.KeyHash)
+	global.Logger.Info("[APIKey] 吊销 API Key", zap.Uint("key_id", id), zap.Uint("user_id", key.UserID))
+	return nil
+}
+
+// ─── 鉴权 ───
+
+// APIKeyIdentity API Key 鉴权结果
+type APIKeyIdentity struct {
+	Key         model.APIKey `json:"key"`
+	CharacterID int64        `json:"character_id"` // 所属用户的主角色
+	Role        string       `json:"role"`         // 所属用户的 user.role 字段（向后兼容）
+}
+
+// Authenticate 校验 API Key：存在、未过期、用户未禁用、未超出限流，并记录最近使用
+func (s *APIKeyService) Authenticate(ctx context.Context, raw, ip string) (*APIKeyIdentity, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	hash := hashToken(raw)
+	cacheKey := apiKeyCachePrefix + hash
+
+	var ident APIKeyIdentity
+	if err := cache.Get(ctx, cacheKey, &ident); err != nil {
+		key, err := s.repo.GetByHash(hash)
+		if err != nil {
+			return nil, ErrAPIKeyInvalid
+		}
+		user, err := s.userRepo.GetByID(key.UserID)
+		if err != nil {
+			return nil, ErrAPIKeyInvalid
+		}
+		ident = APIKeyIdentity{Key: *key, CharacterID: user.PrimaryCharacterID, Role: user.Role}
+		_ = cache.Set(ctx, cacheKey, ident, apiKeyCacheTTL)
+	}
+
+	key := &ident.Key
+	now := time.Now()
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(now) {
+		return nil, ErrAPIKeyExpired
+	}
+	if err := NewSessionService().checkUserStatus(ctx, key.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, key, now); err != nil {
+		return nil, err
+	}
+
+	if ok, err := global.Redis.SetNX(ctx, fmt.Sprintf("%s%d", apiKeySeenPrefix, key.ID), ip, time.Minute).Result(); err == nil && ok {
+		if err := s.repo.TouchLastUsed(key.ID, ip, now); err != nil {
+			global.Logger.Warn("[APIKey] 更新最近使用时间失败", zap.Uint("key_id", key.ID), zap.Error(err))
+		}
+	}
+	return &ident, nil
+}
+
+// checkRate 按自然分钟计数限流
+func (s *APIKeyService) checkRate(ctx context.Context, key *model.APIKey, now time.Time) error {
+	limit := key.RateLimit
+	if limit <= 0 {
+		limit = defaultAPIKeyRate
+	}
+	rateKey := fmt.Sprintf("%s%d:%d", apiKeyRatePrefix, key.ID, now.Unix()/60)
+	n, err := cache.Incr(ctx, rateKey)
+	if err != nil {
+		// Redis 故障时不阻断请求
+		return nil
+	}
+	if n == 1 {
+		_ = cache.Expire(ctx, rateKey, 2*time.Minute)
+	}
+	if n > int64(limit) {
+		return ErrAPIKeyRateLimited
+	}
+	return nil
+}
+
+// EffectivePermissions 计算 API Key 请求的实际权限：scopes 与用户权限的交集
+// fullScope 为 true 表示 Key 拥有 * 范围，直接继承用户全部权限
+func EffectivePermissions(key *model.APIKey, roles, userPerms []string) (perms []string, fullScope bool) {
+	scopes := key.ScopeList()
+	for _, scope := range scopes {
+		if scope == model.APIKeyScopeAll {
+			return userPerms, true
+		}
+	}
+	if model.IsSuperAdmin(roles) {
+		return scopes, false
+	}
+	perms = make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if model.HasAnyPermission(userPerms, scope) {
+			perms = append(perms, scope)
+		}
+	}
+	return perms, false
+}
+
+// EffectiveScopedGrants 计算 API Key 请求可用的限定范围角色权限：权限与 scopes 取交集
+func EffectiveScopedGrants(key *model.APIKey, grants []model.ScopedGrant) []model.ScopedGrant {
+	scopes := key.ScopeList()
+	for _, scope := range scopes {
+		if scope == model.APIKeyScopeAll {
+			return grants
+		}
+	}
+	result := make([]model.ScopedGrant, 0, len(grants))
+	for _, grant := range grants {
+		perms := make([]string, 0, len(grant.Permissions))
+		for _, p := range grant.Permissions {
+			if model.HasAnyPermission(scopes, p) {
+				perms = append(perms, p)
+			}
+		}
+		if len(perms) > 0 {
+			grant.Permissions = perms
+			result = append(result, grant)
+		}
+	}
+	return result
+}
+
+func newAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"context"
 	"encoding/json"
 	"errors"
@@ -83,7 +84,7 @@ func NewAssetService() *AssetService {
 		assetRepo: repository.NewAssetRepository(),
 		sdeRepo:   repository.NewSdeRepository(),
 		ssoSvc:    NewEveSSOService(),
-		http:      &http.Client{Timeout: 30 * time.Second},
+		http:      eve.NewESIHTTPClient(30 * time.Second),
 	}
 }
 
@@ -454,7 +455,7 @@ func (s *AssetService) fetchAndCacheStructure(characterID, structureID int64, ac
 // ─────────────────────────────────────────────
 
 func (s *AssetService) esiGet(ctx context.Context, path, accessToken string, out interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+path, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiURL(path), nil)
 	if err != nil {
 		return err
 	}
@@ -476,7 +477,7 @@ func (s *AssetService) esiGet(ctx context.Context, path, accessToken string, out
 }
 
 func (s *AssetService) esiGetPublic(ctx context.Context, path string, out interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+path, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiURL(path), nil)
 	if err != nil {
 		return err
 	}
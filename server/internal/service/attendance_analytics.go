@@ -0,0 +1,573 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  出勤统计
+//  基于 fleet_pap_log 计算滚动 30/90 天出勤（按用户 / 军团 / 舰船分组）、
+//  连续出勤周数、仍持有职权的不活跃成员、FC 排行与舰队开始时间热力图
+//  统计口径：仅计入 PAP > 0 的记录；军团按用户主角色当前所在军团归属
+// ─────────────────────────────────────────────
+
+const (
+	AttendanceGroupUser      = "user"
+	AttendanceGroupCorp      = "corp"
+	AttendanceGroupShipGroup = "ship_group"
+
+	attendanceShortWindow = 30 // 天
+	attendanceLongWindow  = 90 // 天
+	maxAttendanceDays     = 365
+	attendanceStreakWeeks = 52
+)
+
+// attendanceExcludedRoles 判断"持有职权"时忽略的基础角色
+var attendanceExcludedRoles = []string{model.RoleUser, model.RoleGuest}
+
+// AttendanceAnalyticsService 出勤统计业务逻辑层
+type AttendanceAnalyticsService struct {
+	repo *repository.AttendanceAnalyticsRepository
+}
+
+func NewAttendanceAnalyticsService() *AttendanceAnalyticsService {
+	return &AttendanceAnalyticsService{repo: repository.NewAttendanceAnalyticsRepository()}
+}
+
+// scopeCorporations 将数据范围转换为仓储层的军团过滤条件（nil 表示不限制）
+func scopeCorporations(scope *model.DataScope) []int64 {
+	if scope == nil {
+		return nil
+	}
+	if scope.CorporationIDs == nil {
+		return []int64{}
+	}
+	return scope.CorporationIDs
+}
+
+// userSummaryMap 批量查询用户摘要
+func (s *AttendanceAnalyticsService) userSummaryMap(userIDs []uint) (map[uint]repository.UserSummary, error) {
+	list, err := s.repo.ListUserSummaries(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]repository.UserSummary, len(list))
+	for _, u := range list {
+		result[u.UserID] = u
+	}
+	return result, nil
+}
+
+// ─── 滚动出勤 ───
+
+// ParticipationRow 单个分组的滚动出勤
+// 比例 = 参与舰队数 / 窗口内舰队总数
+type ParticipationRow struct {
+	UserID            uint    `json:"user_id,omitempty"`
+	Nickname          string  `json:"nickname,omitempty"`
+	MainCharacterName string  `json:"main_character_name,omitempty"`
+	CorporationID     int64   `json:"corporation_id,omitempty"`
+	ShipGroupID       int64   `json:"ship_group_id,omitempty"`
+	ShipGroupName     string  `json:"ship_group_name,omitempty"`
+	Members30         int     `json:"members_30"` // 出勤用户数（按用户分组时恒为 0/1）
+	Members90         int     `json:"members_90"`
+	Fleets30          int     `json:"fleets_30"`
+	Fleets90          int     `json:"fleets_90"`
+	Pap30             float64 `json:"pap_30"`
+	Pap90             float64 `json:"pap_90"`
+	Rate30            float64 `json:"rate_30"`
+	Rate90            float64 `json:"rate_90"`
+	AlliancePap30     float64 `json:"alliance_pap_30"` // 联盟 PAP（仅按用户分组时统计，按主角色名匹配）
+	AlliancePap90     float64 `json:"alliance_pap_90"`
+}
+
+// ParticipationReport 滚动出勤报表
+type ParticipationReport struct {
+	GroupBy     string             `json:"group_by"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Fleets30    int                `json:"fleets_30"` // 窗口内舰队总数
+	Fleets90    int                `json:"fleets_90"`
+	Rows        []ParticipationRow `json:"rows"`
+}
+
+// participationAcc 分组累加器
+type participationAcc struct {
+	row      ParticipationRow
+	fleets30 map[string]bool
+	fleets90 map[string]bool
+	users30  map[uint]bool
+	users90  map[uint]bool
+}
+
+// GetParticipation 计算滚动 30/90 天出勤
+func (s *AttendanceAnalyticsService) GetParticipation(groupBy, lang string, scope *model.DataScope) (*ParticipationReport, error) {
+	if groupBy == "" {
+		groupBy = AttendanceGroupUser
+	}
+	if groupBy != AttendanceGroupUser && groupBy != AttendanceGroupCorp && groupBy != AttendanceGroupShipGroup {
+		return nil, errors.New("group_by 仅支持 user / corp / ship_group")
+	}
+	now := time.Now()
+	since30 := now.AddDate(0, 0, -attendanceShortWindow)
+	since90 := now.AddDate(0, 0, -attendanceLongWindow)
+
+	rows, err := s.repo.ListAttendance(since90, scopeCorporations(scope))
+	if err != nil {
+		return nil, err
+	}
+	fleets, err := s.repo.ListFleetTurnout(since90, nil)
+	if err != nil {
+		return nil, err
+	}
+	report := &ParticipationReport{GroupBy: groupBy, GeneratedAt: now, Rows: []ParticipationRow{}}
+	for _, f := range fleets {
+		report.Fleets90++
+		if !f.StartAt.Before(since30) {
+			report.Fleets30++
+		}
+	}
+
+	var shipGroups map[int64]int64
+	groupNames := make(map[int64]string)
+	if groupBy == AttendanceGroupShipGroup {
+		typeSet := make(map[int64]bool)
+		for _, r := range rows {
+			if r.ShipTypeID != nil {
+				typeSet[*r.ShipTypeID] = true
+			}
+		}
+		shipGroups, groupNames = attendanceShipGroups(typeSet, lang)
+	}
+
+	accs := make(map[int64]*participationAcc)
+	var order []int64
+	for _, r := range rows {
+		var key int64
+		switch groupBy {
+		case AttendanceGroupUser:
+			key = int64(r.UserID)
+		case AttendanceGroupCorp:
+			key = r.CorporationID
+		case AttendanceGroupShipGroup:
+			if r.ShipTypeID != nil {
+				key = shipGroups[*r.ShipTypeID]
+			}
+		}
+		acc, ok := accs[key]
+		if !ok {
+			acc = &participationAcc{
+				fleets30: make(map[string]bool),
+				fleets90: make(map[string]bool),
+				users30:  make(map[uint]bool),
+				users90:  make(map[uint]bool),
+			}
+			switch groupBy {
+			case AttendanceGroupUser:
+				acc.row.UserID = r.UserID
+				acc.row.CorporationID = r.CorporationID
+			case AttendanceGroupCorp:
+				acc.row.CorporationID = key
+			case AttendanceGroupShipGroup:
+				acc.row.ShipGroupID = key
+				acc.row.ShipGroupName = groupNames[key]
+				if key == 0 {
+					acc.row.ShipGroupName = "未知"
+				}
+			}
+			accs[key] = acc
+			order = append(order, key)
+		}
+		acc.fleets90[r.FleetID] = true
+		acc.users90[r.UserID] = true
+		acc.row.Pap90 += r.PapCount
+		if !r.FleetStartAt.Before(since30) {
+			acc.fleets30[r.FleetID] = true
+			acc.users30[r.UserID] = true
+			acc.row.Pap30 += r.PapCount
+		}
+	}
+
+	for _, key := range order {
+		acc := accs[key]
+		row := acc.row
+		row.Fleets30, row.Fleets90 = len(acc.fleets30), len(acc.fleets90)
+		row.Members30, row.Members90 = len(acc.users30), len(acc.users90)
+		row.Pap30, row.Pap90 = roundTo(row.Pap30, 2), roundTo(row.Pap90, 2)
+		if report.Fleets30 > 0 {
+			row.Rate30 = roundTo(float64(row.Fleets30)/float64(report.Fleets30), 4)
+		}
+		if report.Fleets90 > 0 {
+			row.Rate90 = roundTo(float64(row.Fleets90)/float64(report.Fleets90), 4)
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if groupBy == AttendanceGroupUser && len(report.Rows) > 0 {
+		if err := s.fillUserParticipation(report.Rows, since30, since90); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Pap90 != report.Rows[j].Pap90 {
+			return report.Rows[i].Pap90 > report.Rows[j].Pap90
+		}
+		return report.Rows[i].Pap30 > report.Rows[j].Pap30
+	})
+	return report, nil
+}
+
+// fillUserParticipation 补充用户昵称、主角色与联盟 PAP
+func (s *AttendanceAnalyticsService) fillUserParticipation(rows []ParticipationRow, since30, since90 time.Time) error {
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.UserID)
+	}
+	users, err := s.userSummaryMap(ids)
+	if err != nil {
+		return err
+	}
+	alliance30, err := s.repo.SumAlliancePapByMain(since30)
+	if err != nil {
+		return err
+	}
+	alliance90, err := s.repo.SumAlliancePapByMain(since90)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		u, ok := users[rows[i].UserID]
+		if !ok {
+			continue
+		}
+		rows[i].Nickname = u.Nickname
+		rows[i].MainCharacterName = u.MainCharacterName
+		if u.MainCharacterName != "" {
+			rows[i].AlliancePap30 = roundTo(alliance30[u.MainCharacterName], 2)
+			rows[i].AlliancePap90 = roundTo(alliance90[u.MainCharacterName], 2)
+		}
+	}
+	return nil
+}
+
+// attendanceShipGroups 查询舰船所属分组及分组名称
+func attendanceShipGroups(typeSet map[int64]bool, lang string) (map[int64]int64, map[int64]string) {
+	groups := make(map[int64]int64, len(typeSet))
+	names := make(map[int64]string)
+	if len(typeSet) == 0 {
+		return groups, names
+	}
+	ids := make([]int, 0, len(typeSet))
+	for id := range typeSet {
+		ids = append(ids, int(id))
+	}
+	types, err := repository.NewSdeRepository().GetTypes(ids, nil, lang)
+	if err != nil {
+		global.Logger.Warn("[AttendanceAnalytics] 查询舰船分组失败", zap.Error(err))
+		return groups, names
+	}
+	for _, t := range types {
+		groups[int64(t.TypeID)] = int64(t.GroupID)
+		names[int64(t.GroupID)] = t.GroupName
+	}
+	return groups, names
+}
+
+// ─── 连续出勤 ───
+
+// StreakRow 用户连续出勤周数（按 UTC 自然周，周一为一周开始）
+type StreakRow struct {
+	UserID            uint       `json:"user_id"`
+	Nickname          string     `json:"nickname"`
+	MainCharacterName string     `json:"main_character_name"`
+	CorporationID     int64      `json:"corporation_id"`
+	CurrentWeeks      int        `json:"current_weeks"` // 截至本周（本周尚未出勤时截至上周）的连续出勤周数
+	LongestWeeks      int        `json:"longest_weeks"` // 统计范围内最长连续出勤周数
+	ActiveWeeks       int        `json:"active_weeks"`  // 统计范围内有出勤的周数
+	LastAttendedAt    *time.Time `json:"last_attended_at"`
+}
+
+// weekStart 返回 t 所在 UTC 周的周一零点
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// GetStreaks 计算最近 52 周的连续出勤
+func (s *AttendanceAnalyticsService) GetStreaks(scope *model.DataScope) ([]StreakRow, error) {
+	thisWeek := weekStart(time.Now())
+	since := thisWeek.AddDate(0, 0, -7*(attendanceStreakWeeks-1))
+	rows, err := s.repo.ListAttendance(since, scopeCorporations(scope))
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := make(map[uint]map[time.Time]bool)
+	last := make(map[uint]time.Time)
+	corps := make(map[uint]int64)
+	var ids []uint
+	for _, r := range rows {
+		if _, ok := weeks[r.UserID]; !ok {
+			weeks[r.UserID] = make(map[time.Time]bool)
+			ids = append(ids, r.UserID)
+		}
+		weeks[r.UserID][weekStart(r.FleetStartAt)] = true
+		if r.FleetStartAt.After(last[r.UserID]) {
+			last[r.UserID] = r.FleetStartAt
+		}
+		corps[r.UserID] = r.CorporationID
+	}
+	users, err := s.userSummaryMap(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]StreakRow, 0, len(ids))
+	for _, uid := range ids {
+		set := weeks[uid]
+		row := StreakRow{UserID: uid, CorporationID: corps[uid], ActiveWeeks: len(set)}
+		if u, ok := users[uid]; ok {
+			row.Nickname = u.Nickname
+			row.MainCharacterName = u.MainCharacterName
+		}
+		lastAt := last[uid]
+		row.LastAttendedAt = &lastAt
+
+		w := thisWeek
+		if !set[w] {
+			w = w.AddDate(0, 0, -7)
+		}
+		for set[w] {
+			row.CurrentWeeks++
+			w = w.AddDate(0, 0, -7)
+		}
+		run := 0
+		for w := since; !w.After(thisWeek); w = w.AddDate(0, 0, 7) {
+			if set[w] {
+				run++
+				if run > row.LongestWeeks {
+					row.LongestWeeks = run
+				}
+			} else {
+				run = 0
+			}
+		}
+		result = append(result, row)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].CurrentWeeks != result[j].CurrentWeeks {
+			return result[i].CurrentWeeks > result[j].CurrentWeeks
+		}
+		return result[i].LongestWeeks > result[j].LongestWeeks
+	})
+	return result, nil
+}
+
+// ─── 仍持有职权的不活跃成员 ───
+
+// InactiveRoleHolder 超过指定天数未出勤但仍持有职权的用户
+type InactiveRoleHolder struct {
+	UserID            uint       `json:"user_id"`
+	Nickname          string     `json:"nickname"`
+	MainCharacterName string     `json:"main_character_name"`
+	CorporationID     int64      `json:"corporation_id"`
+	Roles             []string   `json:"roles"`
+	LastAttendedAt    *time.Time `json:"last_attended_at"` // 从未出勤时为空
+	DaysSinceAttended int        `json:"days_since_attended"`
+	LastLoginAt       *time.Time `json:"last_login_at"`
+}
+
+// ListInactiveRoleHolders 列出持有 user / guest 以外角色、且 days 天内没有出勤的用户
+func (s *AttendanceAnalyticsService) ListInactiveRoleHolders(days int, scope *model.DataScope) ([]InactiveRoleHolder, error) {
+	if days <= 0 {
+		days = attendanceShortWindow
+	}
+	if days > maxAttendanceDays {
+		return nil, errors.New("days 不能超过 365")
+	}
+	holders, err := s.repo.ListRoleHolders(attendanceExcludedRoles)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(holders))
+	for uid := range holders {
+		ids = append(ids, uid)
+	}
+	users, err := s.userSummaryMap(ids)
+	if err != nil {
+		return nil, err
+	}
+	lastMap, err := s.repo.LastAttendanceByUser(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+	result := make([]InactiveRoleHolder, 0)
+	for _, uid := range ids {
+		u, ok := users[uid]
+		if !ok || !scope.ContainsCorporation(u.CorporationID) {
+			continue
+		}
+		row := InactiveRoleHolder{
+			UserID:            uid,
+			Nickname:          u.Nickname,
+			MainCharacterName: u.MainCharacterName,
+			CorporationID:     u.CorporationID,
+			Roles:             holders[uid],
+			DaysSinceAttended: -1,
+			LastLoginAt:       u.LastLoginAt,
+		}
+		if lastAt, ok := lastMap[uid]; ok {
+			if lastAt.After(cutoff) {
+				continue
+			}
+			row.LastAttendedAt = &lastAt
+			row.DaysSinceAttended = int(now.Sub(lastAt).Hours() / 24)
+		}
+		sort.Strings(row.Roles)
+		result = append(result, row)
+	}
+	// 从未出勤的排在最前，其余按未出勤天数倒序
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].DaysSinceAttended, result[j].DaysSinceAttended
+		if a < 0 || b < 0 {
+			return a < 0 && b >= 0
+		}
+		return a > b
+	})
+	return result, nil
+}
+
+// ─── FC 排行 ───
+
+// FCLeaderboardRow 单个 FC 的带队统计
+type FCLeaderboardRow struct {
+	UserID            uint      `json:"user_id"`
+	Nickname          string    `json:"nickname"`
+	MainCharacterName string    `json:"main_character_name"`
+	Fleets            int       `json:"fleets"`
+	TotalPilots       int       `json:"total_pilots"` // 各舰队获得 PAP 的角色数之和
+	AvgPilots         float64   `json:"avg_pilots"`   // 平均出勤人数
+	MaxPilots         int       `json:"max_pilots"`
+	TotalPap          float64   `json:"total_pap"`
+	LastFleetAt       time.Time `json:"last_fleet_at"`
+}
+
+// GetFCLeaderboard 统计 days 天内各 FC 的带队次数与平均出勤人数
+func (s *AttendanceAnalyticsService) GetFCLeaderboard(days int, scope *model.DataScope) ([]FCLeaderboardRow, error) {
+	if days <= 0 {
+		days = attendanceLongWindow
+	}
+	if days > maxAttendanceDays {
+		return nil, errors.New("days 不能超过 365")
+	}
+	fleets, err := s.repo.ListFleetTurnout(time.Now().AddDate(0, 0, -days), scopeCorporations(scope))
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make(map[uint]int)
+	result := make([]FCLeaderboardRow, 0)
+	for _, f := range fleets {
+		i, ok := idx[f.FCUserID]
+		if !ok {
+			i = len(result)
+			idx[f.FCUserID] = i
+			result = append(result, FCLeaderboardRow{UserID: f.FCUserID, MainCharacterName: f.FCCharacterName})
+		}
+		row := &result[i]
+		row.Fleets++
+		row.TotalPilots += f.Pilots
+		row.TotalPap += f.TotalPap
+		if f.Pilots > row.MaxPilots {
+			row.MaxPilots = f.Pilots
+		}
+		if f.StartAt.After(row.LastFleetAt) {
+			row.LastFleetAt = f.StartAt
+		}
+	}
+
+	ids := make([]uint, 0, len(result))
+	for _, row := range result {
+		ids = append(ids, row.UserID)
+	}
+	users, err := s.userSummaryMap(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		row := &result[i]
+		if u, ok := users[row.UserID]; ok {
+			row.Nickname = u.Nickname
+			if u.MainCharacterName != "" {
+				row.MainCharacterName = u.MainCharacterName
+			}
+		}
+		row.AvgPilots = roundTo(float64(row.TotalPilots)/float64(row.Fleets), 2)
+		row.TotalPap = roundTo(row.TotalPap, 2)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Fleets != result[j].Fleets {
+			return result[i].Fleets > result[j].Fleets
+		}
+		return result[i].AvgPilots > result[j].AvgPilots
+	})
+	return result, nil
+}
+
+// ─── 开始时间热力图 ───
+
+// FleetHeatmap 舰队开始时间热力图，下标为 [星期][小时]，星期 0=周日 … 6=周六
+type FleetHeatmap struct {
+	Timezone  string         `json:"timezone"`
+	Days      int            `json:"days"`
+	Total     int            `json:"total"`
+	Fleets    [7][24]int     `json:"fleets"`     // 舰队数
+	Pilots    [7][24]int     `json:"pilots"`     // 获得 PAP 的角色数之和
+	AvgPilots [7][24]float64 `json:"avg_pilots"` // 平均出勤人数
+}
+
+// GetFleetHeatmap 按时区统计 days 天内舰队开始时间分布；tz 为空时使用 EVE 时间（UTC）
+func (s *AttendanceAnalyticsService) GetFleetHeatmap(days int, tz string, scope *model.DataScope) (*FleetHeatmap, error) {
+	if days <= 0 {
+		days = attendanceLongWindow
+	}
+	if days > maxAttendanceDays {
+		return nil, errors.New("days 不能超过 365")
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.New("无效的时区")
+	}
+	fleets, err := s.repo.ListFleetTurnout(time.Now().AddDate(0, 0, -days), scopeCorporations(scope))
+	if err != nil {
+		return nil, err
+	}
+	heatmap := &FleetHeatmap{Timezone: tz, Days: days, Total: len(fleets)}
+	for _, f := range fleets {
+		t := f.StartAt.In(loc)
+		heatmap.Fleets[t.Weekday()][t.Hour()]++
+		heatmap.Pilots[t.Weekday()][t.Hour()] += f.Pilots
+	}
+	for d := 0; d < 7; d++ {
+		for h := 0; h < 24; h++ {
+			if n := heatmap.Fleets[d][h]; n > 0 {
+				heatmap.AvgPilots[d][h] = roundTo(float64(heatmap.Pilots[d][h])/float64(n), 2)
+			}
+		}
+	}
+	return heatmap, nil
+}
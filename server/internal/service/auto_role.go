@@ -17,26 +17,30 @@ import (
 
 // AutoRoleService ESI / SeAT 自动权限映射服务
 type AutoRoleService struct {
-	autoRoleRepo *repository.AutoRoleRepository
-	allowRepo    *repository.AllowedEntityRepository
-	roleRepo     *repository.RoleRepository
-	charRepo     *repository.EveCharacterRepository
-	userRepo     *repository.UserRepository
-	seatUserRepo *repository.SeatUserRepository
-	configRepo   *repository.SysConfigRepository
-	roleSvc      *RoleService
+	autoRoleRepo  *repository.AutoRoleRepository
+	allowRepo     *repository.AllowedEntityRepository
+	roleRepo      *repository.RoleRepository
+	charRepo      *repository.EveCharacterRepository
+	userRepo      *repository.UserRepository
+	seatUserRepo  *repository.SeatUserRepository
+	configRepo    *repository.SysConfigRepository
+	skillPlanRepo *repository.SkillPlanRepository
+	fleetRepo     *repository.FleetRepository
+	roleSvc       *RoleService
 }
 
 func NewAutoRoleService() *AutoRoleService {
 	return &AutoRoleService{
-		autoRoleRepo: repository.NewAutoRoleRepository(),
-		allowRepo:    repository.NewAllowedEntityRepository(),
-		roleRepo:     repository.NewRoleRepository(),
-		charRepo:     repository.NewEveCharacterRepository(),
-		userRepo:     repository.NewUserRepository(),
-		seatUserRepo: repository.NewSeatUserRepository(),
-		configRepo:   repository.NewSysConfigRepository(),
-		roleSvc:      NewRoleService(),
+		autoRoleRepo:  repository.NewAutoRoleRepository(),
+		allowRepo:     repository.NewAllowedEntityRepository(),
+		roleRepo:      repository.NewRoleRepository(),
+		charRepo:      repository.NewEveCharacterRepository(),
+		userRepo:      repository.NewUserRepository(),
+		seatUserRepo:  repository.NewSeatUserRepository(),
+		configRepo:    repository.NewSysConfigRepository(),
+		skillPlanRepo: repository.NewSkillPlanRepository(),
+		fleetRepo:     repository.NewFleetRepository(),
+		roleSvc:       NewRoleService(),
 	}
 }
 
@@ -117,6 +121,9 @@ func (s *AutoRoleService) ListCorpTitles() ([]repository.CorpTitleInfo, error) {
 
 // ListAllowedEntities 获取指定名单的所有实体
 func (s *AutoRoleService) ListAllowedEntities(listType string) ([]model.AllowedEntity, error) {
+	if listType != model.AllowListAutoRole && listType != model.AllowListBasicAccess {
+		return nil, errors.New("无效的名单类型")
+	}
 	return s.allowRepo.List(listType)
 }
 
@@ -137,9 +144,21 @@ func (s *AutoRoleService) AddAllowedEntity(e *model.AllowedEntity) error {
 	return s.allowRepo.Add(e)
 }
 
-// RemoveAllowedEntity 从名单中删除实体
-func (s *AutoRoleService) RemoveAllowedEntity(id uint) error {
-	return s.allowRepo.Remove(id)
+// RemoveAllowedEntity 从名单中删除实体（仅限准入名单，敌对观察名单由成员管理接口维护）
+func (s *AutoRoleService) RemoveAllowedEntity(listType string, id uint) error {
+	if listType != model.AllowListAutoRole && listType != model.AllowListBasicAccess {
+		return errors.New("无效的名单类型")
+	}
+	entities, err := s.allowRepo.List(listType)
+	if err != nil {
+		return err
+	}
+	for _, e := range entities {
+		if e.ID == id {
+			return s.allowRepo.Remove(id)
+		}
+	}
+	return errors.New("名单条目不存在")
 }
 
 // ─── 准入名单"仅主角色"配置 ───
@@ -304,31 +323,96 @@ func (s *AutoRoleService) GetAllSeatRoles() ([]string, error) {
 
 // ─── 自动权限同步 ───
 
-// SyncUserAutoRoles 根据 ESI 军团角色 + 头衔 + SeAT 分组，自动同步用户的系统权限
+// autoRolePlan 单个用户的自动角色变更计划
+type autoRolePlan struct {
+	userID       uint
+	username     string
+	toAdd        []uint
+	toRemove     []uint
+	matchedRules map[int]bool // 条件成立的规则（rules 下标）
+	failedRules  map[int]bool // 求值失败、本次跳过的规则（rules 下标）
+}
+
+// SyncUserAutoRoles 根据 ESI 军团角色 + 头衔 + SeAT 分组 + 自动权限规则，自动同步用户的系统权限
 // 规则：
 //   - Director 始终对应 admin 角色
 //   - 根据 esi_role_mapping 表的配置，将 ESI 角色映射到系统角色
 //   - 根据 esi_title_mapping 表的配置，将 ESI 头衔映射到系统角色
 //   - 根据 seat_role_mapping 表的配置，将 SeAT 分组映射到系统角色
+//   - 根据已启用的 auto_role_rule 条件树，授予规则对应的系统角色
 //   - super_admin 不受影响
 //   - 保留用户手动分配的角色，仅补充自动映射的角色
 func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) error {
+	rules, err := s.autoRoleRepo.ListEnabledAutoRoleRules()
+	if err != nil {
+		return err
+	}
+	plan, err := s.planUserAutoRoles(userID, rules, newAutoRoleRuleEvaluator(s))
+	if err != nil || plan == nil {
+		return err
+	}
+	s.applyAutoRolePlan(ctx, plan)
+	return nil
+}
+
+// applyAutoRolePlan 执行自动角色变更计划
+func (s *AutoRoleService) applyAutoRolePlan(ctx context.Context, plan *autoRolePlan) {
+	userID, username, toAdd, toRemove := plan.userID, plan.username, plan.toAdd, plan.toRemove
+	changed := false
+
+	for _, rid := range toRemove {
+		if err := s.roleRepo.RemoveUserRole(userID, rid); err != nil {
+			global.Logger.Warn("[AutoRole] 移除过期自动角色失败",
+				zap.Uint("user_id", userID),
+				zap.Uint("role_id", rid),
+				zap.Error(err))
+		} else {
+			changed = true
+			s.writeLog(userID, username, rid, "remove")
+		}
+	}
+
+	for _, rid := range toAdd {
+		if err := s.roleRepo.AddAutoUserRole(userID, rid); err != nil {
+			global.Logger.Warn("[AutoRole] 添加自动角色失败",
+				zap.Uint("user_id", userID),
+				zap.Uint("role_id", rid),
+				zap.Error(err))
+		} else {
+			changed = true
+			s.writeLog(userID, username, rid, "add")
+		}
+	}
+
+	if changed {
+		s.roleSvc.InvalidateUserCache(ctx, userID)
+		s.roleSvc.SyncUserPrimaryRole(userID)
+		global.Logger.Info("[AutoRole] 用户自动角色已更新",
+			zap.Uint("user_id", userID),
+			zap.Int("added", len(toAdd)),
+			zap.Int("removed", len(toRemove)))
+	}
+}
+
+// planUserAutoRoles 计算用户应新增 / 移除的自动角色（不写入数据）
+// 返回 nil 表示该用户不参与自动权限（super_admin、无角色、主角色未通过准入）
+func (s *AutoRoleService) planUserAutoRoles(userID uint, rules []model.AutoRoleRule, ev *autoRoleRuleEvaluator) (*autoRolePlan, error) {
 	// admin / super_admin 不受自动权限影响
 	currentCodes, err := s.roleRepo.GetUserRoleCodes(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if model.ContainsAnyRole(currentCodes, model.RoleSuperAdmin) {
-		return nil
+		return nil, nil
 	}
 
 	// 获取用户绑定的所有角色
 	chars, err := s.charRepo.ListByUserID(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(chars) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// 获取用户主角色 ID 和昵称（primaryCharID 供 only_main_char 映射使用，username 供日志冗余）
@@ -374,7 +458,7 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 			break
 		}
 		if !primaryQualified {
-			return nil
+			return nil, nil
 		}
 		// 主角色通过准入，后续不再逐角色过滤
 		allowFiltered = false
@@ -383,9 +467,11 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 	// 收集所有角色的 ESI 军团角色（仅限允许军团/联盟）
 	// allEsiRoles: 所有允许角色的军团职位集合
 	// primaryEsiRoles: 仅主角色（primary_character_id）的军团职位集合，供 only_main_char 映射使用
+	// qualifiedChars: 通过准入过滤的角色，供自动权限规则求值
 	allEsiRoles := make(map[string]struct{})
 	primaryEsiRoles := make(map[string]struct{})
 	hasDirector := false
+	qualifiedChars := make([]model.EveCharacter, 0, len(chars))
 
 	for _, char := range chars {
 		// 跳过不在允许名单中的角色
@@ -403,6 +489,7 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 				continue
 			}
 		}
+		qualifiedChars = append(qualifiedChars, char)
 
 		corpRoles, err := s.autoRoleRepo.ListCharacterCorpRoles(char.CharacterID)
 		if err != nil {
@@ -516,10 +603,32 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 		}
 	}
 
+	// 自动权限规则：单条规则求值失败时仅跳过该规则（不新增、也不移除其角色），不影响其他映射
+	matchedRules := make(map[int]bool)
+	failedRules := make(map[int]bool)
+	keepRoleIDs := make(map[uint]struct{})
+	if len(rules) > 0 && len(qualifiedChars) > 0 {
+		subject := newAutoRoleSubject(userID, primaryCharID, qualifiedChars)
+		for i := range rules {
+			ok, err := ev.evaluate(subject, &rules[i].Condition)
+			if err != nil {
+				failedRules[i] = true
+				keepRoleIDs[rules[i].RoleID] = struct{}{}
+				global.Logger.Warn("[AutoRole] 自动权限规则求值失败，本次跳过",
+					zap.Uint("user_id", userID), zap.Uint("rule_id", rules[i].ID), zap.Error(err))
+				continue
+			}
+			if ok {
+				matchedRules[i] = true
+				autoRoleIDs[rules[i].RoleID] = struct{}{}
+			}
+		}
+	}
+
 	// 获取用户所有当前角色 ID（用于判断是否需要新增）
 	currentRoleIDs, err := s.roleRepo.GetUserRoleIDs(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	existingSet := make(map[uint]struct{}, len(currentRoleIDs))
 	for _, id := range currentRoleIDs {
@@ -529,7 +638,7 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 	// 获取用户当前由自动系统分配的角色 ID（用于判断是否需要移除）
 	currentAutoRoleIDs, err := s.roleRepo.GetUserAutoRoleIDs(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	currentAutoSet := make(map[uint]struct{}, len(currentAutoRoleIDs))
 	for _, id := range currentAutoRoleIDs {
@@ -547,92 +656,48 @@ func (s *AutoRoleService) SyncUserAutoRoles(ctx context.Context, userID uint) er
 	// 计算需要移除的角色（自动分配但不再符合条件的）
 	var toRemove []uint
 	for rid := range currentAutoSet {
-		if _, shouldHave := autoRoleIDs[rid]; !shouldHave {
-			toRemove = append(toRemove, rid)
-		}
-	}
-
-	changed := false
-
-	for _, rid := range toRemove {
-		if err := s.roleRepo.RemoveUserRole(userID, rid); err != nil {
-			global.Logger.Warn("[AutoRole] 移除过期自动角色失败",
-				zap.Uint("user_id", userID),
-				zap.Uint("role_id", rid),
-				zap.Error(err))
-		} else {
-			changed = true
-			s.writeLog(userID, username, rid, "remove")
+		if _, shouldHave := autoRoleIDs[rid]; shouldHave {
+			continue
 		}
-	}
-
-	for _, rid := range toAdd {
-		if err := s.roleRepo.AddAutoUserRole(userID, rid); err != nil {
-			global.Logger.Warn("[AutoRole] 添加自动角色失败",
-				zap.Uint("user_id", userID),
-				zap.Uint("role_id", rid),
-				zap.Error(err))
-		} else {
-			changed = true
-			s.writeLog(userID, username, rid, "add")
+		if _, keep := keepRoleIDs[rid]; keep {
+			continue
 		}
+		toRemove = append(toRemove, rid)
 	}
 
-	if changed {
-		s.roleSvc.InvalidateUserCache(ctx, userID)
-		s.roleSvc.SyncUserPrimaryRole(userID)
-		global.Logger.Info("[AutoRole] 用户自动角色已更新",
-			zap.Uint("user_id", userID),
-			zap.Int("added", len(toAdd)),
-			zap.Int("removed", len(toRemove)))
-	}
-
-	return nil
+	return &autoRolePlan{userID: userID, username: username, toAdd: toAdd, toRemove: toRemove, matchedRules: matchedRules, failedRules: failedRules}, nil
 }
 
 // SyncAllUsersAutoRoles 同步所有用户的自动权限（供定时任务调用）
-func (s *AutoRoleService) SyncAllUsersAutoRoles(ctx context.Context) {
-	userRepo := repository.NewUserRepository()
-	ids, err := userRepo.ListAllIDs()
+// 先为全部用户计算变更，force 为 false 且移除比例超过阈值时整轮中止并告警
+func (s *AutoRoleService) SyncAllUsersAutoRoles(ctx context.Context, force bool) (*RoleSyncPreview, error) {
+	preview, plans, err := s.planAllAutoRoles(ctx)
 	if err != nil {
-		global.Logger.Error("[AutoRole] 查询用户 ID 列表失败", zap.Error(err))
-		return
+		global.Logger.Error("[AutoRole] 计算自动权限失败", zap.Error(err))
+		return nil, err
+	}
+	if preview.Blocked && !force {
+		alertRoleSyncGuard(ctx, preview)
+		return preview, ErrRoleSyncGuardTripped
 	}
 
-	global.Logger.Info("[AutoRole] 开始自动权限同步", zap.Int("users", len(ids)))
-	for _, uid := range ids {
-		if err := s.SyncUserAutoRoles(ctx, uid); err != nil {
-			global.Logger.Warn("[AutoRole] 同步失败",
-				zap.Uint("user_id", uid),
-				zap.Error(err))
-		}
+	global.Logger.Info("[AutoRole] 开始自动权限同步",
+		zap.Int("users", preview.TotalUsers), zap.Int("changed", preview.ChangedUsers))
+	for _, plan := range plans {
+		s.applyAutoRolePlan(ctx, plan)
 	}
 	global.Logger.Info("[AutoRole] 自动权限同步完成")
+	return preview, nil
 }
 
 // SyncAllUsersBasicAccess 同步所有用户的基础准入权限（basic_access 名单）
 // 与 roleCheckTask 逻辑相同，供手动触发调用
-func (s *AutoRoleService) SyncAllUsersBasicAccess(ctx context.Context) {
-	nonEmpty, _ := s.allowRepo.IsNonEmpty(model.AllowListBasicAccess)
-	if !nonEmpty {
-		return
-	}
-
-	ids, err := s.userRepo.ListAllIDs()
-	if err != nil {
-		global.Logger.Error("[AutoRole] 查询用户 ID 列表失败（basic_access 同步）", zap.Error(err))
-		return
-	}
-
-	global.Logger.Info("[AutoRole] 开始基础准入同步", zap.Int("users", len(ids)))
-	for _, uid := range ids {
-		if err := s.roleSvc.CheckCorpAccessAndAdjustRole(ctx, uid); err != nil {
-			global.Logger.Warn("[AutoRole] basic_access 同步失败",
-				zap.Uint("user_id", uid),
-				zap.Error(err))
-		}
+func (s *AutoRoleService) SyncAllUsersBasicAccess(ctx context.Context, force bool) (*RoleSyncPreview, error) {
+	preview, err := s.roleSvc.SyncAllBasicAccess(ctx, force)
+	if err != nil && !errors.Is(err, ErrRoleSyncGuardTripped) {
+		global.Logger.Error("[AutoRole] 基础准入同步失败", zap.Error(err))
 	}
-	global.Logger.Info("[AutoRole] 基础准入同步完成")
+	return preview, err
 }
 
 // ─── 内部辅助 ───
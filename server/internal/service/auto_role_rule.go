@@ -0,0 +1,548 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  自动权限规则
+//  规则由 and / or 条件树组成，叶子条件覆盖军团、联盟、头衔、ESI 角色、SeAT 分组、
+//  技能规划、近期 PAP 与角色年龄；同步时与单一映射的结果取并集
+// ─────────────────────────────────────────────
+
+const (
+	maxAutoRoleConditionDepth = 5
+	maxAutoRoleConditionNodes = 50
+	defaultAutoRolePapDays    = 30
+	maxAutoRolePapDays        = 365
+)
+
+// AutoRoleRuleRequest 创建 / 更新自动权限规则参数
+type AutoRoleRuleRequest struct {
+	Name        string                  `json:"name"        binding:"required,max=128"`
+	Description string                  `json:"description" binding:"max=512"`
+	RoleID      uint                    `json:"role_id"     binding:"required"`
+	Enabled     bool                    `json:"enabled"`
+	Condition   model.AutoRoleCondition `json:"condition"`
+}
+
+// AutoRoleRuleDryRunRequest 规则预览参数；ID 非 0 时表示预览编辑已有规则后的结果
+type AutoRoleRuleDryRunRequest struct {
+	ID uint `json:"id"`
+	AutoRoleRuleRequest
+}
+
+// AutoRoleRuleChange 预览结果中的单条角色变更
+type AutoRoleRuleChange struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	RoleID   uint   `json:"role_id"`
+	RoleCode string `json:"role_code"`
+	RoleName string `json:"role_name"`
+	Action   string `json:"action"` // "add" | "remove"
+}
+
+// AutoRoleRuleDryRunResult 规则预览结果
+type AutoRoleRuleDryRunResult struct {
+	TotalUsers   int                  `json:"total_users"`
+	MatchedUsers int                  `json:"matched_users"` // 满足规则条件的用户数
+	FailedUsers  int                  `json:"failed_users"`  // 求值失败（已跳过，含该规则求值失败）的用户数
+	Changes      []AutoRoleRuleChange `json:"changes"`
+}
+
+// ─── 规则 CRUD ───
+
+// ListAutoRoleRules 获取所有自动权限规则（带角色信息）
+func (s *AutoRoleService) ListAutoRoleRules() ([]model.AutoRoleRule, error) {
+	rules, err := s.autoRoleRepo.ListAutoRoleRules()
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range rules {
+		if role, err := s.roleRepo.GetByID(r.RoleID); err == nil {
+			rules[i].RoleCode = role.Code
+			rules[i].RoleName = role.Name
+		}
+	}
+	return rules, nil
+}
+
+// CreateAutoRoleRule 创建自动权限规则
+func (s *AutoRoleService) CreateAutoRoleRule(operatorID uint, req *AutoRoleRuleRequest) (*model.AutoRoleRule, error) {
+	rule := &model.AutoRoleRule{CreatedBy: operatorID}
+	if err := s.applyAutoRoleRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.autoRoleRepo.CreateAutoRoleRule(rule); err != nil {
+		return nil, err
+	}
+	global.Logger.Info("[AutoRole] 创建自动权限规则",
+		zap.Uint("rule_id", rule.ID), zap.Uint("role_id", rule.RoleID), zap.Uint("operator", operatorID))
+	return rule, nil
+}
+
+// UpdateAutoRoleRule 更新自动权限规则
+func (s *AutoRoleService) UpdateAutoRoleRule(id uint, req *AutoRoleRuleRequest) (*model.AutoRoleRule, error) {
+	rule, err := s.autoRoleRepo.GetAutoRoleRule(id)
+	if err != nil {
+		return nil, errors.New("规则不存在")
+	}
+	if err := s.applyAutoRoleRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.autoRoleRepo.UpdateAutoRoleRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteAutoRoleRule 删除自动权限规则（已授予的角色在下次同步时回收）
+func (s *AutoRoleService) DeleteAutoRoleRule(id uint) error {
+	return s.autoRoleRepo.DeleteAutoRoleRule(id)
+}
+
+// applyAutoRoleRuleRequest 校验参数并写入规则
+func (s *AutoRoleService) applyAutoRoleRuleRequest(rule *model.AutoRoleRule, req *AutoRoleRuleRequest) error {
+	role, err := s.roleRepo.GetByID(req.RoleID)
+	if err != nil {
+		return errors.New("系统角色不存在")
+	}
+	if role.Code == model.RoleSuperAdmin {
+		return errors.New("不可映射到超级管理员")
+	}
+	nodes := 0
+	if err := s.validateAutoRoleCondition(&req.Condition, 1, &nodes); err != nil {
+		return err
+	}
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.RoleID = req.RoleID
+	rule.Enabled = req.Enabled
+	rule.Condition = req.Condition
+	rule.RoleCode = role.Code
+	rule.RoleName = role.Name
+	return nil
+}
+
+// validateAutoRoleCondition 校验条件树：类型合法、参数完整、深度与节点数不超限
+func (s *AutoRoleService) validateAutoRoleCondition(c *model.AutoRoleCondition, depth int, nodes *int) error {
+	if depth > maxAutoRoleConditionDepth {
+		return fmt.Errorf("条件嵌套不能超过 %d 层", maxAutoRoleConditionDepth)
+	}
+	*nodes++
+	if *nodes > maxAutoRoleConditionNodes {
+		return fmt.Errorf("条件数量不能超过 %d 个", maxAutoRoleConditionNodes)
+	}
+
+	switch c.Type {
+	case model.AutoRoleCondAnd, model.AutoRoleCondOr:
+		if len(c.Conditions) == 0 {
+			return errors.New("组合条件至少需要一个子条件")
+		}
+		for i := range c.Conditions {
+			if err := s.validateAutoRoleCondition(&c.Conditions[i], depth+1, nodes); err != nil {
+				return err
+			}
+		}
+	case model.AutoRoleCondCorporation, model.AutoRoleCondAlliance:
+		if len(c.IDs) == 0 {
+			return errors.New("军团 / 联盟条件至少需要一个 ID")
+		}
+	case model.AutoRoleCondTitle:
+		if c.CorporationID <= 0 || c.TitleID <= 0 {
+			return errors.New("头衔条件需要军团 ID 与头衔 ID")
+		}
+	case model.AutoRoleCondEsiRole:
+		if !isValidEsiRole(c.Name) {
+			return errors.New("无效的 ESI 军团角色名")
+		}
+	case model.AutoRoleCondSeatGroup:
+		if c.Name == "" {
+			return errors.New("SeAT 分组条件需要分组名")
+		}
+	case model.AutoRoleCondSkillPlan:
+		if _, err := s.skillPlanRepo.GetByID(c.SkillPlanID); err != nil {
+			return errors.New("技能规划不存在")
+		}
+	case model.AutoRoleCondPap:
+		if c.Min <= 0 {
+			return errors.New("PAP 条件的下限必须大于 0")
+		}
+		if c.Days < 0 || c.Days > maxAutoRolePapDays {
+			return fmt.Errorf("PAP 统计天数须在 1-%d 之间", maxAutoRolePapDays)
+		}
+	case model.AutoRoleCondCharacterAge:
+		if c.Min <= 0 {
+			return errors.New("角色年龄条件的天数必须大于 0")
+		}
+	default:
+		return fmt.Errorf("未知的条件类型: %q", c.Type)
+	}
+	return nil
+}
+
+// ─── 规则预览 ───
+
+// DryRunAutoRoleRule 预览规则保存后的效果：对全部用户按"保存后"的规则集计算自动角色，
+// 仅返回该规则涉及的系统角色（编辑时含原角色）上的新增 / 移除，不写入任何数据
+func (s *AutoRoleService) DryRunAutoRoleRule(ctx context.Context, req *AutoRoleRuleDryRunRequest) (*AutoRoleRuleDryRunResult, error) {
+	candidate := model.AutoRoleRule{}
+	candidate.ID = req.ID
+	if err := s.applyAutoRoleRuleRequest(&candidate, &req.AutoRoleRuleRequest); err != nil {
+		return nil, err
+	}
+
+	watchRoles := map[uint]struct{}{candidate.RoleID: {}}
+	if req.ID != 0 {
+		old, err := s.autoRoleRepo.GetAutoRoleRule(req.ID)
+		if err != nil {
+			return nil, errors.New("规则不存在")
+		}
+		watchRoles[old.RoleID] = struct{}{}
+	}
+
+	enabled, err := s.autoRoleRepo.ListEnabledAutoRoleRules()
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]model.AutoRoleRule, 0, len(enabled)+1)
+	for _, r := range enabled {
+		if r.ID != req.ID || req.ID == 0 {
+			rules = append(rules, r)
+		}
+	}
+	candidateIdx := -1
+	if candidate.Enabled {
+		candidateIdx = len(rules)
+		rules = append(rules, candidate)
+	}
+
+	ids, err := s.userRepo.ListAllIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AutoRoleRuleDryRunResult{TotalUsers: len(ids), Changes: []AutoRoleRuleChange{}}
+	roleInfo := make(map[uint]*model.Role)
+	ev := newAutoRoleRuleEvaluator(s)
+	for _, uid := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		plan, err := s.planUserAutoRoles(uid, rules, ev)
+		if err != nil {
+			result.FailedUsers++
+			global.Logger.Warn("[AutoRole] 规则预览求值失败", zap.Uint("user_id", uid), zap.Error(err))
+			continue
+		}
+		if plan == nil {
+			continue
+		}
+		if candidateIdx >= 0 && plan.matchedRules[candidateIdx] {
+			result.MatchedUsers++
+		}
+		if candidateIdx >= 0 && plan.failedRules[candidateIdx] {
+			result.FailedUsers++
+		}
+		appendChange := func(rid uint, action string) {
+			if _, ok := watchRoles[rid]; !ok {
+				return
+			}
+			role, ok := roleInfo[rid]
+			if !ok {
+				role, _ = s.roleRepo.GetByID(rid)
+				roleInfo[rid] = role
+			}
+			change := AutoRoleRuleChange{UserID: uid, Username: plan.username, RoleID: rid, Action: action}
+			if role != nil {
+				change.RoleCode = role.Code
+				change.RoleName = role.Name
+			}
+			result.Changes = append(result.Changes, change)
+		}
+		for _, rid := range plan.toAdd {
+			appendChange(rid, "add")
+		}
+		for _, rid := range plan.toRemove {
+			appendChange(rid, "remove")
+		}
+	}
+	return result, nil
+}
+
+// ─── 条件求值 ───
+
+// autoRoleRuleEvaluator 规则求值器：缓存跨用户共享的数据（技能规划条目），单次同步 / 预览内复用
+type autoRoleRuleEvaluator struct {
+	s         *AutoRoleService
+	now       time.Time
+	planItems map[uint][]model.SkillPlanItem
+}
+
+func newAutoRoleRuleEvaluator(s *AutoRoleService) *autoRoleRuleEvaluator {
+	return &autoRoleRuleEvaluator{
+		s:         s,
+		now:       time.Now(),
+		planItems: make(map[uint][]model.SkillPlanItem),
+	}
+}
+
+// autoRoleSubject 单个用户的求值上下文，各项数据按需加载
+type autoRoleSubject struct {
+	userID        uint
+	primaryCharID int64
+	chars         []model.EveCharacter
+
+	corpRoles  map[int64][]string
+	titles     map[int64][]model.EveCharacterTitle
+	seatGroups []string
+	seatLoaded bool
+	pap        map[int]float64
+	skills     map[int64]map[int]int
+}
+
+func newAutoRoleSubject(userID uint, primaryCharID int64, chars []model.EveCharacter) *autoRoleSubject {
+	return &autoRoleSubject{
+		userID:        userID,
+		primaryCharID: primaryCharID,
+		chars:         chars,
+		corpRoles:     make(map[int64][]string),
+		titles:        make(map[int64][]model.EveCharacterTitle),
+		pap:           make(map[int]float64),
+	}
+}
+
+// evaluate 对条件树求值
+func (e *autoRoleRuleEvaluator) evaluate(sub *autoRoleSubject, c *model.AutoRoleCondition) (bool, error) {
+	ok, err := e.evaluateNode(sub, c)
+	if err != nil {
+		return false, err
+	}
+	return ok != c.Negate, nil
+}
+
+func (e *autoRoleRuleEvaluator) evaluateNode(sub *autoRoleSubject, c *model.AutoRoleCondition) (bool, error) {
+	switch c.Type {
+	case model.AutoRoleCondAnd:
+		for i := range c.Conditions {
+			ok, err := e.evaluate(sub, &c.Conditions[i])
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case model.AutoRoleCondOr:
+		for i := range c.Conditions {
+			ok, err := e.evaluate(sub, &c.Conditions[i])
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case model.AutoRoleCondCorporation:
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			return containsInt64(c.IDs, ch.CorporationID), nil
+		})
+	case model.AutoRoleCondAlliance:
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			return ch.AllianceID != nil && containsInt64(c.IDs, *ch.AllianceID), nil
+		})
+	case model.AutoRoleCondTitle:
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			if ch.CorporationID != c.CorporationID {
+				return false, nil
+			}
+			titles, err := e.characterTitles(sub, ch.CharacterID)
+			if err != nil {
+				return false, err
+			}
+			for _, t := range titles {
+				if t.TitleID == c.TitleID {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+	case model.AutoRoleCondEsiRole:
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			roles, err := e.characterCorpRoles(sub, ch.CharacterID)
+			if err != nil {
+				return false, err
+			}
+			for _, r := range roles {
+				if r == c.Name {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+	case model.AutoRoleCondSeatGroup:
+		groups, err := e.seatGroups(sub)
+		if err != nil {
+			return false, err
+		}
+		for _, g := range groups {
+			if g == c.Name {
+				return true, nil
+			}
+		}
+		return false, nil
+	case model.AutoRoleCondSkillPlan:
+		items, err := e.skillPlanItems(c.SkillPlanID)
+		if err != nil || len(items) == 0 {
+			return false, err
+		}
+		skills, err := e.characterSkills(sub)
+		if err != nil {
+			return false, err
+		}
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			levels := skills[ch.CharacterID]
+			for _, item := range items {
+				if levels[item.SkillTypeID] < item.RequiredLevel {
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+	case model.AutoRoleCondPap:
+		days := c.Days
+		if days <= 0 {
+			days = defaultAutoRolePapDays
+		}
+		total, ok := sub.pap[days]
+		if !ok {
+			var err error
+			total, err = e.s.fleetRepo.SumPapByUserSince(sub.userID, e.now.AddDate(0, 0, -days))
+			if err != nil {
+				return false, err
+			}
+			sub.pap[days] = total
+		}
+		return total >= c.Min, nil
+	case model.AutoRoleCondCharacterAge:
+		return sub.anyChar(c, func(ch *model.EveCharacter) (bool, error) {
+			birthday, err := e.characterBirthday(ch)
+			if err != nil {
+				return false, err
+			}
+			return e.now.Sub(*birthday).Hours()/24 >= c.Min, nil
+		})
+	}
+	return false, fmt.Errorf("未知的条件类型: %q", c.Type)
+}
+
+// anyChar 角色级条件：任一角色（only_main 时仅主角色）满足即成立
+func (sub *autoRoleSubject) anyChar(c *model.AutoRoleCondition, match func(ch *model.EveCharacter) (bool, error)) (bool, error) {
+	for i := range sub.chars {
+		ch := &sub.chars[i]
+		if c.OnlyMain && ch.CharacterID != sub.primaryCharID {
+			continue
+		}
+		ok, err := match(ch)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (e *autoRoleRuleEvaluator) characterCorpRoles(sub *autoRoleSubject, characterID int64) ([]string, error) {
+	if roles, ok := sub.corpRoles[characterID]; ok {
+		return roles, nil
+	}
+	roles, err := e.s.autoRoleRepo.ListCharacterCorpRoles(characterID)
+	if err != nil {
+		return nil, err
+	}
+	sub.corpRoles[characterID] = roles
+	return roles, nil
+}
+
+func (e *autoRoleRuleEvaluator) characterTitles(sub *autoRoleSubject, characterID int64) ([]model.EveCharacterTitle, error) {
+	if titles, ok := sub.titles[characterID]; ok {
+		return titles, nil
+	}
+	var titles []model.EveCharacterTitle
+	if err := global.DB.Where("character_id = ?", characterID).Find(&titles).Error; err != nil {
+		return nil, err
+	}
+	sub.titles[characterID] = titles
+	return titles, nil
+}
+
+func (e *autoRoleRuleEvaluator) seatGroups(sub *autoRoleSubject) ([]string, error) {
+	if sub.seatLoaded {
+		return sub.seatGroups, nil
+	}
+	sub.seatLoaded = true
+	seatUser, err := e.s.seatUserRepo.GetByUserID(sub.userID)
+	if err != nil || seatUser.Groups == "" {
+		// 未绑定 SeAT 视为不属于任何分组
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(seatUser.Groups), &sub.seatGroups); err != nil {
+		return nil, nil
+	}
+	return sub.seatGroups, nil
+}
+
+func (e *autoRoleRuleEvaluator) skillPlanItems(planID uint) ([]model.SkillPlanItem, error) {
+	if items, ok := e.planItems[planID]; ok {
+		return items, nil
+	}
+	items, err := e.s.skillPlanRepo.GetItems(planID)
+	if err != nil {
+		return nil, err
+	}
+	e.planItems[planID] = items
+	return items, nil
+}
+
+// characterSkills 一次性加载用户所有角色的技能等级 charID -> skillID -> level
+func (e *autoRoleRuleEvaluator) characterSkills(sub *autoRoleSubject) (map[int64]map[int]int, error) {
+	if sub.skills != nil {
+		return sub.skills, nil
+	}
+	charIDs := make([]int64, 0, len(sub.chars))
+	for _, ch := range sub.chars {
+		charIDs = append(charIDs, ch.CharacterID)
+	}
+	skills, err := e.s.skillPlanRepo.GetSkillsByCharacterIDs(charIDs)
+	if err != nil {
+		return nil, err
+	}
+	sub.skills = make(map[int64]map[int]int, len(charIDs))
+	for _, sk := range skills {
+		if sub.skills[sk.CharacterID] == nil {
+			sub.skills[sk.CharacterID] = make(map[int]int)
+		}
+		sub.skills[sk.CharacterID][sk.SkillID] = sk.TrainedLevel
+	}
+	return sub.skills, nil
+}
+
+// characterBirthday 角色创建时间；未知时返回错误（该规则本次跳过），由角色归属刷新任务回填
+func (e *autoRoleRuleEvaluator) characterBirthday(ch *model.EveCharacter) (*time.Time, error) {
+	if ch.Birthday == nil {
+		return nil, fmt.Errorf("角色 %d 创建时间未知，等待角色归属刷新回填", ch.CharacterID)
+	}
+	return ch.Birthday, nil
+}
+
+func containsInt64(list []int64, v int64) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,7 @@ type AutoSrpService struct {
 	srpRepo         *repository.SrpRepository
 	charRepo        *repository.EveCharacterRepository
 	sdeRepo         *repository.SdeRepository
+	pricing         *SrpPricingService
 }
 
 // NewAutoSrpService 创建自动 SRP 服务
@@ -28,6 +29,7 @@ func NewAutoSrpService() *AutoSrpService {
 		srpRepo:         repository.NewSrpRepository(),
 		charRepo:        repository.NewEveCharacterRepository(),
 		sdeRepo:         repository.NewSdeRepository(),
+		pricing:         NewSrpPricingService(),
 	}
 }
 
@@ -151,30 +153,39 @@ func (s *AutoSrpService) processOneMember(
 			continue
 		}
 
-		// 确定 SRP 金额
-		baseAmount := s.getBaseAmount(fitting, km.ShipTypeID)
+		// 按定价策略确定 SRP 金额
+		quote, err := s.pricing.Quote(&km, fitting)
+		if err != nil {
+			global.Logger.Warn("[AutoSRP] 计算推荐金额失败",
+				zap.Int64("killmail_id", ckm.KillmailID),
+				zap.Int64("character_id", member.CharacterID),
+				zap.Error(err),
+			)
+			continue
+		}
+		baseAmount := quote.Amount
 
 		// 提交 SRP 申请
 		fleetID := fleet.ID
 		app := &model.SrpApplication{
-			UserID:            member.UserID,
-			CharacterID:       member.CharacterID,
-			CharacterName:     member.CharacterName,
-			KillmailID:        ckm.KillmailID,
-			FleetID:           &fleetID,
-			Note:              "",
-			ShipTypeID:        km.ShipTypeID,
-			SolarSystemID:     km.SolarSystemID,
-			KillmailTime:      km.KillmailTime,
-			CorporationID:     km.CorporationID,
-			AllianceID:        km.AllianceID,
-			RecommendedAmount: baseAmount,
-			FinalAmount:       baseAmount,
-			ReviewStatus:      model.SrpReviewPending,
-			PayoutStatus:      model.SrpPayoutPending,
+			UserID:        member.UserID,
+			CharacterID:   member.CharacterID,
+			CharacterName: member.CharacterName,
+			KillmailID:    ckm.KillmailID,
+			FleetID:       &fleetID,
+			Note:          "",
+			ShipTypeID:    km.ShipTypeID,
+			SolarSystemID: km.SolarSystemID,
+			KillmailTime:  km.KillmailTime,
+			CorporationID: km.CorporationID,
+			AllianceID:    km.AllianceID,
+			FinalAmount:   baseAmount,
+			ReviewStatus:  model.SrpReviewPending,
+			PayoutStatus:  model.SrpPayoutPending,
 		}
 
-		if fleet.AutoSrpMode == model.FleetAutoSrpAutoApprove {
+		// 推荐金额为回退结果时不自动审批，保留为待审批
+		if fleet.AutoSrpMode == model.FleetAutoSrpAutoApprove && !quote.NeedsReview {
 			configItemsForFitting := itemsByFitting[fitting.ID]
 			finalAmount, note := s.validateFitting(km.KillmailID, configItemsForFitting, repByItem, baseAmount)
 			app.FinalAmount = finalAmount
@@ -188,7 +199,9 @@ func (s *AutoSrpService) processOneMember(
 			}
 		}
 
-		if err := s.srpRepo.CreateApplication(app); err != nil {
+		quote.Apply(app)
+
+		if err := s.srpRepo.CreateApplicationWithLines(app, quote.Lines); err != nil {
 			if isDuplicateSrpApplicationError(err) {
 				continue
 			}
@@ -207,17 +220,6 @@ func isDuplicateSrpApplicationError(err error) bool {
 	return strings.Contains(msg, "duplicate key") || strings.Contains(msg, "UNIQUE constraint") || strings.Contains(msg, "Duplicate entry")
 }
 
-// getBaseAmount 获取 SRP 基础金额：配置金额 > 0 则用配置金额，否则查全局价格表
-func (s *AutoSrpService) getBaseAmount(fitting *model.FleetConfigFitting, shipTypeID int64) float64 {
-	if fitting.SrpAmount > 0 {
-		return fitting.SrpAmount
-	}
-	if price, err := s.srpRepo.GetShipPriceByTypeID(shipTypeID); err == nil {
-		return price.Amount
-	}
-	return 0
-}
-
 // validateFitting 验证 KM 装配是否符合配置要求，返回最终金额和不符说明
 func (s *AutoSrpService) validateFitting(
 	killmailID int64,
@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"context"
 	"encoding/json"
 	"errors"
@@ -83,7 +84,7 @@ func NewCloneService() *CloneService {
 		cloneRepo: repository.NewCloneRepository(),
 		sdeRepo:   repository.NewSdeRepository(),
 		ssoSvc:    NewEveSSOService(),
-		http:      &http.Client{Timeout: 30 * time.Second},
+		http:      eve.NewESIHTTPClient(30 * time.Second),
 	}
 }
 
@@ -346,7 +347,7 @@ func (s *CloneService) fetchAndCacheStructure(characterID, structureID int64) st
 // ─────────────────────────────────────────────
 
 func (s *CloneService) esiGet(ctx context.Context, path, accessToken string, out interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+path, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiURL(path), nil)
 	if err != nil {
 		return err
 	}
@@ -6,7 +6,6 @@ import (
 	"amiya-eden/internal/repository"
 	"amiya-eden/pkg/cache"
 	"amiya-eden/pkg/eve"
-	"amiya-eden/pkg/jwt"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -194,7 +193,8 @@ func (s *EveSSOService) GetBindAuthURL(ctx context.Context, userID uint, extraSc
 
 // CallbackResult EVE SSO 回调处理结果
 type CallbackResult struct {
-	Token         string              `json:"token"` // 我们系统颁发的 JWT
+	Token         string              `json:"token"`         // 我们系统颁发的 JWT（Access Token）
+	RefreshToken  string              `json:"refresh_token"` // 会话 Refresh Token
 	User          *model.User         `json:"user"`
 	Character     *model.EveCharacter `json:"character"`
 	RedirectURL   string              `json:"redirect_url"` // 前端跳转地址（可能为空）
@@ -286,11 +286,11 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 				}
 			}
 
-			jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+			tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 			if err != nil {
 				return nil, err
 			}
-			return &CallbackResult{Token: jwtToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
+			return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
 		}
 
 		// ── 登录流程：首次登录，创建新用户 + 新角色 ──
@@ -334,11 +334,11 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 			go OnNewCharacterFunc(characterID, user.ID)
 		}
 
-		jwtToken, err := jwt.GenerateToken(user.ID, characterID, user.Role, global.Config.JWT.ExpireDay)
+		tokens, err := issueSession(ctx, user, characterID)
 		if err != nil {
 			return nil, err
 		}
-		return &CallbackResult{Token: jwtToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
+		return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
 	}
 
 	// 已有角色
@@ -361,6 +361,8 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 				char.CharacterName = claims.Name
 				char.PortraitURL = portraitURL
 				char.TokenInvalid = false
+				char.TokenInvalidAt = nil
+				char.TokenInvalidReason = ""
 				if err := s.charRepo.Update(char); err != nil {
 					return nil, err
 				}
@@ -389,11 +391,11 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 					zap.Uint("oldUserID", oldUserID),
 					zap.Uint("newUserID", sd.BindToUserID))
 
-				jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+				tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 				if err != nil {
 					return nil, err
 				}
-				return &CallbackResult{Token: jwtToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
+				return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
 			}
 
 			// 原用户存在，角色已绑定到其他账号 → 生成 pending transfer，让前端询问用户是否迁移
@@ -434,6 +436,8 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 		char.CharacterName = claims.Name
 		char.PortraitURL = portraitURL
 		char.TokenInvalid = false
+		char.TokenInvalidAt = nil
+		char.TokenInvalidReason = ""
 		if err := s.charRepo.Update(char); err != nil {
 			return nil, err
 		}
@@ -445,11 +449,11 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 		if OnCharacterBindFunc != nil {
 			go OnCharacterBindFunc(user.ID)
 		}
-		jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+		tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 		if err != nil {
 			return nil, err
 		}
-		return &CallbackResult{Token: jwtToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
+		return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
 	}
 
 	// ── 登录流程：已有角色重新登录 ──
@@ -460,6 +464,8 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 	char.CharacterName = claims.Name
 	char.PortraitURL = portraitURL
 	char.TokenInvalid = false
+	char.TokenInvalidAt = nil
+	char.TokenInvalidReason = ""
 	if err := s.charRepo.Update(char); err != nil {
 		return nil, err
 	}
@@ -487,11 +493,11 @@ func (s *EveSSOService) HandleCallback(ctx context.Context, code, state, clientI
 		go OnCharacterBindFunc(user.ID)
 	}
 
-	jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+	tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 	if err != nil {
 		return nil, err
 	}
-	return &CallbackResult{Token: jwtToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
+	return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char, RedirectURL: sd.RedirectURL}, nil
 }
 
 // GetValidToken 获取指定角色的有效 access_token（如即将过期则自动刷新）
@@ -529,18 +535,19 @@ func (s *EveSSOService) GetValidToken(ctx context.Context, characterID int64) (s
 }
 
 // refreshCharacterToken 刷新角色 Token 并持久化
+// 仅当 EVE SSO 明确返回 invalid_grant 时标记 Token 失效，暂时性错误不做标记，下次调度会重试
 func (s *EveSSOService) refreshCharacterToken(ctx context.Context, char *model.EveCharacter) error {
 	tokenResp, err := s.eveClient.RefreshAccessToken(ctx, char.RefreshToken)
 	if err != nil {
-		char.TokenInvalid = true
-		_ = s.charRepo.Update(char)
+		if errors.Is(err, eve.ErrInvalidGrant) {
+			s.markTokenInvalid(char, err.Error())
+			return errors.New("该角色的 token 已失效，请重新授权")
+		}
 		return err
 	}
 
 	claims, err := eve.ParseAccessToken(tokenResp.AccessToken)
 	if err != nil {
-		char.TokenInvalid = true
-		_ = s.charRepo.Update(char)
 		return err
 	}
 
@@ -549,10 +556,35 @@ func (s *EveSSOService) refreshCharacterToken(ctx context.Context, char *model.E
 	char.TokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 	char.Scopes = strings.Join(claims.GetScopes(), " ")
 	char.TokenInvalid = false
+	char.TokenInvalidAt = nil
+	char.TokenInvalidReason = ""
 
 	return s.charRepo.Update(char)
 }
 
+// markTokenInvalid 标记角色 Token 失效（仅首次标记时记录日志），角色随即退出 ESI 刷新调度
+func (s *EveSSOService) markTokenInvalid(char *model.EveCharacter, reason string) {
+	if len(reason) > 512 {
+		reason = reason[:512]
+	}
+	now := time.Now()
+	flagged, err := s.charRepo.MarkTokenInvalid(char.CharacterID, reason, now)
+	if err != nil {
+		global.Logger.Error("标记角色 Token 失效失败", zap.Int64("character_id", char.CharacterID), zap.Error(err))
+		return
+	}
+	char.TokenInvalid = true
+	if flagged {
+		char.TokenInvalidAt = &now
+		char.TokenInvalidReason = reason
+		global.Logger.Warn("角色 Token 已失效，停止 ESI 刷新，等待用户重新授权",
+			zap.Int64("character_id", char.CharacterID),
+			zap.Uint("user_id", char.UserID),
+			zap.String("reason", reason),
+		)
+	}
+}
+
 // GetCharactersByUserID 获取用户绑定的所有 EVE 角色（不含 Token）
 func (s *EveSSOService) GetCharactersByUserID(userID uint) ([]model.EveCharacter, error) {
 	return s.charRepo.ListByUserID(userID)
@@ -657,6 +689,8 @@ func (s *EveSSOService) ConfirmTransfer(ctx context.Context, token string, reque
 	char.CharacterName = ptData.CharacterName
 	char.PortraitURL = ptData.PortraitURL
 	char.TokenInvalid = false
+	char.TokenInvalidAt = nil
+	char.TokenInvalidReason = ""
 	if err := s.charRepo.Update(char); err != nil {
 		return nil, err
 	}
@@ -697,11 +731,11 @@ func (s *EveSSOService) ConfirmTransfer(ctx context.Context, token string, reque
 		zap.Uint("fromUserID", oldUserID),
 		zap.Uint("toUserID", ptData.TargetUserID))
 
-	jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+	tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 	if err != nil {
 		return nil, err
 	}
-	return &CallbackResult{Token: jwtToken, User: user, Character: char}, nil
+	return &CallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, Character: char}, nil
 }
 
 // GetRedirectURLFromState 仅读取 state 对应的前端 redirect URL（不删除 state）
@@ -3,6 +3,7 @@ package service
 import (
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -307,12 +308,12 @@ func (s *FittingsService) SaveFitting(userID uint, req *SaveFittingRequest) (*Fi
 		return nil, errors.New("角色 Token 不可用，请重新绑定")
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := eve.NewESIHTTPClient(30 * time.Second)
 	bgCtx := context.Background()
 
 	// 如果有 fittingID，先删除 ESI 上的旧装配
 	if req.FittingID != nil && *req.FittingID > 0 {
-		deleteURL := fmt.Sprintf("https://esi.evetech.net/characters/%d/fittings/%d/", req.CharacterID, *req.FittingID)
+		deleteURL := eve.ESIURL(fmt.Sprintf("/characters/%d/fittings/%d/", req.CharacterID, *req.FittingID))
 		delReq, _ := http.NewRequestWithContext(bgCtx, http.MethodDelete, deleteURL, nil)
 		delReq.Header.Set("Authorization", "Bearer "+char.AccessToken)
 		resp, err := httpClient.Do(delReq)
@@ -346,7 +347,7 @@ func (s *FittingsService) SaveFitting(userID uint, req *SaveFittingRequest) (*Fi
 		return nil, fmt.Errorf("序列化请求体失败: %w", err)
 	}
 
-	postURL := fmt.Sprintf("https://esi.evetech.net/characters/%d/fittings/", req.CharacterID)
+	postURL := eve.ESIURL(fmt.Sprintf("/characters/%d/fittings/", req.CharacterID))
 	postReq, err := http.NewRequestWithContext(bgCtx, http.MethodPost, postURL, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return nil, fmt.Errorf("构建 ESI 请求失败: %w", err)
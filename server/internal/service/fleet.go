@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"bytes"
 	"context"
 	"crypto/rand"
@@ -20,7 +21,10 @@ import (
 	"go.uber.org/zap"
 )
 
-const esiBaseURL = "https://esi.evetech.net/latest"
+// esiURL 拼接 ESI 完整地址（/latest 路由，基础地址来自配置 esi.base_url）
+func esiURL(path string) string {
+	return eve.ESIURL("/latest" + path)
+}
 
 // FleetKMRefreshFunc 触发单个角色 KM 刷新的钩子，由 jobs 层注入以避免循环依赖
 var FleetKMRefreshFunc func(characterID int64)
@@ -36,6 +40,7 @@ type FleetService struct {
 	walletSvc  *SysWalletService
 	webhookSvc *WebhookService
 	http       *http.Client
+	esiHTTP    *http.Client
 }
 
 func NewFleetService() *FleetService {
@@ -46,6 +51,7 @@ func NewFleetService() *FleetService {
 		walletSvc:  NewSysWalletService(),
 		webhookSvc: NewWebhookService(),
 		http:       &http.Client{Timeout: 30 * time.Second},
+		esiHTTP:    eve.NewESIHTTPClient(30 * time.Second),
 	}
 }
 
@@ -64,6 +70,7 @@ type CreateFleetRequest struct {
 	CharacterID   int64   `json:"character_id" binding:"required"` // FC 角色 ID
 	SendPing      bool    `json:"send_ping"`                       // 是否发送 Ping 通知
 	FleetConfigID *uint   `json:"fleet_config_id"`                 // 舰队配置 ID
+	PapPolicyID   *uint   `json:"pap_policy_id"`                   // PAP 策略 ID，为空时使用重要等级默认策略
 	AutoSrpMode   string  `json:"auto_srp_mode"`                   // disabled/submit_only/auto_approve
 }
 
@@ -90,6 +97,14 @@ func (s *FleetService) CreateFleet(userID uint, req *CreateFleetRequest) (*model
 	if endAt.Before(startAt) {
 		return nil, errors.New("结束时间不能早于起始时间")
 	}
+	if req.PapPolicyID != nil && *req.PapPolicyID == 0 {
+		req.PapPolicyID = nil
+	}
+	if req.PapPolicyID != nil {
+		if err := papPolicyExists(*req.PapPolicyID); err != nil {
+			return nil, err
+		}
+	}
 
 	fleet := &model.Fleet{
 		ID:              uuid.New().String(),
@@ -103,6 +118,7 @@ func (s *FleetService) CreateFleet(userID uint, req *CreateFleetRequest) (*model
 		FCCharacterID:   req.CharacterID,
 		FCCharacterName: char.CharacterName,
 		FleetConfigID:   req.FleetConfigID,
+		PapPolicyID:     req.PapPolicyID,
 		AutoSrpMode:     normalizeAutoSrpMode(req.AutoSrpMode),
 	}
 
@@ -140,12 +156,12 @@ func (s *FleetService) CreateFleet(userID uint, req *CreateFleetRequest) (*model
 }
 
 // PingFleet 手动触发舰队 Ping（仅 FC 或管理员）
-func (s *FleetService) PingFleet(fleetID string, userID uint, userRole string) error {
+func (s *FleetService) PingFleet(fleetID string, userID uint, userRole string, scope *model.DataScope) error {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return errors.New("权限不足")
 	}
 	return s.webhookSvc.SendFleetPing(fleet)
@@ -162,17 +178,18 @@ type UpdateFleetRequest struct {
 	CharacterID   *int64   `json:"character_id"`
 	ESIFleetID    *int64   `json:"esi_fleet_id"`
 	FleetConfigID *uint    `json:"fleet_config_id"`
+	PapPolicyID   *uint    `json:"pap_policy_id"` // 0 表示改为使用重要等级默认策略
 	AutoSrpMode   *string  `json:"auto_srp_mode"`
 }
 
 // UpdateFleet 更新舰队信息
-func (s *FleetService) UpdateFleet(fleetID string, userID uint, userRole string, req *UpdateFleetRequest) (*model.Fleet, error) {
+func (s *FleetService) UpdateFleet(fleetID string, userID uint, userRole string, scope *model.DataScope, req *UpdateFleetRequest) (*model.Fleet, error) {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, errors.New("舰队不存在")
 	}
 
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, errors.New("权限不足")
 	}
 
@@ -223,6 +240,16 @@ func (s *FleetService) UpdateFleet(fleetID string, userID uint, userRole string,
 			fleet.FleetConfigID = req.FleetConfigID
 		}
 	}
+	if req.PapPolicyID != nil {
+		if *req.PapPolicyID == 0 {
+			fleet.PapPolicyID = nil
+		} else {
+			if err := papPolicyExists(*req.PapPolicyID); err != nil {
+				return nil, err
+			}
+			fleet.PapPolicyID = req.PapPolicyID
+		}
+	}
 	if req.AutoSrpMode != nil {
 		fleet.AutoSrpMode = normalizeAutoSrpMode(*req.AutoSrpMode)
 	}
@@ -234,12 +261,12 @@ func (s *FleetService) UpdateFleet(fleetID string, userID uint, userRole string,
 }
 
 // DeleteFleet 删除舰队
-func (s *FleetService) DeleteFleet(fleetID string, userID uint, userRole string) error {
+func (s *FleetService) DeleteFleet(fleetID string, userID uint, userRole string, scope *model.DataScope) error {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return errors.New("权限不足")
 	}
 	return s.repo.SoftDelete(fleetID)
@@ -251,12 +278,12 @@ func (s *FleetService) GetFleet(fleetID string) (*model.Fleet, error) {
 }
 
 // RefreshESIFleetID 从 ESI 刷新舰队的 esi_fleet_id 并持久化
-func (s *FleetService) RefreshESIFleetID(fleetID string, userID uint, userRole string) (*model.Fleet, error) {
+func (s *FleetService) RefreshESIFleetID(fleetID string, userID uint, userRole string, scope *model.DataScope) (*model.Fleet, error) {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, errors.New("权限不足")
 	}
 
@@ -382,13 +409,14 @@ func (s *FleetService) esiInviteMember(fleet *model.Fleet, char *model.EveCharac
 //  PAP 发放
 // ─────────────────────────────────────────────
 
-// IssuePap 发放 PAP 到舰队所有成员
-func (s *FleetService) IssuePap(fleetID string, userID uint, userRole string) error {
+// IssuePap 按舰队的 PAP 策略向所有成员发放 PAP，并保存每个成员的计算明细
+// proRata 为 true 时无论策略模式均按在队时长折算
+func (s *FleetService) IssuePap(fleetID string, userID uint, userRole string, scope *model.DataScope, proRata bool) error {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return errors.New("权限不足")
 	}
 	if fleet.PapCount <= 0 {
@@ -397,7 +425,7 @@ func (s *FleetService) IssuePap(fleetID string, userID uint, userRole string) er
 
 	// 1. 先尝试 ESI 同步成员（失败不阻断发放）
 	if fleet.ESIFleetID != nil {
-		if _, syncErr := s.SyncESIMembers(fleetID, userID, userRole); syncErr != nil {
+		if _, syncErr := s.SyncESIMembers(fleetID, userID, userRole, scope); syncErr != nil {
 			global.Logger.Warn("[Fleet] IssuePap ESI 同步失败，继续发放",
 				zap.String("fleet_id", fleetID),
 				zap.Error(syncErr),
@@ -414,6 +442,12 @@ func (s *FleetService) IssuePap(fleetID string, userID uint, userRole string) er
 		return errors.New("舰队中没有成员")
 	}
 
+	// 按策略计算；没有跟踪记录的成员（邀请链接 / 手动加入）按全程在队计算
+	policy, results, err := s.calculatePap(fleet, members, proRata)
+	if err != nil {
+		return err
+	}
+
 	// 3. 获取旧 PAP 记录，用于钱包差量计算（在事务外读取，快照一致即可）
 	oldLogs, err := s.repo.ListPapLogsByFleet(fleetID)
 	if err != nil {
@@ -421,21 +455,24 @@ func (s *FleetService) IssuePap(fleetID string, userID uint, userRole string) er
 	}
 	oldPapPerUser := make(map[uint]float64, len(oldLogs))
 	for _, ol := range oldLogs {
-		oldPapPerUser[ol.UserID] = ol.PapCount
+		oldPapPerUser[ol.UserID] += ol.PapCount
 	}
 
-	// 4. 构建新 PAP 记录
-	newLogs := make([]model.FleetPapLog, 0, len(members))
-	newPapPerUser := make(map[uint]float64, len(members))
-	for _, m := range members {
+	// 4. 构建新 PAP 记录（未达到在队下限的成员记录 0 PAP，便于查看原因）
+	newLogs := make([]model.FleetPapLog, 0, len(results))
+	newPapPerUser := make(map[uint]float64, len(results))
+	for i := range results {
+		r := &results[i]
 		newLogs = append(newLogs, model.FleetPapLog{
 			FleetID:     fleetID,
-			CharacterID: m.CharacterID,
-			UserID:      m.UserID,
-			PapCount:    fleet.PapCount,
+			CharacterID: r.CharacterID,
+			UserID:      r.UserID,
+			PapCount:    r.PapCount,
+			PolicyName:  policy.Name,
+			Breakdown:   &r.Breakdown,
 			IssuedBy:    userID,
 		})
-		newPapPerUser[m.UserID] += fleet.PapCount
+		newPapPerUser[r.UserID] += r.PapCount
 	}
 
 	// 5. 事务：更新 PAP 记录 + 钱包差量
@@ -622,41 +659,48 @@ type ESIFleetMember struct {
 }
 
 // SyncESIMembers 从 ESI 获取当前舰队成员并记录到数据库
-func (s *FleetService) SyncESIMembers(fleetID string, userID uint, userRole string) ([]ESIFleetMember, error) {
+func (s *FleetService) SyncESIMembers(fleetID string, userID uint, userRole string, scope *model.DataScope) ([]ESIFleetMember, error) {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, errors.New("权限不足")
 	}
 	if fleet.ESIFleetID == nil {
 		return nil, errors.New("未设置 ESI 舰队 ID")
 	}
 
-	ctx := context.Background()
+	esiMembers, _, err := s.fetchESIMembers(context.Background(), fleet)
+	return esiMembers, err
+}
+
+// fetchESIMembers 从 ESI 获取舰队成员并记录到数据库，同时返回系统中已绑定的角色
+func (s *FleetService) fetchESIMembers(ctx context.Context, fleet *model.Fleet) ([]ESIFleetMember, map[int64]*model.EveCharacter, error) {
 	accessToken, err := s.ssoSvc.GetValidToken(ctx, fleet.FCCharacterID)
 	if err != nil {
-		return nil, fmt.Errorf("获取 FC Token 失败: %w", err)
+		return nil, nil, fmt.Errorf("获取 FC Token 失败: %w", err)
 	}
 
 	path := fmt.Sprintf("/fleets/%d/members/", *fleet.ESIFleetID)
 	var esiMembers []ESIFleetMember
 	if err := s.esiGet(ctx, path, accessToken, &esiMembers); err != nil {
-		return nil, fmt.Errorf("获取 ESI 舰队成员失败: %w", err)
+		return nil, nil, fmt.Errorf("获取 ESI 舰队成员失败: %w", err)
 	}
 
 	// 将 ESI 成员记录到数据库
+	known := make(map[int64]*model.EveCharacter, len(esiMembers))
 	for _, em := range esiMembers {
 		char, err := s.charRepo.GetByCharacterID(em.CharacterID)
 		if err != nil {
 			// 角色不在系统中，跳过
 			continue
 		}
+		known[em.CharacterID] = char
 		shipTypeID := em.ShipTypeID
 		solarSystemID := em.SolarSystemID
 		member := &model.FleetMember{
-			FleetID:       fleetID,
+			FleetID:       fleet.ID,
 			CharacterID:   em.CharacterID,
 			CharacterName: char.CharacterName,
 			UserID:        char.UserID,
@@ -666,7 +710,7 @@ func (s *FleetService) SyncESIMembers(fleetID string, userID uint, userRole stri
 		_ = s.repo.AddMember(member)
 	}
 
-	return esiMembers, nil
+	return esiMembers, known, nil
 }
 
 // ─────────────────────────────────────────────
@@ -674,12 +718,12 @@ func (s *FleetService) SyncESIMembers(fleetID string, userID uint, userRole stri
 // ─────────────────────────────────────────────
 
 // CreateInvite 创建舰队邀请链接
-func (s *FleetService) CreateInvite(fleetID string, userID uint, userRole string) (*model.FleetInvite, error) {
+func (s *FleetService) CreateInvite(fleetID string, userID uint, userRole string, scope *model.DataScope) (*model.FleetInvite, error) {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, errors.New("权限不足")
 	}
 
@@ -708,15 +752,29 @@ func (s *FleetService) CreateInvite(fleetID string, userID uint, userRole string
 	return invite, nil
 }
 
-// GetInvites 获取舰队邀请链接列表
-func (s *FleetService) GetInvites(fleetID string) ([]model.FleetInvite, error) {
+// GetInvites 获取舰队邀请链接列表（需能管理该舰队）
+func (s *FleetService) GetInvites(fleetID string, userID uint, userRole string, scope *model.DataScope) ([]model.FleetInvite, error) {
+	fleet, err := s.repo.GetByID(fleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
 	return s.repo.ListInvitesByFleet(fleetID)
 }
 
-// DeactivateInvite 禁用邀请链接
-func (s *FleetService) DeactivateInvite(inviteID uint, userID uint, userRole string) error {
-	// 简单处理：admin 和 fc 都可以禁用
-	if !model.HasRole(userRole, model.RoleFC) {
+// DeactivateInvite 禁用邀请链接（需能管理邀请所属舰队）
+func (s *FleetService) DeactivateInvite(inviteID uint, userID uint, userRole string, scope *model.DataScope) error {
+	invite, err := s.repo.GetInviteByID(inviteID)
+	if err != nil {
+		return errors.New("邀请链接不存在")
+	}
+	fleet, err := s.repo.GetByID(invite.FleetID)
+	if err != nil {
+		return errors.New("舰队不存在")
+	}
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return errors.New("权限不足")
 	}
 	return s.repo.DeactivateInvite(inviteID)
@@ -738,12 +796,12 @@ type ManualPapResult struct {
 
 // ManualPap 解析文本（每行取首个字段为角色名），将角色加入舰队并发放 PAP。
 // 每行格式可以是 "角色名" 或 "角色名<TAB>舰船<TAB>星系" 等（兼容 EVE 名单格式）。
-func (s *FleetService) ManualPap(fleetID string, userID uint, userRole string, text string) (*ManualPapResult, error) {
+func (s *FleetService) ManualPap(fleetID string, userID uint, userRole string, scope *model.DataScope, text string) (*ManualPapResult, error) {
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, errors.New("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, errors.New("权限不足")
 	}
 	if fleet.PapCount <= 0 {
@@ -816,6 +874,8 @@ func (s *FleetService) ManualPap(fleetID string, userID uint, userRole string, t
 			CharacterID: char.CharacterID,
 			UserID:      char.UserID,
 			PapCount:    fleet.PapCount,
+			PolicyName:  manualPapPolicyName,
+			Breakdown:   manualPapBreakdown(fleet),
 			IssuedBy:    userID,
 		}
 		if createErr := tx.Create(&papLog).Error; createErr != nil {
@@ -839,12 +899,24 @@ func (s *FleetService) ManualPap(fleetID string, userID uint, userRole string, t
 	return result, nil
 }
 
-// canManageFleet 判断用户是否有权管理该舰队（admin 或创建者）
-func (s *FleetService) canManageFleet(fleet *model.Fleet, userID uint, userRole string) bool {
+// canManageFleet 判断用户是否有权管理该舰队（admin、创建者，或限定范围角色覆盖该舰队 FC 所在军团）
+func (s *FleetService) canManageFleet(fleet *model.Fleet, userID uint, userRole string, scope *model.DataScope) bool {
+	return canManageAsFC(fleet.FCUserID, userID, userRole, scope)
+}
+
+// canManageAsFC 判断用户能否管理 FC 为 fcUserID 的舰队 / 行动
+func canManageAsFC(fcUserID, userID uint, userRole string, scope *model.DataScope) bool {
 	if model.HasRole(userRole, model.RoleAdmin) {
 		return true
 	}
-	return fleet.FCUserID == userID
+	if fcUserID == userID {
+		return true
+	}
+	if scope == nil {
+		return false
+	}
+	ok, err := repository.UserInScope(fcUserID, scope)
+	return err == nil && ok
 }
 
 // ─────────────────────────────────────────────
@@ -901,14 +973,14 @@ func (s *FleetService) GetCharacterFleetInfo(userID uint, characterID int64) (*C
 
 // esiGet GET 请求并解析 JSON 响应
 func (s *FleetService) esiGet(ctx context.Context, path, accessToken string, out interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+path, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiURL(path), nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.http.Do(req)
+	resp, err := s.esiHTTP.Do(req)
 	if err != nil {
 		return err
 	}
@@ -928,14 +1000,14 @@ func (s *FleetService) esiPost(ctx context.Context, path, accessToken string, bo
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, esiBaseURL+path, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, esiURL(path), bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.http.Do(req)
+	resp, err := s.esiHTTP.Do(req)
 	if err != nil {
 		return err
 	}
@@ -954,14 +1026,14 @@ func (s *FleetService) esiPut(ctx context.Context, path, accessToken string, bod
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, esiBaseURL+path, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, esiURL(path), bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.http.Do(req)
+	resp, err := s.esiHTTP.Do(req)
 	if err != nil {
 		return err
 	}
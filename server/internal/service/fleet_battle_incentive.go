@@ -155,7 +155,7 @@ func (s *FleetBattleIncentiveService) CheckAndIssueIncentive(fleetID string, fcU
 	seen := make(map[uint]bool)
 	for _, log := range papLogs {
 		uid := log.UserID
-		if seen[uid] {
+		if seen[uid] || log.PapCount <= 0 {
 			continue
 		}
 		seen[uid] = true
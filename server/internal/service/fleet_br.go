@@ -90,13 +90,13 @@ type FleetBRResult struct {
 }
 
 // GenerateBattleReport 生成舰队战报并入库
-func (s *FleetService) GenerateBattleReport(fleetID string, userID uint, userRole string) (*FleetBRResult, error) {
+func (s *FleetService) GenerateBattleReport(fleetID string, userID uint, userRole string, scope *model.DataScope) (*FleetBRResult, error) {
 	// 1. 校验权限
 	fleet, err := s.repo.GetByID(fleetID)
 	if err != nil {
 		return nil, fmt.Errorf("舰队不存在")
 	}
-	if !s.canManageFleet(fleet, userID, userRole) {
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
 		return nil, fmt.Errorf("权限不足")
 	}
 
@@ -299,8 +299,8 @@ func (s *FleetService) fetchAffilBatch(charIDs []int64) []esiAffil {
 			end = len(charIDs)
 		}
 		var batch []esiAffil
-		url := esiBaseURL + "/characters/affiliation/?datasource=tranquility"
-		if err := brPostJSON(s.http, url, charIDs[i:end], &batch); err != nil {
+		url := esiURL("/characters/affiliation/?datasource=tranquility")
+		if err := brPostJSON(s.esiHTTP, url, charIDs[i:end], &batch); err != nil {
 			global.Logger.Warn("[BR] ESI affiliation 失败", zap.Error(err))
 			continue
 		}
@@ -316,8 +316,8 @@ func (s *FleetService) fetchSystemNames(sysKMs map[int64][]time.Time) map[int64]
 		var sys struct {
 			Name string `json:"name"`
 		}
-		url := fmt.Sprintf("%s/universe/systems/%d/?datasource=tranquility", esiBaseURL, sysID)
-		if err := brGetJSON(s.http, url, &sys); err == nil && sys.Name != "" {
+		url := esiURL(fmt.Sprintf("/universe/systems/%d/?datasource=tranquility", sysID))
+		if err := brGetJSON(s.esiHTTP, url, &sys); err == nil && sys.Name != "" {
 			names[sysID] = sys.Name
 		} else {
 			names[sysID] = fmt.Sprintf("%d", sysID)
@@ -3,6 +3,7 @@ package service
 import (
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -31,7 +32,7 @@ func NewFleetConfigService() *FleetConfigService {
 		charRepo: repository.NewEveCharacterRepository(),
 		sdeRepo:  repository.NewSdeRepository(),
 		ssoSvc:   NewEveSSOService(),
-		http:     &http.Client{Timeout: 30 * time.Second},
+		http:     eve.NewESIHTTPClient(30 * time.Second),
 	}
 }
 
@@ -396,7 +397,7 @@ func (s *FleetConfigService) ExportToESI(userID uint, req *ExportToESIRequest) e
 		return fmt.Errorf("获取 Token 失败: %w", err)
 	}
 
-	postURL := fmt.Sprintf("https://esi.evetech.net/latest/characters/%d/fittings/", req.CharacterID)
+	postURL := esiURL(fmt.Sprintf("/characters/%d/fittings/", req.CharacterID))
 	postReq, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return fmt.Errorf("构建请求失败: %w", err)
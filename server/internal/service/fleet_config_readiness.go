@@ -0,0 +1,437 @@
+package service
+
+import (
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"sort"
+)
+
+// ─────────────────────────────────────────────
+//  舰队配置战备
+//  按 SDE dgmTypeAttributes 技能需求比对成员角色已生效技能，
+//  判断能否驾驶每个装配的舰船及其全部装备，并统计指定空间站 / 建筑内已备好的舰船
+// ─────────────────────────────────────────────
+
+// 角色对单个装配的战备状态
+const (
+	doctrineStatusReady     = "ready"      // 舰船与全部必要装备技能均满足
+	doctrineStatusHullOnly  = "hull_only"  // 可驾驶舰船，但部分必要装备技能不足
+	doctrineStatusCannotFly = "cannot_fly" // 舰船技能不足
+)
+
+// doctrineTopMissingLimit 每个装配统计的最常见缺失技能数
+const doctrineTopMissingLimit = 10
+
+// DoctrineMissingSkill 装配最常见的缺失技能
+type DoctrineMissingSkill struct {
+	SkillTypeID   int    `json:"skill_type_id"`
+	SkillName     string `json:"skill_name"`
+	RequiredLevel int    `json:"required_level"`
+	Characters    int    `json:"characters"` // 缺少该技能的角色数
+}
+
+// DoctrineFittingSummary 单个装配的战备汇总
+type DoctrineFittingSummary struct {
+	FittingID        uint                   `json:"fitting_id"`
+	FittingName      string                 `json:"fitting_name"`
+	ShipTypeID       int64                  `json:"ship_type_id"`
+	ShipName         string                 `json:"ship_name"`
+	Ready            int                    `json:"ready"`
+	HullOnly         int                    `json:"hull_only"`
+	CannotFly        int                    `json:"cannot_fly"`
+	ReadyUsers       int                    `json:"ready_users"`  // 至少有一个角色 ready 的成员数
+	Staged           int                    `json:"staged"`       // 在指定位置已有该舰船的角色数
+	ReadyAndStaged   int                    `json:"ready_staged"` // ready 且已备船的角色数
+	TopMissingSkills []DoctrineMissingSkill `json:"top_missing_skills"`
+}
+
+// DoctrinePilotFitting 角色对单个装配的战备情况
+type DoctrinePilotFitting struct {
+	FittingID     uint               `json:"fitting_id"`
+	Status        string             `json:"status"` // ready / hull_only / cannot_fly
+	StagedHulls   int                `json:"staged_hulls"`
+	OptionalUnmet int                `json:"optional_unmet"` // 技能不足的非必要装备数
+	MissingSkills []MissingSkillItem `json:"missing_skills"`
+}
+
+// DoctrinePilot 单个角色的战备情况
+type DoctrinePilot struct {
+	UserID        uint                   `json:"user_id"`
+	UserName      string                 `json:"user_name"`
+	CharacterID   int64                  `json:"character_id"`
+	CharacterName string                 `json:"character_name"`
+	ReadyCount    int                    `json:"ready_count"`
+	Fittings      []DoctrinePilotFitting `json:"fittings"`
+}
+
+// DoctrineReadiness 舰队配置战备看板
+type DoctrineReadiness struct {
+	FleetConfigID   uint                     `json:"fleet_config_id"`
+	FleetConfigName string                   `json:"fleet_config_name"`
+	LocationID      int64                    `json:"location_id"`
+	LocationName    string                   `json:"location_name"`
+	TotalCharacters int                      `json:"total_characters"`
+	TotalUsers      int                      `json:"total_users"`
+	Fittings        []DoctrineFittingSummary `json:"fittings"`
+	Pilots          []DoctrinePilot          `json:"pilots"`
+}
+
+// doctrineItemReq 装配中单个装备（或可替换装备的任一替代品）的技能需求
+type doctrineItemReq struct {
+	required   bool    // 必要 / 可替换装备为 true
+	candidates [][]int // 每个候选物品对应 skillReqs 的下标列表
+}
+
+// GetReadiness 统计成员角色对舰队配置的战备情况；locationID 非 0 时统计该位置已备好的舰船
+// 只统计通过基础准入的成员，scope 非 nil 时限定在数据范围内
+func (s *FleetConfigService) GetReadiness(configID uint, locationID int64, lang string, scope *model.DataScope) (*DoctrineReadiness, error) {
+	if lang == "" {
+		lang = "zh"
+	}
+	config, err := s.repo.GetByID(configID)
+	if err != nil {
+		return nil, errors.New("舰队配置不存在")
+	}
+	fittings, err := s.repo.ListFittingsByConfigID(configID)
+	if err != nil {
+		return nil, err
+	}
+	fittingIDs := make([]uint, 0, len(fittings))
+	for _, f := range fittings {
+		fittingIDs = append(fittingIDs, f.ID)
+	}
+	items, err := s.repo.ListItemsByFittingIDs(fittingIDs)
+	if err != nil {
+		return nil, err
+	}
+	itemIDs := make([]uint, 0, len(items))
+	for _, it := range items {
+		itemIDs = append(itemIDs, it.ID)
+	}
+	replacements, err := s.repo.ListReplacementsByItemIDs(itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	repByItem := make(map[uint][]int64)
+	for _, r := range replacements {
+		repByItem[r.FleetConfigFittingItemID] = append(repByItem[r.FleetConfigFittingItemID], r.TypeID)
+	}
+
+	// 1. 收集舰船与装备类型，批量查询直接技能需求（前置技能已隐含在直接需求中）
+	typeSet := make(map[int]struct{})
+	for _, f := range fittings {
+		typeSet[int(f.ShipTypeID)] = struct{}{}
+	}
+	for _, it := range items {
+		if it.Flag == "Cargo" { // 货柜中的弹药 / 备件不影响能否出船
+			continue
+		}
+		typeSet[int(it.TypeID)] = struct{}{}
+		for _, rep := range repByItem[it.ID] {
+			typeSet[int(rep)] = struct{}{}
+		}
+	}
+	typeIDs := make([]int, 0, len(typeSet))
+	for id := range typeSet {
+		typeIDs = append(typeIDs, id)
+	}
+	reqRows, err := s.sdeRepo.GetShipSkillRequirements(typeIDs)
+	if err != nil {
+		return nil, err
+	}
+	var skillReqs []repository.ShipSkillReq
+	reqsByType := make(map[int][]int)
+	skillSet := make(map[int]struct{})
+	for _, r := range reqRows {
+		if r.Depth != 1 {
+			continue
+		}
+		reqsByType[r.ShipTypeID] = append(reqsByType[r.ShipTypeID], len(skillReqs))
+		skillReqs = append(skillReqs, r)
+		skillSet[r.SkillTypeID] = struct{}{}
+	}
+
+	// 2. 每个装配的舰船需求与装备需求
+	itemsByFitting := make(map[uint][]doctrineItemReq)
+	for _, it := range items {
+		if it.Flag == "Cargo" {
+			continue
+		}
+		req := doctrineItemReq{
+			required:   it.Importance != model.FittingItemOptional,
+			candidates: [][]int{reqsByType[int(it.TypeID)]},
+		}
+		if it.Importance == model.FittingItemReplaceable {
+			for _, rep := range repByItem[it.ID] {
+				req.candidates = append(req.candidates, reqsByType[int(rep)])
+			}
+		}
+		itemsByFitting[it.FleetConfigFittingID] = append(itemsByFitting[it.FleetConfigFittingID], req)
+	}
+
+	// 3. 名称
+	nameIDs := make([]int, 0, len(skillSet)+len(fittings))
+	for id := range skillSet {
+		nameIDs = append(nameIDs, id)
+	}
+	for _, f := range fittings {
+		nameIDs = append(nameIDs, int(f.ShipTypeID))
+	}
+	nameMap := make(map[int]string)
+	if infos, err := s.sdeRepo.GetTypes(nameIDs, nil, lang); err == nil {
+		for _, t := range infos {
+			nameMap[t.TypeID] = t.TypeName
+		}
+	}
+
+	// 4. 成员角色及其已生效技能
+	chars, err := s.charRepo.ListMemberCharsWithToken(scope)
+	if err != nil {
+		return nil, err
+	}
+	charIDs := make([]int64, 0, len(chars))
+	userIDSet := make(map[uint]bool)
+	userIDs := make([]uint, 0)
+	for _, c := range chars {
+		charIDs = append(charIDs, c.CharacterID)
+		if !userIDSet[c.UserID] {
+			userIDSet[c.UserID] = true
+			userIDs = append(userIDs, c.UserID)
+		}
+	}
+	skillRows, err := repository.NewSkillPlanRepository().GetSkillsByCharacterIDs(charIDs)
+	if err != nil {
+		return nil, err
+	}
+	charSkills := make(map[int64]map[int]int)
+	for _, sk := range skillRows {
+		if charSkills[sk.CharacterID] == nil {
+			charSkills[sk.CharacterID] = make(map[int]int)
+		}
+		charSkills[sk.CharacterID][sk.SkillID] = sk.ActiveLevel
+	}
+	userNames := make(map[uint]string)
+	if users, err := repository.NewUserRepository().ListByIDs(userIDs); err == nil {
+		for _, u := range users {
+			userNames[u.ID] = u.Nickname
+		}
+	}
+
+	// 5. 指定位置已备好的舰船
+	assetSvc := NewAssetService()
+	staged := make(map[int64]map[int64]int) // characterID -> shipTypeID -> 数量
+	result := &DoctrineReadiness{
+		FleetConfigID:   config.ID,
+		FleetConfigName: config.Name,
+		LocationID:      locationID,
+		TotalCharacters: len(chars),
+		TotalUsers:      len(userIDs),
+		Fittings:        make([]DoctrineFittingSummary, 0, len(fittings)),
+		Pilots:          make([]DoctrinePilot, 0, len(chars)),
+	}
+	if locationID != 0 {
+		staged, err = s.stagedHulls(assetSvc, charIDs, fittings, locationID)
+		if err != nil {
+			return nil, err
+		}
+		locationType := "structure"
+		if locationID >= 60000000 && locationID < 64000000 {
+			locationType = "station"
+		}
+		result.LocationName = assetSvc.resolveLocationName(chars, locationID, locationType)
+	}
+
+	// 6. 逐角色比对
+	meets := func(skills map[int]int, idx []int) bool {
+		for _, i := range idx {
+			if skills[skillReqs[i].SkillTypeID] < skillReqs[i].RequiredLevel {
+				return false
+			}
+		}
+		return true
+	}
+	type missingKey struct{ skill, level int }
+	missingCount := make([]map[missingKey]int, len(fittings))
+	readyUsers := make([]map[uint]bool, len(fittings))
+	for i := range fittings {
+		missingCount[i] = make(map[missingKey]int)
+		readyUsers[i] = make(map[uint]bool)
+		result.Fittings = append(result.Fittings, DoctrineFittingSummary{
+			FittingID:        fittings[i].ID,
+			FittingName:      fittings[i].FittingName,
+			ShipTypeID:       fittings[i].ShipTypeID,
+			ShipName:         nameMap[int(fittings[i].ShipTypeID)],
+			TopMissingSkills: []DoctrineMissingSkill{},
+		})
+	}
+
+	for _, c := range chars {
+		skills := charSkills[c.CharacterID]
+		pilot := DoctrinePilot{
+			UserID:        c.UserID,
+			UserName:      userNames[c.UserID],
+			CharacterID:   c.CharacterID,
+			CharacterName: c.CharacterName,
+			Fittings:      make([]DoctrinePilotFitting, 0, len(fittings)),
+		}
+		for fi, f := range fittings {
+			pf := DoctrinePilotFitting{FittingID: f.ID, StagedHulls: staged[c.CharacterID][f.ShipTypeID], MissingSkills: []MissingSkillItem{}}
+			missing := make(map[int]int) // skillID -> 所需等级
+
+			hullReqs := reqsByType[int(f.ShipTypeID)]
+			hullOK := meets(skills, hullReqs)
+			addMissing := func(idx []int) {
+				for _, i := range idx {
+					r := skillReqs[i]
+					if skills[r.SkillTypeID] < r.RequiredLevel && r.RequiredLevel > missing[r.SkillTypeID] {
+						missing[r.SkillTypeID] = r.RequiredLevel
+					}
+				}
+			}
+			if !hullOK {
+				addMissing(hullReqs)
+			}
+			modulesOK := true
+			for _, it := range itemsByFitting[f.ID] {
+				usable := false
+				for _, cand := range it.candidates {
+					if meets(skills, cand) {
+						usable = true
+						break
+					}
+				}
+				if usable {
+					continue
+				}
+				if it.required {
+					modulesOK = false
+					addMissing(it.candidates[0])
+				} else {
+					pf.OptionalUnmet++
+				}
+			}
+
+			summary := &result.Fittings[fi]
+			switch {
+			case !hullOK:
+				pf.Status = doctrineStatusCannotFly
+				summary.CannotFly++
+			case !modulesOK:
+				pf.Status = doctrineStatusHullOnly
+				summary.HullOnly++
+			default:
+				pf.Status = doctrineStatusReady
+				summary.Ready++
+				pilot.ReadyCount++
+				readyUsers[fi][c.UserID] = true
+			}
+			if pf.StagedHulls > 0 {
+				summary.Staged++
+				if pf.Status == doctrineStatusReady {
+					summary.ReadyAndStaged++
+				}
+			}
+			for skillID, level := range missing {
+				pf.MissingSkills = append(pf.MissingSkills, MissingSkillItem{
+					SkillTypeID:   skillID,
+					SkillName:     nameMap[skillID],
+					RequiredLevel: level,
+					CurrentLevel:  skills[skillID],
+				})
+				missingCount[fi][missingKey{skillID, level}]++
+			}
+			sort.Slice(pf.MissingSkills, func(a, b int) bool { return pf.MissingSkills[a].SkillTypeID < pf.MissingSkills[b].SkillTypeID })
+			pilot.Fittings = append(pilot.Fittings, pf)
+		}
+		result.Pilots = append(result.Pilots, pilot)
+	}
+
+	// 7. 汇总
+	for fi := range result.Fittings {
+		result.Fittings[fi].ReadyUsers = len(readyUsers[fi])
+		top := make([]DoctrineMissingSkill, 0, len(missingCount[fi]))
+		for k, n := range missingCount[fi] {
+			top = append(top, DoctrineMissingSkill{SkillTypeID: k.skill, SkillName: nameMap[k.skill], RequiredLevel: k.level, Characters: n})
+		}
+		sort.Slice(top, func(a, b int) bool {
+			if top[a].Characters != top[b].Characters {
+				return top[a].Characters > top[b].Characters
+			}
+			return top[a].SkillTypeID < top[b].SkillTypeID
+		})
+		if len(top) > doctrineTopMissingLimit {
+			top = top[:doctrineTopMissingLimit]
+		}
+		result.Fittings[fi].TopMissingSkills = top
+	}
+	sort.SliceStable(result.Pilots, func(a, b int) bool {
+		if result.Pilots[a].ReadyCount != result.Pilots[b].ReadyCount {
+			return result.Pilots[a].ReadyCount > result.Pilots[b].ReadyCount
+		}
+		return result.Pilots[a].CharacterName < result.Pilots[b].CharacterName
+	})
+	return result, nil
+}
+
+// stagedHulls 统计各角色在指定位置（含其中的容器内）的舰船数量
+func (s *FleetConfigService) stagedHulls(assetSvc *AssetService, charIDs []int64, fittings []model.FleetConfigFitting, locationID int64) (map[int64]map[int64]int, error) {
+	shipTypes := make([]int, 0, len(fittings))
+	for _, f := range fittings {
+		shipTypes = append(shipTypes, int(f.ShipTypeID))
+	}
+	hulls, err := assetSvc.assetRepo.GetAssetsByTypeIDs(charIDs, shipTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 向上查找容器链，直到根位置（空间站 / 建筑）
+	parents := make(map[int64]model.EveCharacterAsset)
+	pending := make(map[int64]bool)
+	for _, h := range hulls {
+		if h.LocationType == "item" {
+			pending[h.LocationID] = true
+		}
+	}
+	for depth := 0; depth < 5 && len(pending) > 0; depth++ {
+		ids := make([]int64, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		pending = make(map[int64]bool)
+		rows, err := assetSvc.assetRepo.GetAssetsByItemIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range rows {
+			parents[p.ItemID] = p
+			if p.LocationType == "item" {
+				if _, seen := parents[p.LocationID]; !seen {
+					pending[p.LocationID] = true
+				}
+			}
+		}
+	}
+	rootLocation := func(a model.EveCharacterAsset) int64 {
+		for i := 0; i < 6 && a.LocationType == "item"; i++ {
+			p, ok := parents[a.LocationID]
+			if !ok {
+				return a.LocationID // 不在资产中：location_id 为玩家建筑 ID
+			}
+			a = p
+		}
+		return a.LocationID
+	}
+
+	staged := make(map[int64]map[int64]int)
+	for _, h := range hulls {
+		if rootLocation(h) != locationID {
+			continue
+		}
+		if staged[h.CharacterID] == nil {
+			staged[h.CharacterID] = make(map[int64]int)
+		}
+		staged[h.CharacterID][int64(h.TypeID)] += h.Quantity
+	}
+	return staged, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  舰队持续跟踪
+//  行动期间按配置间隔轮询 ESI 舰队成员，记录每个成员的在队时间段、
+//  换船与跳星系，用于按在队时长折算 PAP 和行动复盘
+// ─────────────────────────────────────────────
+
+const (
+	defaultFleetTrackingInterval = 60  // 秒
+	minFleetTrackingInterval     = 15  // 秒，与定时任务频率一致
+	maxFleetTrackingInterval     = 600 // 秒
+	fleetTrackingPollPrefix      = "fleet:tracking:poll:"
+	fleetLeftEarlyGrace          = 10 * time.Minute // 最后在队时间早于跟踪结束超过该时长视为提前离队
+)
+
+// FleetTrackingConfig 舰队持续跟踪配置
+type FleetTrackingConfig struct {
+	IntervalSeconds int `json:"interval_seconds" binding:"gte=0,lte=600"` // 0 表示关闭
+}
+
+// GetFleetTrackingConfig 读取舰队持续跟踪配置
+func GetFleetTrackingConfig() FleetTrackingConfig {
+	v := repository.NewSysConfigRepository().GetFloat(model.SysConfigFleetTrackingInterval, defaultFleetTrackingInterval)
+	return FleetTrackingConfig{IntervalSeconds: int(v)}
+}
+
+// SetFleetTrackingConfig 更新舰队持续跟踪配置
+func SetFleetTrackingConfig(cfg FleetTrackingConfig) error {
+	if cfg.IntervalSeconds != 0 && (cfg.IntervalSeconds < minFleetTrackingInterval || cfg.IntervalSeconds > maxFleetTrackingInterval) {
+		return errors.New("轮询间隔须在 15-600 秒之间（0 表示关闭）")
+	}
+	return repository.NewSysConfigRepository().Set(model.SysConfigFleetTrackingInterval,
+		strconv.Itoa(cfg.IntervalSeconds), "舰队持续跟踪轮询 ESI 成员的间隔（秒，0 关闭）")
+}
+
+// TrackActiveFleets 轮询所有进行中且关联了 ESI 舰队的舰队（定时任务调用）
+// 每个舰队按配置间隔轮询；返回本次轮询的舰队数
+func (s *FleetService) TrackActiveFleets(now time.Time) (int, error) {
+	if _, err := s.repo.CloseSegmentsOfEndedFleets(now); err != nil {
+		global.Logger.Warn("[FleetTracking] 结束已结束舰队的时间段失败", zap.Error(err))
+	}
+
+	interval := GetFleetTrackingConfig().IntervalSeconds
+	if interval <= 0 {
+		return 0, nil
+	}
+	fleets, err := s.repo.ListTrackableFleets(now)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := context.Background()
+	// 留出少量余量，避免定时任务触发时间抖动导致跳过一轮
+	throttle := time.Duration(interval)*time.Second - 2*time.Second
+	polled := 0
+	for i := range fleets {
+		fleet := &fleets[i]
+		if ok, err := global.Redis.SetNX(ctx, fleetTrackingPollPrefix+fleet.ID, 1, throttle).Result(); err == nil && !ok {
+			continue
+		}
+		if err := s.trackFleet(ctx, fleet, now); err != nil {
+			global.Logger.Warn("[FleetTracking] 轮询舰队成员失败", zap.String("fleet_id", fleet.ID), zap.Error(err))
+			continue
+		}
+		polled++
+	}
+	return polled, nil
+}
+
+// trackFleet 轮询一次舰队成员并更新在队时间段
+func (s *FleetService) trackFleet(ctx context.Context, fleet *model.Fleet, now time.Time) error {
+	members, known, err := s.fetchESIMembers(ctx, fleet)
+	if err != nil {
+		return err
+	}
+	open, err := s.repo.ListOpenSegments(fleet.ID)
+	if err != nil {
+		return err
+	}
+	openByChar := make(map[int64]model.FleetMemberSegment, len(open))
+	for _, seg := range open {
+		openByChar[seg.CharacterID] = seg
+	}
+
+	var touch, changed, left []uint
+	var created []model.FleetMemberSegment
+	present := make(map[int64]bool, len(members))
+	for _, em := range members {
+		char, ok := known[em.CharacterID]
+		if !ok {
+			continue
+		}
+		present[em.CharacterID] = true
+		if seg, ok := openByChar[em.CharacterID]; ok {
+			if seg.ShipTypeID == em.ShipTypeID && seg.SolarSystemID == em.SolarSystemID {
+				touch = append(touch, seg.ID)
+				continue
+			}
+			changed = append(changed, seg.ID)
+		}
+		created = append(created, model.FleetMemberSegment{
+			FleetID:       fleet.ID,
+			CharacterID:   em.CharacterID,
+			CharacterName: char.CharacterName,
+			UserID:        char.UserID,
+			ShipTypeID:    em.ShipTypeID,
+			SolarSystemID: em.SolarSystemID,
+			StartedAt:     now,
+			LastSeenAt:    now,
+		})
+	}
+	for charID, seg := range openByChar {
+		if !present[charID] {
+			left = append(left, seg.ID)
+		}
+	}
+
+	if err := s.repo.TouchSegments(touch, now); err != nil {
+		return err
+	}
+	if err := s.repo.CloseSegmentsAt(changed, now); err != nil {
+		return err
+	}
+	if err := s.repo.CloseSegmentsAtLastSeen(left); err != nil {
+		return err
+	}
+	return s.repo.CreateSegments(created)
+}
+
+// segmentEnd 时间段的结束时间（进行中的以最近在队时间计）
+func segmentEnd(seg *model.FleetMemberSegment) time.Time {
+	if seg.EndedAt != nil {
+		return *seg.EndedAt
+	}
+	return seg.LastSeenAt
+}
+
+// fleetPresence 按时间段统计每个角色的在队时长，并返回跟踪覆盖的时间范围
+func fleetPresence(segments []model.FleetMemberSegment) (map[int64]time.Duration, time.Time, time.Time) {
+	presence := make(map[int64]time.Duration)
+	var from, to time.Time
+	for i := range segments {
+		seg := &segments[i]
+		end := segmentEnd(seg)
+		presence[seg.CharacterID] += end.Sub(seg.StartedAt)
+		if from.IsZero() || seg.StartedAt.Before(from) {
+			from = seg.StartedAt
+		}
+		if end.After(to) {
+			to = end
+		}
+	}
+	return presence, from, to
+}
+
+// ─────────────────────────────────────────────
+//  行动复盘
+// ─────────────────────────────────────────────
+
+// FleetTrackingMember 单个成员的跟踪汇总
+type FleetTrackingMember struct {
+	CharacterID        int64                      `json:"character_id"`
+	CharacterName      string                     `json:"character_name"`
+	UserID             uint                       `json:"user_id"`
+	FirstSeen          time.Time                  `json:"first_seen"`
+	LastSeen           time.Time                  `json:"last_seen"`
+	PresentSeconds     int64                      `json:"present_seconds"`
+	PresenceRate       float64                    `json:"presence_rate"` // 在队时长 / 跟踪总时长（0-1）
+	InFleet            bool                       `json:"in_fleet"`      // 最近一次轮询仍在队中
+	LeftEarly          bool                       `json:"left_early"`
+	ShipChanges        int                        `json:"ship_changes"`
+	SystemChanges      int                        `json:"system_changes"`
+	Ships              []int64                    `json:"ships"`                // 按首次出现顺序
+	OffDoctrineSeconds int64                      `json:"off_doctrine_seconds"` // 驾驶非配置舰船的时长
+	Segments           []model.FleetMemberSegment `json:"segments"`
+}
+
+// FleetTrackingReport 舰队跟踪复盘
+type FleetTrackingReport struct {
+	FleetID           string                `json:"fleet_id"`
+	IntervalSeconds   int                   `json:"interval_seconds"`
+	TrackedFrom       *time.Time            `json:"tracked_from,omitempty"`
+	TrackedTo         *time.Time            `json:"tracked_to,omitempty"`
+	DoctrineShipTypes []int64               `json:"doctrine_ship_types"` // 舰队配置中的舰船（未关联配置时为空，不判断非配置舰船）
+	Members           []FleetTrackingMember `json:"members"`
+}
+
+// GetTrackingReport 汇总舰队跟踪记录：在队时长、提前离队、换船与非配置舰船
+func (s *FleetService) GetTrackingReport(fleetID string, userID uint, userRole string, scope *model.DataScope) (*FleetTrackingReport, error) {
+	fleet, err := s.repo.GetByID(fleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	segments, err := s.repo.ListSegments(fleetID)
+	if err != nil {
+		return nil, err
+	}
+	report := &FleetTrackingReport{
+		FleetID:           fleetID,
+		IntervalSeconds:   GetFleetTrackingConfig().IntervalSeconds,
+		DoctrineShipTypes: []int64{},
+		Members:           []FleetTrackingMember{},
+	}
+	doctrine := make(map[int64]bool)
+	if fleet.FleetConfigID != nil {
+		fittings, err := repository.NewFleetConfigRepository().ListFittingsByConfigID(*fleet.FleetConfigID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fittings {
+			if !doctrine[f.ShipTypeID] {
+				doctrine[f.ShipTypeID] = true
+				report.DoctrineShipTypes = append(report.DoctrineShipTypes, f.ShipTypeID)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return report, nil
+	}
+
+	presence, from, to := fleetPresence(segments)
+	report.TrackedFrom, report.TrackedTo = &from, &to
+	span := to.Sub(from).Seconds()
+
+	idx := make(map[int64]int)
+	for i := range segments {
+		seg := segments[i]
+		end := segmentEnd(&seg)
+		mi, ok := idx[seg.CharacterID]
+		if !ok {
+			mi = len(report.Members)
+			idx[seg.CharacterID] = mi
+			report.Members = append(report.Members, FleetTrackingMember{
+				CharacterID:   seg.CharacterID,
+				CharacterName: seg.CharacterName,
+				UserID:        seg.UserID,
+				FirstSeen:     seg.StartedAt,
+				Ships:         []int64{},
+				Segments:      []model.FleetMemberSegment{},
+			})
+		}
+		m := &report.Members[mi]
+		if n := len(m.Segments); n > 0 {
+			prev := m.Segments[n-1]
+			if prev.ShipTypeID != seg.ShipTypeID {
+				m.ShipChanges++
+			}
+			if prev.SolarSystemID != seg.SolarSystemID {
+				m.SystemChanges++
+			}
+		}
+		if !containsInt64(m.Ships, seg.ShipTypeID) {
+			m.Ships = append(m.Ships, seg.ShipTypeID)
+		}
+		if len(doctrine) > 0 && !doctrine[seg.ShipTypeID] {
+			m.OffDoctrineSeconds += int64(end.Sub(seg.StartedAt).Seconds())
+		}
+		if end.After(m.LastSeen) {
+			m.LastSeen = end
+		}
+		if seg.EndedAt == nil {
+			m.InFleet = true
+		}
+		m.Segments = append(m.Segments, seg)
+	}
+	for i := range report.Members {
+		m := &report.Members[i]
+		m.PresentSeconds = int64(presence[m.CharacterID].Seconds())
+		if span > 0 {
+			m.PresenceRate = math.Min(1, presence[m.CharacterID].Seconds()/span)
+		}
+		m.LeftEarly = !m.InFleet && m.LastSeen.Before(to.Add(-fleetLeftEarlyGrace))
+	}
+	return report, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  模拟登录
+//  管理员以成员身份创建一个限时会话：Token 携带发起人 ID（imp）与只读标记（ro），
+//  不下发 Refresh Token，到期后需重新发起；结束模拟时吊销该会话并回到管理员自己的会话
+// ─────────────────────────────────────────────
+
+const defaultImpersonationMinutes = 30
+
+// ImpersonationRequest 发起模拟登录参数
+type ImpersonationRequest struct {
+	ReadOnly bool `json:"read_only"` // 只读模式：禁止写操作
+	Minutes  int  `json:"minutes"`   // 有效分钟数，0 或超过上限时取配置上限
+}
+
+func impersonationMaxTTL() time.Duration {
+	if m := global.Config.JWT.ImpersonationMinutes; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return defaultImpersonationMinutes * time.Minute
+}
+
+// StartImpersonation 管理员以目标用户身份创建限时会话
+// adminSessionID 为管理员当前会话，结束模拟后据此恢复
+func (s *SessionService) StartImpersonation(ctx context.Context, adminID uint, adminSessionID string, target *model.User, req ImpersonationRequest) (*SessionTokens, error) {
+	if target.ID == adminID {
+		return nil, errors.New("不能模拟自己")
+	}
+	if target.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+	ttl := impersonationMaxTTL()
+	if req.Minutes > 0 && time.Duration(req.Minutes)*time.Minute < ttl {
+		ttl = time.Duration(req.Minutes) * time.Minute
+	}
+
+	session, _, err := s.newSession(ctx, target.ID, target.PrimaryCharacterID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	session.ImpersonatorID = adminID
+	session.ParentSessionID = adminSessionID
+	session.ReadOnly = req.ReadOnly
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+
+	global.Logger.Info("[Session] 管理员开始模拟登录",
+		zap.Uint("impersonator_id", adminID),
+		zap.Uint("user_id", target.ID),
+		zap.String("session_id", session.ID),
+		zap.Bool("read_only", req.ReadOnly),
+		zap.Duration("ttl", ttl))
+	return s.signAccess(ctx, session, target.Role, "")
+}
+
+// EndImpersonation 结束模拟登录：吊销模拟会话，并为管理员原会话重新签发 Token
+// 管理员原会话已失效时返回 ErrSessionRevoked，需重新登录
+func (s *SessionService) EndImpersonation(ctx context.Context, sessionID string) (*SessionTokens, error) {
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil || session.ImpersonatorID == 0 {
+		return nil, errors.New("当前不在模拟登录中")
+	}
+	if err := s.revoke(ctx, session.ID, model.SessionRevokeImpersonationEnd); err != nil {
+		return nil, err
+	}
+	global.Logger.Info("[Session] 管理员结束模拟登录",
+		zap.Uint("impersonator_id", session.ImpersonatorID),
+		zap.Uint("user_id", session.UserID),
+		zap.String("session_id", session.ID))
+
+	parent, err := s.repo.GetByID(session.ParentSessionID)
+	now := time.Now()
+	if err != nil || parent.UserID != session.ImpersonatorID || parent.RevokedAt != nil || parent.ExpiresAt.Before(now) {
+		return nil, ErrSessionRevoked
+	}
+	admin, err := s.userRepo.GetByID(parent.UserID)
+	if err != nil {
+		return nil, ErrSessionRevoked
+	}
+	if admin.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+
+	// 前端在模拟期间可能丢失了管理员的 Refresh Token，这里重新签发一个
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ResetRefreshToken(parent.ID, hash, clientInfoFrom(ctx).IP, now); err != nil {
+		return nil, err
+	}
+	return s.signAccess(ctx, parent, admin.Role, refresh)
+}
@@ -0,0 +1,556 @@
+package service
+
+import (
+	"amiya-eden/config"
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  市场价格
+//  定时拉取 ESI /markets/prices/（adjusted / average）与交易中心订单簿，
+//  供 SRP、资产、合同、击杀邮件等模块按物品估值
+// ─────────────────────────────────────────────
+
+// 估价方式
+const (
+	PriceKindAdjusted       = "adjusted"        // ESI adjusted_price（全宇宙，工业 / 保险基准）
+	PriceKindAverage        = "average"         // ESI average_price（全宇宙成交均价）
+	PriceKindBuy            = "buy"             // 交易中心最高收单价
+	PriceKindSell           = "sell"            // 交易中心最低卖单价
+	PriceKindBuyPercentile  = "buy_percentile"  // 交易中心收单 5% 加权价（剔除个别高价收单）
+	PriceKindSellPercentile = "sell_percentile" // 交易中心卖单 5% 加权价（剔除个别低价卖单）
+)
+
+const (
+	// marketPercentile 订单簿百分位价取最优 5% 挂单量的加权均价
+	marketPercentile = 0.05
+	// marketOrderPageWorkers 并发拉取订单簿分页的协程数
+	marketOrderPageWorkers = 8
+)
+
+// defaultMarketHubs 未配置 market.hubs 时默认拉取 Jita 4-4
+var defaultMarketHubs = []config.MarketHubConfig{
+	{Name: "jita", RegionID: 10000002, LocationID: 60003760},
+}
+
+// PriceBasis 估价基准：ESI 全宇宙价格，或某交易中心的订单簿价格
+type PriceBasis struct {
+	Hub  string `json:"hub,omitempty"` // 交易中心名称，adjusted / average 时为空
+	Kind string `json:"kind"`
+}
+
+// DefaultPriceBasis 默认估价基准：Jita 卖单 5% 加权价
+var DefaultPriceBasis = PriceBasis{Hub: "jita", Kind: PriceKindSellPercentile}
+
+// String 返回 "adjusted"、"jita_sell" 这样的字符串形式
+func (b PriceBasis) String() string {
+	if b.Hub == "" {
+		return b.Kind
+	}
+	return b.Hub + "_" + b.Kind
+}
+
+// ParsePriceBasis 解析估价基准字符串，空字符串返回 DefaultPriceBasis
+//
+//	adjusted | average | <hub>_buy | <hub>_sell | <hub>_buy_percentile | <hub>_sell_percentile
+func ParsePriceBasis(s string) (PriceBasis, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return DefaultPriceBasis, nil
+	case PriceKindAdjusted, PriceKindAverage:
+		return PriceBasis{Kind: s}, nil
+	}
+	for _, kind := range []string{PriceKindBuyPercentile, PriceKindSellPercentile, PriceKindBuy, PriceKindSell} {
+		if hub, ok := strings.CutSuffix(s, "_"+kind); ok && hub != "" {
+			return PriceBasis{Hub: hub, Kind: kind}, nil
+		}
+	}
+	return PriceBasis{}, fmt.Errorf("无效的估价方式: %s", s)
+}
+
+// MarketPriceService 市场价格业务逻辑层
+type MarketPriceService struct {
+	repo *repository.MarketRepository
+	http *http.Client
+}
+
+func NewMarketPriceService() *MarketPriceService {
+	return &MarketPriceService{
+		repo: repository.NewMarketRepository(),
+		http: eve.NewESIHTTPClient(60 * time.Second),
+	}
+}
+
+// Hubs 返回已配置的交易中心
+func (s *MarketPriceService) Hubs() []config.MarketHubConfig {
+	if global.Config != nil && len(global.Config.Market.Hubs) > 0 {
+		return global.Config.Market.Hubs
+	}
+	return defaultMarketHubs
+}
+
+// ─────────────────────────────────────────────
+//  价格拉取
+// ─────────────────────────────────────────────
+
+// RefreshAll 拉取 ESI 全宇宙价格与所有交易中心订单簿，单个来源失败不影响其它来源
+func (s *MarketPriceService) RefreshAll(ctx context.Context) error {
+	var errs []error
+	if err := s.RefreshESIPrices(ctx); err != nil {
+		global.Logger.Error("[Market] 拉取 ESI 市场价格失败", zap.Error(err))
+		errs = append(errs, err)
+	}
+	for _, hub := range s.Hubs() {
+		if err := s.RefreshHub(ctx, hub); err != nil {
+			global.Logger.Error("[Market] 拉取交易中心订单簿失败", zap.String("hub", hub.Name), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// esiMarketPrice ESI /markets/prices/ 响应项
+type esiMarketPrice struct {
+	TypeID        int64   `json:"type_id"`
+	AdjustedPrice float64 `json:"adjusted_price"`
+	AveragePrice  float64 `json:"average_price"`
+}
+
+// RefreshESIPrices 拉取 ESI 全宇宙 adjusted / average 价格
+func (s *MarketPriceService) RefreshESIPrices(ctx context.Context) error {
+	var list []esiMarketPrice
+	if _, err := s.esiGet(ctx, "/markets/prices/", &list); err != nil {
+		return err
+	}
+	prices := make([]model.MarketPrice, 0, len(list))
+	for _, p := range list {
+		prices = append(prices, model.MarketPrice{
+			TypeID:        p.TypeID,
+			AdjustedPrice: p.AdjustedPrice,
+			AveragePrice:  p.AveragePrice,
+		})
+	}
+	if err := s.repo.ReplacePrices(model.MarketPriceSourceESI, prices, time.Now()); err != nil {
+		return err
+	}
+	global.Logger.Info("[Market] ESI 市场价格已更新", zap.Int("types", len(prices)))
+	return nil
+}
+
+// esiMarketOrder ESI /markets/{region_id}/orders/ 响应项
+type esiMarketOrder struct {
+	TypeID       int64   `json:"type_id"`
+	LocationID   int64   `json:"location_id"`
+	IsBuyOrder   bool    `json:"is_buy_order"`
+	Price        float64 `json:"price"`
+	VolumeRemain int64   `json:"volume_remain"`
+}
+
+// RefreshHub 拉取交易中心所在星域的完整订单簿，按 location_id 过滤后汇总每个物品的价格
+func (s *MarketPriceService) RefreshHub(ctx context.Context, hub config.MarketHubConfig) error {
+	if hub.Name == "" || hub.RegionID == 0 {
+		return fmt.Errorf("交易中心配置不完整: %+v", hub)
+	}
+	source := strings.ToLower(hub.Name)
+	path := fmt.Sprintf("/markets/%d/orders/?order_type=all", hub.RegionID)
+
+	var first []esiMarketOrder
+	pages, err := s.esiGet(ctx, path+"&page=1", &first)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu      sync.Mutex
+		orders  = filterHubOrders(first, hub.LocationID)
+		pageErr error
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, marketOrderPageWorkers)
+	)
+	for page := 2; page <= pages; page++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(page int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var list []esiMarketOrder
+			if _, err := s.esiGet(ctx, fmt.Sprintf("%s&page=%d", path, page), &list); err != nil {
+				mu.Lock()
+				if pageErr == nil {
+					pageErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			filtered := filterHubOrders(list, hub.LocationID)
+			mu.Lock()
+			orders = append(orders, filtered...)
+			mu.Unlock()
+		}(page)
+	}
+	wg.Wait()
+	// 缺页会导致价格偏差，整体放弃本次结果，保留上一次快照
+	if pageErr != nil {
+		return pageErr
+	}
+
+	prices := aggregateOrders(orders)
+	if err := s.repo.ReplacePrices(source, prices, time.Now()); err != nil {
+		return err
+	}
+	global.Logger.Info("[Market] 交易中心价格已更新",
+		zap.String("hub", source),
+		zap.Int("pages", pages),
+		zap.Int("orders", len(orders)),
+		zap.Int("types", len(prices)),
+	)
+	return nil
+}
+
+// filterHubOrders 仅保留位于交易中心的订单（locationID 为 0 时保留整个星域）
+func filterHubOrders(orders []esiMarketOrder, locationID int64) []esiMarketOrder {
+	if locationID == 0 {
+		return orders
+	}
+	result := make([]esiMarketOrder, 0, len(orders))
+	for _, o := range orders {
+		if o.LocationID == locationID {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// aggregateOrders 按物品汇总订单簿
+func aggregateOrders(orders []esiMarketOrder) []model.MarketPrice {
+	buys := make(map[int64][]esiMarketOrder)
+	sells := make(map[int64][]esiMarketOrder)
+	for _, o := range orders {
+		if o.IsBuyOrder {
+			buys[o.TypeID] = append(buys[o.TypeID], o)
+		} else {
+			sells[o.TypeID] = append(sells[o.TypeID], o)
+		}
+	}
+
+	byType := make(map[int64]*model.MarketPrice)
+	get := func(typeID int64) *model.MarketPrice {
+		p, ok := byType[typeID]
+		if !ok {
+			p = &model.MarketPrice{TypeID: typeID}
+			byType[typeID] = p
+		}
+		return p
+	}
+	for typeID, list := range buys {
+		// 收单从高到低
+		sort.Slice(list, func(i, j int) bool { return list[i].Price > list[j].Price })
+		p := get(typeID)
+		p.BuyMax = list[0].Price
+		p.BuyPercentile, p.BuyVolume = percentilePrice(list)
+	}
+	for typeID, list := range sells {
+		// 卖单从低到高
+		sort.Slice(list, func(i, j int) bool { return list[i].Price < list[j].Price })
+		p := get(typeID)
+		p.SellMin = list[0].Price
+		p.SellPercentile, p.SellVolume = percentilePrice(list)
+	}
+
+	result := make([]model.MarketPrice, 0, len(byType))
+	for _, p := range byType {
+		result = append(result, *p)
+	}
+	return result
+}
+
+// percentilePrice 计算已按最优价排序的订单中，前 5% 挂单量的加权均价，并返回总挂单量
+func percentilePrice(sorted []esiMarketOrder) (float64, int64) {
+	var total int64
+	for _, o := range sorted {
+		total += o.VolumeRemain
+	}
+	if total == 0 {
+		return sorted[0].Price, 0
+	}
+	target := int64(math.Ceil(float64(total) * marketPercentile))
+	var (
+		filled int64
+		value  float64
+	)
+	for _, o := range sorted {
+		take := min(o.VolumeRemain, target-filled)
+		value += float64(take) * o.Price
+		filled += take
+		if filled >= target {
+			break
+		}
+	}
+	return roundISK(value / float64(filled)), total
+}
+
+// roundISK 金额保留两位小数
+func roundISK(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// esiGet 公开接口 GET 请求，返回 X-Pages 总页数
+func (s *MarketPriceService) esiGet(ctx context.Context, path string, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiURL(path), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("ESI GET %s 返回 %d: %s", path, resp.StatusCode, string(body))
+	}
+
+	pages := 1
+	if v := resp.Header.Get("X-Pages"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			pages = n
+		}
+	}
+	return pages, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ─────────────────────────────────────────────
+//  估值
+// ─────────────────────────────────────────────
+
+// ValuationItem 待估值物品
+type ValuationItem struct {
+	TypeID   int64 `json:"type_id"`
+	Quantity int64 `json:"quantity"`
+}
+
+// ValuationLine 单个物品的估值结果
+type ValuationLine struct {
+	TypeID    int64   `json:"type_id"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+	// Basis 实际采用的估价基准；交易中心无挂单时回退为 adjusted，均无价格时为空
+	Basis string `json:"basis"`
+}
+
+// Valuation 一组物品的估值结果
+type Valuation struct {
+	Basis   string          `json:"basis"`
+	Total   float64         `json:"total"`
+	Lines   []ValuationLine `json:"lines"`
+	Missing []int64         `json:"missing"` // 无任何价格的物品
+}
+
+// GetPrices 查询物品在所有来源的最新价格（type_id → 来源 → 价格）
+func (s *MarketPriceService) GetPrices(typeIDs []int64) (map[int64]map[string]model.MarketPrice, error) {
+	list, err := s.repo.GetPrices(typeIDs, "")
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64]map[string]model.MarketPrice, len(typeIDs))
+	for _, p := range list {
+		if result[p.TypeID] == nil {
+			result[p.TypeID] = make(map[string]model.MarketPrice)
+		}
+		result[p.TypeID][p.Source] = p
+	}
+	return result, nil
+}
+
+// GetUnitPrices 按估价基准查询单价；交易中心无挂单时回退为 adjusted，均无价格的物品不在结果中
+func (s *MarketPriceService) GetUnitPrices(typeIDs []int64, basis PriceBasis) (map[int64]float64, error) {
+	lines, err := s.unitPrices(typeIDs, basis)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64]float64, len(lines))
+	for typeID, line := range lines {
+		result[typeID] = line.UnitPrice
+	}
+	return result, nil
+}
+
+// unitPrices 按估价基准查询单价，返回的 ValuationLine 仅填充 TypeID / UnitPrice / Basis
+func (s *MarketPriceService) unitPrices(typeIDs []int64, basis PriceBasis) (map[int64]ValuationLine, error) {
+	prices, err := s.GetPrices(typeIDs)
+	if err != nil {
+		return nil, err
+	}
+	fallback := PriceBasis{Kind: PriceKindAdjusted}
+	result := make(map[int64]ValuationLine, len(prices))
+	for typeID, bySource := range prices {
+		if v := priceOf(bySource, basis); v > 0 {
+			result[typeID] = ValuationLine{TypeID: typeID, UnitPrice: v, Basis: basis.String()}
+		} else if v := priceOf(bySource, fallback); v > 0 && basis.Hub != "" {
+			result[typeID] = ValuationLine{TypeID: typeID, UnitPrice: v, Basis: fallback.String()}
+		}
+	}
+	return result, nil
+}
+
+// priceOf 按估价基准取价格
+func priceOf(bySource map[string]model.MarketPrice, basis PriceBasis) float64 {
+	if basis.Hub == "" {
+		p := bySource[model.MarketPriceSourceESI]
+		if basis.Kind == PriceKindAverage {
+			return p.AveragePrice
+		}
+		return p.AdjustedPrice
+	}
+	p, ok := bySource[basis.Hub]
+	if !ok {
+		return 0
+	}
+	switch basis.Kind {
+	case PriceKindBuy:
+		return p.BuyMax
+	case PriceKindSell:
+		return p.SellMin
+	case PriceKindBuyPercentile:
+		return p.BuyPercentile
+	case PriceKindSellPercentile:
+		return p.SellPercentile
+	}
+	return 0
+}
+
+// ValueItems 对一组物品估值（同一物品多行时合并数量）
+func (s *MarketPriceService) ValueItems(items []ValuationItem, basis PriceBasis) (*Valuation, error) {
+	quantities := make(map[int64]int64)
+	order := make([]int64, 0, len(items))
+	for _, it := range items {
+		if it.TypeID <= 0 || it.Quantity <= 0 {
+			continue
+		}
+		if _, ok := quantities[it.TypeID]; !ok {
+			order = append(order, it.TypeID)
+		}
+		quantities[it.TypeID] += it.Quantity
+	}
+
+	prices, err := s.unitPrices(order, basis)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Valuation{Basis: basis.String(), Lines: make([]ValuationLine, 0, len(order)), Missing: make([]int64, 0)}
+	for _, typeID := range order {
+		line, ok := prices[typeID]
+		if !ok {
+			line = ValuationLine{TypeID: typeID}
+			v.Missing = append(v.Missing, typeID)
+		}
+		line.Quantity = quantities[typeID]
+		line.Total = roundISK(line.UnitPrice * float64(line.Quantity))
+		v.Total += line.Total
+		v.Lines = append(v.Lines, line)
+	}
+	v.Total = roundISK(v.Total)
+	return v, nil
+}
+
+// ValueShip 舰船船体估值
+func (s *MarketPriceService) ValueShip(shipTypeID int64, basis PriceBasis) (float64, error) {
+	v, err := s.ValueItems([]ValuationItem{{TypeID: shipTypeID, Quantity: 1}}, basis)
+	if err != nil {
+		return 0, err
+	}
+	return v.Total, nil
+}
+
+// KillmailValuation 击杀邮件估值（船体 + 被摧毁 / 掉落的物品）
+type KillmailValuation struct {
+	KillmailID int64      `json:"killmail_id"`
+	ShipTypeID int64      `json:"ship_type_id"`
+	Hull       float64    `json:"hull"`
+	Destroyed  *Valuation `json:"destroyed"`
+	Dropped    *Valuation `json:"dropped"`
+	Total      float64    `json:"total"`
+}
+
+// ValueKillmail 按估价基准对击杀邮件估值
+func (s *MarketPriceService) ValueKillmail(killmailID int64, basis PriceBasis) (*KillmailValuation, error) {
+	var km model.EveKillmailList
+	if err := global.DB.Where("kill_mail_id = ?", killmailID).First(&km).Error; err != nil {
+		return nil, errors.New("KM 不存在")
+	}
+	var items []model.EveKillmailItem
+	if err := global.DB.Where("kill_mail_id = ?", killmailID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var destroyed, dropped []ValuationItem
+	for _, it := range items {
+		item := ValuationItem{TypeID: int64(it.ItemID), Quantity: it.ItemNum}
+		if it.DropType != nil && *it.DropType {
+			dropped = append(dropped, item)
+		} else {
+			destroyed = append(destroyed, item)
+		}
+	}
+
+	hull, err := s.ValueShip(km.ShipTypeID, basis)
+	if err != nil {
+		return nil, err
+	}
+	result := &KillmailValuation{KillmailID: killmailID, ShipTypeID: km.ShipTypeID, Hull: hull}
+	if result.Destroyed, err = s.ValueItems(destroyed, basis); err != nil {
+		return nil, err
+	}
+	if result.Dropped, err = s.ValueItems(dropped, basis); err != nil {
+		return nil, err
+	}
+	result.Total = roundISK(hull + result.Destroyed.Total + result.Dropped.Total)
+	return result, nil
+}
+
+// ─────────────────────────────────────────────
+//  查询
+// ─────────────────────────────────────────────
+
+// MarketStatus 各价格来源的更新状态
+type MarketStatus struct {
+	Hubs        []config.MarketHubConfig `json:"hubs"`
+	LastUpdated map[string]time.Time     `json:"last_updated"`
+}
+
+// GetStatus 查询已配置的交易中心及各来源最近更新时间
+func (s *MarketPriceService) GetStatus() (*MarketStatus, error) {
+	updated, err := s.repo.GetLastUpdated()
+	if err != nil {
+		return nil, err
+	}
+	return &MarketStatus{Hubs: s.Hubs(), LastUpdated: updated}, nil
+}
+
+// ListHistory 查询物品价格历史
+func (s *MarketPriceService) ListHistory(typeID int64, source string, start, end *time.Time) ([]model.MarketPriceHistory, error) {
+	return s.repo.ListHistory(typeID, strings.ToLower(source), start, end)
+}
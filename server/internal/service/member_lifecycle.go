@@ -0,0 +1,324 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  成员生命周期
+//  affiliation 刷新时记录角色军团 / 联盟的加入与离开事件，
+//  提供用户归属时间线、近期离队报表，以及加入敌对势力时的告警
+// ─────────────────────────────────────────────
+
+const maxDepartureDays = 365
+
+// MemberLifecycleService 成员生命周期业务逻辑层
+type MemberLifecycleService struct {
+	repo      *repository.AffiliationHistoryRepository
+	charRepo  *repository.EveCharacterRepository
+	userRepo  *repository.UserRepository
+	allowRepo *repository.AllowedEntityRepository
+}
+
+func NewMemberLifecycleService() *MemberLifecycleService {
+	return &MemberLifecycleService{
+		repo:      repository.NewAffiliationHistoryRepository(),
+		charRepo:  repository.NewEveCharacterRepository(),
+		userRepo:  repository.NewUserRepository(),
+		allowRepo: repository.NewAllowedEntityRepository(),
+	}
+}
+
+// ─── 事件记录 ───
+
+// RecordAffiliationChange 记录角色归属变化（old 为更新前的角色记录）；归属未变化时不写入
+func (s *MemberLifecycleService) RecordAffiliationChange(old *model.EveCharacter, corporationID int64, allianceID *int64) {
+	fromCorp, toCorp := old.CorporationID, corporationID
+	fromAlliance, toAlliance := int64(0), int64(0)
+	if old.AllianceID != nil {
+		fromAlliance = *old.AllianceID
+	}
+	if allianceID != nil {
+		toAlliance = *allianceID
+	}
+	if fromCorp == toCorp && fromAlliance == toAlliance {
+		return
+	}
+
+	hostile := s.matchHostile(fromCorp != toCorp, toCorp, fromAlliance != toAlliance, toAlliance)
+	base := model.CharacterAffiliationEvent{
+		CharacterID:       old.CharacterID,
+		UserID:            old.UserID,
+		FromCorporationID: fromCorp,
+		FromAllianceID:    fromAlliance,
+		ToCorporationID:   toCorp,
+		ToAllianceID:      toAlliance,
+		Initial:           fromCorp == 0,
+	}
+	newEvent := func(eventType string, join bool) model.CharacterAffiliationEvent {
+		e := base
+		e.EventType = eventType
+		e.Hostile = join && hostile != nil
+		return e
+	}
+
+	events := make([]model.CharacterAffiliationEvent, 0, 4)
+	if fromCorp != toCorp {
+		if fromCorp != 0 {
+			events = append(events, newEvent(model.AffiliationEventCorpLeave, false))
+		}
+		if toCorp != 0 {
+			events = append(events, newEvent(model.AffiliationEventCorpJoin, true))
+		}
+	}
+	if fromAlliance != toAlliance {
+		if fromAlliance != 0 {
+			events = append(events, newEvent(model.AffiliationEventAllianceLeave, false))
+		}
+		if toAlliance != 0 {
+			events = append(events, newEvent(model.AffiliationEventAllianceJoin, true))
+		}
+	}
+	if err := s.repo.CreateEvents(events); err != nil {
+		global.Logger.Warn("[Member] 写入归属事件失败", zap.Int64("character_id", old.CharacterID), zap.Error(err))
+	}
+
+	if hostile != nil {
+		s.alertHostile(old, hostile, base.Initial)
+	}
+}
+
+// matchHostile 新归属是否命中敌对观察名单（仅检查发生变化的军团 / 联盟）
+func (s *MemberLifecycleService) matchHostile(corpChanged bool, corpID int64, allianceChanged bool, allianceID int64) *model.AllowedEntity {
+	if (!corpChanged || corpID == 0) && (!allianceChanged || allianceID == 0) {
+		return nil
+	}
+	entities, err := s.allowRepo.List(model.WatchListHostile)
+	if err != nil {
+		global.Logger.Warn("[Member] 读取敌对观察名单失败", zap.Error(err))
+		return nil
+	}
+	for i, e := range entities {
+		switch {
+		case corpChanged && e.EntityType == model.AllowEntityTypeCorporation && e.EntityID == corpID:
+			return &entities[i]
+		case allianceChanged && e.EntityType == model.AllowEntityTypeAlliance && e.EntityID == allianceID:
+			return &entities[i]
+		}
+	}
+	return nil
+}
+
+// alertHostile 角色加入敌对势力：记录日志并通过 Webhook 告警，附带同账号的其他角色
+func (s *MemberLifecycleService) alertHostile(char *model.EveCharacter, hostile *model.AllowedEntity, initial bool) {
+	nickname := ""
+	mainCharID := int64(0)
+	if u, err := s.userRepo.GetByID(char.UserID); err == nil {
+		nickname = u.Nickname
+		mainCharID = u.PrimaryCharacterID
+	}
+	var alts []string
+	mainName := ""
+	if chars, err := s.charRepo.ListByUserID(char.UserID); err == nil {
+		for _, c := range chars {
+			if c.CharacterID == mainCharID {
+				mainName = c.CharacterName
+			}
+			if c.CharacterID != char.CharacterID {
+				alts = append(alts, fmt.Sprintf("%s（军团 %d）", c.CharacterName, c.CorporationID))
+			}
+		}
+	}
+
+	global.Logger.Warn("[Member] 角色加入敌对观察名单中的势力",
+		zap.Int64("character_id", char.CharacterID),
+		zap.Uint("user_id", char.UserID),
+		zap.String("entity_type", hostile.EntityType),
+		zap.Int64("entity_id", hostile.EntityID))
+
+	entityLabel := "联盟"
+	if hostile.EntityType == model.AllowEntityTypeCorporation {
+		entityLabel = "军团"
+	}
+	var b strings.Builder
+	b.WriteString("🚨 敌对势力告警\n")
+	fmt.Fprintf(&b, "角色 %s（用户 %s）加入了%s %s", char.CharacterName, nickname, entityLabel, hostile.EntityName)
+	if initial {
+		b.WriteString("（首次获取到归属）")
+	}
+	if mainName != "" {
+		fmt.Fprintf(&b, "\n主角色: %s", mainName)
+	}
+	if len(alts) > 0 {
+		fmt.Fprintf(&b, "\n同账号其他角色: %s", strings.Join(alts, "、"))
+	}
+	if err := NewWebhookService().SendAlert(b.String()); err != nil {
+		global.Logger.Warn("[Member] 发送敌对势力告警失败", zap.Error(err))
+	}
+}
+
+// ─── 归属时间线 ───
+
+// AffiliationTimelineItem 归属时间线条目
+type AffiliationTimelineItem struct {
+	model.CharacterAffiliationEvent
+	CharacterName string `json:"character_name"`
+}
+
+// GetUserTimeline 用户所有角色的归属事件时间线（按时间倒序）
+func (s *MemberLifecycleService) GetUserTimeline(userID uint) ([]AffiliationTimelineItem, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	events, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string)
+	if chars, err := s.charRepo.ListByUserID(userID); err == nil {
+		for _, c := range chars {
+			names[c.CharacterID] = c.CharacterName
+		}
+	}
+	items := make([]AffiliationTimelineItem, 0, len(events))
+	for _, e := range events {
+		items = append(items, AffiliationTimelineItem{CharacterAffiliationEvent: e, CharacterName: names[e.CharacterID]})
+	}
+	return items, nil
+}
+
+// ─── 离队报表 ───
+
+// DepartureAlt 离队用户仍留在军团 / 联盟内的其他角色
+type DepartureAlt struct {
+	CharacterID   int64  `json:"character_id"`
+	CharacterName string `json:"character_name"`
+	CorporationID int64  `json:"corporation_id"`
+}
+
+// DepartureItem 离队报表条目
+type DepartureItem struct {
+	model.CharacterAffiliationEvent
+	CharacterName string         `json:"character_name"`
+	Nickname      string         `json:"nickname"`
+	IsMain        bool           `json:"is_main"`        // 离开的是否为主角色
+	RemainingAlts []DepartureAlt `json:"remaining_alts"` // 同账号仍在军团 / 联盟内的角色
+}
+
+// ListDepartures 最近 days 天离开军团 / 联盟的角色
+// corporationIDs 为空时以 basic_access 准入名单（军团 + 联盟）为准；名单也为空时返回全部离开军团事件
+func (s *MemberLifecycleService) ListDepartures(page, pageSize, days int, corporationIDs []int64) ([]DepartureItem, int64, error) {
+	if days < 1 || days > maxDepartureDays {
+		return nil, 0, fmt.Errorf("天数须在 1-%d 之间", maxDepartureDays)
+	}
+	filter := repository.DepartureFilter{
+		Since:          time.Now().AddDate(0, 0, -days),
+		CorporationIDs: corporationIDs,
+	}
+	if len(filter.CorporationIDs) == 0 {
+		corps, alliances, err := s.allowRepo.GetAllIDs(model.AllowListBasicAccess)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.CorporationIDs, filter.AllianceIDs = corps, alliances
+	}
+
+	events, total, err := s.repo.ListDepartures(page, pageSize, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type userInfo struct {
+		nickname   string
+		mainCharID int64
+		chars      []model.EveCharacter
+	}
+	users := make(map[uint]*userInfo)
+	items := make([]DepartureItem, 0, len(events))
+	for _, e := range events {
+		info, ok := users[e.UserID]
+		if !ok {
+			info = &userInfo{}
+			if u, err := s.userRepo.GetByID(e.UserID); err == nil {
+				info.nickname = u.Nickname
+				info.mainCharID = u.PrimaryCharacterID
+			}
+			info.chars, _ = s.charRepo.ListByUserID(e.UserID)
+			users[e.UserID] = info
+		}
+
+		item := DepartureItem{
+			CharacterAffiliationEvent: e,
+			Nickname:                  info.nickname,
+			IsMain:                    e.CharacterID == info.mainCharID,
+			RemainingAlts:             []DepartureAlt{},
+		}
+		for _, c := range info.chars {
+			if c.CharacterID == e.CharacterID {
+				item.CharacterName = c.CharacterName
+				continue
+			}
+			if inAffiliation(&c, filter) {
+				item.RemainingAlts = append(item.RemainingAlts, DepartureAlt{
+					CharacterID:   c.CharacterID,
+					CharacterName: c.CharacterName,
+					CorporationID: c.CorporationID,
+				})
+			}
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// inAffiliation 角色当前是否在筛选的军团 / 联盟内（未指定范围时视为不在）
+func inAffiliation(c *model.EveCharacter, filter repository.DepartureFilter) bool {
+	if containsInt64(filter.CorporationIDs, c.CorporationID) {
+		return true
+	}
+	return c.AllianceID != nil && containsInt64(filter.AllianceIDs, *c.AllianceID)
+}
+
+// ─── 敌对观察名单 ───
+
+// ListHostileWatchlist 获取敌对观察名单
+func (s *MemberLifecycleService) ListHostileWatchlist() ([]model.AllowedEntity, error) {
+	return s.allowRepo.List(model.WatchListHostile)
+}
+
+// AddHostileEntity 添加军团 / 联盟到敌对观察名单
+func (s *MemberLifecycleService) AddHostileEntity(e *model.AllowedEntity) error {
+	if e.EntityType != model.AllowEntityTypeAlliance && e.EntityType != model.AllowEntityTypeCorporation {
+		return errors.New("无效的实体类型")
+	}
+	if e.EntityID <= 0 {
+		return errors.New("实体ID无效")
+	}
+	if e.EntityName == "" {
+		return errors.New("实体名称不能为空")
+	}
+	e.ListType = model.WatchListHostile
+	return s.allowRepo.Add(e)
+}
+
+// RemoveHostileEntity 从敌对观察名单删除
+func (s *MemberLifecycleService) RemoveHostileEntity(id uint) error {
+	entities, err := s.allowRepo.List(model.WatchListHostile)
+	if err != nil {
+		return err
+	}
+	for _, e := range entities {
+		if e.ID == id {
+			return s.allowRepo.Remove(id)
+		}
+	}
+	return errors.New("名单条目不存在")
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"sort"
 )
 
 type MenuService struct {
@@ -51,6 +52,45 @@ func (s *MenuService) DeleteMenu(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// PermissionItem 权限标识（来源于按钮类型菜单）
+type PermissionItem struct {
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	MenuName    string `json:"menu_name"`
+	ParentTitle string `json:"parent_title"`
+}
+
+// ListPermissions 列出所有权限标识，按所属菜单分组排序，供角色分配权限时参考
+func (s *MenuService) ListPermissions() ([]PermissionItem, error) {
+	menus, err := s.repo.ListAllIncludeDisabled()
+	if err != nil {
+		return nil, err
+	}
+	titleByID := make(map[uint]string, len(menus))
+	for _, m := range menus {
+		titleByID[m.ID] = m.Title
+	}
+	result := make([]PermissionItem, 0)
+	for _, m := range menus {
+		if m.Type != model.MenuTypeButton || m.Permission == "" {
+			continue
+		}
+		result = append(result, PermissionItem{
+			Code:        m.Permission,
+			Title:       m.Title,
+			MenuName:    m.Name,
+			ParentTitle: titleByID[m.ParentID],
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ParentTitle != result[j].ParentTitle {
+			return result[i].ParentTitle < result[j].ParentTitle
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result, nil
+}
+
 // ─── 用户菜单（前端路由用）───
 
 // GetUserMenuTree 获取用户可访问的菜单树（前端路由格式）
@@ -69,15 +109,21 @@ func (s *MenuService) GetUserMenuTree(userID uint, roleCodes []string) ([]*model
 	if err != nil {
 		return nil, err
 	}
-	if len(roleIDs) == 0 {
-		return []*model.MenuItem{}, nil
-	}
 
 	// 获取所有角色的菜单ID并集
-	menuIDs, err := s.roleRepo.GetMenuIDsByRoles(roleIDs)
+	menuIDs := make([]uint, 0)
+	if len(roleIDs) > 0 {
+		if menuIDs, err = s.roleRepo.GetMenuIDsByRoles(roleIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// 限定作用范围的角色只开放支持范围限定的按钮（所在页面由 ensureParentMenus 补全）
+	scopedMenuIDs, err := s.scopedMenuIDs(userID)
 	if err != nil {
 		return nil, err
 	}
+	menuIDs = append(menuIDs, scopedMenuIDs...)
 	if len(menuIDs) == 0 {
 		return []*model.MenuItem{}, nil
 	}
@@ -94,6 +140,29 @@ func (s *MenuService) GetUserMenuTree(userID uint, roleCodes []string) ([]*model
 	return repository.BuildMenuTree(menus), nil
 }
 
+// scopedMenuIDs 限定作用范围的角色可见的按钮菜单 ID
+func (s *MenuService) scopedMenuIDs(userID uint) ([]uint, error) {
+	roleIDs, err := s.roleRepo.GetUserScopedRoleIDs(userID)
+	if err != nil || len(roleIDs) == 0 {
+		return nil, err
+	}
+	menuIDs, err := s.roleRepo.GetMenuIDsByRoles(roleIDs)
+	if err != nil || len(menuIDs) == 0 {
+		return nil, err
+	}
+	menus, err := s.repo.ListByIDs(menuIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0)
+	for _, m := range menus {
+		if m.Type == model.MenuTypeButton && model.IsScopablePermission(m.Permission) {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids, nil
+}
+
 // ensureParentMenus 确保所有菜单的父目录菜单也被包含
 func (s *MenuService) ensureParentMenus(menus []model.Menu, existingIDs []uint) []model.Menu {
 	idSet := make(map[uint]bool, len(existingIDs))
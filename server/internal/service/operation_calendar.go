@@ -0,0 +1,1017 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ─────────────────────────────────────────────
+//  行动日历
+//  周期模板按 EVE 时间（UTC）每周固定时间生成未来 operationGenerateDays 天的行动；
+//  成员报名意向装配 / 职责；定时任务按提前量发送 Webhook 提醒；
+//  每个用户可生成私有令牌订阅 iCal
+// ─────────────────────────────────────────────
+
+const (
+	operationGenerateDays   = 14
+	maxOperationListDays    = 120
+	maxReminderOffsets      = 5
+	maxReminderOffset       = 7 * 24 * 60 // 最长提前 7 天提醒
+	maxOperationDuration    = 24 * 60
+	calendarFeedTokenPrefix = "cal_"
+	calendarFeedPastDays    = 30
+	calendarFeedFutureDays  = 90
+)
+
+var defaultReminderOffsets = []int{60, 15}
+
+var validRSVPStatuses = map[string]bool{model.RSVPYes: true, model.RSVPMaybe: true, model.RSVPNo: true}
+
+var validRSVPRoles = map[string]bool{
+	"":                   true,
+	model.RSVPRoleDPS:    true,
+	model.RSVPRoleLogi:   true,
+	model.RSVPRoleTackle: true,
+	model.RSVPRoleEwar:   true,
+	model.RSVPRoleBoost:  true,
+	model.RSVPRoleScout:  true,
+	model.RSVPRoleOther:  true,
+}
+
+// OperationCalendarService 行动日历业务逻辑层
+type OperationCalendarService struct {
+	repo       *repository.OperationCalendarRepository
+	charRepo   *repository.EveCharacterRepository
+	configRepo *repository.FleetConfigRepository
+	webhookSvc *WebhookService
+}
+
+func NewOperationCalendarService() *OperationCalendarService {
+	return &OperationCalendarService{
+		repo:       repository.NewOperationCalendarRepository(),
+		charRepo:   repository.NewEveCharacterRepository(),
+		configRepo: repository.NewFleetConfigRepository(),
+		webhookSvc: NewWebhookService(),
+	}
+}
+
+// ─────────────────────────────────────────────
+//  通用校验
+// ─────────────────────────────────────────────
+
+// normalizeReminderOffsets 去重并倒序排列提醒提前量；nil 时使用默认值
+func normalizeReminderOffsets(offsets []int) ([]int, error) {
+	if offsets == nil {
+		return append([]int{}, defaultReminderOffsets...), nil
+	}
+	if len(offsets) > maxReminderOffsets {
+		return nil, fmt.Errorf("提醒最多设置 %d 个", maxReminderOffsets)
+	}
+	seen := make(map[int]bool, len(offsets))
+	result := make([]int, 0, len(offsets))
+	for _, o := range offsets {
+		if o <= 0 || o > maxReminderOffset {
+			return nil, fmt.Errorf("提醒提前量须在 1-%d 分钟之间", maxReminderOffset)
+		}
+		if !seen[o] {
+			seen[o] = true
+			result = append(result, o)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(result)))
+	return result, nil
+}
+
+// fcCharacter 校验 FC 角色属于当前用户
+func (s *OperationCalendarService) fcCharacter(userID uint, characterID int64) (*model.EveCharacter, error) {
+	char, err := s.charRepo.GetByCharacterID(characterID)
+	if err != nil {
+		return nil, errors.New("角色不存在")
+	}
+	if char.UserID != userID {
+		return nil, errors.New("该角色不属于当前用户")
+	}
+	return char, nil
+}
+
+// checkFleetConfig 校验舰队配置存在
+func (s *OperationCalendarService) checkFleetConfig(id *uint) error {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	if _, err := s.configRepo.GetByID(*id); err != nil {
+		return errors.New("舰队配置不存在")
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────
+//  周期模板
+// ─────────────────────────────────────────────
+
+// OperationTemplateRequest 创建 / 更新周期模板
+type OperationTemplateRequest struct {
+	Title           string `json:"title"            binding:"required,max=256"`
+	Description     string `json:"description"`
+	Importance      string `json:"importance"       binding:"required,oneof=strat_op cta other"`
+	FleetConfigID   *uint  `json:"fleet_config_id"`
+	Weekdays        []int  `json:"weekdays"         binding:"required,min=1"`
+	TimeOfDay       string `json:"time_of_day"      binding:"required"` // HH:MM（UTC）
+	DurationMinutes int    `json:"duration_minutes" binding:"required,gt=0"`
+	ReminderOffsets []int  `json:"reminder_offsets"`
+	CharacterID     int64  `json:"character_id"     binding:"required"` // FC 角色
+	Enabled         *bool  `json:"enabled"`
+}
+
+// ListTemplates 模板列表
+func (s *OperationCalendarService) ListTemplates() ([]model.OperationTemplate, error) {
+	return s.repo.ListTemplates()
+}
+
+// CreateTemplate 创建模板并立即生成未来的行动
+func (s *OperationCalendarService) CreateTemplate(userID uint, req *OperationTemplateRequest) (*model.OperationTemplate, error) {
+	t := &model.OperationTemplate{CreatedBy: userID, FCUserID: userID, Enabled: true}
+	if err := s.applyTemplateRequest(t, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateTemplate(t); err != nil {
+		return nil, err
+	}
+	if err := s.syncTemplateOperations(t, time.Now()); err != nil {
+		global.Logger.Warn("[OpCalendar] 生成模板行动失败", zap.Uint("template_id", t.ID), zap.Error(err))
+	}
+	return t, nil
+}
+
+// UpdateTemplate 更新模板，并按新排期调整未来的行动（模板 FC、管理员或数据范围覆盖该 FC 的用户；FC 不变）
+func (s *OperationCalendarService) UpdateTemplate(id, userID uint, userRole string, scope *model.DataScope, req *OperationTemplateRequest) (*model.OperationTemplate, error) {
+	t, err := s.repo.GetTemplate(id)
+	if err != nil {
+		return nil, errors.New("模板不存在")
+	}
+	if !canManageAsFC(t.FCUserID, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	if err := s.applyTemplateRequest(t, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateTemplate(t); err != nil {
+		return nil, err
+	}
+	if err := s.syncTemplateOperations(t, time.Now()); err != nil {
+		global.Logger.Warn("[OpCalendar] 同步模板行动失败", zap.Uint("template_id", t.ID), zap.Error(err))
+	}
+	return t, nil
+}
+
+// DeleteTemplate 删除模板：未来无人报名的行动直接删除，已有报名的标记为取消
+func (s *OperationCalendarService) DeleteTemplate(id, userID uint, userRole string, scope *model.DataScope) error {
+	t, err := s.repo.GetTemplate(id)
+	if err != nil {
+		return errors.New("模板不存在")
+	}
+	if !canManageAsFC(t.FCUserID, userID, userRole, scope) {
+		return errors.New("权限不足")
+	}
+	t.Enabled = false
+	if err := s.syncTemplateOperations(t, time.Now()); err != nil {
+		return err
+	}
+	return s.repo.DeleteTemplate(id)
+}
+
+// applyTemplateRequest 写入请求内容；FC 角色须属于模板的 FC（t.FCUserID）
+func (s *OperationCalendarService) applyTemplateRequest(t *model.OperationTemplate, req *OperationTemplateRequest) error {
+	if _, err := parseTimeOfDay(req.TimeOfDay); err != nil {
+		return err
+	}
+	if req.DurationMinutes > maxOperationDuration {
+		return fmt.Errorf("行动时长不能超过 %d 分钟", maxOperationDuration)
+	}
+	weekdays := make([]int, 0, len(req.Weekdays))
+	seen := make(map[int]bool)
+	for _, d := range req.Weekdays {
+		if d < 0 || d > 6 {
+			return errors.New("星期须在 0-6 之间（0 为周日）")
+		}
+		if !seen[d] {
+			seen[d] = true
+			weekdays = append(weekdays, d)
+		}
+	}
+	sort.Ints(weekdays)
+	offsets, err := normalizeReminderOffsets(req.ReminderOffsets)
+	if err != nil {
+		return err
+	}
+	if err := s.checkFleetConfig(req.FleetConfigID); err != nil {
+		return err
+	}
+	char, err := s.fcCharacter(t.FCUserID, req.CharacterID)
+	if err != nil {
+		return err
+	}
+
+	t.Title = req.Title
+	t.Description = req.Description
+	t.Importance = req.Importance
+	t.FleetConfigID = req.FleetConfigID
+	t.Weekdays = weekdays
+	t.TimeOfDay = req.TimeOfDay
+	t.DurationMinutes = req.DurationMinutes
+	t.ReminderOffsets = offsets
+	t.FCCharacterName = char.CharacterName
+	if req.Enabled != nil {
+		t.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// parseTimeOfDay 解析 HH:MM，返回距当日 0 点的时长
+func parseTimeOfDay(v string) (time.Duration, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, errors.New("时间格式错误（需 HH:MM）")
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// templateOccurrences 模板在 (from, to) 内的开始时间（UTC）
+func templateOccurrences(t *model.OperationTemplate, from, to time.Time) []time.Time {
+	offset, err := parseTimeOfDay(t.TimeOfDay)
+	if err != nil {
+		return nil
+	}
+	days := make(map[int]bool, len(t.Weekdays))
+	for _, d := range t.Weekdays {
+		days[d] = true
+	}
+	from, to = from.UTC(), to.UTC()
+	var result []time.Time
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !days[int(day.Weekday())] {
+			continue
+		}
+		start := day.Add(offset)
+		if start.After(from) && start.Before(to) {
+			result = append(result, start)
+		}
+	}
+	return result
+}
+
+// GenerateFromTemplates 为所有启用的模板生成未来的行动（定时任务调用）
+func (s *OperationCalendarService) GenerateFromTemplates(now time.Time) (int, error) {
+	templates, err := s.repo.ListEnabledTemplates()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for i := range templates {
+		n, err := s.generateTemplateOperations(&templates[i], now)
+		if err != nil {
+			global.Logger.Warn("[OpCalendar] 生成模板行动失败", zap.Uint("template_id", templates[i].ID), zap.Error(err))
+			continue
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// generateTemplateOperations 补齐模板未来的行动（已生成过的时间点不重复生成，包括已取消的）
+func (s *OperationCalendarService) generateTemplateOperations(t *model.OperationTemplate, now time.Time) (int, error) {
+	if !t.Enabled {
+		return 0, nil
+	}
+	created := 0
+	for _, start := range templateOccurrences(t, now, now.AddDate(0, 0, operationGenerateDays)) {
+		exists, err := s.repo.TemplateOperationExists(t.ID, start)
+		if err != nil {
+			return created, err
+		}
+		if exists {
+			continue
+		}
+		templateID, occurrence := t.ID, start
+		op := &model.FleetOperation{TemplateID: &templateID, OccurrenceAt: &occurrence, StartAt: start, CreatedBy: t.CreatedBy, Status: model.OperationStatusScheduled}
+		applyTemplateToOperation(t, op)
+		if err := s.repo.CreateOperation(op); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// syncTemplateOperations 模板变更后调整未来的行动：排期仍匹配的更新详情，不再匹配的删除（有报名时标记取消），再补齐缺少的
+func (s *OperationCalendarService) syncTemplateOperations(t *model.OperationTemplate, now time.Time) error {
+	expected := make(map[int64]bool)
+	if t.Enabled {
+		for _, start := range templateOccurrences(t, now, now.AddDate(0, 0, operationGenerateDays)) {
+			expected[start.Unix()] = true
+		}
+	}
+	ops, err := s.repo.ListTemplateOperationsAfter(t.ID, now)
+	if err != nil {
+		return err
+	}
+	for i := range ops {
+		op := &ops[i]
+		if op.OccurrenceAt != nil && expected[op.OccurrenceAt.Unix()] {
+			if op.Status == model.OperationStatusScheduled {
+				applyTemplateToOperation(t, op)
+				if err := s.repo.UpdateOperation(op); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		count, err := s.repo.CountRSVPs(op.ID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := s.repo.PurgeOperation(op.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if op.Status != model.OperationStatusCancelled {
+			op.Status = model.OperationStatusCancelled
+			if err := s.repo.UpdateOperation(op); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = s.generateTemplateOperations(t, now)
+	return err
+}
+
+// applyTemplateToOperation 用模板详情覆盖行动（单独改期过的行动保留其开始时间）
+func applyTemplateToOperation(t *model.OperationTemplate, op *model.FleetOperation) {
+	op.Title = t.Title
+	op.Description = t.Description
+	op.Importance = t.Importance
+	op.FleetConfigID = t.FleetConfigID
+	op.EndAt = op.StartAt.Add(time.Duration(t.DurationMinutes) * time.Minute)
+	op.ReminderOffsets = t.ReminderOffsets
+	op.FCUserID = t.FCUserID
+	op.FCCharacterName = t.FCCharacterName
+}
+
+// ─────────────────────────────────────────────
+//  排期行动
+// ─────────────────────────────────────────────
+
+// OperationRequest 创建 / 更新单次行动
+type OperationRequest struct {
+	Title           string `json:"title"       binding:"required,max=256"`
+	Description     string `json:"description"`
+	Importance      string `json:"importance"  binding:"required,oneof=strat_op cta other"`
+	FleetConfigID   *uint  `json:"fleet_config_id"`
+	StartAt         string `json:"start_at"    binding:"required"` // RFC3339
+	EndAt           string `json:"end_at"      binding:"required"` // RFC3339
+	ReminderOffsets []int  `json:"reminder_offsets"`
+	CharacterID     int64  `json:"character_id" binding:"required"` // FC 角色
+}
+
+// OperationListItem 日历条目（含报名统计与当前用户的报名）
+type OperationListItem struct {
+	model.FleetOperation
+	Going    int                  `json:"going"`
+	Maybe    int                  `json:"maybe"`
+	Declined int                  `json:"declined"`
+	MyRSVP   *model.OperationRSVP `json:"my_rsvp"`
+}
+
+// ListOperations 查询时间范围内的行动
+func (s *OperationCalendarService) ListOperations(userID uint, from, to time.Time) ([]OperationListItem, error) {
+	if !to.After(from) {
+		return nil, errors.New("结束时间必须晚于开始时间")
+	}
+	if to.Sub(from) > maxOperationListDays*24*time.Hour {
+		return nil, fmt.Errorf("查询范围不能超过 %d 天", maxOperationListDays)
+	}
+	ops, err := s.repo.ListOperations(from, to, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.withRSVPSummary(userID, ops)
+}
+
+// GetOperation 行动详情
+func (s *OperationCalendarService) GetOperation(userID, id uint) (*OperationListItem, error) {
+	op, err := s.repo.GetOperation(id)
+	if err != nil {
+		return nil, errors.New("行动不存在")
+	}
+	items, err := s.withRSVPSummary(userID, []model.FleetOperation{*op})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *OperationCalendarService) withRSVPSummary(userID uint, ops []model.FleetOperation) ([]OperationListItem, error) {
+	ids := make([]uint, 0, len(ops))
+	for _, op := range ops {
+		ids = append(ids, op.ID)
+	}
+	counts, err := s.repo.CountRSVPsByStatus(ids)
+	if err != nil {
+		return nil, err
+	}
+	mine, err := s.repo.ListUserRSVPs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	type summary struct{ going, maybe, declined int }
+	byOp := make(map[uint]*summary, len(ops))
+	for _, c := range counts {
+		sm := byOp[c.OperationID]
+		if sm == nil {
+			sm = &summary{}
+			byOp[c.OperationID] = sm
+		}
+		switch c.Status {
+		case model.RSVPYes:
+			sm.going = c.Count
+		case model.RSVPMaybe:
+			sm.maybe = c.Count
+		case model.RSVPNo:
+			sm.declined = c.Count
+		}
+	}
+	mineByOp := make(map[uint]*model.OperationRSVP, len(mine))
+	for i := range mine {
+		mineByOp[mine[i].OperationID] = &mine[i]
+	}
+
+	items := make([]OperationListItem, 0, len(ops))
+	for _, op := range ops {
+		item := OperationListItem{FleetOperation: op, MyRSVP: mineByOp[op.ID]}
+		if sm := byOp[op.ID]; sm != nil {
+			item.Going, item.Maybe, item.Declined = sm.going, sm.maybe, sm.declined
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// CreateOperation 创建单次行动
+func (s *OperationCalendarService) CreateOperation(userID uint, req *OperationRequest) (*model.FleetOperation, error) {
+	op := &model.FleetOperation{CreatedBy: userID, FCUserID: userID, Status: model.OperationStatusScheduled}
+	if err := s.applyOperationRequest(op, req); err != nil {
+		return nil, err
+	}
+	if op.StartAt.Before(time.Now()) {
+		return nil, errors.New("开始时间不能早于当前时间")
+	}
+	if err := s.repo.CreateOperation(op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// UpdateOperation 更新行动（修改开始时间后会重新发送尚未到期的提醒）
+// 权限规则与舰队相同：admin、行动 FC，或限定范围角色覆盖 FC 所在军团；FC 不随编辑人变化
+func (s *OperationCalendarService) UpdateOperation(id, userID uint, userRole string, scope *model.DataScope, req *OperationRequest) (*model.FleetOperation, error) {
+	op, err := s.repo.GetOperation(id)
+	if err != nil {
+		return nil, errors.New("行动不存在")
+	}
+	if !canManageAsFC(op.FCUserID, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	if op.Status == model.OperationStatusCancelled {
+		return nil, errors.New("行动已取消")
+	}
+	oldStart := op.StartAt
+	if err := s.applyOperationRequest(op, req); err != nil {
+		return nil, err
+	}
+	if !op.StartAt.Equal(oldStart) {
+		op.RemindersSent = nil
+	}
+	if err := s.repo.UpdateOperation(op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// applyOperationRequest 写入请求内容；FC 角色须属于行动的 FC（op.FCUserID）
+func (s *OperationCalendarService) applyOperationRequest(op *model.FleetOperation, req *OperationRequest) error {
+	startAt, err := time.Parse(time.RFC3339, req.StartAt)
+	if err != nil {
+		return errors.New("起始时间格式错误（需 RFC3339）")
+	}
+	endAt, err := time.Parse(time.RFC3339, req.EndAt)
+	if err != nil {
+		return errors.New("结束时间格式错误（需 RFC3339）")
+	}
+	if !endAt.After(startAt) {
+		return errors.New("结束时间必须晚于起始时间")
+	}
+	if endAt.Sub(startAt) > maxOperationDuration*time.Minute {
+		return fmt.Errorf("行动时长不能超过 %d 分钟", maxOperationDuration)
+	}
+	offsets, err := normalizeReminderOffsets(req.ReminderOffsets)
+	if err != nil {
+		return err
+	}
+	if err := s.checkFleetConfig(req.FleetConfigID); err != nil {
+		return err
+	}
+	char, err := s.fcCharacter(op.FCUserID, req.CharacterID)
+	if err != nil {
+		return err
+	}
+
+	op.Title = req.Title
+	op.Description = req.Description
+	op.Importance = req.Importance
+	op.FleetConfigID = req.FleetConfigID
+	op.StartAt = startAt.UTC()
+	op.EndAt = endAt.UTC()
+	op.ReminderOffsets = offsets
+	op.FCCharacterName = char.CharacterName
+	return nil
+}
+
+// CancelOperation 取消行动（权限规则同 UpdateOperation）
+func (s *OperationCalendarService) CancelOperation(id, userID uint, userRole string, scope *model.DataScope) error {
+	op, err := s.repo.GetOperation(id)
+	if err != nil {
+		return errors.New("行动不存在")
+	}
+	if !canManageAsFC(op.FCUserID, userID, userRole, scope) {
+		return errors.New("权限不足")
+	}
+	if op.Status == model.OperationStatusCancelled {
+		return nil
+	}
+	op.Status = model.OperationStatusCancelled
+	return s.repo.UpdateOperation(op)
+}
+
+// FormUpRequest 按排期行动集结舰队
+type FormUpRequest struct {
+	CharacterID int64   `json:"character_id" binding:"required"` // FC 角色
+	PapCount    float64 `json:"pap_count"`
+	SendPing    bool    `json:"send_ping"`
+	AutoSrpMode string  `json:"auto_srp_mode"`
+}
+
+// FormUp 按排期行动创建舰队并关联（行动 FC、管理员或数据范围覆盖该 FC 的用户）
+// 行动行锁保持到关联完成，并发集结时后到的请求会看到已关联的舰队
+func (s *OperationCalendarService) FormUp(id, userID uint, userRole string, scope *model.DataScope, req *FormUpRequest) (*model.Fleet, error) {
+	var fleet *model.Fleet
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := s.repo.GetOperationForUpdateTx(tx, id)
+		if err != nil {
+			return errors.New("行动不存在")
+		}
+		if !canManageAsFC(op.FCUserID, userID, userRole, scope) {
+			return errors.New("权限不足")
+		}
+		if op.Status == model.OperationStatusCancelled {
+			return errors.New("行动已取消")
+		}
+		if op.FleetID != nil {
+			return errors.New("该行动已集结舰队")
+		}
+		fleet, err = NewFleetService().CreateFleet(userID, &CreateFleetRequest{
+			Title:         op.Title,
+			Description:   op.Description,
+			StartAt:       op.StartAt.Format(time.RFC3339),
+			EndAt:         op.EndAt.Format(time.RFC3339),
+			Importance:    op.Importance,
+			PapCount:      req.PapCount,
+			CharacterID:   req.CharacterID,
+			SendPing:      req.SendPing,
+			FleetConfigID: op.FleetConfigID,
+			AutoSrpMode:   req.AutoSrpMode,
+		})
+		if err != nil {
+			return err
+		}
+		op.FleetID = &fleet.ID
+		return s.repo.UpdateOperationTx(tx, op)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fleet, nil
+}
+
+// ─────────────────────────────────────────────
+//  报名
+// ─────────────────────────────────────────────
+
+// RSVPRequest 报名
+type RSVPRequest struct {
+	Status      string `json:"status"       binding:"required,oneof=yes maybe no"`
+	CharacterID int64  `json:"character_id" binding:"required"`
+	FittingID   *uint  `json:"fitting_id"`   // 行动舰队配置中的装配
+	ShipTypeID  *int64 `json:"ship_type_id"` // 未选装配时的意向舰船
+	Role        string `json:"role"`
+	Note        string `json:"note"         binding:"max=256"`
+}
+
+// SetRSVP 报名 / 修改报名
+func (s *OperationCalendarService) SetRSVP(operationID, userID uint, req *RSVPRequest) (*model.OperationRSVP, error) {
+	op, err := s.repo.GetOperation(operationID)
+	if err != nil {
+		return nil, errors.New("行动不存在")
+	}
+	if op.Status == model.OperationStatusCancelled {
+		return nil, errors.New("行动已取消")
+	}
+	if !op.EndAt.After(time.Now()) {
+		return nil, errors.New("行动已结束")
+	}
+	if !validRSVPStatuses[req.Status] {
+		return nil, errors.New("无效的报名状态")
+	}
+	if !validRSVPRoles[req.Role] {
+		return nil, errors.New("无效的职责")
+	}
+	char, err := s.charRepo.GetByCharacterID(req.CharacterID)
+	if err != nil || char.UserID != userID {
+		return nil, errors.New("该角色不属于当前用户")
+	}
+	rsvp := &model.OperationRSVP{
+		OperationID:   operationID,
+		UserID:        userID,
+		CharacterID:   char.CharacterID,
+		CharacterName: char.CharacterName,
+		Status:        req.Status,
+		Role:          req.Role,
+		Note:          req.Note,
+	}
+	if req.FittingID != nil && *req.FittingID > 0 {
+		fitting, err := s.configRepo.GetFittingByID(*req.FittingID)
+		if err != nil || op.FleetConfigID == nil || fitting.FleetConfigID != *op.FleetConfigID {
+			return nil, errors.New("装配不属于该行动的舰队配置")
+		}
+		rsvp.FittingID = &fitting.ID
+		shipTypeID := fitting.ShipTypeID
+		rsvp.ShipTypeID = &shipTypeID
+	} else if req.ShipTypeID != nil && *req.ShipTypeID > 0 {
+		rsvp.ShipTypeID = req.ShipTypeID
+	}
+	if err := s.repo.UpsertRSVP(rsvp); err != nil {
+		return nil, err
+	}
+	return rsvp, nil
+}
+
+// DeleteRSVP 撤销报名
+func (s *OperationCalendarService) DeleteRSVP(operationID, userID uint) error {
+	return s.repo.DeleteRSVP(operationID, userID)
+}
+
+// TurnoutShip 按装配 / 舰船统计的预计出勤
+type TurnoutShip struct {
+	FittingID   *uint  `json:"fitting_id,omitempty"`
+	FittingName string `json:"fitting_name,omitempty"`
+	ShipTypeID  int64  `json:"ship_type_id"`
+	Going       int    `json:"going"`
+	Maybe       int    `json:"maybe"`
+}
+
+// TurnoutRole 按职责统计的预计出勤
+type TurnoutRole struct {
+	Role  string `json:"role"`
+	Going int    `json:"going"`
+	Maybe int    `json:"maybe"`
+}
+
+// OperationTurnout 行动预计出勤（供 FC 集结前查看）
+type OperationTurnout struct {
+	Operation   *model.FleetOperation `json:"operation"`
+	Going       int                   `json:"going"`
+	Maybe       int                   `json:"maybe"`
+	Declined    int                   `json:"declined"`
+	Doctrine    []TurnoutShip         `json:"doctrine"`     // 舰队配置中的每个装配（含无人报名的）
+	OffDoctrine []TurnoutShip         `json:"off_doctrine"` // 未选配置装配的意向舰船
+	Unspecified TurnoutShip           `json:"unspecified"`  // 未填写舰船
+	Roles       []TurnoutRole         `json:"roles"`
+	RSVPs       []model.OperationRSVP `json:"rsvps"`
+}
+
+// GetTurnout 统计行动预计出勤
+func (s *OperationCalendarService) GetTurnout(id uint) (*OperationTurnout, error) {
+	op, err := s.repo.GetOperation(id)
+	if err != nil {
+		return nil, errors.New("行动不存在")
+	}
+	rsvps, err := s.repo.ListRSVPs(id)
+	if err != nil {
+		return nil, err
+	}
+	result := &OperationTurnout{
+		Operation:   op,
+		Doctrine:    []TurnoutShip{},
+		OffDoctrine: []TurnoutShip{},
+		Roles:       []TurnoutRole{},
+		RSVPs:       rsvps,
+	}
+
+	doctrineIdx := make(map[uint]int)
+	if op.FleetConfigID != nil && *op.FleetConfigID > 0 {
+		fittings, err := s.configRepo.ListFittingsByConfigID(*op.FleetConfigID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fittings {
+			fid := f.ID
+			doctrineIdx[f.ID] = len(result.Doctrine)
+			result.Doctrine = append(result.Doctrine, TurnoutShip{FittingID: &fid, FittingName: f.FittingName, ShipTypeID: f.ShipTypeID})
+		}
+	}
+	offIdx := make(map[int64]int)
+	roleIdx := make(map[string]int)
+	count := func(t *TurnoutShip, status string) {
+		if status == model.RSVPYes {
+			t.Going++
+		} else {
+			t.Maybe++
+		}
+	}
+
+	for _, r := range rsvps {
+		switch r.Status {
+		case model.RSVPNo:
+			result.Declined++
+			continue
+		case model.RSVPYes:
+			result.Going++
+		case model.RSVPMaybe:
+			result.Maybe++
+		}
+
+		switch {
+		case r.FittingID != nil:
+			if i, ok := doctrineIdx[*r.FittingID]; ok {
+				count(&result.Doctrine[i], r.Status)
+				break
+			}
+			fallthrough // 装配已从配置中移除，按舰船统计
+		case r.ShipTypeID != nil:
+			if r.ShipTypeID == nil {
+				count(&result.Unspecified, r.Status)
+				break
+			}
+			i, ok := offIdx[*r.ShipTypeID]
+			if !ok {
+				i = len(result.OffDoctrine)
+				offIdx[*r.ShipTypeID] = i
+				result.OffDoctrine = append(result.OffDoctrine, TurnoutShip{ShipTypeID: *r.ShipTypeID})
+			}
+			count(&result.OffDoctrine[i], r.Status)
+		default:
+			count(&result.Unspecified, r.Status)
+		}
+
+		role := r.Role
+		if role == "" {
+			role = model.RSVPRoleOther
+		}
+		i, ok := roleIdx[role]
+		if !ok {
+			i = len(result.Roles)
+			roleIdx[role] = i
+			result.Roles = append(result.Roles, TurnoutRole{Role: role})
+		}
+		if r.Status == model.RSVPYes {
+			result.Roles[i].Going++
+		} else {
+			result.Roles[i].Maybe++
+		}
+	}
+	return result, nil
+}
+
+// ─────────────────────────────────────────────
+//  提醒
+// ─────────────────────────────────────────────
+
+// SendDueReminders 发送到期的行动提醒（定时任务每分钟调用）
+// 同一行动多个提醒同时到期（如临近开始才创建）时只发送提前量最小的一条
+func (s *OperationCalendarService) SendDueReminders(now time.Time) (int, error) {
+	ops, err := s.repo.ListUpcoming(now, now.Add(maxReminderOffset*time.Minute+time.Minute))
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range ops {
+		op := &ops[i]
+		due := dueReminders(op, now)
+		if len(due) == 0 {
+			continue
+		}
+		going, maybe := 0, 0
+		if rsvps, err := s.repo.ListRSVPs(op.ID); err == nil {
+			for _, r := range rsvps {
+				switch r.Status {
+				case model.RSVPYes:
+					going++
+				case model.RSVPMaybe:
+					maybe++
+				}
+			}
+		}
+		// 先记录再发送，避免 Webhook 失败时每分钟重复
+		op.RemindersSent = append(op.RemindersSent, due...)
+		if err := s.repo.UpdateOperation(op); err != nil {
+			global.Logger.Warn("[OpCalendar] 记录提醒状态失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+			continue
+		}
+		if err := s.webhookSvc.SendOperationReminder(op, due[len(due)-1], going, maybe); err != nil {
+			global.Logger.Warn("[OpCalendar] 发送行动提醒失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// dueReminders 已到期且未发送的提醒（倒序，最后一个为提前量最小的）
+func dueReminders(op *model.FleetOperation, now time.Time) []int {
+	sent := make(map[int]bool, len(op.RemindersSent))
+	for _, o := range op.RemindersSent {
+		sent[o] = true
+	}
+	var due []int
+	for _, o := range op.ReminderOffsets {
+		if !sent[o] && !now.Before(op.StartAt.Add(-time.Duration(o)*time.Minute)) {
+			due = append(due, o)
+		}
+	}
+	return due
+}
+
+// ─────────────────────────────────────────────
+//  iCal 订阅
+// ─────────────────────────────────────────────
+
+// CalendarFeedStatus 订阅状态
+type CalendarFeedStatus struct {
+	Enabled   bool       `json:"enabled"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// GetFeedStatus 查询当前用户是否已生成订阅令牌
+func (s *OperationCalendarService) GetFeedStatus(userID uint) (*CalendarFeedStatus, error) {
+	t, err := s.repo.GetFeedToken(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &CalendarFeedStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CalendarFeedStatus{Enabled: true, CreatedAt: &t.CreatedAt}, nil
+}
+
+// ResetFeedToken 生成新的订阅令牌（旧令牌立即失效），明文只返回这一次
+func (s *OperationCalendarService) ResetFeedToken(userID uint) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := calendarFeedTokenPrefix + hex.EncodeToString(b)
+	if err := s.repo.SetFeedToken(userID, hashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeFeedToken 关闭订阅
+func (s *OperationCalendarService) RevokeFeedToken(userID uint) error {
+	return s.repo.DeleteFeedToken(userID)
+}
+
+// RenderFeed 按令牌生成用户的 iCal 日历（过去 calendarFeedPastDays 天至未来 calendarFeedFutureDays 天）
+func (s *OperationCalendarService) RenderFeed(token string) (string, error) {
+	if !strings.HasPrefix(token, calendarFeedTokenPrefix) {
+		return "", errors.New("无效的订阅令牌")
+	}
+	t, err := s.repo.GetFeedTokenByHash(hashToken(token))
+	if err != nil {
+		return "", errors.New("无效的订阅令牌")
+	}
+	now := time.Now()
+	ops, err := s.repo.ListOperations(now.AddDate(0, 0, -calendarFeedPastDays), now.AddDate(0, 0, calendarFeedFutureDays), true)
+	if err != nil {
+		return "", err
+	}
+	items, err := s.withRSVPSummary(t.UserID, ops)
+	if err != nil {
+		return "", err
+	}
+
+	configNames := make(map[uint]string)
+	var b strings.Builder
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//AmiyaEden//Operations//ZH")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(siteTitle()+" 行动日历"))
+	for _, item := range items {
+		status := "CONFIRMED"
+		if item.Status == model.OperationStatusCancelled {
+			status = "CANCELLED"
+		}
+		summary := fmt.Sprintf("[%s] %s", fleetImportanceLabel(item.Importance), item.Title)
+		if item.MyRSVP != nil {
+			summary += map[string]string{model.RSVPYes: " ✔", model.RSVPMaybe: " ?", model.RSVPNo: " ✘"}[item.MyRSVP.Status]
+		}
+
+		desc := []string{}
+		if item.FCCharacterName != "" {
+			desc = append(desc, "FC: "+item.FCCharacterName)
+		}
+		if item.FleetConfigID != nil && *item.FleetConfigID > 0 {
+			name, ok := configNames[*item.FleetConfigID]
+			if !ok {
+				if fc, err := s.configRepo.GetByID(*item.FleetConfigID); err == nil {
+					name = fc.Name
+				}
+				configNames[*item.FleetConfigID] = name
+			}
+			if name != "" {
+				desc = append(desc, "舰队配置: "+name)
+			}
+		}
+		desc = append(desc, fmt.Sprintf("报名: %d 参加 / %d 待定", item.Going, item.Maybe))
+		if item.Description != "" {
+			desc = append(desc, "", item.Description)
+		}
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, fmt.Sprintf("UID:operation-%d@amiya-eden", item.ID))
+		writeICalLine(&b, "DTSTAMP:"+item.UpdatedAt.UTC().Format(icalTimeLayout))
+		writeICalLine(&b, "DTSTART:"+item.StartAt.UTC().Format(icalTimeLayout))
+		writeICalLine(&b, "DTEND:"+item.EndAt.UTC().Format(icalTimeLayout))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(summary))
+		writeICalLine(&b, "DESCRIPTION:"+escapeICalText(strings.Join(desc, "\n")))
+		writeICalLine(&b, "STATUS:"+status)
+		writeICalLine(&b, "END:VEVENT")
+	}
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String(), nil
+}
+
+const icalTimeLayout = "20060102T150405Z"
+
+// writeICalLine 写入一行并按 RFC 5545 折行（每行不超过 75 字节，不截断 UTF-8 字符）
+func writeICalLine(b *strings.Builder, line string) {
+	const limit = 75
+	first := true
+	for len(line) > 0 {
+		max := limit
+		if !first {
+			max = limit - 1 // 续行以空格开头
+			b.WriteString(" ")
+		}
+		if len(line) <= max {
+			b.WriteString(line)
+			break
+		}
+		cut := max
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n")
+		line = line[cut:]
+		first = false
+	}
+	b.WriteString("\r\n")
+}
+
+func escapeICalText(v string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(v)
+}
+
+// siteTitle 站点标题（用于日历名称）
+func siteTitle() string {
+	title, err := repository.NewSysConfigRepository().Get(model.SysConfigSiteTitle, model.SysConfigDefaultSiteTitle)
+	if err != nil || title == "" {
+		return model.SysConfigDefaultSiteTitle
+	}
+	return title
+}
@@ -0,0 +1,576 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ─────────────────────────────────────────────
+//  PAP 补登申请
+//  成员为漏记 PAP 的舰队提交申请，系统按 ESI 舰队快照、位置记录与击杀邮件自动核对，
+//  舰队 FC 或管理员审批；通过时将角色加入舰队，按舰队 PAP 策略补发并按差量更新钱包
+// ─────────────────────────────────────────────
+
+const (
+	maxPapClaimEvidenceLen = 2000
+	maxPapClaimReasonLen   = 512
+	papClaimWindow         = 14 * 24 * time.Hour // 舰队结束后可提交申请的期限
+)
+
+// PapClaimService PAP 补登申请业务逻辑层
+type PapClaimService struct {
+	repo      *repository.PapClaimRepository
+	fleetRepo *repository.FleetRepository
+	charRepo  *repository.EveCharacterRepository
+	kmRepo    *repository.KillmailRepository
+	userRepo  *repository.UserRepository
+	fleetSvc  *FleetService
+}
+
+func NewPapClaimService() *PapClaimService {
+	return &PapClaimService{
+		repo:      repository.NewPapClaimRepository(),
+		fleetRepo: repository.NewFleetRepository(),
+		charRepo:  repository.NewEveCharacterRepository(),
+		kmRepo:    repository.NewKillmailRepository(),
+		userRepo:  repository.NewUserRepository(),
+		fleetSvc:  NewFleetService(),
+	}
+}
+
+// ─── 申请人 ───
+
+// SubmitPapClaimRequest 提交补登申请
+type SubmitPapClaimRequest struct {
+	FleetID     string `json:"fleet_id"     binding:"required"`
+	CharacterID int64  `json:"character_id" binding:"required"`
+	Evidence    string `json:"evidence"` // 说明 / 截图链接，可选
+}
+
+// Submit 提交补登申请并立即自动核对
+func (s *PapClaimService) Submit(userID uint, req *SubmitPapClaimRequest) (*model.PapClaim, error) {
+	evidence := strings.TrimSpace(req.Evidence)
+	if len([]rune(evidence)) > maxPapClaimEvidenceLen {
+		return nil, fmt.Errorf("说明不能超过 %d 字", maxPapClaimEvidenceLen)
+	}
+	char, err := s.charRepo.GetByCharacterID(req.CharacterID)
+	if err != nil || char.UserID != userID {
+		return nil, errors.New("该角色不属于当前用户")
+	}
+	fleet, err := s.fleetRepo.GetByID(req.FleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	now := time.Now()
+	if fleet.StartAt.After(now) {
+		return nil, errors.New("舰队尚未开始")
+	}
+	if now.Sub(fleet.EndAt) > papClaimWindow {
+		return nil, errors.New("舰队结束已超过 14 天，无法补登")
+	}
+	if fleet.PapCount <= 0 {
+		return nil, errors.New("该舰队不发放 PAP")
+	}
+	if has, err := s.fleetRepo.HasPapLog(fleet.ID, char.CharacterID); err != nil {
+		return nil, err
+	} else if has {
+		return nil, errors.New("该角色已获得本舰队 PAP")
+	}
+	if pending, err := s.repo.HasPending(fleet.ID, char.CharacterID); err != nil {
+		return nil, err
+	} else if pending {
+		return nil, errors.New("该角色已有待审批的补登申请")
+	}
+
+	claim := &model.PapClaim{
+		FleetID:       fleet.ID,
+		UserID:        userID,
+		CharacterID:   char.CharacterID,
+		CharacterName: char.CharacterName,
+		Evidence:      evidence,
+		Status:        model.PapClaimStatusPending,
+	}
+	if err := s.check(fleet, claim); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(claim); err != nil {
+		return nil, err
+	}
+	s.writeLog(claim, userID, model.PapClaimActionSubmit, "", model.PapClaimStatusPending, evidence)
+	claim.FleetTitle = fleet.Title
+	return claim, nil
+}
+
+// ListMine 查询自己的补登申请
+func (s *PapClaimService) ListMine(userID uint) ([]model.PapClaim, error) {
+	claims, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.fillFleetTitles(claims)
+	return claims, nil
+}
+
+// Withdraw 申请人撤回待审批的申请
+func (s *PapClaimService) Withdraw(userID, id uint) error {
+	claim, err := s.repo.GetByID(id)
+	if err != nil || claim.UserID != userID {
+		return errors.New("申请不存在")
+	}
+	if claim.Status != model.PapClaimStatusPending {
+		return errors.New("只能撤回待审批的申请")
+	}
+	claim.Status = model.PapClaimStatusWithdrawn
+	if err := s.repo.Update(claim); err != nil {
+		return err
+	}
+	s.writeLog(claim, userID, model.PapClaimActionWithdraw, model.PapClaimStatusPending, model.PapClaimStatusWithdrawn, "")
+	return nil
+}
+
+// ─── 审批 ───
+
+// PapClaimDetail 补登申请详情（含操作记录）
+type PapClaimDetail struct {
+	Claim    *model.PapClaim     `json:"claim"`
+	Nickname string              `json:"nickname"`
+	Logs     []model.PapClaimLog `json:"logs"`
+}
+
+// List 分页查询补登申请（管理员可见全部，其他人仅可见自己担任 FC 或数据范围覆盖 FC 的舰队）
+func (s *PapClaimService) List(page, pageSize int, status, fleetID string, userID uint, userRole string, scope *model.DataScope) ([]model.PapClaim, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	var managed *repository.ManagedFleetFilter
+	if !model.HasRole(userRole, model.RoleAdmin) {
+		managed = &repository.ManagedFleetFilter{UserID: userID, Scope: scope}
+	}
+	claims, total, err := s.repo.List(page, pageSize, status, fleetID, managed)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.fillFleetTitles(claims)
+	return claims, total, nil
+}
+
+// GetDetail 获取补登申请详情（含位置与击杀邮件核对结果，权限同审批）
+func (s *PapClaimService) GetDetail(id, userID uint, userRole string, scope *model.DataScope) (*PapClaimDetail, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	fleet, err := s.fleetRepo.GetByID(claim.FleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	if !s.fleetSvc.canManageFleet(fleet, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	logs, err := s.repo.ListLogs(id)
+	if err != nil {
+		return nil, err
+	}
+	claim.FleetTitle = fleet.Title
+	return &PapClaimDetail{Claim: claim, Nickname: s.nickname(claim.UserID), Logs: logs}, nil
+}
+
+// Recheck 重新自动核对（例如位置 / 击杀邮件数据刷新后，权限同审批）
+func (s *PapClaimService) Recheck(id, operatorID uint, userRole string, scope *model.DataScope) (*model.PapClaim, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	if claim.Status != model.PapClaimStatusPending {
+		return nil, errors.New("只能核对待审批的申请")
+	}
+	fleet, err := s.fleetRepo.GetByID(claim.FleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	if !s.fleetSvc.canManageFleet(fleet, operatorID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	if err := s.check(fleet, claim); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(claim); err != nil {
+		return nil, err
+	}
+	s.writeLog(claim, operatorID, model.PapClaimActionRecheck, "", "", claim.AutoVerdict)
+	claim.FleetTitle = fleet.Title
+	return claim, nil
+}
+
+// ReviewPapClaimRequest 审批补登申请
+type ReviewPapClaimRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Approve 通过申请：将角色加入舰队，按舰队 PAP 策略补发 PAP 并更新钱包（舰队 FC、管理员或数据范围覆盖该舰队的角色）
+func (s *PapClaimService) Approve(id, operatorID uint, userRole string, scope *model.DataScope, reason string) (*model.PapClaim, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxPapClaimReasonLen {
+		return nil, fmt.Errorf("原因不能超过 %d 字", maxPapClaimReasonLen)
+	}
+	claim, fleet, err := s.reviewable(id, operatorID, userRole, scope)
+	if err != nil {
+		return nil, err
+	}
+	if fleet.PapCount <= 0 {
+		return nil, errors.New("该舰队不发放 PAP")
+	}
+	char, err := s.charRepo.GetByCharacterID(claim.CharacterID)
+	if err != nil || char.UserID != claim.UserID {
+		return nil, errors.New("该角色已不属于申请人")
+	}
+	member := &model.FleetMember{
+		FleetID:       fleet.ID,
+		CharacterID:   char.CharacterID,
+		CharacterName: char.CharacterName,
+		UserID:        char.UserID,
+	}
+
+	tx := global.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	locked, err := s.repo.GetForUpdateTx(tx, claim.ID)
+	if err != nil || locked.Status != model.PapClaimStatusPending {
+		tx.Rollback()
+		return nil, errors.New("申请已被处理")
+	}
+	// 加锁确认申请仍待审批后再加入舰队，成员与 PAP 随事务一并提交或回滚
+	if err := s.fleetRepo.AddMemberTx(tx, member); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	papLog, err := s.fleetSvc.grantMemberPapTx(tx, fleet, member, operatorID, fmt.Sprintf("舰队 PAP 补登: %s", fleet.ID))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	now := time.Now()
+	locked.Status = model.PapClaimStatusApproved
+	locked.PapCount = papLog.PapCount
+	locked.ReviewerID = &operatorID
+	locked.DecidedAt = &now
+	locked.DecisionReason = reason
+	if err := s.repo.UpdateTx(tx, locked); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	note := fmt.Sprintf("补发 %.2f PAP（%s）", papLog.PapCount, papLog.PolicyName)
+	if reason != "" {
+		note += "：" + reason
+	}
+	if err := s.repo.CreateLogTx(tx, s.newLog(locked, operatorID, model.PapClaimActionApprove, model.PapClaimStatusPending, model.PapClaimStatusApproved, note)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	locked.FleetTitle = fleet.Title
+	return locked, nil
+}
+
+// Reject 拒绝申请
+func (s *PapClaimService) Reject(id, operatorID uint, userRole string, scope *model.DataScope, reason string) (*model.PapClaim, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("请填写拒绝原因")
+	}
+	if len([]rune(reason)) > maxPapClaimReasonLen {
+		return nil, fmt.Errorf("原因不能超过 %d 字", maxPapClaimReasonLen)
+	}
+	claim, fleet, err := s.reviewable(id, operatorID, userRole, scope)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	claim.Status = model.PapClaimStatusRejected
+	claim.ReviewerID = &operatorID
+	claim.DecidedAt = &now
+	claim.DecisionReason = reason
+	if err := s.repo.Update(claim); err != nil {
+		return nil, err
+	}
+	s.writeLog(claim, operatorID, model.PapClaimActionReject, model.PapClaimStatusPending, model.PapClaimStatusRejected, reason)
+	claim.FleetTitle = fleet.Title
+	return claim, nil
+}
+
+// reviewable 查询待审批的申请并校验审批人是否有权管理该舰队（不能审批本人的申请）
+func (s *PapClaimService) reviewable(id, operatorID uint, userRole string, scope *model.DataScope) (*model.PapClaim, *model.Fleet, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, errors.New("申请不存在")
+	}
+	if claim.UserID == operatorID {
+		return nil, nil, errors.New("不能审批自己的申请")
+	}
+	if claim.Status != model.PapClaimStatusPending {
+		return nil, nil, errors.New("申请已被处理")
+	}
+	fleet, err := s.fleetRepo.GetByID(claim.FleetID)
+	if err != nil {
+		return nil, nil, errors.New("舰队不存在")
+	}
+	if !s.fleetSvc.canManageFleet(fleet, operatorID, userRole, scope) {
+		return nil, nil, errors.New("权限不足")
+	}
+	return claim, fleet, nil
+}
+
+// ─── 自动核对 ───
+
+// check 按 ESI 舰队快照、位置记录与击杀邮件核对申请，结果写入 claim（不入库）
+func (s *PapClaimService) check(fleet *model.Fleet, claim *model.PapClaim) error {
+	segments, err := s.fleetRepo.ListSegments(fleet.ID)
+	if err != nil {
+		return err
+	}
+	members, err := s.fleetRepo.ListMembers(fleet.ID)
+	if err != nil {
+		return err
+	}
+	papLogs, err := s.fleetRepo.ListPapLogsByFleet(fleet.ID)
+	if err != nil {
+		return err
+	}
+
+	// 舰队时间范围（跟踪记录超出计划时间时以跟踪为准）与舰队出现过的星系
+	from, to := fleet.StartAt, fleet.EndAt
+	systems := make(map[int64]bool)
+	var ownSeconds float64
+	for i := range segments {
+		seg := &segments[i]
+		end := segmentEnd(seg)
+		if seg.StartedAt.Before(from) {
+			from = seg.StartedAt
+		}
+		if end.After(to) {
+			to = end
+		}
+		systems[seg.SolarSystemID] = true
+		if seg.CharacterID == claim.CharacterID {
+			ownSeconds += end.Sub(seg.StartedAt).Seconds()
+		}
+	}
+	fleetChars := make(map[int64]bool)
+	var inMembers bool
+	for _, m := range members {
+		if m.SolarSystemID != nil {
+			systems[*m.SolarSystemID] = true
+		}
+		if m.CharacterID == claim.CharacterID {
+			inMembers = true
+		} else {
+			fleetChars[m.CharacterID] = true
+		}
+	}
+	for _, l := range papLogs {
+		if l.CharacterID != claim.CharacterID {
+			fleetChars[l.CharacterID] = true
+		}
+	}
+
+	checks := []model.PapClaimCheck{
+		s.checkFleetSnapshot(inMembers, ownSeconds),
+		s.checkLocation(claim.CharacterID, from, to, systems),
+		s.checkKillmails(claim.CharacterID, from, to, systems, fleetChars),
+	}
+	claim.Checks = checks
+	claim.AutoVerdict = model.PapClaimVerdictNoEvidence
+	for _, c := range checks {
+		if c.Matched {
+			claim.AutoVerdict = model.PapClaimVerdictSupported
+			break
+		}
+	}
+	now := time.Now()
+	claim.CheckedAt = &now
+	return nil
+}
+
+func (s *PapClaimService) checkFleetSnapshot(inMembers bool, ownSeconds float64) model.PapClaimCheck {
+	c := model.PapClaimCheck{Source: model.PapClaimCheckFleetSnapshot}
+	switch {
+	case ownSeconds > 0:
+		c.Matched = true
+		c.Detail = fmt.Sprintf("持续跟踪记录到该角色在队 %d 分钟", int(ownSeconds/60))
+	case inMembers:
+		c.Matched = true
+		c.Detail = "该角色在舰队成员列表中，但没有 PAP 记录（可能在发放后加入）"
+	default:
+		c.Detail = "ESI 舰队快照中没有该角色"
+	}
+	return c
+}
+
+func (s *PapClaimService) checkLocation(characterID int64, from, to time.Time, systems map[int64]bool) model.PapClaimCheck {
+	c := model.PapClaimCheck{Source: model.PapClaimCheckLocation}
+	locs, err := s.repo.ListLocationsBetween(characterID, from, to)
+	if err != nil {
+		global.Logger.Warn("[PapClaim] 查询位置记录失败", zap.Int64("character_id", characterID), zap.Error(err))
+		c.Detail = "查询位置记录失败"
+		return c
+	}
+	if len(locs) == 0 {
+		c.Detail = "舰队时间内没有位置记录（角色可能未授权位置 scope）"
+		return c
+	}
+	if len(systems) == 0 {
+		c.Detail = fmt.Sprintf("舰队时间内有 %d 条位置记录，但舰队没有星系快照，无法比对", len(locs))
+		return c
+	}
+	for _, l := range locs {
+		if systems[l.SolarSystemID] {
+			c.Matched = true
+			c.Detail = fmt.Sprintf("舰队时间内位于舰队所在星系 %d", l.SolarSystemID)
+			return c
+		}
+	}
+	c.Detail = fmt.Sprintf("舰队时间内有 %d 条位置记录，均不在舰队所在星系", len(locs))
+	return c
+}
+
+func (s *PapClaimService) checkKillmails(characterID int64, from, to time.Time, systems map[int64]bool, fleetChars map[int64]bool) model.PapClaimCheck {
+	c := model.PapClaimCheck{Source: model.PapClaimCheckKillmail}
+	rows, _, err := s.kmRepo.ListByCharacter(characterID, from, to, 0, 0)
+	if err != nil {
+		global.Logger.Warn("[PapClaim] 查询击杀邮件失败", zap.Int64("character_id", characterID), zap.Error(err))
+		c.Detail = "查询击杀邮件失败"
+		return c
+	}
+	if len(rows) == 0 {
+		c.Detail = "舰队时间内没有击杀邮件"
+		return c
+	}
+	kmIDs := make([]int64, 0, len(rows))
+	inSystem := 0
+	for _, r := range rows {
+		kmIDs = append(kmIDs, r.KillmailID)
+		if systems[r.SolarSystemID] {
+			inSystem++
+		}
+	}
+	charIDs := make([]int64, 0, len(fleetChars))
+	for id := range fleetChars {
+		charIDs = append(charIDs, id)
+	}
+	shared, err := s.repo.ListCoInvolvedKillmails(kmIDs, charIDs)
+	if err != nil {
+		global.Logger.Warn("[PapClaim] 查询共同击杀邮件失败", zap.Int64("character_id", characterID), zap.Error(err))
+	}
+	switch {
+	case len(shared) > 0:
+		c.Matched = true
+		c.Detail = fmt.Sprintf("%d 封击杀邮件中有舰队其他成员", len(shared))
+	case inSystem > 0:
+		c.Matched = true
+		c.Detail = fmt.Sprintf("%d 封击杀邮件发生在舰队所在星系", inSystem)
+	default:
+		c.Detail = fmt.Sprintf("舰队时间内有 %d 封击杀邮件，但与舰队无关", len(rows))
+	}
+	return c
+}
+
+// ─── 内部 ───
+
+func (s *PapClaimService) fillFleetTitles(claims []model.PapClaim) {
+	titles := make(map[string]string)
+	for i := range claims {
+		id := claims[i].FleetID
+		if _, ok := titles[id]; !ok {
+			if fleet, err := s.fleetRepo.GetByID(id); err == nil {
+				titles[id] = fleet.Title
+			} else {
+				titles[id] = ""
+			}
+		}
+		claims[i].FleetTitle = titles[id]
+	}
+}
+
+func (s *PapClaimService) newLog(claim *model.PapClaim, operatorID uint, action, from, to, note string) *model.PapClaimLog {
+	return &model.PapClaimLog{
+		ClaimID:      claim.ID,
+		OperatorID:   operatorID,
+		OperatorName: s.nickname(operatorID),
+		Action:       action,
+		FromStatus:   from,
+		ToStatus:     to,
+		Note:         note,
+	}
+}
+
+// writeLog 写入操作记录（失败只记日志，不影响主流程）
+func (s *PapClaimService) writeLog(claim *model.PapClaim, operatorID uint, action, from, to, note string) {
+	if err := s.repo.CreateLog(s.newLog(claim, operatorID, action, from, to, note)); err != nil {
+		global.Logger.Warn("[PapClaim] 写入操作记录失败", zap.Uint("claim_id", claim.ID), zap.Error(err))
+	}
+}
+
+func (s *PapClaimService) nickname(userID uint) string {
+	if u, err := s.userRepo.GetByID(userID); err == nil {
+		return u.Nickname
+	}
+	return ""
+}
+
+// grantMemberPapTx 在事务中按舰队 PAP 策略为单个成员发放 PAP，替换其原有记录并按差量更新钱包
+func (s *FleetService) grantMemberPapTx(tx *gorm.DB, fleet *model.Fleet, member *model.FleetMember, issuedBy uint, reason string) (*model.FleetPapLog, error) {
+	policy, results, err := s.calculatePap(fleet, []model.FleetMember{*member}, false)
+	if err != nil {
+		return nil, err
+	}
+	r := results[0]
+	// 补登成员通常没有跟踪记录，审批人已人工核实在队，按全程计算
+	if r.Breakdown.Untracked {
+		r.Breakdown.PresenceFactor = 1
+		r.PapCount = roundTo(r.Breakdown.Base*r.Breakdown.Multiplier, 2)
+	}
+
+	var oldLogs []model.FleetPapLog
+	if err := tx.Where("fleet_id = ? AND character_id = ?", fleet.ID, member.CharacterID).Find(&oldLogs).Error; err != nil {
+		return nil, err
+	}
+	var oldPap float64
+	for _, l := range oldLogs {
+		oldPap += l.PapCount
+	}
+	if err := tx.Where("fleet_id = ? AND character_id = ?", fleet.ID, member.CharacterID).Delete(&model.FleetPapLog{}).Error; err != nil {
+		return nil, err
+	}
+	papLog := &model.FleetPapLog{
+		FleetID:     fleet.ID,
+		CharacterID: member.CharacterID,
+		UserID:      member.UserID,
+		PapCount:    r.PapCount,
+		PolicyName:  policy.Name,
+		Breakdown:   &r.Breakdown,
+		IssuedBy:    issuedBy,
+	}
+	if err := tx.Create(papLog).Error; err != nil {
+		return nil, err
+	}
+	if err := s.walletSvc.ApplyWalletDeltaTx(tx, member.UserID, r.PapCount-oldPap, reason, model.WalletRefPapReward, fleet.ID); err != nil {
+		return nil, err
+	}
+	return papLog, nil
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"fmt"
+	"math"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  PAP 计算策略
+//  按舰队指定的策略（或重要等级默认策略）计算每个成员的 PAP：
+//  固定 / 按在队时长折算，在队比例下限，以及按角色或舰船分组的倍率
+// ─────────────────────────────────────────────
+
+const (
+	builtinPapPolicyName = "固定 PAP"
+	manualPapPolicyName  = "手动补录"
+	maxPapMultiplier     = 10
+)
+
+// papShipRoles 按舰船分组识别加成角色（invGroups.groupID）
+var papShipRoles = map[int64]string{
+	832:  model.PapRoleLogi,    // Logistics
+	1527: model.PapRoleLogi,    // Logistics Frigate
+	1538: model.PapRoleLogi,    // Force Auxiliary
+	830:  model.PapRoleScout,   // Covert Ops
+	831:  model.PapRoleScout,   // Interceptor
+	833:  model.PapRoleScout,   // Force Recon Ship
+	540:  model.PapRoleBooster, // Command Ship
+	1534: model.PapRoleBooster, // Command Destroyer
+}
+
+var papRoles = []string{model.PapRoleFC, model.PapRoleLogi, model.PapRoleScout, model.PapRoleBooster}
+
+// PapPolicyService PAP 策略管理
+type PapPolicyService struct {
+	repo *repository.PapPolicyRepository
+}
+
+func NewPapPolicyService() *PapPolicyService {
+	return &PapPolicyService{repo: repository.NewPapPolicyRepository()}
+}
+
+// PapPolicyRequest 创建 / 更新 PAP 策略请求
+type PapPolicyRequest struct {
+	Name                 string             `json:"name"                  binding:"required,max=128"`
+	Description          string             `json:"description"           binding:"max=512"`
+	Mode                 string             `json:"mode"                  binding:"required,oneof=flat time_weighted"`
+	MinPresencePercent   float64            `json:"min_presence_percent"  binding:"gte=0,lte=100"`
+	FullPresencePercent  float64            `json:"full_presence_percent" binding:"gte=0,lte=100"`
+	RoleMultipliers      map[string]float64 `json:"role_multipliers"`
+	ShipGroupMultipliers map[int64]float64  `json:"ship_group_multipliers"`
+	Importance           string             `json:"importance"            binding:"omitempty,oneof=strat_op cta other"`
+}
+
+func (req *PapPolicyRequest) validate() error {
+	if req.FullPresencePercent > 0 && req.MinPresencePercent > req.FullPresencePercent {
+		return errors.New("最低在队比例不能高于全程比例")
+	}
+	for role, v := range req.RoleMultipliers {
+		if !containsString(papRoles, role) {
+			return fmt.Errorf("不支持的加成角色: %s", role)
+		}
+		if v < 0 || v > maxPapMultiplier {
+			return fmt.Errorf("角色 %s 的倍率须在 0-%d 之间", role, maxPapMultiplier)
+		}
+	}
+	for groupID, v := range req.ShipGroupMultipliers {
+		if v < 0 || v > maxPapMultiplier {
+			return fmt.Errorf("舰船分组 %d 的倍率须在 0-%d 之间", groupID, maxPapMultiplier)
+		}
+	}
+	return nil
+}
+
+func (req *PapPolicyRequest) apply(p *model.PapPolicy) {
+	p.Name = req.Name
+	p.Description = req.Description
+	p.Mode = req.Mode
+	p.MinPresencePercent = req.MinPresencePercent
+	p.FullPresencePercent = req.FullPresencePercent
+	p.RoleMultipliers = req.RoleMultipliers
+	p.ShipGroupMultipliers = req.ShipGroupMultipliers
+	p.Importance = req.Importance
+}
+
+// ListPolicies 查询全部策略
+func (s *PapPolicyService) ListPolicies() ([]model.PapPolicy, error) {
+	return s.repo.List()
+}
+
+// CreatePolicy 创建策略
+func (s *PapPolicyService) CreatePolicy(userID uint, req *PapPolicyRequest) (*model.PapPolicy, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	p := &model.PapPolicy{CreatedBy: userID}
+	req.apply(p)
+	if err := s.repo.Create(p); err != nil {
+		return nil, err
+	}
+	if p.Importance != "" {
+		if err := s.repo.ClearImportance(p.Importance, p.ID); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// UpdatePolicy 更新策略
+func (s *PapPolicyService) UpdatePolicy(id uint, req *PapPolicyRequest) (*model.PapPolicy, error) {
+	p, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("PAP 策略不存在")
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	req.apply(p)
+	if err := s.repo.Update(p); err != nil {
+		return nil, err
+	}
+	if p.Importance != "" {
+		if err := s.repo.ClearImportance(p.Importance, p.ID); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// DeletePolicy 删除策略（有舰队指定该策略时不允许删除）
+func (s *PapPolicyService) DeletePolicy(id uint) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return errors.New("PAP 策略不存在")
+	}
+	n, err := s.repo.CountFleetsUsing(id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("有 %d 个舰队指定了该策略，无法删除", n)
+	}
+	return s.repo.Delete(id)
+}
+
+// ─────────────────────────────────────────────
+//  PAP 计算
+// ─────────────────────────────────────────────
+
+// resolvePapPolicy 舰队使用的 PAP 策略：舰队指定 > 重要等级默认 > 内置固定策略
+func resolvePapPolicy(fleet *model.Fleet) *model.PapPolicy {
+	repo := repository.NewPapPolicyRepository()
+	if fleet.PapPolicyID != nil {
+		if p, err := repo.GetByID(*fleet.PapPolicyID); err == nil {
+			return p
+		}
+	}
+	if p, err := repo.GetByImportance(fleet.Importance); err == nil {
+		return p
+	}
+	return &model.PapPolicy{Name: builtinPapPolicyName, Mode: model.PapModeFlat}
+}
+
+// papShipUsage 成员驾驶某艘舰船的时长（没有跟踪记录时为 1，仅用于加权）
+type papShipUsage struct {
+	shipTypeID int64
+	weight     float64
+}
+
+// MemberPap 单个成员的 PAP 计算结果
+type MemberPap struct {
+	CharacterID   int64              `json:"character_id"`
+	CharacterName string             `json:"character_name"`
+	UserID        uint               `json:"user_id"`
+	PapCount      float64            `json:"pap_count"`
+	Breakdown     model.PapBreakdown `json:"breakdown"`
+}
+
+// calculatePap 按策略计算舰队所有成员的 PAP
+// forceTimeWeighted 为 true 时无论策略模式均按在队时长折算，且要求有跟踪记录
+func (s *FleetService) calculatePap(fleet *model.Fleet, members []model.FleetMember, forceTimeWeighted bool) (*model.PapPolicy, []MemberPap, error) {
+	policy := resolvePapPolicy(fleet)
+	mode := policy.Mode
+	if forceTimeWeighted {
+		mode = model.PapModeTimeWeighted
+	}
+
+	segments, err := s.repo.ListSegments(fleet.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	presence, from, to := fleetPresence(segments)
+	span := to.Sub(from)
+	if forceTimeWeighted && span <= 0 {
+		return nil, nil, errors.New("没有足够的跟踪数据，无法按在队时长发放 PAP")
+	}
+
+	// 每个成员驾驶过的舰船及时长；没有跟踪记录的成员使用成员快照中的舰船
+	usage := make(map[int64][]papShipUsage, len(members))
+	typeSet := make(map[int64]bool)
+	for i := range segments {
+		seg := &segments[i]
+		usage[seg.CharacterID] = append(usage[seg.CharacterID], papShipUsage{
+			shipTypeID: seg.ShipTypeID,
+			weight:     segmentEnd(seg).Sub(seg.StartedAt).Seconds(),
+		})
+		typeSet[seg.ShipTypeID] = true
+	}
+	for _, m := range members {
+		if _, ok := usage[m.CharacterID]; !ok && m.ShipTypeID != nil {
+			usage[m.CharacterID] = []papShipUsage{{shipTypeID: *m.ShipTypeID, weight: 1}}
+			typeSet[*m.ShipTypeID] = true
+		}
+	}
+	shipGroups := papShipGroups(typeSet)
+
+	minRatio := policy.MinPresencePercent / 100
+	fullRatio := policy.FullPresencePercent / 100
+	if fullRatio <= 0 {
+		fullRatio = 1
+	}
+
+	results := make([]MemberPap, 0, len(members))
+	for _, m := range members {
+		b := model.PapBreakdown{
+			Policy:         policy.Name,
+			Mode:           mode,
+			Base:           fleet.PapCount,
+			PresenceFactor: 1,
+		}
+		if d, ok := presence[m.CharacterID]; ok && span > 0 {
+			b.PresentSeconds = int64(d.Seconds())
+			b.TrackedSeconds = int64(span.Seconds())
+			ratio := math.Min(1, d.Seconds()/span.Seconds())
+			switch {
+			case minRatio > 0 && ratio < minRatio:
+				b.BelowMinimum = true
+				b.PresenceFactor = 0
+			case mode == model.PapModeTimeWeighted:
+				b.PresenceFactor = roundTo(math.Min(1, ratio/fullRatio), 4)
+			}
+		} else if span > 0 && (mode == model.PapModeTimeWeighted || minRatio > 0) {
+			// 舰队有跟踪记录但该成员没有：无法确认在队时长，不按全程发放
+			b.TrackedSeconds = int64(span.Seconds())
+			b.Untracked = true
+			b.PresenceFactor = 0
+		}
+		applyPapMultiplier(&b, policy, usage[m.CharacterID], shipGroups, m.CharacterID == fleet.FCCharacterID)
+
+		results = append(results, MemberPap{
+			CharacterID:   m.CharacterID,
+			CharacterName: m.CharacterName,
+			UserID:        m.UserID,
+			PapCount:      roundTo(b.Base*b.PresenceFactor*b.Multiplier, 2),
+			Breakdown:     b,
+		})
+	}
+	return policy, results, nil
+}
+
+// applyPapMultiplier 计算按驾驶时长加权的倍率，并记录在队时间最长的舰船对应的角色与分组倍率
+// 每艘舰船取适用倍率（FC 角色、舰船角色、舰船分组）中的最高值，不叠加；都未配置时为 1
+func applyPapMultiplier(b *model.PapBreakdown, policy *model.PapPolicy, usage []papShipUsage, shipGroups map[int64]int64, isFC bool) {
+	if len(usage) == 0 {
+		usage = []papShipUsage{{weight: 1}}
+	}
+	var total float64
+	for _, u := range usage {
+		total += u.weight
+	}
+	// 只被轮询到一次的成员时长为 0，按舰船平均
+	if total <= 0 {
+		for i := range usage {
+			usage[i].weight = 1
+		}
+		total = float64(len(usage))
+	}
+
+	var weighted, longest float64
+	for _, u := range usage {
+		groupID := shipGroups[u.shipTypeID]
+		roles := []string{papShipRoles[groupID]}
+		if isFC {
+			roles = append(roles, model.PapRoleFC)
+		}
+
+		role, roleMult, groupMult := "", 1.0, 1.0
+		mult, configured := 1.0, false
+		for _, r := range roles {
+			if r == "" {
+				continue
+			}
+			if role == "" || r == model.PapRoleFC {
+				role = r
+			}
+			if v, ok := policy.RoleMultipliers[r]; ok && (!configured || v > mult) {
+				role, roleMult, mult, configured = r, v, v, true
+			}
+		}
+		if v, ok := policy.ShipGroupMultipliers[groupID]; ok && groupID != 0 {
+			groupMult = v
+			if !configured || v > mult {
+				mult = v
+			}
+		}
+		weighted += mult * u.weight
+
+		if u.weight > longest {
+			longest = u.weight
+			b.ShipTypeID = u.shipTypeID
+			b.ShipGroupID = groupID
+			b.Role = role
+			b.RoleMultiplier = roleMult
+			b.GroupMultiplier = groupMult
+		}
+	}
+	b.Multiplier = roundTo(weighted/total, 4)
+}
+
+// papShipGroups 查询舰船所属分组；SDE 查询失败时不识别角色与分组倍率
+func papShipGroups(typeSet map[int64]bool) map[int64]int64 {
+	groups := make(map[int64]int64, len(typeSet))
+	if len(typeSet) == 0 {
+		return groups
+	}
+	ids := make([]int, 0, len(typeSet))
+	for id := range typeSet {
+		ids = append(ids, int(id))
+	}
+	types, err := repository.NewSdeRepository().GetTypes(ids, nil, "en")
+	if err != nil {
+		global.Logger.Warn("[PapPolicy] 查询舰船分组失败", zap.Error(err))
+		return groups
+	}
+	for _, t := range types {
+		groups[int64(t.TypeID)] = int64(t.GroupID)
+	}
+	return groups
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTo 四舍五入到指定小数位
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+// PapPreview PAP 发放预览
+type PapPreview struct {
+	FleetID    string      `json:"fleet_id"`
+	PolicyID   uint        `json:"policy_id"` // 0 表示内置固定策略
+	PolicyName string      `json:"policy_name"`
+	Mode       string      `json:"mode"`
+	Total      float64     `json:"total"`
+	Members    []MemberPap `json:"members"`
+}
+
+// PreviewPap 按当前成员与跟踪记录预览每个成员将获得的 PAP（不发放、不同步 ESI）
+func (s *FleetService) PreviewPap(fleetID string, userID uint, userRole string, scope *model.DataScope, proRata bool) (*PapPreview, error) {
+	fleet, err := s.repo.GetByID(fleetID)
+	if err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	if !s.canManageFleet(fleet, userID, userRole, scope) {
+		return nil, errors.New("权限不足")
+	}
+	members, err := s.repo.ListMembers(fleetID)
+	if err != nil {
+		return nil, err
+	}
+	policy, results, err := s.calculatePap(fleet, members, proRata)
+	if err != nil {
+		return nil, err
+	}
+	preview := &PapPreview{
+		FleetID:    fleetID,
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Mode:       policy.Mode,
+		Members:    results,
+	}
+	if proRata {
+		preview.Mode = model.PapModeTimeWeighted
+	}
+	for _, r := range results {
+		preview.Total += r.PapCount
+	}
+	preview.Total = roundTo(preview.Total, 2)
+	return preview, nil
+}
+
+// manualPapBreakdown 手动补录 PAP 的明细（固定发放舰队设定的 PAP）
+func manualPapBreakdown(fleet *model.Fleet) *model.PapBreakdown {
+	return &model.PapBreakdown{
+		Policy:          manualPapPolicyName,
+		Mode:            model.PapModeManual,
+		Base:            fleet.PapCount,
+		PresenceFactor:  1,
+		RoleMultiplier:  1,
+		GroupMultiplier: 1,
+		Multiplier:      1,
+	}
+}
+
+// papPolicyExists 校验舰队指定的 PAP 策略是否存在
+func papPolicyExists(id uint) error {
+	if _, err := repository.NewPapPolicyRepository().GetByID(id); err != nil {
+		return errors.New("PAP 策略不存在")
+	}
+	return nil
+}
@@ -0,0 +1,593 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ─────────────────────────────────────────────
+//  招新与申请人审核
+//  申请人（通常为 guest）授权审核所需 scope 后提交申请表；招新官基于已拉取的 ESI 数据
+//  （技能、钱包流水、合同、击杀邮件）与公开的军团履历审核同账号下的全部角色，
+//  通过时可授予角色、将申请人所在军团 / 联盟加入 basic_access 准入名单
+// ─────────────────────────────────────────────
+
+const (
+	maxRecruitQuestions = 30
+	maxRecruitAnswerLen = 4000
+	maxRecruitReasonLen = 512
+)
+
+// recruitRequiredScopes 提交申请前每个角色必须授权的 scope（审核视图的数据来源）
+var recruitRequiredScopes = []string{
+	"esi-skills.read_skills.v1",
+	"esi-wallet.read_character_wallet.v1",
+	"esi-contracts.read_character_contracts.v1",
+	"esi-killmails.read_killmails.v1",
+}
+
+var defaultRecruitQuestions = []string{
+	"你的游戏经历（入坑时间、之前待过的军团 / 联盟）",
+	"为什么想加入我们？",
+	"你的主要活动时间（时区）",
+	"还有什么想告诉招新官的？",
+}
+
+// recruitTransitions 申请状态可流转的目标状态
+var recruitTransitions = map[string][]string{
+	model.RecruitStatusPending:   {model.RecruitStatusReviewing, model.RecruitStatusAccepted, model.RecruitStatusRejected, model.RecruitStatusWithdrawn},
+	model.RecruitStatusReviewing: {model.RecruitStatusPending, model.RecruitStatusAccepted, model.RecruitStatusRejected, model.RecruitStatusWithdrawn},
+	model.RecruitStatusAccepted:  {model.RecruitStatusRejected},
+	model.RecruitStatusRejected:  {model.RecruitStatusReviewing},
+}
+
+// RecruitService 招新业务逻辑层
+type RecruitService struct {
+	repo      *repository.RecruitRepository
+	charRepo  *repository.EveCharacterRepository
+	userRepo  *repository.UserRepository
+	roleRepo  *repository.RoleRepository
+	allowRepo *repository.AllowedEntityRepository
+	roleSvc   *RoleService
+}
+
+func NewRecruitService() *RecruitService {
+	return &RecruitService{
+		repo:      repository.NewRecruitRepository(),
+		charRepo:  repository.NewEveCharacterRepository(),
+		userRepo:  repository.NewUserRepository(),
+		roleRepo:  repository.NewRoleRepository(),
+		allowRepo: repository.NewAllowedEntityRepository(),
+		roleSvc:   NewRoleService(),
+	}
+}
+
+// ─── 配置 ───
+
+// RecruitConfig 招新配置
+type RecruitConfig struct {
+	Questions []string `json:"questions"`
+}
+
+// GetRecruitConfig 读取招新配置（未配置问题时使用默认问题）
+func GetRecruitConfig() RecruitConfig {
+	cfg := RecruitConfig{Questions: defaultRecruitQuestions}
+	raw, err := repository.NewSysConfigRepository().Get(model.SysConfigRecruitQuestions, "")
+	if err != nil || raw == "" {
+		return cfg
+	}
+	var questions []string
+	if err := json.Unmarshal([]byte(raw), &questions); err != nil {
+		global.Logger.Warn("[Recruit] 申请表问题配置解析失败", zap.Error(err))
+		return cfg
+	}
+	cfg.Questions = questions
+	return cfg
+}
+
+// SetRecruitConfig 更新招新配置
+func SetRecruitConfig(cfg RecruitConfig) error {
+	questions := make([]string, 0, len(cfg.Questions))
+	for _, q := range cfg.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			questions = append(questions, q)
+		}
+	}
+	if len(questions) == 0 {
+		return errors.New("至少需要一个问题")
+	}
+	if len(questions) > maxRecruitQuestions {
+		return fmt.Errorf("问题不能超过 %d 个", maxRecruitQuestions)
+	}
+	data, err := json.Marshal(questions)
+	if err != nil {
+		return err
+	}
+	return repository.NewSysConfigRepository().Set(model.SysConfigRecruitQuestions, string(data), "招新申请表问题（JSON 数组）")
+}
+
+// ─── 申请人 ───
+
+// RecruitScopeStatus 单个角色的 scope 授权情况
+type RecruitScopeStatus struct {
+	CharacterID   int64    `json:"character_id"`
+	CharacterName string   `json:"character_name"`
+	TokenInvalid  bool     `json:"token_invalid"`
+	MissingScopes []string `json:"missing_scopes"`
+}
+
+// RecruitForm 申请表：问题、所需 scope、当前用户各角色的授权情况与最近一次申请
+type RecruitForm struct {
+	Questions      []string                  `json:"questions"`
+	RequiredScopes []string                  `json:"required_scopes"`
+	Characters     []RecruitScopeStatus      `json:"characters"`
+	Ready          bool                      `json:"ready"` // 全部角色均已授权，可以提交
+	Current        *model.RecruitApplication `json:"current"`
+}
+
+// GetForm 获取申请表
+func (s *RecruitService) GetForm(userID uint) (*RecruitForm, error) {
+	chars, err := s.charRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	form := &RecruitForm{
+		Questions:      GetRecruitConfig().Questions,
+		RequiredScopes: recruitRequiredScopes,
+		Characters:     recruitScopeStatus(chars),
+		Ready:          len(chars) > 0,
+	}
+	for _, st := range form.Characters {
+		if st.TokenInvalid || len(st.MissingScopes) > 0 {
+			form.Ready = false
+		}
+	}
+	apps, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) > 0 {
+		form.Current = &apps[0]
+	}
+	return form, nil
+}
+
+// recruitScopeStatus 检查角色是否授权了审核所需的全部 scope
+func recruitScopeStatus(chars []model.EveCharacter) []RecruitScopeStatus {
+	result := make([]RecruitScopeStatus, 0, len(chars))
+	for _, c := range chars {
+		granted := make(map[string]bool)
+		for _, sc := range strings.Fields(c.Scopes) {
+			granted[sc] = true
+		}
+		st := RecruitScopeStatus{
+			CharacterID:   c.CharacterID,
+			CharacterName: c.CharacterName,
+			TokenInvalid:  c.TokenInvalid,
+			MissingScopes: []string{},
+		}
+		for _, sc := range recruitRequiredScopes {
+			if !granted[sc] {
+				st.MissingScopes = append(st.MissingScopes, sc)
+			}
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// SubmitRecruitRequest 提交申请
+type SubmitRecruitRequest struct {
+	Answers []string `json:"answers" binding:"required"` // 与申请表问题一一对应
+}
+
+// Submit 提交申请：全部角色须已授权所需 scope，且没有处理中的申请
+func (s *RecruitService) Submit(userID uint, req *SubmitRecruitRequest) (*model.RecruitApplication, error) {
+	form, err := s.GetForm(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !form.Ready {
+		return nil, errors.New("请先为所有角色授权招新审核所需的 ESI 权限")
+	}
+	open, err := s.repo.HasOpenByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, errors.New("已有处理中的申请")
+	}
+	if len(req.Answers) != len(form.Questions) {
+		return nil, errors.New("回答数量与问题不一致")
+	}
+	answers := make([]model.RecruitAnswer, 0, len(form.Questions))
+	for i, q := range form.Questions {
+		a := strings.TrimSpace(req.Answers[i])
+		if a == "" {
+			return nil, fmt.Errorf("请回答问题：%s", q)
+		}
+		if len([]rune(a)) > maxRecruitAnswerLen {
+			return nil, fmt.Errorf("回答不能超过 %d 字", maxRecruitAnswerLen)
+		}
+		answers = append(answers, model.RecruitAnswer{Question: q, Answer: a})
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	main, err := s.charRepo.GetByCharacterID(user.PrimaryCharacterID)
+	if err != nil {
+		return nil, errors.New("请先设置主角色")
+	}
+	app := &model.RecruitApplication{
+		UserID:        userID,
+		CharacterID:   main.CharacterID,
+		CharacterName: main.CharacterName,
+		CorporationID: main.CorporationID,
+		Status:        model.RecruitStatusPending,
+		Answers:       answers,
+	}
+	if main.AllianceID != nil {
+		app.AllianceID = *main.AllianceID
+	}
+	if err := s.repo.Create(app); err != nil {
+		return nil, err
+	}
+
+	if err := NewWebhookService().SendAlert(fmt.Sprintf("📝 新的招新申请\n角色: %s（军团 %d）\n申请编号: %d", app.CharacterName, app.CorporationID, app.ID)); err != nil {
+		global.Logger.Warn("[Recruit] 发送新申请通知失败", zap.Error(err))
+	}
+	return app, nil
+}
+
+// ListMine 查询自己的申请
+func (s *RecruitService) ListMine(userID uint) ([]model.RecruitApplication, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// Withdraw 申请人撤回处理中的申请
+func (s *RecruitService) Withdraw(userID, id uint) error {
+	app, err := s.repo.GetByID(id)
+	if err != nil || app.UserID != userID {
+		return errors.New("申请不存在")
+	}
+	return s.transition(app, userID, model.RecruitStatusWithdrawn, "申请人撤回")
+}
+
+// ─── 招新官 ───
+
+// RecruitApplicationDetail 申请详情（含留言与状态变更记录）
+type RecruitApplicationDetail struct {
+	Application *model.RecruitApplication `json:"application"`
+	Nickname    string                    `json:"nickname"`
+	Comments    []model.RecruitComment    `json:"comments"`
+}
+
+// List 分页查询申请
+func (s *RecruitService) List(page, pageSize int, status string) ([]model.RecruitApplication, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.repo.List(page, pageSize, status)
+}
+
+// GetDetail 获取申请详情
+func (s *RecruitService) GetDetail(id uint) (*RecruitApplicationDetail, error) {
+	app, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	comments, err := s.repo.ListComments(id)
+	if err != nil {
+		return nil, err
+	}
+	detail := &RecruitApplicationDetail{Application: app, Comments: comments}
+	if u, err := s.userRepo.GetByID(app.UserID); err == nil {
+		detail.Nickname = u.Nickname
+	}
+	return detail, nil
+}
+
+// AddComment 招新官留言
+func (s *RecruitService) AddComment(id, operatorID uint, content string) (*model.RecruitComment, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("留言内容不能为空")
+	}
+	if len([]rune(content)) > maxRecruitAnswerLen {
+		return nil, fmt.Errorf("留言不能超过 %d 字", maxRecruitAnswerLen)
+	}
+	comment := &model.RecruitComment{
+		ApplicationID: id,
+		AuthorID:      operatorID,
+		AuthorName:    s.nickname(operatorID),
+		Content:       content,
+	}
+	if err := s.repo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateStatus 流转申请状态（仅 pending / reviewing；通过、拒绝见 Accept / Reject）
+func (s *RecruitService) UpdateStatus(id, operatorID uint, status, reason string) error {
+	if status != model.RecruitStatusPending && status != model.RecruitStatusReviewing {
+		return errors.New("无效的状态")
+	}
+	app, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("申请不存在")
+	}
+	return s.transition(app, operatorID, status, reason)
+}
+
+// AcceptRecruitRequest 通过申请
+type AcceptRecruitRequest struct {
+	RoleIDs   []uint `json:"role_ids"`   // 额外授予的角色（不可为 admin / super_admin / guest，且不能超出操作人自身权限）
+	AllowList string `json:"allow_list"` // "" | corporation | alliance：将申请人主角色当前所在军团 / 联盟加入 basic_access 名单
+	Reason    string `json:"reason"`
+}
+
+// Accept 通过申请：按需加入准入名单、授予角色，并立即执行一次基础准入检查；
+// 名单、角色与状态变更在同一事务中写入
+func (s *RecruitService) Accept(ctx context.Context, id, operatorID uint, req *AcceptRecruitRequest) (*model.RecruitApplication, error) {
+	app, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	if !canTransition(app.Status, model.RecruitStatusAccepted) {
+		return nil, fmt.Errorf("申请当前状态为 %s，不能通过", app.Status)
+	}
+	if err := checkRecruitReason(req.Reason); err != nil {
+		return nil, err
+	}
+	guard, err := s.roleSvc.newGrantGuard(ctx, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]*model.Role, 0, len(req.RoleIDs))
+	for _, rid := range req.RoleIDs {
+		role, err := s.roleRepo.GetByID(rid)
+		if err != nil {
+			return nil, fmt.Errorf("角色ID %d 不存在", rid)
+		}
+		if role.Code == model.RoleAdmin || role.Code == model.RoleSuperAdmin || role.Code == model.RoleGuest {
+			return nil, fmt.Errorf("招新通过时不能授予角色 %s", role.Name)
+		}
+		if err := guard.checkRole(role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	// 加入准入名单会放行整个军团 / 联盟，与直接编辑名单一样要求 system:auto-role:edit
+	if req.AllowList != model.RecruitAllowListNone && !guard.covers("system:auto-role:edit") {
+		return nil, errors.New("加入准入名单需要 system:auto-role:edit 权限")
+	}
+	entity, err := s.recruitAllowEntity(ctx, app, req.AllowList)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.roleRepo.GetUserRoleIDs(app.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.repo.GetForUpdateTx(tx, id)
+		if err != nil {
+			return errors.New("申请不存在")
+		}
+		app = locked
+
+		// 加入准入名单（名单中已存在时不记录）
+		if entity != nil {
+			created, err := s.allowRepo.AddTx(tx, entity)
+			if err != nil {
+				return err
+			}
+			if created {
+				app.AllowEntityID = entity.ID
+			}
+		}
+
+		// 授予角色（只记录新增的角色，拒绝时据此撤销）
+		app.GrantedRoleIDs = []uint{}
+		for _, role := range roles {
+			if containsUint(current, role.ID) || containsUint(app.GrantedRoleIDs, role.ID) {
+				continue
+			}
+			if err := s.roleRepo.AddUserRoleTx(tx, app.UserID, role.ID); err != nil {
+				return err
+			}
+			app.GrantedRoleIDs = append(app.GrantedRoleIDs, role.ID)
+		}
+		return s.transitionTx(tx, app, operatorID, model.RecruitStatusAccepted, req.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if entity != nil {
+		s.allowRepo.InvalidateCache(entity.ListType)
+	}
+	if len(app.GrantedRoleIDs) > 0 {
+		s.roleSvc.SyncUserPrimaryRole(app.UserID)
+		s.roleSvc.InvalidateUserCache(ctx, app.UserID)
+	}
+	if err := s.roleSvc.CheckCorpAccessAndAdjustRole(ctx, app.UserID); err != nil {
+		global.Logger.Warn("[Recruit] 通过后准入检查失败", zap.Uint("user_id", app.UserID), zap.Error(err))
+	}
+	global.Logger.Info("[Recruit] 申请已通过",
+		zap.Uint("application_id", app.ID),
+		zap.Uint("user_id", app.UserID),
+		zap.Uint("operator_id", operatorID),
+		zap.Uints("granted_role_ids", app.GrantedRoleIDs),
+		zap.Uint("allow_entity_id", app.AllowEntityID))
+	return app, nil
+}
+
+// recruitAllowEntity 根据通过选项构造准入名单条目（以申请人主角色当前归属为准，提交后可能已变更）
+func (s *RecruitService) recruitAllowEntity(ctx context.Context, app *model.RecruitApplication, allowList string) (*model.AllowedEntity, error) {
+	if allowList == model.RecruitAllowListNone {
+		return nil, nil
+	}
+	corpID, allianceID := app.CorporationID, app.AllianceID
+	if main, err := s.charRepo.GetByCharacterID(app.CharacterID); err == nil {
+		corpID, allianceID = main.CorporationID, 0
+		if main.AllianceID != nil {
+			allianceID = *main.AllianceID
+		}
+	}
+
+	e := &model.AllowedEntity{ListType: model.AllowListBasicAccess}
+	switch allowList {
+	case model.RecruitAllowListCorporation:
+		if corpID == 0 {
+			return nil, errors.New("申请人当前不在任何军团")
+		}
+		e.EntityType, e.EntityID = model.AllowEntityTypeCorporation, corpID
+	case model.RecruitAllowListAlliance:
+		if allianceID == 0 {
+			return nil, errors.New("申请人当前不在任何联盟")
+		}
+		e.EntityType, e.EntityID = model.AllowEntityTypeAlliance, allianceID
+	default:
+		return nil, errors.New("无效的准入名单选项")
+	}
+	e.EntityName = s.esiEntityName(ctx, e.EntityID)
+	return e, nil
+}
+
+// RejectRecruitRequest 拒绝申请
+type RejectRecruitRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Reject 拒绝申请；已通过的申请会撤销通过时授予的角色。
+// 准入名单条目可能已被其他成员依赖，不会自动移除，需要时由管理员手动处理
+func (s *RecruitService) Reject(ctx context.Context, id, operatorID uint, req *RejectRecruitRequest) (*model.RecruitApplication, error) {
+	if err := checkRecruitReason(req.Reason); err != nil {
+		return nil, err
+	}
+
+	var app *model.RecruitApplication
+	wasAccepted := false
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.repo.GetForUpdateTx(tx, id)
+		if err != nil {
+			return errors.New("申请不存在")
+		}
+		app = locked
+		if !canTransition(app.Status, model.RecruitStatusRejected) {
+			return fmt.Errorf("申请当前状态为 %s，不能拒绝", app.Status)
+		}
+		wasAccepted = app.Status == model.RecruitStatusAccepted
+		if wasAccepted {
+			for _, rid := range app.GrantedRoleIDs {
+				if err := s.roleRepo.RemoveUserRoleTx(tx, app.UserID, rid); err != nil {
+					return err
+				}
+			}
+			app.GrantedRoleIDs = []uint{}
+		}
+		return s.transitionTx(tx, app, operatorID, model.RecruitStatusRejected, req.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if wasAccepted {
+		s.roleSvc.SyncUserPrimaryRole(app.UserID)
+		s.roleSvc.InvalidateUserCache(ctx, app.UserID)
+		if err := s.roleSvc.CheckCorpAccessAndAdjustRole(ctx, app.UserID); err != nil {
+			global.Logger.Warn("[Recruit] 拒绝后准入检查失败", zap.Uint("user_id", app.UserID), zap.Error(err))
+		}
+	}
+	return app, nil
+}
+
+// transition 校验并执行状态流转，写入状态变更记录
+func (s *RecruitService) transition(app *model.RecruitApplication, operatorID uint, to, reason string) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		return s.transitionTx(tx, app, operatorID, to, reason)
+	})
+}
+
+// transitionTx 在事务中执行状态流转，状态与变更记录一并提交
+func (s *RecruitService) transitionTx(tx *gorm.DB, app *model.RecruitApplication, operatorID uint, to, reason string) error {
+	if !canTransition(app.Status, to) {
+		return fmt.Errorf("申请状态不能从 %s 变更为 %s", app.Status, to)
+	}
+	if err := checkRecruitReason(reason); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	from := app.Status
+	app.Status = to
+	if to == model.RecruitStatusAccepted || to == model.RecruitStatusRejected {
+		now := time.Now()
+		app.ReviewerID = &operatorID
+		app.DecidedAt = &now
+		app.DecisionReason = reason
+	}
+	if err := s.repo.UpdateTx(tx, app); err != nil {
+		return err
+	}
+	return s.repo.CreateCommentTx(tx, &model.RecruitComment{
+		ApplicationID: app.ID,
+		AuthorID:      operatorID,
+		AuthorName:    s.nickname(operatorID),
+		Content:       reason,
+		FromStatus:    from,
+		ToStatus:      to,
+	})
+}
+
+func checkRecruitReason(reason string) error {
+	if len([]rune(strings.TrimSpace(reason))) > maxRecruitReasonLen {
+		return fmt.Errorf("原因不能超过 %d 字", maxRecruitReasonLen)
+	}
+	return nil
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range recruitTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *RecruitService) nickname(userID uint) string {
+	if u, err := s.userRepo.GetByID(userID); err == nil {
+		return u.Nickname
+	}
+	return ""
+}
+
+func containsUint(list []uint, v uint) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/cache"
+	"amiya-eden/pkg/eve"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  招新审核视图
+//  汇总申请人账号下全部角色的技能、钱包、合同、击杀邮件与军团履历
+// ─────────────────────────────────────────────
+
+const (
+	recruitVettingJournalLimit  = 50
+	recruitVettingContractLimit = 50
+	recruitVettingKillmailLimit = 20
+	recruitVettingKillmailDays  = 90
+	recruitCorpHistoryPrefix    = "recruit:corp_history:"
+	recruitCorpHistoryTTL       = time.Hour
+)
+
+// RecruitCorpHistoryEntry 角色军团履历（ESI 公开数据）
+type RecruitCorpHistoryEntry struct {
+	CorporationID int64     `json:"corporation_id"`
+	RecordID      int64     `json:"record_id"`
+	StartDate     time.Time `json:"start_date"`
+	IsDeleted     bool      `json:"is_deleted"`
+}
+
+// RecruitKillmail 审核视图中的击杀 / 损失记录
+type RecruitKillmail struct {
+	KillmailID    int64     `json:"killmail_id"`
+	KillmailTime  time.Time `json:"killmail_time"`
+	SolarSystemID int64     `json:"solar_system_id"`
+	ShipTypeID    int64     `json:"ship_type_id"`
+	IsVictim      bool      `json:"is_victim"`
+}
+
+// RecruitVettingCharacter 单个角色的审核数据
+type RecruitVettingCharacter struct {
+	CharacterID   int64      `json:"character_id"`
+	CharacterName string     `json:"character_name"`
+	IsMain        bool       `json:"is_main"`
+	CorporationID int64      `json:"corporation_id"`
+	AllianceID    *int64     `json:"alliance_id,omitempty"`
+	Birthday      *time.Time `json:"birthday,omitempty"`
+	TokenInvalid  bool       `json:"token_invalid"`
+	MissingScopes []string   `json:"missing_scopes"`
+	// 技能
+	TotalSP     int64   `json:"total_sp"`
+	SkillTotals []Total `json:"skill_totals"` // 按技能分组统计的已学技能数
+	// 钱包
+	WalletBalance  float64                           `json:"wallet_balance"`
+	RecentJournal  []model.EVECharacterWalletJournal `json:"recent_journal"`
+	JournalEntries int64                             `json:"journal_entries"`
+	// 击杀邮件（最近 recruitVettingKillmailDays 天）
+	Kills           int               `json:"kills"`
+	Losses          int               `json:"losses"`
+	RecentKillmails []RecruitKillmail `json:"recent_killmails"`
+	// 军团履历（ESI 拉取失败时为空）
+	CorpHistory []RecruitCorpHistoryEntry `json:"corp_history"`
+}
+
+// RecruitVetting 申请审核视图
+type RecruitVetting struct {
+	Application     *model.RecruitApplication         `json:"application"`
+	Characters      []RecruitVettingCharacter         `json:"characters"`
+	RecentContracts []model.EveCharacterContract      `json:"recent_contracts"` // 全部角色的最近合同
+	Affiliation     []model.CharacterAffiliationEvent `json:"affiliation"`      // 本系统记录的归属变更
+	GeneratedAt     time.Time                         `json:"generated_at"`
+}
+
+// GetVetting 生成申请人审核视图
+func (s *RecruitService) GetVetting(ctx context.Context, id uint) (*RecruitVetting, error) {
+	app, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	chars, err := s.charRepo.ListByUserID(app.UserID)
+	if err != nil {
+		return nil, err
+	}
+	mainCharID := app.CharacterID
+	if u, err := s.userRepo.GetByID(app.UserID); err == nil && u.PrimaryCharacterID != 0 {
+		mainCharID = u.PrimaryCharacterID
+	}
+
+	skillSvc := NewEveSkillService()
+	walletRepo := repository.NewEveWalletRepository()
+	kmRepo := repository.NewKillmailRepository()
+	scopeStatus := recruitScopeStatus(chars)
+	now := time.Now()
+
+	result := &RecruitVetting{
+		Application: app,
+		Characters:  make([]RecruitVettingCharacter, 0, len(chars)),
+		GeneratedAt: now,
+	}
+	charIDs := make([]int64, 0, len(chars))
+	for i, c := range chars {
+		charIDs = append(charIDs, c.CharacterID)
+		vc := RecruitVettingCharacter{
+			CharacterID:     c.CharacterID,
+			CharacterName:   c.CharacterName,
+			IsMain:          c.CharacterID == mainCharID,
+			CorporationID:   c.CorporationID,
+			AllianceID:      c.AllianceID,
+			Birthday:        c.Birthday,
+			TokenInvalid:    c.TokenInvalid,
+			MissingScopes:   scopeStatus[i].MissingScopes,
+			SkillTotals:     []Total{},
+			RecentJournal:   []model.EVECharacterWalletJournal{},
+			RecentKillmails: []RecruitKillmail{},
+			CorpHistory:     []RecruitCorpHistoryEntry{},
+		}
+
+		if skills, err := skillSvc.GetEveCharacterSkills(int(c.CharacterID)); err == nil {
+			vc.TotalSP = skills.TotalSP
+			if skills.Totals != nil {
+				vc.SkillTotals = skills.Totals
+			}
+		}
+		if wallet, err := walletRepo.GetWallet(int(c.CharacterID)); err == nil {
+			vc.WalletBalance = wallet.Balance
+		}
+		if journal, total, err := walletRepo.GetWalletJournals(c.CharacterID, 1, recruitVettingJournalLimit); err == nil {
+			vc.RecentJournal = journal
+			vc.JournalEntries = total
+		}
+		if rows, _, err := kmRepo.ListByCharacter(c.CharacterID, now.AddDate(0, 0, -recruitVettingKillmailDays), now, 0, 0); err == nil {
+			for _, r := range rows {
+				if r.IsVictim {
+					vc.Losses++
+				} else {
+					vc.Kills++
+				}
+				if len(vc.RecentKillmails) < recruitVettingKillmailLimit {
+					vc.RecentKillmails = append(vc.RecentKillmails, RecruitKillmail{
+						KillmailID:    r.KillmailID,
+						KillmailTime:  r.KillmailTime,
+						SolarSystemID: r.SolarSystemID,
+						ShipTypeID:    r.ShipTypeID,
+						IsVictim:      r.IsVictim,
+					})
+				}
+			}
+		}
+		if history, err := s.corpHistory(ctx, c.CharacterID); err != nil {
+			global.Logger.Warn("[Recruit] 获取军团履历失败", zap.Int64("character_id", c.CharacterID), zap.Error(err))
+		} else {
+			vc.CorpHistory = history
+		}
+		result.Characters = append(result.Characters, vc)
+	}
+
+	result.RecentContracts = []model.EveCharacterContract{}
+	if len(charIDs) > 0 {
+		contracts, _, err := repository.NewContractRepository().ListContracts(1, recruitVettingContractLimit, charIDs, repository.ContractFilter{})
+		if err != nil {
+			return nil, err
+		}
+		result.RecentContracts = contracts
+	}
+	result.Affiliation, err = repository.NewAffiliationHistoryRepository().ListByUser(app.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// corpHistory 从 ESI 公开接口获取角色军团履历（Redis 缓存 recruitCorpHistoryTTL）
+func (s *RecruitService) corpHistory(ctx context.Context, characterID int64) ([]RecruitCorpHistoryEntry, error) {
+	key := recruitCorpHistoryPrefix + strconv.FormatInt(characterID, 10)
+	var history []RecruitCorpHistoryEntry
+	if err := cache.Get(ctx, key, &history); err == nil {
+		return history, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		esiURL(fmt.Sprintf("/characters/%d/corporationhistory/?datasource=tranquility", characterID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := eve.NewESIHTTPClient(15 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, err
+	}
+	_ = cache.Set(ctx, key, history, recruitCorpHistoryTTL)
+	return history, nil
+}
+
+// esiEntityName 通过 ESI /universe/names 解析军团 / 联盟名称，失败时返回 ID
+func (s *RecruitService) esiEntityName(ctx context.Context, id int64) string {
+	fallback := strconv.FormatInt(id, 10)
+	body, err := json.Marshal([]int64{id})
+	if err != nil {
+		return fallback
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, esiURL("/universe/names/?datasource=tranquility"), bytes.NewReader(body))
+	if err != nil {
+		return fallback
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := eve.NewESIHTTPClient(15 * time.Second).Do(req)
+	if err != nil {
+		global.Logger.Warn("[Recruit] 解析实体名称失败", zap.Int64("entity_id", id), zap.Error(err))
+		return fallback
+	}
+	defer resp.Body.Close()
+	var names []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&names) != nil {
+		return fallback
+	}
+	for _, n := range names {
+		if n.ID == id && n.Name != "" {
+			return n.Name
+		}
+	}
+	return fallback
+}
@@ -30,9 +30,10 @@ func NewRoleService() *RoleService {
 // ─── Redis 缓存 ───
 
 const (
-	userRolesCachePrefix = "user_roles:"
-	userPermsCachePrefix = "user_perms:"
-	cacheTTL             = 30 * time.Minute
+	userRolesCachePrefix  = "user_roles:"
+	userPermsCachePrefix  = "user_perms:"
+	userScopesCachePrefix = "user_scopes:"
+	cacheTTL              = 30 * time.Minute
 )
 
 // GetUserRoleNames 获取用户角色编码列表（带缓存）
@@ -104,13 +105,24 @@ func (s *RoleService) GetUserPermissions(ctx context.Context, userID uint) ([]st
 		return []string{}, nil
 	}
 
-	// 获取角色所有菜单ID
-	menuIDs, err := s.repo.GetMenuIDsByRoles(roleIDs)
+	perms, err := s.permissionsOfRoles(roleIDs)
 	if err != nil {
 		return nil, err
 	}
-	if len(menuIDs) == 0 {
-		return []string{}, nil
+
+	if data, err := json.Marshal(perms); err == nil {
+		global.Redis.Set(ctx, cacheKey, string(data), cacheTTL)
+	}
+	return perms, nil
+}
+
+// permissionsOfRoles 汇总角色菜单中的按钮权限
+func (s *RoleService) permissionsOfRoles(roleIDs []uint) ([]string, error) {
+	perms := make([]string, 0)
+	// 获取角色所有菜单ID
+	menuIDs, err := s.repo.GetMenuIDsByRoles(roleIDs)
+	if err != nil || len(menuIDs) == 0 {
+		return perms, err
 	}
 
 	// 获取菜单，过滤出按钮权限
@@ -118,24 +130,104 @@ func (s *RoleService) GetUserPermissions(ctx context.Context, userID uint) ([]st
 	if err != nil {
 		return nil, err
 	}
-
-	perms := make([]string, 0)
 	for _, m := range menus {
 		if m.Type == model.MenuTypeButton && m.Permission != "" {
 			perms = append(perms, m.Permission)
 		}
 	}
+	return perms, nil
+}
 
-	if data, err := json.Marshal(perms); err == nil {
+// GetUserScopedGrants 获取用户限定作用范围的角色权限（带缓存）
+// 只保留 model.ScopablePermissions 中被角色权限覆盖的部分，联盟范围展开为已知军团 ID
+func (s *RoleService) GetUserScopedGrants(ctx context.Context, userID uint) ([]model.ScopedGrant, error) {
+	cacheKey := fmt.Sprintf("%s%d", userScopesCachePrefix, userID)
+	val, err := global.Redis.Get(ctx, cacheKey).Result()
+	if err == nil && val != "" {
+		var grants []model.ScopedGrant
+		if json.Unmarshal([]byte(val), &grants) == nil {
+			return grants, nil
+		}
+	}
+
+	scopes, err := s.repo.ListUserRoleScopes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一角色的多个范围合并为一条
+	type roleScopes struct {
+		roleID      uint
+		corpIDs     []int64
+		allianceIDs []int64
+	}
+	byRole := make(map[string]*roleScopes)
+	order := make([]string, 0)
+	for _, sc := range scopes {
+		rs, ok := byRole[sc.RoleCode]
+		if !ok {
+			rs = &roleScopes{roleID: sc.RoleID}
+			byRole[sc.RoleCode] = rs
+			order = append(order, sc.RoleCode)
+		}
+		if sc.ScopeType == model.RoleScopeAlliance {
+			rs.allianceIDs = append(rs.allianceIDs, sc.ScopeID)
+		} else {
+			rs.corpIDs = append(rs.corpIDs, sc.ScopeID)
+		}
+	}
+
+	grants := make([]model.ScopedGrant, 0, len(order))
+	for _, code := range order {
+		rs := byRole[code]
+		rolePerms, err := s.permissionsOfRoles([]uint{rs.roleID})
+		if err != nil {
+			return nil, err
+		}
+		perms := make([]string, 0)
+		for _, p := range model.ScopablePermissions {
+			if model.HasAnyPermission(rolePerms, p) {
+				perms = append(perms, p)
+			}
+		}
+		if len(perms) == 0 {
+			continue
+		}
+		allianceCorpIDs, err := repository.ListCorporationIDsByAlliance(rs.allianceIDs)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, model.ScopedGrant{
+			RoleCode:       code,
+			Permissions:    perms,
+			CorporationIDs: uniqueInt64s(append(rs.corpIDs, allianceCorpIDs...)),
+		})
+	}
+
+	if data, err := json.Marshal(grants); err == nil {
 		global.Redis.Set(ctx, cacheKey, string(data), cacheTTL)
 	}
-	return perms, nil
+	return grants, nil
+}
+
+func uniqueInt64s(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
 }
 
 // InvalidateUserCache 清除用户角色和权限缓存
 func (s *RoleService) InvalidateUserCache(ctx context.Context, userID uint) {
 	global.Redis.Del(ctx, fmt.Sprintf("%s%d", userRolesCachePrefix, userID))
 	global.Redis.Del(ctx, fmt.Sprintf("%s%d", userPermsCachePrefix, userID))
+	global.Redis.Del(ctx, fmt.Sprintf("%s%d", userScopesCachePrefix, userID))
 }
 
 // InvalidateUserRolesCache 兼容旧接口
@@ -212,11 +304,40 @@ func (s *RoleService) GetRoleMenuIDs(roleID uint) ([]uint, error) {
 	return s.repo.GetRoleMenuIDs(roleID)
 }
 
-func (s *RoleService) SetRoleMenus(ctx context.Context, roleID uint, menuIDs []uint) error {
-	_, err := s.repo.GetByID(roleID)
+// SetRoleMenus 设置角色菜单；新增的按钮权限必须被操作人自身权限覆盖，防止越权提权
+func (s *RoleService) SetRoleMenus(ctx context.Context, operatorID, roleID uint, menuIDs []uint) error {
+	role, err := s.repo.GetByID(roleID)
 	if err != nil {
 		return errors.New("角色不存在")
 	}
+	guard, err := s.newGrantGuard(ctx, operatorID)
+	if err != nil {
+		return err
+	}
+	if err := guard.checkRoleCode(role); err != nil {
+		return err
+	}
+	current, err := s.repo.GetRoleMenuIDs(roleID)
+	if err != nil {
+		return err
+	}
+	added := make([]uint, 0, len(menuIDs))
+	for _, id := range menuIDs {
+		if !containsUint(current, id) {
+			added = append(added, id)
+		}
+	}
+	if len(added) > 0 {
+		menus, err := s.menuRepo.ListByIDs(added)
+		if err != nil {
+			return err
+		}
+		for _, m := range menus {
+			if m.Type == model.MenuTypeButton && m.Permission != "" && !guard.covers(m.Permission) {
+				return fmt.Errorf("不能授予自己未拥有的权限: %s", m.Permission)
+			}
+		}
+	}
 	if err := s.repo.SetRoleMenus(roleID, menuIDs); err != nil {
 		return err
 	}
@@ -246,15 +367,26 @@ func (s *RoleService) GetUserRoles(userID uint) ([]model.Role, error) {
 	return roles, err
 }
 
-func (s *RoleService) SetUserRoles(ctx context.Context, operatorRoles []string, userID uint, roleIDs []uint) error {
-	// 检查是否包含 super_admin 角色
+// SetUserRoles 设置用户角色；新增的角色须通过 grantGuard 校验（已持有的角色不受影响）
+func (s *RoleService) SetUserRoles(ctx context.Context, operatorID, userID uint, roleIDs []uint) error {
+	current, err := s.repo.GetUserRoleIDs(userID)
+	if err != nil {
+		return err
+	}
+	guard, err := s.newGrantGuard(ctx, operatorID)
+	if err != nil {
+		return err
+	}
 	for _, rid := range roleIDs {
 		role, err := s.repo.GetByID(rid)
 		if err != nil {
 			return fmt.Errorf("角色ID %d 不存在", rid)
 		}
-		if role.Code == model.RoleSuperAdmin && !model.IsSuperAdmin(operatorRoles) {
-			return errors.New("只有超级管理员可以分配该角色")
+		if containsUint(current, rid) {
+			continue
+		}
+		if err := guard.checkRole(role); err != nil {
+			return err
 		}
 	}
 
@@ -268,6 +400,163 @@ func (s *RoleService) SetUserRoles(ctx context.Context, operatorRoles []string,
 	return nil
 }
 
+// ─── 授权校验 ───
+
+// grantGuard 校验操作人可授予的角色与权限：
+//   - super_admin 仅超级管理员可授予；admin 仅 admin / super_admin 可授予，其按钮权限也仅它们可修改
+//   - 其余角色（含自定义角色）包含的按钮权限必须全部被操作人自身的全局权限覆盖
+type grantGuard struct {
+	svc   *RoleService
+	roles []string
+	perms []string
+}
+
+func (s *RoleService) newGrantGuard(ctx context.Context, operatorID uint) (*grantGuard, error) {
+	roles, err := s.repo.GetUserRoleCodes(operatorID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.GetUserPermissions(ctx, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	return &grantGuard{svc: s, roles: roles, perms: perms}, nil
+}
+
+func (g *grantGuard) covers(perm string) bool {
+	return model.IsSuperAdmin(g.roles) || model.HasAnyPermission(g.perms, perm)
+}
+
+// checkRoleCode 校验系统管理员角色的授予 / 修改
+func (g *grantGuard) checkRoleCode(role *model.Role) error {
+	switch role.Code {
+	case model.RoleSuperAdmin:
+		if !model.IsSuperAdmin(g.roles) {
+			return errors.New("只有超级管理员可以分配或修改该角色")
+		}
+	case model.RoleAdmin:
+		if !model.ContainsAnyRole(g.roles, model.RoleSuperAdmin, model.RoleAdmin) {
+			return errors.New("只有管理员可以分配或修改该角色")
+		}
+	}
+	return nil
+}
+
+// checkRole 校验角色能否被授予：除系统管理员角色外，角色权限不能超出操作人自身权限
+func (g *grantGuard) checkRole(role *model.Role) error {
+	if err := g.checkRoleCode(role); err != nil {
+		return err
+	}
+	if model.IsSuperAdmin(g.roles) {
+		return nil
+	}
+	perms, err := g.svc.permissionsOfRoles([]uint{role.ID})
+	if err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if !g.covers(p) {
+			return fmt.Errorf("角色 %s 包含您未拥有的权限 %s，无法分配", role.Name, p)
+		}
+	}
+	return nil
+}
+
+// ─── 限定作用范围的角色 ───
+
+// ErrOutOfScope 操作对象超出限定范围角色的军团/联盟
+var ErrOutOfScope = errors.New("权限不足：目标用户不在角色的军团/联盟范围内")
+
+// CheckUserScope 校验目标用户是否在数据范围内（scope 为 nil 时不限制）
+func CheckUserScope(userID uint, scope *model.DataScope) error {
+	ok, err := repository.UserInScope(userID, scope)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOutOfScope
+	}
+	return nil
+}
+
+// AddUserRoleScopeRequest 分配限定作用范围的角色
+type AddUserRoleScopeRequest struct {
+	RoleID    uint   `json:"role_id"    binding:"required"`
+	ScopeType string `json:"scope_type" binding:"required,oneof=corporation alliance"`
+	ScopeID   int64  `json:"scope_id"   binding:"required,gt=0"`
+}
+
+// ListUserRoleScopes 查询用户限定作用范围的角色
+func (s *RoleService) ListUserRoleScopes(userID uint) ([]model.UserRoleScope, error) {
+	list, err := s.repo.ListUserRoleScopes(userID)
+	if list == nil {
+		list = []model.UserRoleScope{}
+	}
+	return list, err
+}
+
+// AddUserRoleScope 为用户分配限定在某军团/联盟范围内的角色
+// 超级管理员与基础角色（user / guest）不支持限定范围
+func (s *RoleService) AddUserRoleScope(ctx context.Context, operatorID, userID uint, req *AddUserRoleScopeRequest) (*model.UserRoleScope, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	role, err := s.repo.GetByID(req.RoleID)
+	if err != nil {
+		return nil, errors.New("角色不存在")
+	}
+	switch role.Code {
+	case model.RoleSuperAdmin, model.RoleUser, model.RoleGuest:
+		return nil, fmt.Errorf("角色 %s 不支持限定作用范围", role.Name)
+	}
+	guard, err := s.newGrantGuard(ctx, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := guard.checkRole(role); err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.ExistsUserRoleScope(userID, role.ID, req.ScopeType, req.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("该用户已拥有此范围的角色")
+	}
+
+	scope := &model.UserRoleScope{
+		UserID:    userID,
+		RoleID:    role.ID,
+		ScopeType: req.ScopeType,
+		ScopeID:   req.ScopeID,
+		CreatedBy: operatorID,
+	}
+	if err := s.repo.CreateUserRoleScope(scope); err != nil {
+		return nil, err
+	}
+	scope.RoleCode = role.Code
+	scope.RoleName = role.Name
+	s.InvalidateUserCache(ctx, userID)
+	global.Logger.Info("分配限定范围角色",
+		zap.Uint("user_id", userID), zap.String("role", role.Code),
+		zap.String("scope_type", req.ScopeType), zap.Int64("scope_id", req.ScopeID),
+		zap.Uint("operator_id", operatorID))
+	return scope, nil
+}
+
+// RemoveUserRoleScope 移除用户限定作用范围的角色
+func (s *RoleService) RemoveUserRoleScope(ctx context.Context, userID, scopeID uint) error {
+	scope, err := s.repo.GetUserRoleScope(scopeID)
+	if err != nil || scope.UserID != userID {
+		return errors.New("角色范围不存在")
+	}
+	if err := s.repo.DeleteUserRoleScope(scopeID); err != nil {
+		return err
+	}
+	s.InvalidateUserCache(ctx, userID)
+	return nil
+}
+
 // ─── 内部辅助 ───
 
 func (s *RoleService) SyncUserPrimaryRole(userID uint) {
@@ -376,6 +665,7 @@ func (s *RoleService) seedDefaultRoleMenus(nameToID map[string]uint) {
 						global.Logger.Error("设置管理员全部菜单失败", zap.String("role", roleCode), zap.Error(err))
 					} else {
 						global.Logger.Info("管理员菜单已增量更新", zap.String("role", roleCode), zap.Int("added", len(toAdd)))
+						s.invalidateRoleUsers(role.ID)
 					}
 				}
 			}
@@ -420,47 +710,89 @@ func (s *RoleService) seedDefaultRoleMenus(nameToID map[string]uint) {
 				global.Logger.Error("增量更新角色菜单失败", zap.String("role", roleCode), zap.Error(err))
 			} else {
 				global.Logger.Info("角色菜单已增量更新", zap.String("role", roleCode), zap.Int("added", len(toAdd)))
+				s.invalidateRoleUsers(role.ID)
 			}
 		}
 	}
 	global.Logger.Info("默认角色菜单映射完成")
 }
 
-// CheckCorpAccessAndAdjustRole 检查用户名下所有角色的军团/联盟归属是否在准入列表内
-// 规则：
-//   - basic_access 名单为空 → 不限制，直接返回
-//   - admin / super_admin → 不受影响
-//   - 至少有一个角色的 CorporationID 或 AllianceID 在允许列表内 → 确保拥有 user 角色（从 guest 升级）
-//   - 没有符合条件的角色 → 降级为 guest（清除所有非高级角色）
-func (s *RoleService) CheckCorpAccessAndAdjustRole(ctx context.Context, userID uint) error {
+// invalidateRoleUsers 清除角色下所有用户的权限缓存，使新增的按钮权限立即生效
+func (s *RoleService) invalidateRoleUsers(roleID uint) {
+	userIDs, _ := s.repo.GetRoleUserIDs(roleID)
+	for _, uid := range userIDs {
+		s.InvalidateUserCache(context.Background(), uid)
+	}
+}
+
+// basicAccessPolicy basic_access 准入名单快照（批量检查时只读取一次）
+type basicAccessPolicy struct {
+	corpSet     map[int64]struct{}
+	allianceSet map[int64]struct{}
+	onlyMain    bool
+}
+
+// loadBasicAccessPolicy 读取 basic_access 准入名单；名单为空时返回 nil（不限制）
+func loadBasicAccessPolicy() (*basicAccessPolicy, error) {
 	allowRepo := repository.NewAllowedEntityRepository()
 	allowCorpIDs, allowAllianceIDs, err := allowRepo.GetAllIDs(model.AllowListBasicAccess)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(allowCorpIDs)+len(allowAllianceIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	allowCorpSet := make(map[int64]struct{}, len(allowCorpIDs))
+	p := &basicAccessPolicy{
+		corpSet:     make(map[int64]struct{}, len(allowCorpIDs)),
+		allianceSet: make(map[int64]struct{}, len(allowAllianceIDs)),
+		onlyMain:    repository.NewSysConfigRepository().GetBool(model.SysConfigBasicAccessAllowOnlyMainChar, false),
+	}
 	for _, id := range allowCorpIDs {
-		allowCorpSet[id] = struct{}{}
+		p.corpSet[id] = struct{}{}
 	}
-	allowAllianceSet := make(map[int64]struct{}, len(allowAllianceIDs))
 	for _, id := range allowAllianceIDs {
-		allowAllianceSet[id] = struct{}{}
+		p.allianceSet[id] = struct{}{}
 	}
+	return p, nil
+}
+
+// basicAccessPlan 单个用户的基础准入变更计划
+type basicAccessPlan struct {
+	promote       bool   // guest → user
+	removeRoleIDs []uint // 降级时移除的全部角色；升级时为 guest
+	addRoleID     uint   // 升级为 user / 降级为 guest
+}
 
+// CheckCorpAccessAndAdjustRole 检查用户名下所有角色的军团/联盟归属是否在准入列表内
+// 规则：
+//   - basic_access 名单为空 → 不限制，直接返回
+//   - admin / super_admin → 不受影响
+//   - 至少有一个角色的 CorporationID 或 AllianceID 在允许列表内 → 确保拥有 user 角色（从 guest 升级）
+//   - 没有符合条件的角色 → 降级为 guest（清除所有非高级角色）
+func (s *RoleService) CheckCorpAccessAndAdjustRole(ctx context.Context, userID uint) error {
+	policy, err := loadBasicAccessPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+	plan, err := s.planBasicAccess(userID, policy)
+	if err != nil || plan == nil {
+		return err
+	}
+	return s.applyBasicAccessPlan(ctx, userID, plan)
+}
+
+// planBasicAccess 计算用户的基础准入变更（不写入数据）；无需变更时返回 nil
+func (s *RoleService) planBasicAccess(userID uint, policy *basicAccessPolicy) (*basicAccessPlan, error) {
 	// 查询该用户绑定的所有 EVE 角色
 	charRepo := repository.NewEveCharacterRepository()
 	chars, err := charRepo.ListByUserID(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 若开启了"准入仅主角色"，只看主角色的军团/联盟是否在准入名单内
-	configRepo := repository.NewSysConfigRepository()
-	if configRepo.GetBool(model.SysConfigBasicAccessAllowOnlyMainChar, false) {
+	if policy.onlyMain {
 		if u, err := s.userRepo.GetByID(userID); err == nil && u.PrimaryCharacterID != 0 {
 			filtered := make([]model.EveCharacter, 0, 1)
 			for _, c := range chars {
@@ -477,13 +809,13 @@ func (s *RoleService) CheckCorpAccessAndAdjustRole(ctx context.Context, userID u
 	hasAccess := false
 	for _, c := range chars {
 		if c.CorporationID != 0 {
-			if _, ok := allowCorpSet[c.CorporationID]; ok {
+			if _, ok := policy.corpSet[c.CorporationID]; ok {
 				hasAccess = true
 				break
 			}
 		}
 		if c.AllianceID != nil && *c.AllianceID != 0 {
-			if _, ok := allowAllianceSet[*c.AllianceID]; ok {
+			if _, ok := policy.allianceSet[*c.AllianceID]; ok {
 				hasAccess = true
 				break
 			}
@@ -493,59 +825,69 @@ func (s *RoleService) CheckCorpAccessAndAdjustRole(ctx context.Context, userID u
 	// 获取用户当前拥有的角色
 	rollCodes, err := s.repo.GetUserRoleCodes(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// admin / super_admin 不受军团限制影响
 	if model.ContainsAnyRole(rollCodes, model.RoleAdmin, model.RoleSuperAdmin) {
-		return nil
+		return nil, nil
 	}
 
 	if hasAccess {
 		// 已有 user 或更高普通权限则无需变更
 		if model.ContainsRole(rollCodes, model.RoleUser) {
-			return nil
+			return nil, nil
 		}
 		// 从 guest 升级为 user：先移除 guest，再添加 user
 		userRole, err := s.repo.GetByCode(model.RoleUser)
 		if err != nil {
-			return err
-		}
-		if guestRole, err := s.repo.GetByCode(model.RoleGuest); err == nil {
-			_ = s.repo.RemoveUserRole(userID, guestRole.ID)
+			return nil, err
 		}
-		if err := s.repo.AddUserRole(userID, userRole.ID); err != nil {
-			return err
+		plan := &basicAccessPlan{promote: true, addRoleID: userRole.ID}
+		if guestRole, err := s.repo.GetByCode(model.RoleGuest); err == nil && model.ContainsRole(rollCodes, model.RoleGuest) {
+			plan.removeRoleIDs = []uint{guestRole.ID}
 		}
-		s.InvalidateUserCache(ctx, userID)
+		return plan, nil
+	}
+
+	// 已经是纯 guest 则无需变更
+	if len(rollCodes) == 1 && rollCodes[0] == model.RoleGuest {
+		return nil, nil
+	}
+	// 清除所有角色，降级为 guest
+	guestRole, err := s.repo.GetByCode(model.RoleGuest)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs, _ := s.repo.GetUserRoleIDs(userID)
+	return &basicAccessPlan{removeRoleIDs: roleIDs, addRoleID: guestRole.ID}, nil
+}
+
+// applyBasicAccessPlan 执行基础准入变更计划
+func (s *RoleService) applyBasicAccessPlan(ctx context.Context, userID uint, plan *basicAccessPlan) error {
+	for _, rid := range plan.removeRoleIDs {
+		_ = s.repo.RemoveUserRole(userID, rid)
+	}
+	if err := s.repo.AddUserRole(userID, plan.addRoleID); err != nil {
+		return err
+	}
+	s.InvalidateUserCache(ctx, userID)
+
+	userRole, err := s.repo.GetByCode(model.RoleUser)
+	if plan.promote {
 		global.Logger.Info("[CorpCheck] 用户升级为 user",
 			zap.Uint("user_id", userID))
 		// 写入同步日志
-		s.writeBasicAccessLog(userID, userRole.ID, userRole.Name, model.RoleUser, "add")
-	} else {
-		// 已经是纯 guest 则无需变更
-		if len(rollCodes) == 1 && rollCodes[0] == model.RoleGuest {
-			return nil
-		}
-		// 清除所有角色，降级为 guest
-		guestRole, err := s.repo.GetByCode(model.RoleGuest)
-		if err != nil {
-			return err
-		}
-		roleIDs, _ := s.repo.GetUserRoleIDs(userID)
-		for _, rid := range roleIDs {
-			_ = s.repo.RemoveUserRole(userID, rid)
-		}
-		if err := s.repo.AddUserRole(userID, guestRole.ID); err != nil {
-			return err
-		}
-		s.InvalidateUserCache(ctx, userID)
-		global.Logger.Info("[CorpCheck] 用户降级为 guest",
-			zap.Uint("user_id", userID))
-		// 写入同步日志（记录 user 角色被移除）
-		if userRole, err := s.repo.GetByCode(model.RoleUser); err == nil {
-			s.writeBasicAccessLog(userID, userRole.ID, userRole.Name, model.RoleUser, "remove")
+		if err == nil {
+			s.writeBasicAccessLog(userID, userRole.ID, userRole.Name, model.RoleUser, "add")
 		}
+		return nil
+	}
+	global.Logger.Info("[CorpCheck] 用户降级为 guest",
+		zap.Uint("user_id", userID))
+	// 写入同步日志（记录 user 角色被移除）
+	if err == nil {
+		s.writeBasicAccessLog(userID, userRole.ID, userRole.Name, model.RoleUser, "remove")
 	}
 	return nil
 }
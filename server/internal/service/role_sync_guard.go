@@ -0,0 +1,284 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  批量权限同步：预览与保护
+//  自动权限同步与基础准入检查均先为全部用户计算变更计划，再统一执行；
+//  若本轮将被移除角色的用户占比超过阈值，整轮中止并告警（管理员确认后可强制执行）
+// ─────────────────────────────────────────────
+
+const (
+	RoleSyncKindAutoRole    = "auto_role"
+	RoleSyncKindBasicAccess = "basic_access"
+
+	defaultRoleSyncMaxRemovePercent = 20.0
+	roleSyncGuardMinRemovals        = 3 // 移除人数低于该值时不触发保护，避免用户很少时误报
+	roleSyncAlertPrefix             = "role_sync:guard_alert:"
+	roleSyncAlertCooldown           = time.Hour
+)
+
+var ErrRoleSyncGuardTripped = errors.New("本轮将被移除角色的用户比例超过阈值，同步已中止")
+
+// RoleSyncRoleRef 预览中的角色信息
+type RoleSyncRoleRef struct {
+	RoleID   uint   `json:"role_id"`
+	RoleCode string `json:"role_code"`
+	RoleName string `json:"role_name"`
+}
+
+// RoleSyncUserDiff 单个用户的角色变更
+type RoleSyncUserDiff struct {
+	UserID   uint              `json:"user_id"`
+	Username string            `json:"username"`
+	Add      []RoleSyncRoleRef `json:"add"`
+	Remove   []RoleSyncRoleRef `json:"remove"`
+}
+
+// RoleSyncPreview 批量同步预览结果
+type RoleSyncPreview struct {
+	Kind             string             `json:"kind"` // auto_role | basic_access
+	TotalUsers       int                `json:"total_users"`
+	ChangedUsers     int                `json:"changed_users"`
+	RemovingUsers    int                `json:"removing_users"` // 将被移除角色的用户数
+	RemovePercent    float64            `json:"remove_percent"`
+	MaxRemovePercent float64            `json:"max_remove_percent"` // 0 表示未开启保护
+	Blocked          bool               `json:"blocked"`            // 超出阈值，非强制同步时将被中止
+	FailedUsers      int                `json:"failed_users"`       // 计算失败（已跳过）的用户数
+	Users            []RoleSyncUserDiff `json:"users"`
+}
+
+// RoleSyncGuardConfig 批量同步保护配置
+type RoleSyncGuardConfig struct {
+	MaxRemovePercent float64 `json:"max_remove_percent" binding:"gte=0,lte=100"` // 0 表示关闭保护
+}
+
+// GetRoleSyncGuardConfig 读取批量同步保护配置
+func GetRoleSyncGuardConfig() RoleSyncGuardConfig {
+	return RoleSyncGuardConfig{
+		MaxRemovePercent: repository.NewSysConfigRepository().GetFloat(model.SysConfigRoleSyncMaxRemovePercent, defaultRoleSyncMaxRemovePercent),
+	}
+}
+
+// SetRoleSyncGuardConfig 更新批量同步保护配置
+func SetRoleSyncGuardConfig(cfg RoleSyncGuardConfig) error {
+	return repository.NewSysConfigRepository().Set(model.SysConfigRoleSyncMaxRemovePercent,
+		strconv.FormatFloat(cfg.MaxRemovePercent, 'f', -1, 64), "批量权限同步单轮最多移除的用户比例（%，0 关闭）")
+}
+
+// newRoleSyncPreview 创建预览结果
+func newRoleSyncPreview(kind string, totalUsers int) *RoleSyncPreview {
+	return &RoleSyncPreview{Kind: kind, TotalUsers: totalUsers, Users: []RoleSyncUserDiff{}}
+}
+
+// addUser 记录用户变更；removing 表示该用户会失去权限（计入保护阈值）
+func (p *RoleSyncPreview) addUser(diff RoleSyncUserDiff, removing bool) {
+	if len(diff.Add)+len(diff.Remove) == 0 {
+		return
+	}
+	p.ChangedUsers++
+	if removing {
+		p.RemovingUsers++
+	}
+	p.Users = append(p.Users, diff)
+}
+
+// evaluateGuard 按阈值判定本轮是否应中止
+func (p *RoleSyncPreview) evaluateGuard() {
+	p.MaxRemovePercent = GetRoleSyncGuardConfig().MaxRemovePercent
+	if p.TotalUsers > 0 {
+		p.RemovePercent = float64(p.RemovingUsers) / float64(p.TotalUsers) * 100
+	}
+	p.Blocked = p.MaxRemovePercent > 0 &&
+		p.RemovingUsers >= roleSyncGuardMinRemovals &&
+		p.RemovePercent > p.MaxRemovePercent
+}
+
+// alertRoleSyncGuard 保护触发：记录错误日志并通过 Webhook 告警（同类告警冷却 roleSyncAlertCooldown）
+func alertRoleSyncGuard(ctx context.Context, p *RoleSyncPreview) {
+	global.Logger.Error("[RoleSync] 移除比例超过阈值，本轮同步已中止",
+		zap.String("kind", p.Kind),
+		zap.Int("removing_users", p.RemovingUsers),
+		zap.Int("total_users", p.TotalUsers),
+		zap.Float64("remove_percent", p.RemovePercent),
+		zap.Float64("max_remove_percent", p.MaxRemovePercent))
+
+	if ok, err := global.Redis.SetNX(ctx, roleSyncAlertPrefix+p.Kind, 1, roleSyncAlertCooldown).Result(); err == nil && !ok {
+		return
+	}
+	label := "自动权限同步"
+	if p.Kind == RoleSyncKindBasicAccess {
+		label = "基础准入检查"
+	}
+	content := fmt.Sprintf("⚠️ %s已中止\n本轮将移除 %d/%d 名用户的角色（%.1f%%），超过阈值 %.1f%%。\n请检查准入名单与映射配置，确认无误后可在后台预览并强制同步。",
+		label, p.RemovingUsers, p.TotalUsers, p.RemovePercent, p.MaxRemovePercent)
+	if err := NewWebhookService().SendAlert(content); err != nil {
+		global.Logger.Warn("[RoleSync] 发送告警失败", zap.Error(err))
+	}
+}
+
+// roleRefResolver 角色信息查询（预览内缓存）
+type roleRefResolver struct {
+	repo  *repository.RoleRepository
+	cache map[uint]RoleSyncRoleRef
+}
+
+func newRoleRefResolver(repo *repository.RoleRepository) *roleRefResolver {
+	return &roleRefResolver{repo: repo, cache: make(map[uint]RoleSyncRoleRef)}
+}
+
+func (r *roleRefResolver) refs(roleIDs []uint) []RoleSyncRoleRef {
+	result := make([]RoleSyncRoleRef, 0, len(roleIDs))
+	for _, rid := range roleIDs {
+		ref, ok := r.cache[rid]
+		if !ok {
+			ref = RoleSyncRoleRef{RoleID: rid}
+			if role, err := r.repo.GetByID(rid); err == nil {
+				ref.RoleCode = role.Code
+				ref.RoleName = role.Name
+			}
+			r.cache[rid] = ref
+		}
+		result = append(result, ref)
+	}
+	return result
+}
+
+// ─── 自动权限同步 ───
+
+// PreviewAutoRoleSync 预览自动权限同步：返回每个用户将新增 / 移除的角色，不写入数据
+func (s *AutoRoleService) PreviewAutoRoleSync(ctx context.Context) (*RoleSyncPreview, error) {
+	preview, _, err := s.planAllAutoRoles(ctx)
+	return preview, err
+}
+
+// planAllAutoRoles 为全部用户计算自动权限变更计划
+func (s *AutoRoleService) planAllAutoRoles(ctx context.Context) (*RoleSyncPreview, []*autoRolePlan, error) {
+	rules, err := s.autoRoleRepo.ListEnabledAutoRoleRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err := s.userRepo.ListAllIDs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	preview := newRoleSyncPreview(RoleSyncKindAutoRole, len(ids))
+	resolver := newRoleRefResolver(s.roleRepo)
+	ev := newAutoRoleRuleEvaluator(s)
+	plans := make([]*autoRolePlan, 0)
+	for _, uid := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		plan, err := s.planUserAutoRoles(uid, rules, ev)
+		if err != nil {
+			preview.FailedUsers++
+			global.Logger.Warn("[AutoRole] 计算自动权限失败", zap.Uint("user_id", uid), zap.Error(err))
+			continue
+		}
+		if plan == nil || len(plan.toAdd)+len(plan.toRemove) == 0 {
+			continue
+		}
+		plans = append(plans, plan)
+		preview.addUser(RoleSyncUserDiff{
+			UserID:   uid,
+			Username: plan.username,
+			Add:      resolver.refs(plan.toAdd),
+			Remove:   resolver.refs(plan.toRemove),
+		}, len(plan.toRemove) > 0)
+	}
+	preview.evaluateGuard()
+	return preview, plans, nil
+}
+
+// ─── 基础准入检查 ───
+
+// PreviewBasicAccess 预览基础准入检查（见 RoleService.PreviewBasicAccess）
+func (s *AutoRoleService) PreviewBasicAccess(ctx context.Context) (*RoleSyncPreview, error) {
+	return s.roleSvc.PreviewBasicAccess(ctx)
+}
+
+// PreviewBasicAccess 预览基础准入检查：返回每个用户将新增 / 移除的角色，不写入数据
+// basic_access 名单为空时不限制，返回空预览
+func (s *RoleService) PreviewBasicAccess(ctx context.Context) (*RoleSyncPreview, error) {
+	preview, _, err := s.planAllBasicAccess(ctx)
+	return preview, err
+}
+
+// SyncAllBasicAccess 对全部用户执行基础准入检查；force 为 false 时受移除比例保护
+func (s *RoleService) SyncAllBasicAccess(ctx context.Context, force bool) (*RoleSyncPreview, error) {
+	preview, plans, err := s.planAllBasicAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Blocked && !force {
+		alertRoleSyncGuard(ctx, preview)
+		return preview, ErrRoleSyncGuardTripped
+	}
+	for uid, plan := range plans {
+		if err := s.applyBasicAccessPlan(ctx, uid, plan); err != nil {
+			global.Logger.Warn("[CorpCheck] 检查失败",
+				zap.Uint("user_id", uid),
+				zap.Error(err))
+		}
+	}
+	return preview, nil
+}
+
+// planAllBasicAccess 为全部用户计算基础准入变更计划
+func (s *RoleService) planAllBasicAccess(ctx context.Context) (*RoleSyncPreview, map[uint]*basicAccessPlan, error) {
+	policy, err := loadBasicAccessPolicy()
+	if err != nil {
+		return nil, nil, err
+	}
+	if policy == nil {
+		return newRoleSyncPreview(RoleSyncKindBasicAccess, 0), nil, nil
+	}
+	ids, err := s.userRepo.ListAllIDs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	preview := newRoleSyncPreview(RoleSyncKindBasicAccess, len(ids))
+	resolver := newRoleRefResolver(s.repo)
+	plans := make(map[uint]*basicAccessPlan)
+	for _, uid := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		plan, err := s.planBasicAccess(uid, policy)
+		if err != nil {
+			preview.FailedUsers++
+			global.Logger.Warn("[CorpCheck] 计算准入变更失败", zap.Uint("user_id", uid), zap.Error(err))
+			continue
+		}
+		if plan == nil {
+			continue
+		}
+		plans[uid] = plan
+		username := ""
+		if u, err := s.userRepo.GetByID(uid); err == nil {
+			username = u.Nickname
+		}
+		preview.addUser(RoleSyncUserDiff{
+			UserID:   uid,
+			Username: username,
+			Add:      resolver.refs([]uint{plan.addRoleID}),
+			Remove:   resolver.refs(plan.removeRoleIDs),
+		}, !plan.promote)
+	}
+	preview.evaluateGuard()
+	return preview, plans, nil
+}
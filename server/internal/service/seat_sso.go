@@ -6,7 +6,6 @@ import (
 	"amiya-eden/internal/repository"
 	"amiya-eden/pkg/cache"
 	"amiya-eden/pkg/eve"
-	"amiya-eden/pkg/jwt"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -152,6 +151,7 @@ func (s *SeatSSOService) GetRedirectURLFromState(ctx context.Context, state stri
 // SeatCallbackResult SeAT OAuth 回调处理结果
 type SeatCallbackResult struct {
 	Token           string      `json:"token"`
+	RefreshToken    string      `json:"refresh_token"`
 	User            *model.User `json:"user"`
 	RedirectURL     string      `json:"redirect_url"`
 	IsRawRedirect   bool        `json:"-"`
@@ -237,11 +237,11 @@ func (s *SeatSSOService) HandleSeatCallback(ctx context.Context, code, state, cl
 		return nil, err
 	}
 
-	jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+	tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 	if err != nil {
 		return nil, err
 	}
-	return &SeatCallbackResult{Token: jwtToken, User: user, RedirectURL: sd.RedirectURL}, nil
+	return &SeatCallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, RedirectURL: sd.RedirectURL}, nil
 }
 
 // handleSeatFirstLogin 处理 SeAT 用户首次登录
@@ -334,11 +334,11 @@ func (s *SeatSSOService) handleSeatFirstLogin(
 	// 同步角色列表（自动合并，冲突提示迁移）
 	s.syncSeatCharacters(targetUser.ID, userInfo)
 
-	jwtToken, err := jwt.GenerateToken(targetUser.ID, targetUser.PrimaryCharacterID, targetUser.Role, global.Config.JWT.ExpireDay)
+	tokens, err := issueSession(ctx, targetUser, targetUser.PrimaryCharacterID)
 	if err != nil {
 		return nil, err
 	}
-	return &SeatCallbackResult{Token: jwtToken, User: targetUser, RedirectURL: sd.RedirectURL}, nil
+	return &SeatCallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: targetUser, RedirectURL: sd.RedirectURL}, nil
 }
 
 // handleSeatBind 处理 SeAT 账号绑定到已有用户
@@ -398,11 +398,11 @@ func (s *SeatSSOService) handleSeatBind(
 		return nil, err
 	}
 
-	jwtToken, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+	tokens, err := issueSession(ctx, user, user.PrimaryCharacterID)
 	if err != nil {
 		return nil, err
 	}
-	return &SeatCallbackResult{Token: jwtToken, User: user, RedirectURL: sd.RedirectURL}, nil
+	return &SeatCallbackResult{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user, RedirectURL: sd.RedirectURL}, nil
 }
 
 // syncSeatCharacters 将 SeAT 角色列表同步到本系统
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/cache"
+	"amiya-eden/pkg/jwt"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  登录会话
+//  Access Token 短期有效并携带会话 ID（sid），Refresh Token 每次使用后轮换；
+//  会话状态与用户状态在 Redis 中缓存 sessionStateTTL，吊销 / 禁用时立即写入缓存，
+//  因此吊销后已签发的 Access Token 立刻失效
+// ─────────────────────────────────────────────
+
+const (
+	sessionStatePrefix = "session:state:" // 会话状态缓存 "1"=有效 "0"=已吊销
+	userStatusPrefix   = "user:status:"   // 用户状态缓存（model.User.Status）
+	sessionStateTTL    = time.Minute      // 缓存有效期，同时也是 last_seen 的更新粒度
+	loginCodePrefix    = "session:login-code:"
+	loginCodeTTL       = time.Minute // SSO 回调一次性登录码有效期
+
+	defaultAccessTokenMinutes = 15
+	defaultSessionDays        = 7
+)
+
+var (
+	ErrLoginCodeInvalid = errors.New("登录码无效或已过期，请重新登录")
+	ErrSessionRevoked   = errors.New("会话已失效，请重新登录")
+	ErrUserDisabled     = errors.New("账号已被禁用")
+)
+
+// clientInfoKey 请求上下文中客户端信息的 key
+type clientInfoKey struct{}
+
+// ClientInfo 发起登录 / 刷新请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// WithClientInfo 将客户端信息写入 context（由 middleware.ClientInfo 注入），用于记录会话设备与 IP
+func WithClientInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, ClientInfo{IP: ip, UserAgent: userAgent})
+}
+
+func clientInfoFrom(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
+
+// SessionTokens 登录 / 刷新后下发的 Token
+type SessionTokens struct {
+	AccessToken    string `json:"token"`
+	RefreshToken   string `json:"refresh_token,omitempty"` // 模拟登录不下发 Refresh Token
+	ExpiresIn      int64  `json:"expires_in"`              // Access Token 剩余秒数
+	SessionID      string `json:"session_id"`
+	ImpersonatorID uint   `json:"impersonator_id,omitempty"`
+	ReadOnly       bool   `json:"read_only,omitempty"`
+}
+
+// SessionService 登录会话业务逻辑层
+type SessionService struct {
+	repo     *repository.UserSessionRepository
+	userRepo *repository.UserRepository
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		repo:     repository.NewUserSessionRepository(),
+		userRepo: repository.NewUserRepository(),
+	}
+}
+
+func accessTokenTTL() time.Duration {
+	if m := global.Config.JWT.AccessTokenMinutes; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return defaultAccessTokenMinutes * time.Minute
+}
+
+func sessionTTL() time.Duration {
+	if d := global.Config.JWT.ExpireDay; d > 0 {
+		return time.Duration(d) * 24 * time.Hour
+	}
+	return defaultSessionDays * 24 * time.Hour
+}
+
+// issueSession 为用户创建会话并签发 Token（各登录流程统一入口）
+func issueSession(ctx context.Context, user *model.User, characterID int64) (*SessionTokens, error) {
+	return NewSessionService().Issue(ctx, user, characterID)
+}
+
+// Issue 创建会话并签发 Access / Refresh Token
+func (s *SessionService) Issue(ctx context.Context, user *model.User, characterID int64) (*SessionTokens, error) {
+	if user.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+	session, refresh, err := s.newSession(ctx, user.ID, characterID, sessionTTL())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+	return s.signAccess(ctx, session, user.Role, refresh)
+}
+
+// newSession 构造会话记录（未落库），返回 Refresh Token 明文
+func (s *SessionService) newSession(ctx context.Context, userID uint, characterID int64, ttl time.Duration) (*model.UserSession, string, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	info := clientInfoFrom(ctx)
+	if len(info.UserAgent) > 512 {
+		info.UserAgent = info.UserAgent[:512]
+	}
+	now := time.Now()
+	return &model.UserSession{
+		ID:               uuid.New().String(),
+		UserID:           userID,
+		CharacterID:      characterID,
+		RefreshTokenHash: hash,
+		UserAgent:        info.UserAgent,
+		Device:           describeDevice(info.UserAgent),
+		IP:               info.IP,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(ttl),
+	}, refresh, nil
+}
+
+// Refresh 使用 Refresh Token 换取新的 Access Token，并轮换 Refresh Token
+// 已被轮换掉的旧 Refresh Token 再次出现时视为泄露，直接吊销整个会话
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*SessionTokens, error) {
+	hash := hashToken(refreshToken)
+	session, err := s.repo.GetByRefreshHash(hash)
+	if err != nil {
+		if prev, perr := s.repo.GetByPrevHash(hash); perr == nil && prev.RevokedAt == nil {
+			global.Logger.Warn("[Session] 检测到 Refresh Token 重放，吊销会话",
+				zap.String("session_id", prev.ID), zap.Uint("user_id", prev.UserID))
+			_ = s.revoke(ctx, prev.ID, model.SessionRevokeTokenReuse)
+		}
+		return nil, ErrSessionRevoked
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || session.ExpiresAt.Before(now) {
+		return nil, ErrSessionRevoked
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, ErrSessionRevoked
+	}
+	if user.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+
+	refresh, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	ip := clientInfoFrom(ctx).IP
+	ok, err := s.repo.Rotate(session.ID, hash, newHash, ip, now.Add(sessionTTL()), now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 并发刷新：另一个请求已完成轮换
+		return nil, ErrSessionRevoked
+	}
+	return s.signAccess(ctx, session, user.Role, refresh)
+}
+
+func (s *SessionService) signAccess(ctx context.Context, session *model.UserSession, role, refresh string) (*SessionTokens, error) {
+	ttl := accessTokenTTL()
+	if session.ImpersonatorID != 0 {
+		// 模拟登录不可刷新，Access Token 直接覆盖整个模拟时段
+		ttl = time.Until(session.ExpiresAt)
+	}
+	token, err := jwt.GenerateToken(jwt.Claims{
+		SessionID:      session.ID,
+		UserID:         session.UserID,
+		CharacterID:    session.CharacterID,
+		Role:           role,
+		ImpersonatorID: session.ImpersonatorID,
+		ReadOnly:       session.ReadOnly,
+	}, ttl)
+	if err != nil {
+		return nil, err
+	}
+	_ = cache.SetString(ctx, sessionStatePrefix+session.ID, "1", sessionStateTTL)
+	return &SessionTokens{
+		AccessToken:    token,
+		RefreshToken:   refresh,
+		ExpiresIn:      int64(ttl.Seconds()),
+		SessionID:      session.ID,
+		ImpersonatorID: session.ImpersonatorID,
+		ReadOnly:       session.ReadOnly,
+	}, nil
+}
+
+// Validate 校验 Access Token 对应的会话仍有效且用户未被禁用（JWTAuth 每次请求调用）
+func (s *SessionService) Validate(ctx context.Context, claims *jwt.Claims, ip string) error {
+	if claims.SessionID == "" {
+		return ErrSessionRevoked
+	}
+	if err := s.checkSession(ctx, claims.SessionID, ip); err != nil {
+		return err
+	}
+	return s.checkUserStatus(ctx, claims.UserID)
+}
+
+func (s *SessionService) checkSession(ctx context.Context, sessionID, ip string) error {
+	key := sessionStatePrefix + sessionID
+	if state, err := cache.GetString(ctx, key); err == nil {
+		if state == "1" {
+			return nil
+		}
+		return ErrSessionRevoked
+	}
+
+	// 缓存未命中：回源数据库，并顺带更新最近活跃时间
+	session, err := s.repo.GetByID(sessionID)
+	now := time.Now()
+	if err != nil || session.RevokedAt != nil || session.ExpiresAt.Before(now) {
+		_ = cache.SetString(ctx, key, "0", accessTokenTTL())
+		return ErrSessionRevoked
+	}
+	if err := s.repo.TouchLastSeen(sessionID, ip, now); err != nil {
+		global.Logger.Warn("[Session] 更新最近活跃时间失败", zap.String("session_id", sessionID), zap.Error(err))
+	}
+	_ = cache.SetString(ctx, key, "1", sessionStateTTL)
+	return nil
+}
+
+func (s *SessionService) checkUserStatus(ctx context.Context, userID uint) error {
+	key := userStatusPrefix + strconv.FormatUint(uint64(userID), 10)
+	status, err := cache.GetString(ctx, key)
+	if err != nil {
+		user, uerr := s.userRepo.GetByID(userID)
+		if uerr != nil {
+			status = "0"
+		} else {
+			status = strconv.Itoa(int(user.Status))
+		}
+		_ = cache.SetString(ctx, key, status, sessionStateTTL)
+	}
+	if status == "0" {
+		return ErrUserDisabled
+	}
+	return nil
+}
+
+// InvalidateUserStatus 用户状态变更后清除缓存，使禁用立即生效
+func (s *SessionService) InvalidateUserStatus(ctx context.Context, userID uint) {
+	_ = cache.Del(ctx, userStatusPrefix+strconv.FormatUint(uint64(userID), 10))
+}
+
+// ─── 会话管理 ───
+
+// SessionView 会话列表项
+type SessionView struct {
+	model.UserSession
+	Current bool `json:"current"` // 是否为发起请求的会话
+}
+
+// ListSessions 查询用户当前有效的会话
+func (s *SessionService) ListSessions(userID uint, currentID string) ([]SessionView, error) {
+	list, err := s.repo.ListActiveByUser(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SessionView, 0, len(list))
+	for _, item := range list {
+		result = append(result, SessionView{UserSession: item, Current: item.ID == currentID})
+	}
+	return result, nil
+}
+
+// Logout 登出当前会话
+func (s *SessionService) Logout(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.revoke(ctx, sessionID, model.SessionRevokeLogout)
+}
+
+// RevokeOwn 用户登出自己的某个会话
+func (s *SessionService) RevokeOwn(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("会话不存在")
+	}
+	return s.revoke(ctx, sessionID, model.SessionRevokeByUser)
+}
+
+// RevokeOthers 登出用户除当前会话外的所有会话，返回登出数量
+func (s *SessionService) RevokeOthers(ctx context.Context, userID uint, currentID string) (int, error) {
+	return s.revokeByUser(ctx, userID, currentID, model.SessionRevokeByUser)
+}
+
+// RevokeAll 强制下线用户的所有会话（管理员操作 / 用户被禁用），返回登出数量
+func (s *SessionService) RevokeAll(ctx context.Context, userID uint, reason string) (int, error) {
+	return s.revokeByUser(ctx, userID, "", reason)
+}
+
+func (s *SessionService) revoke(ctx context.Context, sessionID, reason string) error {
+	if err := s.repo.Revoke(sessionID, reason, time.Now()); err != nil {
+		return err
+	}
+	return cache.SetString(ctx, sessionStatePrefix+sessionID, "0", accessTokenTTL())
+}
+
+func (s *SessionService) revokeByUser(ctx context.Context, userID uint, exceptID, reason string) (int, error) {
+	ids, err := s.repo.RevokeByUser(userID, exceptID, reason, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		_ = cache.SetString(ctx, sessionStatePrefix+id, "0", accessTokenTTL())
+	}
+	return len(ids), nil
+}
+
+// IssueLoginCode 为 SSO 回调生成一次性登录码（Redis 中只保存哈希）
+// Refresh Token 不放入重定向 URL，由前端以 POST /auth/exchange 换取，避免写入浏览器历史与访问日志
+func IssueLoginCode(ctx context.Context, refreshToken string) (string, error) {
+	code, hash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := global.Redis.Set(ctx, loginCodePrefix+hash, refreshToken, loginCodeTTL).Err(); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode 使用一次性登录码换取 Refresh Token（读取即删除）
+func (s *SessionService) ExchangeLoginCode(ctx context.Context, code string) (string, error) {
+	token, err := global.Redis.GetDel(ctx, loginCodePrefix+hashToken(code)).Result()
+	if err != nil || token == "" {
+		return "", ErrLoginCodeInvalid
+	}
+	return token, nil
+}
+
+// CleanupExpired 删除过期 / 吊销超过 retention 的会话记录
+func (s *SessionService) CleanupExpired(retention time.Duration) (int64, error) {
+	return s.repo.DeleteExpiredBefore(time.Now().Add(-retention))
+}
+
+// ─── 工具函数 ───
+
+func newRefreshToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeDevice 从 User-Agent 粗略识别 "浏览器 / 系统"，用于设备列表展示
+func describeDevice(ua string) string {
+	if ua == "" {
+		return "未知设备"
+	}
+	browser := "其他浏览器"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	system := "其他系统"
+	for _, o := range []struct{ token, name string }{
+		{"Windows", "Windows"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			system = o.name
+			break
+		}
+	}
+	return browser + " / " + system
+}
@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
+	"amiya-eden/pkg/eve"
 	"context"
 	"errors"
 	"fmt"
@@ -15,20 +16,28 @@ import (
 
 // SrpService 补损业务逻辑层
 type SrpService struct {
-	repo      *repository.SrpRepository
-	fleetRepo *repository.FleetRepository
-	charRepo  *repository.EveCharacterRepository
-	sdeRepo   *repository.SdeRepository
-	ssoSvc    *EveSSOService
+	repo       *repository.SrpRepository
+	fleetRepo  *repository.FleetRepository
+	charRepo   *repository.EveCharacterRepository
+	sdeRepo    *repository.SdeRepository
+	ssoSvc     *EveSSOService
+	pricing    *SrpPricingService
+	walletSvc  *SysWalletService
+	walletRepo *repository.SysWalletRepository
+	cfgRepo    *repository.SysConfigRepository
 }
 
 func NewSrpService() *SrpService {
 	return &SrpService{
-		repo:      repository.NewSrpRepository(),
-		fleetRepo: repository.NewFleetRepository(),
-		charRepo:  repository.NewEveCharacterRepository(),
-		sdeRepo:   repository.NewSdeRepository(),
-		ssoSvc:    NewEveSSOService(),
+		repo:       repository.NewSrpRepository(),
+		fleetRepo:  repository.NewFleetRepository(),
+		charRepo:   repository.NewEveCharacterRepository(),
+		sdeRepo:    repository.NewSdeRepository(),
+		ssoSvc:     NewEveSSOService(),
+		pricing:    NewSrpPricingService(),
+		walletSvc:  NewSysWalletService(),
+		walletRepo: repository.NewSysWalletRepository(),
+		cfgRepo:    repository.NewSysConfigRepository(),
 	}
 }
 
@@ -163,44 +172,105 @@ func (s *SrpService) SubmitApplication(userID uint, req *SubmitApplicationReques
 		}
 	}
 
-	// 7. 查找推荐金额
-	recommended := 0.0
-	if priceRecord, perr := s.repo.GetShipPriceByTypeID(km.ShipTypeID); perr == nil {
-		recommended = priceRecord.Amount
+	// 7. 按定价策略计算推荐金额
+	quote, err := s.pricing.Quote(km, s.pricing.DoctrineFitting(req.FleetID, km.ShipTypeID))
+	if err != nil {
+		return nil, fmt.Errorf("计算推荐金额失败: %w", err)
 	}
 
 	finalAmount := req.FinalAmount
 	if finalAmount <= 0 {
-		finalAmount = recommended
+		finalAmount = quote.Amount
 	}
 
 	// 8. 构建申请
 	app := &model.SrpApplication{
-		UserID:            userID,
-		CharacterID:       req.CharacterID,
-		CharacterName:     char.CharacterName,
-		KillmailID:        req.KillmailID,
-		FleetID:           req.FleetID,
-		Note:              req.Note,
-		ShipTypeID:        km.ShipTypeID,
-		ShipName:          "", // 由前端或 SDE 填写；此处留空
-		SolarSystemID:     km.SolarSystemID,
-		SolarSystemName:   "", // 同上
-		KillmailTime:      km.KillmailTime,
-		CorporationID:     km.CorporationID,
-		AllianceID:        km.AllianceID,
-		RecommendedAmount: recommended,
-		FinalAmount:       finalAmount,
-		ReviewStatus:      model.SrpReviewPending,
-		PayoutStatus:      model.SrpPayoutPending,
-	}
-
-	if err := s.repo.CreateApplication(app); err != nil {
+		UserID:          userID,
+		CharacterID:     req.CharacterID,
+		CharacterName:   char.CharacterName,
+		KillmailID:      req.KillmailID,
+		FleetID:         req.FleetID,
+		Note:            req.Note,
+		ShipTypeID:      km.ShipTypeID,
+		ShipName:        "", // 由前端或 SDE 填写；此处留空
+		SolarSystemID:   km.SolarSystemID,
+		SolarSystemName: "", // 同上
+		KillmailTime:    km.KillmailTime,
+		CorporationID:   km.CorporationID,
+		AllianceID:      km.AllianceID,
+		FinalAmount:     finalAmount,
+		ReviewStatus:    model.SrpReviewPending,
+		PayoutStatus:    model.SrpPayoutPending,
+	}
+	quote.Apply(app)
+
+	if err := s.repo.CreateApplicationWithLines(app, quote.Lines); err != nil {
 		return nil, err
 	}
 	return app, nil
 }
 
+// QuoteKillmailRequest 推荐金额预览请求
+type QuoteKillmailRequest struct {
+	CharacterID int64   `json:"character_id" binding:"required"`
+	KillmailID  int64   `json:"killmail_id"  binding:"required"`
+	FleetID     *string `json:"fleet_id"`
+}
+
+// QuoteKillmail 提交前预览推荐金额及明细
+func (s *SrpService) QuoteKillmail(userID uint, req *QuoteKillmailRequest) (*SrpQuote, error) {
+	char, err := s.charRepo.GetByCharacterID(req.CharacterID)
+	if err != nil || char.UserID != userID {
+		return nil, errors.New("角色不属于当前用户或不存在")
+	}
+	km, err := resolveCharacterKillmail(req.KillmailID, req.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pricing.Quote(km, s.pricing.DoctrineFitting(req.FleetID, km.ShipTypeID))
+}
+
+// RepriceApplication 按当前定价策略重新计算推荐金额（仅限待审批 / 已拒绝的申请，已批准的金额不再变动）
+// 最终金额未被手动修改过（等于原推荐金额）时同步更新
+func (s *SrpService) RepriceApplication(appID uint) (*SrpApplicationResponse, error) {
+	app, err := s.repo.GetApplicationByID(appID)
+	if err != nil {
+		return nil, errors.New("申请不存在")
+	}
+	if app.PayoutStatus == model.SrpPayoutPaid {
+		return nil, errors.New("该申请已发放，不能重新计算金额")
+	}
+	if app.ReviewStatus == model.SrpReviewApproved {
+		return nil, errors.New("该申请已批准，不能重新计算金额")
+	}
+	var km model.EveKillmailList
+	if err := global.DB.Where("kill_mail_id = ?", app.KillmailID).First(&km).Error; err != nil {
+		return nil, errors.New("KM 详情不存在")
+	}
+	quote, err := s.pricing.Quote(&km, s.pricing.DoctrineFitting(app.FleetID, app.ShipTypeID))
+	if err != nil {
+		return nil, fmt.Errorf("计算推荐金额失败: %w", err)
+	}
+	if app.FinalAmount == app.RecommendedAmount {
+		app.FinalAmount = quote.Amount
+	}
+	quote.Apply(app)
+	if err := s.repo.UpdateApplicationPricing(app, quote.Lines); err != nil {
+		return nil, err
+	}
+	return s.GetApplication(appID)
+}
+
+// GetPricingConfig 读取 SRP 定价配置
+func (s *SrpService) GetPricingConfig() *SrpPricingConfig {
+	return s.pricing.GetConfig()
+}
+
+// UpdatePricingConfig 更新 SRP 定价配置（仅影响之后提交 / 重算的申请）
+func (s *SrpService) UpdatePricingConfig(cfg *SrpPricingConfig) (*SrpPricingConfig, error) {
+	return s.pricing.UpdateConfig(cfg)
+}
+
 // ─────────────────────────────────────────────
 //  申请列表（管理端）
 // ─────────────────────────────────────────────
@@ -208,8 +278,9 @@ func (s *SrpService) SubmitApplication(userID uint, req *SubmitApplicationReques
 // SrpApplicationResponse 补损申请响应（含舰队信息）
 type SrpApplicationResponse struct {
 	model.SrpApplication
-	FleetTitle  string `json:"fleet_title,omitempty"`
-	FleetFCName string `json:"fleet_fc_name,omitempty"`
+	FleetTitle  string                     `json:"fleet_title,omitempty"`
+	FleetFCName string                     `json:"fleet_fc_name,omitempty"`
+	Lines       []model.SrpApplicationLine `json:"lines,omitempty"` // 推荐金额明细（仅详情接口返回）
 }
 
 // enrichWithFleetInfo 为申请列表填充舰队信息
@@ -274,9 +345,24 @@ func (s *SrpService) GetApplication(id uint) (*SrpApplicationResponse, error) {
 			resp.FleetFCName = fleet.FCCharacterName
 		}
 	}
+	if resp.Lines, err = s.repo.ListApplicationLines(app.ID); err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
 
+// CheckApplicationScope 校验申请人是否在数据范围内（限定军团/联盟的审批人只能处理本范围成员的申请）
+func (s *SrpService) CheckApplicationScope(appID uint, scope *model.DataScope) error {
+	if scope == nil {
+		return nil
+	}
+	app, err := s.repo.GetApplicationByID(appID)
+	if err != nil {
+		return errors.New("申请不存在")
+	}
+	return CheckUserScope(app.UserID, scope)
+}
+
 // ─────────────────────────────────────────────
 //  审批
 // ─────────────────────────────────────────────
@@ -314,6 +400,10 @@ func (s *SrpService) ReviewApplication(reviewerID uint, appID uint, req *ReviewA
 		if req.FinalAmount > 0 {
 			app.FinalAmount = req.FinalAmount
 		}
+		// 已追回的申请重新批准后可再次发放
+		if app.PayoutStatus == model.SrpPayoutReversed {
+			app.PayoutStatus = model.SrpPayoutPending
+		}
 	case "reject":
 		app.ReviewStatus = model.SrpReviewRejected
 	}
@@ -333,16 +423,32 @@ type SrpPayoutRequest struct {
 	FinalAmount float64 `json:"final_amount"` // 允许最终覆盖金额（0=保持原值）
 }
 
+// srpRefID SRP 钱包流水关联 ID，发放与追回共用，按该 ID 汇总流水即为该申请的净发放金额
+func srpRefID(appID uint) string {
+	return fmt.Sprintf("srp:%d", appID)
+}
+
 // Payout 发放补损（srp/admin 可操作）
+// 开启 srp.wallet_payout 时在同一事务中计入申请人系统钱包；按 refID 对账，重复调用不会重复入账
 func (s *SrpService) Payout(payerID uint, appID uint, req *SrpPayoutRequest) (*model.SrpApplication, error) {
-	app, err := s.repo.GetApplicationByID(appID)
+	tx := global.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	app, err := s.repo.GetApplicationForUpdateTx(tx, appID)
 	if err != nil {
+		tx.Rollback()
 		return nil, errors.New("申请不存在")
 	}
 	if app.ReviewStatus != model.SrpReviewApproved {
+		tx.Rollback()
 		return nil, errors.New("申请未被批准，无法发放")
 	}
 	if app.PayoutStatus == model.SrpPayoutPaid {
+		tx.Rollback()
 		return nil, errors.New("该申请已发放，不能重复操作")
 	}
 	if req.FinalAmount > 0 {
@@ -353,12 +459,150 @@ func (s *SrpService) Payout(payerID uint, appID uint, req *SrpPayoutRequest) (*m
 	app.PaidBy = &payerID
 	app.PaidAt = &now
 
-	if err := s.repo.UpdateApplication(app); err != nil {
+	if s.cfgRepo.GetBool(model.SysConfigSrpWalletPayout, false) {
+		refID := srpRefID(app.ID)
+		paid, err := s.walletRepo.SumTransactionsByRefTx(tx, refID, model.WalletRefSrpPayout, model.WalletRefSrpReversal)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if delta := roundISK(app.FinalAmount - paid); delta > 0 {
+			reason := fmt.Sprintf("SRP 补损发放: KM %d", app.KillmailID)
+			if err := s.walletSvc.PostEntryTx(tx, app.UserID, delta, reason, model.WalletRefSrpPayout, refID, payerID); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("计入系统钱包失败: %w", err)
+			}
+		}
+	}
+
+	if err := s.repo.UpdateApplicationTx(tx, app); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 	return app, nil
 }
 
+// RevokeApplicationRequest 撤销已批准申请的请求
+type RevokeApplicationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RevokeApplication 撤销已批准的申请（改为已拒绝）
+// 已通过系统钱包发放的金额会在同一事务中从申请人钱包追回（余额可能因此为负）
+func (s *SrpService) RevokeApplication(operatorID uint, appID uint, req *RevokeApplicationRequest) (*model.SrpApplication, error) {
+	tx := global.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	app, err := s.repo.GetApplicationForUpdateTx(tx, appID)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("申请不存在")
+	}
+	if app.ReviewStatus != model.SrpReviewApproved {
+		tx.Rollback()
+		return nil, errors.New("仅可撤销已批准的申请")
+	}
+
+	if app.PayoutStatus == model.SrpPayoutPaid {
+		refID := srpRefID(app.ID)
+		paid, err := s.walletRepo.SumTransactionsByRefTx(tx, refID, model.WalletRefSrpPayout, model.WalletRefSrpReversal)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if paid > 0 {
+			reason := fmt.Sprintf("SRP 补损追回: KM %d（%s）", app.KillmailID, req.Reason)
+			if err := s.walletSvc.PostEntryTx(tx, app.UserID, -paid, reason, model.WalletRefSrpReversal, refID, operatorID); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("从系统钱包追回失败: %w", err)
+			}
+		}
+		app.PayoutStatus = model.SrpPayoutReversed
+	}
+
+	now := time.Now()
+	app.ReviewStatus = model.SrpReviewRejected
+	app.ReviewedBy = &operatorID
+	app.ReviewedAt = &now
+	app.ReviewNote = "[撤销] " + req.Reason
+
+	if err := s.repo.UpdateApplicationTx(tx, app); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// SrpBatchPayoutFailure 批量发放中失败的申请
+type SrpBatchPayoutFailure struct {
+	ApplicationID uint   `json:"application_id"`
+	Error         string `json:"error"`
+}
+
+// SrpBatchPayoutResult 批量发放结果
+type SrpBatchPayoutResult struct {
+	FleetID     string                  `json:"fleet_id"`
+	Paid        int                     `json:"paid"`
+	TotalAmount float64                 `json:"total_amount"`
+	Failed      []SrpBatchPayoutFailure `json:"failed"`
+}
+
+// BatchPayFleet 发放舰队下所有已批准、待发放的申请（逐条独立事务，单条失败不影响其它）
+// scope 非空时只发放范围内申请人的申请
+func (s *SrpService) BatchPayFleet(payerID uint, fleetID string, scope *model.DataScope) (*SrpBatchPayoutResult, error) {
+	if _, err := s.fleetRepo.GetByID(fleetID); err != nil {
+		return nil, errors.New("舰队不存在")
+	}
+	ids, err := s.repo.ListPayableApplicationIDsByFleet(fleetID, scope)
+	if err != nil {
+		return nil, err
+	}
+	result := &SrpBatchPayoutResult{FleetID: fleetID, Failed: make([]SrpBatchPayoutFailure, 0)}
+	for _, id := range ids {
+		app, err := s.Payout(payerID, id, &SrpPayoutRequest{})
+		if err != nil {
+			result.Failed = append(result.Failed, SrpBatchPayoutFailure{ApplicationID: id, Error: err.Error()})
+			continue
+		}
+		result.Paid++
+		result.TotalAmount += app.FinalAmount
+	}
+	result.TotalAmount = roundISK(result.TotalAmount)
+	return result, nil
+}
+
+// SrpPayoutConfig SRP 发放配置
+type SrpPayoutConfig struct {
+	WalletPayout bool `json:"wallet_payout"` // 发放时计入系统钱包
+}
+
+// GetPayoutConfig 读取 SRP 发放配置
+func (s *SrpService) GetPayoutConfig() *SrpPayoutConfig {
+	return &SrpPayoutConfig{WalletPayout: s.cfgRepo.GetBool(model.SysConfigSrpWalletPayout, false)}
+}
+
+// UpdatePayoutConfig 更新 SRP 发放配置
+func (s *SrpService) UpdatePayoutConfig(cfg *SrpPayoutConfig) (*SrpPayoutConfig, error) {
+	value := "false"
+	if cfg.WalletPayout {
+		value = "true"
+	}
+	if err := s.cfgRepo.Set(model.SysConfigSrpWalletPayout, value, "SRP 发放计入系统钱包"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 // ─────────────────────────────────────────────
 //  ESI: Open Information Window
 // ─────────────────────────────────────────────
@@ -387,7 +631,7 @@ func (s *SrpService) OpenInfoWindow(userID uint, req *OpenInfoWindowRequest) err
 	}
 
 	// 3. 调用 ESI Open Information Window
-	url := fmt.Sprintf("https://esi.evetech.net/ui/openwindow/information/?target_id=%d", req.TargetID)
+	url := eve.ESIURL(fmt.Sprintf("/ui/openwindow/information/?target_id=%d", req.TargetID))
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
 		return fmt.Errorf("构建请求失败: %w", err)
@@ -395,7 +639,7 @@ func (s *SrpService) OpenInfoWindow(userID uint, req *OpenInfoWindowRequest) err
 	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, err := eve.NewESIHTTPClient(30 * time.Second).Do(httpReq)
 	if err != nil {
 		return fmt.Errorf("调用 ESI Open Window 失败: %w", err)
 	}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"errors"
+	"fmt"
+)
+
+// ─────────────────────────────────────────────
+//  SRP 定价策略
+//  根据系统配置选择的策略计算推荐补损金额，并生成逐行明细供审批人员查看
+// ─────────────────────────────────────────────
+
+// errMissingHullPrice 船体没有市场价格，无法按损失价值定价
+var errMissingHullPrice = errors.New("缺少舰船的市场价格，请确认市场价格已拉取")
+
+// srpFittedSlots 计入装配价值的槽位类别（invFlags.flagName 去掉序号）
+var srpFittedSlots = map[string]bool{
+	"HiSlot":        true,
+	"MedSlot":       true,
+	"LoSlot":        true,
+	"RigSlot":       true,
+	"SubSystemSlot": true,
+	"ServiceSlot":   true,
+}
+
+// SrpPricingService SRP 推荐金额计算
+type SrpPricingService struct {
+	srpRepo         *repository.SrpRepository
+	fleetRepo       *repository.FleetRepository
+	fleetConfigRepo *repository.FleetConfigRepository
+	sdeRepo         *repository.SdeRepository
+	cfgRepo         *repository.SysConfigRepository
+	marketSvc       *MarketPriceService
+}
+
+func NewSrpPricingService() *SrpPricingService {
+	return &SrpPricingService{
+		srpRepo:         repository.NewSrpRepository(),
+		fleetRepo:       repository.NewFleetRepository(),
+		fleetConfigRepo: repository.NewFleetConfigRepository(),
+		sdeRepo:         repository.NewSdeRepository(),
+		cfgRepo:         repository.NewSysConfigRepository(),
+		marketSvc:       NewMarketPriceService(),
+	}
+}
+
+// ─── 配置 ───
+
+// SrpPricingConfig SRP 定价配置
+type SrpPricingConfig struct {
+	Policy      string  `json:"policy"        binding:"required,oneof=flat fitted_value loss_percent doctrine_cap"`
+	Basis       string  `json:"basis"`                                 // 市场估价基准，留空为 jita_sell_percentile
+	LossPercent float64 `json:"loss_percent"  binding:"gte=0,lte=100"` // loss_percent 策略的补损比例
+	LossCap     float64 `json:"loss_cap"      binding:"gte=0"`         // loss_percent 策略的单笔上限，0 表示不限
+}
+
+// GetConfig 读取 SRP 定价配置
+func (s *SrpPricingService) GetConfig() *SrpPricingConfig {
+	policy, _ := s.cfgRepo.Get(model.SysConfigSrpPricingPolicy, model.SrpPolicyFlat)
+	basis, _ := s.cfgRepo.Get(model.SysConfigSrpPriceBasis, DefaultPriceBasis.String())
+	return &SrpPricingConfig{
+		Policy:      policy,
+		Basis:       basis,
+		LossPercent: s.cfgRepo.GetFloat(model.SysConfigSrpLossPercent, 100),
+		LossCap:     s.cfgRepo.GetFloat(model.SysConfigSrpLossCap, 0),
+	}
+}
+
+// UpdateConfig 保存 SRP 定价配置
+func (s *SrpPricingService) UpdateConfig(cfg *SrpPricingConfig) (*SrpPricingConfig, error) {
+	basis, err := ParsePriceBasis(cfg.Basis)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Basis = basis.String()
+
+	if err := s.cfgRepo.Set(model.SysConfigSrpPricingPolicy, cfg.Policy, "SRP 定价策略"); err != nil {
+		return nil, err
+	}
+	if err := s.cfgRepo.Set(model.SysConfigSrpPriceBasis, cfg.Basis, "SRP 市场估价基准"); err != nil {
+		return nil, err
+	}
+	if err := s.cfgRepo.Set(model.SysConfigSrpLossPercent, fmt.Sprintf("%g", cfg.LossPercent), "SRP 损失价值补损比例"); err != nil {
+		return nil, err
+	}
+	if err := s.cfgRepo.Set(model.SysConfigSrpLossCap, fmt.Sprintf("%g", cfg.LossCap), "SRP 损失价值补损单笔上限"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ─── 计算 ───
+
+// SrpQuote 推荐金额计算结果
+type SrpQuote struct {
+	Policy    string                     `json:"policy"`
+	Basis     string                     `json:"basis"`
+	LossValue float64                    `json:"loss_value"` // 船体 + 装配槽位装备的市场价值
+	Amount    float64                    `json:"amount"`     // 推荐补损金额
+	Lines     []model.SrpApplicationLine `json:"lines"`
+	// NeedsReview 推荐金额为回退结果（如船体缺少市场价格），需人工核对
+	NeedsReview bool `json:"needs_review"`
+}
+
+// Apply 将计算结果写入补损申请
+func (q *SrpQuote) Apply(app *model.SrpApplication) {
+	app.RecommendedAmount = q.Amount
+	app.PricingPolicy = q.Policy
+	app.PricingBasis = q.Basis
+	app.LossValue = q.LossValue
+	app.NeedsManualReview = q.NeedsReview
+}
+
+// DoctrineFitting 查找舰队配置中与舰船匹配的装配（舰队未关联配置或无匹配时返回 nil）
+func (s *SrpPricingService) DoctrineFitting(fleetID *string, shipTypeID int64) *model.FleetConfigFitting {
+	if fleetID == nil || *fleetID == "" {
+		return nil
+	}
+	fleet, err := s.fleetRepo.GetByID(*fleetID)
+	if err != nil || fleet.FleetConfigID == nil || *fleet.FleetConfigID == 0 {
+		return nil
+	}
+	fittings, err := s.fleetConfigRepo.ListFittingsByConfigID(*fleet.FleetConfigID)
+	if err != nil {
+		return nil
+	}
+	for i := range fittings {
+		if fittings[i].ShipTypeID == shipTypeID {
+			return &fittings[i]
+		}
+	}
+	return nil
+}
+
+// Quote 按当前定价策略计算 KM 的推荐补损金额
+// fitting 为舰队配置中对应舰船的装配（可为 nil），用于 flat 与 doctrine_cap 策略
+func (s *SrpPricingService) Quote(km *model.EveKillmailList, fitting *model.FleetConfigFitting) (*SrpQuote, error) {
+	cfg := s.GetConfig()
+	basis, err := ParsePriceBasis(cfg.Basis)
+	if err != nil {
+		basis = DefaultPriceBasis
+	}
+	quote := &SrpQuote{Policy: cfg.Policy, Basis: basis.String()}
+
+	if cfg.Policy == model.SrpPolicyFlat {
+		amount, note := s.flatAmount(fitting, km.ShipTypeID)
+		quote.Amount = amount
+		quote.Lines = []model.SrpApplicationLine{{
+			Kind:      model.SrpLineFlat,
+			TypeID:    km.ShipTypeID,
+			Quantity:  1,
+			UnitPrice: amount,
+			Amount:    amount,
+			Note:      note,
+		}}
+		// 固定价格策略下损失价值仅供参考，市场价格缺失时不影响提交
+		if _, loss, err := s.lossLines(km, basis); err == nil {
+			quote.LossValue = loss
+		}
+		return quote, nil
+	}
+
+	lines, loss, err := s.lossLines(km, basis)
+	if errors.Is(err, errMissingHullPrice) {
+		// 船体缺少市场价格时回退到固定金额，申请照常创建，标记为需人工审核
+		amount, note := s.flatAmount(fitting, km.ShipTypeID)
+		quote.Amount = amount
+		quote.NeedsReview = true
+		quote.Lines = []model.SrpApplicationLine{{
+			Kind:      model.SrpLineFlat,
+			TypeID:    km.ShipTypeID,
+			Quantity:  1,
+			UnitPrice: amount,
+			Amount:    amount,
+			Note:      "舰船缺少市场价格，按固定金额回退（" + note + "），需人工审核",
+		}}
+		return quote, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	quote.LossValue = loss
+	quote.Lines = lines
+	amount := loss
+
+	switch cfg.Policy {
+	case model.SrpPolicyFittedValue:
+	case model.SrpPolicyLossPercent:
+		if cfg.LossPercent < 100 {
+			target := roundISK(amount * cfg.LossPercent / 100)
+			quote.Lines = append(quote.Lines, adjustmentLine(target-amount, fmt.Sprintf("按损失价值的 %g%% 补损", cfg.LossPercent)))
+			amount = target
+		}
+		if cfg.LossCap > 0 && amount > cfg.LossCap {
+			quote.Lines = append(quote.Lines, adjustmentLine(cfg.LossCap-amount, fmt.Sprintf("单笔上限 %.2f", cfg.LossCap)))
+			amount = cfg.LossCap
+		}
+	case model.SrpPolicyDoctrineCap:
+		limit, note := s.flatAmount(fitting, km.ShipTypeID)
+		if limit > 0 && amount > limit {
+			quote.Lines = append(quote.Lines, adjustmentLine(limit-amount, "上限："+note))
+			amount = limit
+		}
+	default:
+		return nil, fmt.Errorf("未知的 SRP 定价策略: %s", cfg.Policy)
+	}
+
+	quote.Amount = roundISK(amount)
+	return quote, nil
+}
+
+// flatAmount 固定金额：舰队配置装配金额 > 舰船价格表
+func (s *SrpPricingService) flatAmount(fitting *model.FleetConfigFitting, shipTypeID int64) (float64, string) {
+	if fitting != nil && fitting.SrpAmount > 0 {
+		return fitting.SrpAmount, "舰队配置装配金额（" + fitting.FittingName + "）"
+	}
+	if price, err := s.srpRepo.GetShipPriceByTypeID(shipTypeID); err == nil {
+		return price.Amount, "舰船价格表"
+	}
+	return 0, "舰船价格表中无该舰船"
+}
+
+// lossLines 生成船体 + KM 物品的估值明细，返回计入损失价值（船体 + 装配槽位）的合计
+func (s *SrpPricingService) lossLines(km *model.EveKillmailList, basis PriceBasis) ([]model.SrpApplicationLine, float64, error) {
+	var items []model.EveKillmailItem
+	if err := global.DB.Where("kill_mail_id = ?", km.KillmailID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	typeIDs := []int64{km.ShipTypeID}
+	flagIDSet := make(map[int]bool)
+	for _, it := range items {
+		typeIDs = append(typeIDs, int64(it.ItemID))
+		flagIDSet[it.Flag] = true
+	}
+	flagIDs := make([]int, 0, len(flagIDSet))
+	for fid := range flagIDSet {
+		flagIDs = append(flagIDs, fid)
+	}
+	flags, err := s.sdeRepo.GetFlags(flagIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	flagNames := make(map[int]string, len(flags))
+	for _, f := range flags {
+		flagNames[f.FlagID] = f.FlagName
+	}
+
+	prices, err := s.marketSvc.unitPrices(typeIDs, basis)
+	if err != nil {
+		return nil, 0, err
+	}
+	hull, ok := prices[km.ShipTypeID]
+	if !ok {
+		return nil, 0, errMissingHullPrice
+	}
+
+	lines := make([]model.SrpApplicationLine, 0, len(items)+1)
+	lines = append(lines, model.SrpApplicationLine{
+		Kind:      model.SrpLineHull,
+		TypeID:    km.ShipTypeID,
+		Quantity:  1,
+		UnitPrice: hull.UnitPrice,
+		Amount:    hull.UnitPrice,
+		Note:      basisNote(hull.Basis, basis),
+	})
+	loss := hull.UnitPrice
+
+	for _, it := range items {
+		typeID := int64(it.ItemID)
+		price := prices[typeID]
+		line := model.SrpApplicationLine{
+			Kind:      model.SrpLineModule,
+			TypeID:    typeID,
+			Quantity:  it.ItemNum,
+			Flag:      flagNames[it.Flag],
+			Dropped:   it.DropType != nil && *it.DropType,
+			UnitPrice: price.UnitPrice,
+			Note:      basisNote(price.Basis, basis),
+		}
+		switch {
+		case !srpFittedSlots[slotCategory(line.Flag)]:
+			line.Kind = model.SrpLineExcluded
+			line.Note = "非装配槽位，不计入补损"
+		case price.Basis == "":
+			line.Note = "无市场价格"
+		default:
+			line.Amount = roundISK(price.UnitPrice * float64(it.ItemNum))
+			loss += line.Amount
+		}
+		lines = append(lines, line)
+	}
+	return lines, roundISK(loss), nil
+}
+
+// basisNote 实际使用的估价基准与配置不一致（回退）时给出说明
+func basisNote(used string, basis PriceBasis) string {
+	if used == "" || used == basis.String() {
+		return ""
+	}
+	return "交易中心无挂单，按 " + used + " 估价"
+}
+
+func adjustmentLine(amount float64, note string) model.SrpApplicationLine {
+	return model.SrpApplicationLine{Kind: model.SrpLineAdjustment, Amount: roundISK(amount), Note: note}
+}
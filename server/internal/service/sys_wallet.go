@@ -142,14 +142,14 @@ func (s *SysWalletService) AdminAdjust(operatorID uint, req *AdminAdjustRequest)
 }
 
 // AdminListWallets 管理员查询所有钱包（附带主角色名）
-func (s *SysWalletService) AdminListWallets(page, pageSize int) ([]model.WalletWithCharacter, int64, error) {
+func (s *SysWalletService) AdminListWallets(page, pageSize int, scope *model.DataScope) ([]model.WalletWithCharacter, int64, error) {
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
-	return s.repo.ListWalletsWithCharacter(page, pageSize)
+	return s.repo.ListWalletsWithCharacter(page, pageSize, scope)
 }
 
 // AdminGetWallet 管理员查看指定用户钱包
@@ -271,6 +271,31 @@ func (s *SysWalletService) DebitUser(userID uint, amount float64, reason, refTyp
 	return tx.Commit().Error
 }
 
+// PostEntryTx 在已有事务中记一笔流水（钱包行加锁，余额不截断，允许追回后为负）
+// 用于 SRP 发放 / 追回等需要与业务状态同事务、按 refID 对账的场景
+func (s *SysWalletService) PostEntryTx(tx *gorm.DB, userID uint, delta float64, reason, refType, refID string, operatorID uint) error {
+	if delta == 0 {
+		return nil
+	}
+	wallet, err := s.repo.GetOrCreateWalletForUpdateTx(tx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户钱包失败: %w", err)
+	}
+	newBalance := wallet.Balance + delta
+	if err := s.repo.UpdateBalanceTx(tx, userID, newBalance); err != nil {
+		return err
+	}
+	return s.repo.CreateTransactionTx(tx, &model.WalletTransaction{
+		UserID:       userID,
+		Amount:       delta,
+		Reason:       reason,
+		RefType:      refType,
+		RefID:        refID,
+		BalanceAfter: newBalance,
+		OperatorID:   operatorID,
+	})
+}
+
 // ApplyWalletDeltaTx 在已有事务中对用户钱包应用差量（正=充值，负=扣减），用于 PAP 重复发放去重
 func (s *SysWalletService) ApplyWalletDeltaTx(tx *gorm.DB, userID uint, delta float64, reason, refType, refID string) error {
 	if delta == 0 {
@@ -86,14 +86,7 @@ func (s *WebhookService) SendFleetPing(fleet *model.Fleet) error {
 		return nil
 	}
 
-	importanceLabel := map[string]string{
-		model.FleetImportanceStratOp: "战略行动",
-		model.FleetImportanceCTA:     "全面集结",
-		model.FleetImportanceOther:   "其他行动",
-	}[fleet.Importance]
-	if importanceLabel == "" {
-		importanceLabel = fleet.Importance
-	}
+	importanceLabel := fleetImportanceLabel(fleet.Importance)
 
 	desc := fleet.Description
 	if desc == "" {
@@ -130,6 +123,65 @@ func (s *WebhookService) SendFleetPing(fleet *model.Fleet) error {
 	return s.sendMessage(cfg, content)
 }
 
+// SendOperationReminder 发送排期行动提醒（若未启用则静默忽略）
+func (s *WebhookService) SendOperationReminder(op *model.FleetOperation, minutesBefore, going, maybe int) error {
+	cfg, err := s.GetConfig()
+	if err != nil || !cfg.Enabled || cfg.URL == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏰ 行动提醒：%s 将在 %s后开始\n", op.Title, formatReminderOffset(minutesBefore))
+	fmt.Fprintf(&b, "类型: %s\n", fleetImportanceLabel(op.Importance))
+	fmt.Fprintf(&b, "开始时间: %s (EVE)\n", op.StartAt.UTC().Format("01/02 15:04"))
+	if op.FCCharacterName != "" {
+		fmt.Fprintf(&b, "FC: %s\n", op.FCCharacterName)
+	}
+	if op.FleetConfigID != nil && *op.FleetConfigID > 0 {
+		if fc, fcErr := repository.NewFleetConfigRepository().GetByID(*op.FleetConfigID); fcErr == nil {
+			fmt.Fprintf(&b, "舰队配置: %s\n", fc.Name)
+		}
+	}
+	fmt.Fprintf(&b, "报名: %d 人参加，%d 人待定", going, maybe)
+	return s.sendMessage(cfg, b.String())
+}
+
+// fleetImportanceLabel 舰队重要等级显示名
+func fleetImportanceLabel(importance string) string {
+	label := map[string]string{
+		model.FleetImportanceStratOp: "战略行动",
+		model.FleetImportanceCTA:     "全面集结",
+		model.FleetImportanceOther:   "其他行动",
+	}[importance]
+	if label == "" {
+		return importance
+	}
+	return label
+}
+
+// formatReminderOffset 提醒提前量显示（如 "1 小时 30 分钟"）
+func formatReminderOffset(minutes int) string {
+	switch {
+	case minutes >= 1440 && minutes%1440 == 0:
+		return fmt.Sprintf("%d 天", minutes/1440)
+	case minutes >= 60 && minutes%60 == 0:
+		return fmt.Sprintf("%d 小时", minutes/60)
+	case minutes >= 60:
+		return fmt.Sprintf("%d 小时 %d 分钟", minutes/60, minutes%60)
+	default:
+		return fmt.Sprintf("%d 分钟", minutes)
+	}
+}
+
+// SendAlert 发送系统告警（若未启用则静默忽略）
+func (s *WebhookService) SendAlert(content string) error {
+	cfg, err := s.GetConfig()
+	if err != nil || !cfg.Enabled || cfg.URL == "" {
+		return nil
+	}
+	return s.sendMessage(cfg, content)
+}
+
 // SendTest 发送测试消息
 func (s *WebhookService) SendTest(cfg *WebhookConfig, content string) error {
 	if cfg.Type == "" {
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TokenHealthService ESI Token 健康状态（失效提醒、重新授权、管理端报告）
+type TokenHealthService struct {
+	charRepo *repository.EveCharacterRepository
+	userRepo *repository.UserRepository
+	ssoSvc   *EveSSOService
+}
+
+func NewTokenHealthService() *TokenHealthService {
+	return &TokenHealthService{
+		charRepo: repository.NewEveCharacterRepository(),
+		userRepo: repository.NewUserRepository(),
+		ssoSvc:   NewEveSSOService(),
+	}
+}
+
+// ReauthCharacter 需要重新授权的角色
+type ReauthCharacter struct {
+	CharacterID   int64      `json:"character_id"`
+	CharacterName string     `json:"character_name"`
+	PortraitURL   string     `json:"portrait_url"`
+	InvalidAt     *time.Time `json:"invalid_at"`
+	Reason        string     `json:"reason"`
+}
+
+// ListReauthRequired 返回用户名下 Token 已失效的角色（不生成授权链接，点击重新授权时再调用 GetReauthURL）
+func (s *TokenHealthService) ListReauthRequired(userID uint, characters []model.EveCharacter) []ReauthCharacter {
+	result := make([]ReauthCharacter, 0)
+	for _, char := range characters {
+		if !char.TokenInvalid || char.UserID != userID {
+			continue
+		}
+		result = append(result, ReauthCharacter{
+			CharacterID:   char.CharacterID,
+			CharacterName: char.CharacterName,
+			PortraitURL:   char.PortraitURL,
+			InvalidAt:     char.TokenInvalidAt,
+			Reason:        char.TokenInvalidReason,
+		})
+	}
+	return result
+}
+
+// GetReauthURL 为用户名下 Token 已失效的角色生成重新绑定链接，完成后自动清除失效标记
+// 重新授权时沿用角色原有的 scope；redirectURL 为授权完成后的前端跳转地址（可为空）
+func (s *TokenHealthService) GetReauthURL(ctx context.Context, userID uint, characterID int64, redirectURL string) (string, error) {
+	char, err := s.charRepo.GetByCharacterID(characterID)
+	if err != nil || char.UserID != userID {
+		return "", errors.New("角色不存在")
+	}
+	if !char.TokenInvalid {
+		return "", errors.New("该角色 Token 有效，无需重新授权")
+	}
+	return s.ssoSvc.GetBindAuthURL(ctx, userID, strings.Fields(char.Scopes), redirectURL)
+}
+
+// InvalidTokenMember Token 失效的成员角色
+type InvalidTokenMember struct {
+	UserID        uint       `json:"user_id"`
+	Nickname      string     `json:"nickname"`
+	CharacterID   int64      `json:"character_id"`
+	CharacterName string     `json:"character_name"`
+	InvalidAt     *time.Time `json:"invalid_at"`
+	Reason        string     `json:"reason"`
+}
+
+// CorpTokenHealthReport 单个军团的失效 Token 报告
+type CorpTokenHealthReport struct {
+	CorporationID int64                `json:"corporation_id"`
+	Count         int                  `json:"count"`
+	Members       []InvalidTokenMember `json:"members"`
+}
+
+// GetCorpReport 按军团汇总 Token 已失效的成员角色（corporationID 为 0 时返回所有军团）
+func (s *TokenHealthService) GetCorpReport(corporationID int64) ([]CorpTokenHealthReport, error) {
+	chars, err := s.charRepo.ListTokenInvalid(corporationID)
+	if err != nil {
+		return nil, err
+	}
+
+	nicknames := make(map[uint]string)
+	for _, char := range chars {
+		if _, ok := nicknames[char.UserID]; ok {
+			continue
+		}
+		nicknames[char.UserID] = ""
+		if user, err := s.userRepo.GetByID(char.UserID); err == nil {
+			nicknames[char.UserID] = user.Nickname
+		}
+	}
+
+	reports := make([]CorpTokenHealthReport, 0)
+	index := make(map[int64]int)
+	for _, char := range chars {
+		i, ok := index[char.CorporationID]
+		if !ok {
+			i = len(reports)
+			index[char.CorporationID] = i
+			reports = append(reports, CorpTokenHealthReport{CorporationID: char.CorporationID})
+		}
+		reports[i].Members = append(reports[i].Members, InvalidTokenMember{
+			UserID:        char.UserID,
+			Nickname:      nicknames[char.UserID],
+			CharacterID:   char.CharacterID,
+			CharacterName: char.CharacterName,
+			InvalidAt:     char.TokenInvalidAt,
+			Reason:        char.TokenInvalidReason,
+		})
+		reports[i].Count++
+	}
+	return reports, nil
+}
@@ -4,8 +4,10 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"amiya-eden/internal/repository"
-	"amiya-eden/pkg/jwt"
+	"context"
 	"errors"
+
+	"go.uber.org/zap"
 )
 
 type UserService struct {
@@ -67,26 +69,45 @@ func (s *UserService) ListUsers(page, pageSize int, filter repository.UserFilter
 	return dtos, total, nil
 }
 
-func (s *UserService) UpdateUser(user *model.User) error {
-	return s.repo.Update(user)
+func (s *UserService) UpdateUser(ctx context.Context, user *model.User) error {
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+	sessionSvc := NewSessionService()
+	sessionSvc.InvalidateUserStatus(ctx, user.ID)
+	if user.Status == 0 {
+		// 禁用用户时立即下线其所有会话
+		if _, err := sessionSvc.RevokeAll(ctx, user.ID, model.SessionRevokeDisabled); err != nil {
+			global.Logger.Warn("禁用用户后吊销会话失败", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+	}
+	return nil
 }
 
-func (s *UserService) DeleteUser(id uint) error {
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
 	if _, err := s.repo.GetByID(id); err != nil {
 		return errors.New("用户不存在")
 	}
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	sessionSvc := NewSessionService()
+	sessionSvc.InvalidateUserStatus(ctx, id)
+	if _, err := sessionSvc.RevokeAll(ctx, id, model.SessionRevokeDisabled); err != nil {
+		global.Logger.Warn("删除用户后吊销会话失败", zap.Uint("user_id", id), zap.Error(err))
+	}
+	return nil
 }
 
-// ImpersonateUser 以指定用户身份生成 JWT（仅超级管理员可用）
-func (s *UserService) ImpersonateUser(id uint) (string, *model.User, error) {
+// ImpersonateUser 以指定用户身份创建限时模拟会话（仅超级管理员可用）
+func (s *UserService) ImpersonateUser(ctx context.Context, adminID uint, adminSessionID string, id uint, req ImpersonationRequest) (*SessionTokens, *model.User, error) {
 	user, err := s.repo.GetByID(id)
 	if err != nil {
-		return "", nil, errors.New("用户不存在")
+		return nil, nil, errors.New("用户不存在")
 	}
-	token, err := jwt.GenerateToken(user.ID, user.PrimaryCharacterID, user.Role, global.Config.JWT.ExpireDay)
+	tokens, err := NewSessionService().StartImpersonation(ctx, adminID, adminSessionID, user, req)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
-	return token, user, nil
+	return tokens, user, nil
 }
@@ -16,7 +16,7 @@ func registerAlliancePAPJob(c *cron.Cron) {
 	svc := service.NewAlliancePAPService()
 
 	// ── 每小时整点刷新当月 ──
-	hourlyID, err := c.AddFunc("0 0 * * * *", func() {
+	hourlyID, err := addLeasedFunc(c, "alliance_pap_hourly", "0 0 * * * *", func() {
 		now := time.Now()
 		global.Logger.Info("开始联盟 PAP 小时刷新", zap.Int("year", now.Year()), zap.Int("month", int(now.Month())))
 		svc.FetchAllUsers(now.Year(), int(now.Month()))
@@ -28,7 +28,7 @@ func registerAlliancePAPJob(c *cron.Cron) {
 	global.Logger.Info("注册联盟 PAP 小时任务成功", zap.Int("entry_id", int(hourlyID)))
 
 	// ── 每月第一天 01:00 归档上月并拉取最终数据 ──
-	monthlyID, err := c.AddFunc("0 0 1 1 * *", func() {
+	monthlyID, err := addLeasedFunc(c, "alliance_pap_monthly", "0 0 1 1 * *", func() {
 		now := time.Now()
 		// 上月
 		lastMonth := now.AddDate(0, -1, 0)
@@ -12,7 +12,7 @@ import (
 // RegisterAutoRoleJobs 注册自动权限同步定时任务
 func RegisterAutoRoleJobs(c *cron.Cron) {
 	// 每 10 分钟执行一次自动权限同步（在 ESI 刷新之后）
-	id, err := c.AddFunc("0 2/10 * * * ?", autoRoleSyncTask)
+	id, err := addLeasedFunc(c, "auto_role_sync", "0 2/10 * * * ?", autoRoleSyncTask)
 	if err != nil {
 		global.Logger.Error("注册自动权限同步定时任务失败", zap.Error(err))
 		return
@@ -20,7 +20,7 @@ func RegisterAutoRoleJobs(c *cron.Cron) {
 	global.Logger.Info("注册自动权限同步定时任务成功", zap.Int("entry_id", int(id)))
 
 	// 每 30 分钟刷新 SeAT 用户分组并同步权限
-	sid, err := c.AddFunc("0 5/30 * * * ?", seatRoleSyncTask)
+	sid, err := addLeasedFunc(c, "seat_role_sync", "0 5/30 * * * ?", seatRoleSyncTask)
 	if err != nil {
 		global.Logger.Error("注册 SeAT 分组同步定时任务失败", zap.Error(err))
 		return
@@ -32,7 +32,7 @@ func RegisterAutoRoleJobs(c *cron.Cron) {
 func autoRoleSyncTask() {
 	ctx := context.Background()
 	autoRoleSvc := service.NewAutoRoleService()
-	autoRoleSvc.SyncAllUsersAutoRoles(ctx)
+	_, _ = autoRoleSvc.SyncAllUsersAutoRoles(ctx, false)
 }
 
 // seatRoleSyncTask 刷新 SeAT 用户 token 和分组，然后同步自动权限
@@ -45,5 +45,5 @@ func seatRoleSyncTask() {
 
 	// 2. 重新同步自动权限
 	autoRoleSvc := service.NewAutoRoleService()
-	autoRoleSvc.SyncAllUsersAutoRoles(ctx)
+	_, _ = autoRoleSvc.SyncAllUsersAutoRoles(ctx, false)
 }
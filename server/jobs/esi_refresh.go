@@ -5,6 +5,7 @@ import (
 	"amiya-eden/internal/service"
 	"amiya-eden/pkg/eve/esi"
 	"context"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
@@ -77,7 +78,7 @@ func registerESIRefreshJob(c *cron.Cron) {
 	}
 
 	// 每 5 分钟执行一次调度（队列内部根据各任务间隔判断是否需要刷新）
-	id, err := c.AddFunc("0 */5 * * * *", func() {
+	id, err := addLeasedFunc(c, "esi_refresh", "0 */5 * * * *", func() {
 		esiQueue.Run()
 	})
 	if err != nil {
@@ -85,4 +86,25 @@ func registerESIRefreshJob(c *cron.Cron) {
 		return
 	}
 	global.Logger.Info("注册 ESI 刷新定时任务成功", zap.Int("entry_id", int(id)))
+
+	// 每天 04:30 清理过期的任务执行记录
+	cleanupID, err := addLeasedFunc(c, "esi_task_run_cleanup", "0 30 4 * * *", esiTaskRunCleanupTask)
+	if err != nil {
+		global.Logger.Error("注册 ESI 执行记录清理任务失败", zap.Error(err))
+		return
+	}
+	global.Logger.Info("注册 ESI 执行记录清理任务成功", zap.Int("entry_id", int(cleanupID)))
+}
+
+// esiTaskRunRetention ESI 任务执行记录保留时长
+const esiTaskRunRetention = 30 * 24 * time.Hour
+
+// esiTaskRunCleanupTask 删除过期的 ESI 任务执行记录
+func esiTaskRunCleanupTask() {
+	deleted, err := esiQueue.CleanupRuns(esiTaskRunRetention)
+	if err != nil {
+		global.Logger.Error("[ESI Queue] 清理任务执行记录失败", zap.Error(err))
+		return
+	}
+	global.Logger.Info("[ESI Queue] 清理任务执行记录完成", zap.Int64("deleted", deleted))
 }
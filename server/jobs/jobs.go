@@ -8,10 +8,17 @@ import (
 )
 
 // RegisterAll 统一注册所有定时任务
+// 所有任务均通过 addLeasedFunc 注册，多实例部署时由 Redis 租约保证同一任务只在一个实例执行
 func RegisterAll(c *cron.Cron) {
+	initCronInstance()
+	startInstanceHeartbeat()
+
 	registerSdeJob(c)
 	registerESIRefreshJob(c)
 	registerAlliancePAPJob(c)
+	registerMarketPriceJob(c)
+	registerSessionCleanupJob(c)
+	registerOperationJobs(c)
 	RegisterRoleJobs(c)
 	RegisterAutoRoleJobs(c)
 	// registerCleanupJob(c)
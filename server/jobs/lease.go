@@ -0,0 +1,413 @@
+package jobs
+
+import (
+	"amiya-eden/global"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  分布式租约（多实例部署时保证同一定时任务只在一个实例执行）
+//  key: cron:lease:{jobName}       value: 持有者信息 JSON，执行期间按 TTL/3 续期
+//  key: cron:instance:{instanceID} value: 实例心跳 JSON
+// ─────────────────────────────────────────────
+
+const (
+	leaseKeyPrefix    = "cron:lease:"
+	instanceKeyPrefix = "cron:instance:"
+
+	defaultLeaseTTL = 60 * time.Second
+	// leaseMinHold 任务执行结束后租约至少保留的时长
+	// 各实例的 cron 在同一秒触发，若任务很快结束就立即释放，晚到的实例仍会再执行一次
+	leaseMinHold = 30 * time.Second
+
+	instanceHeartbeatInterval = 30 * time.Second
+	instanceHeartbeatTTL      = 90 * time.Second
+)
+
+// renewScript 仅当租约仍由自己持有时续期
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript 仅当租约仍由自己持有时释放；ARGV[2] > 0 时改为保留指定毫秒数
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	if tonumber(ARGV[2]) > 0 then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// LeaseHolder 租约持有者信息
+type LeaseHolder struct {
+	InstanceID string    `json:"instance_id"`
+	Hostname   string    `json:"hostname"`
+	PID        int       `json:"pid"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// CronInstance 在线实例心跳信息
+type CronInstance struct {
+	InstanceID string    `json:"instance_id"`
+	Hostname   string    `json:"hostname"`
+	PID        int       `json:"pid"`
+	StartedAt  time.Time `json:"started_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// JobLeaseStatus 定时任务租约状态（供管理端展示）
+type JobLeaseStatus struct {
+	Name           string       `json:"name"`
+	Spec           string       `json:"spec"`
+	Holder         *LeaseHolder `json:"holder"`           // 当前租约持有者，nil 表示空闲
+	LeaseTTL       string       `json:"lease_ttl"`        // 租约剩余时间
+	RunningLocal   bool         `json:"running_local"`    // 是否正在本实例执行
+	LastRunAt      *time.Time   `json:"last_run_at"`      // 本实例最近一次执行开始时间
+	LastFinishedAt *time.Time   `json:"last_finished_at"` // 本实例最近一次执行结束时间
+	LastSkippedAt  *time.Time   `json:"last_skipped_at"`  // 本实例最近一次因租约被占用而跳过的时间
+	SkippedCount   int64        `json:"skipped_count"`    // 本实例累计跳过次数
+}
+
+// CronLeaseOverview 定时任务租约总览
+type CronLeaseOverview struct {
+	InstanceID string           `json:"instance_id"` // 当前响应请求的实例
+	Instances  []CronInstance   `json:"instances"`
+	Jobs       []JobLeaseStatus `json:"jobs"`
+}
+
+// jobEntry 本实例已注册任务的运行记录
+type jobEntry struct {
+	name           string
+	spec           string
+	running        bool
+	lastRunAt      time.Time
+	lastFinishedAt time.Time
+	lastSkippedAt  time.Time
+	skippedCount   int64
+}
+
+var (
+	instance = newCronInstance()
+
+	jobsMu    sync.RWMutex
+	jobsByKey = make(map[string]*jobEntry)
+
+	heartbeatOnce sync.Once
+)
+
+func newCronInstance() CronInstance {
+	hostname, _ := os.Hostname()
+	return CronInstance{
+		Hostname:  hostname,
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+	}
+}
+
+// initCronInstance 确定本实例标识（配置优先，否则为 主机名-PID），需在注册任务前调用
+func initCronInstance() {
+	if global.Config != nil && global.Config.Cron.InstanceID != "" {
+		instance.InstanceID = global.Config.Cron.InstanceID
+	} else {
+		instance.InstanceID = fmt.Sprintf("%s-%d", instance.Hostname, instance.PID)
+	}
+}
+
+// instanceID 返回本实例标识
+func instanceID() string {
+	return instance.InstanceID
+}
+
+func leaseTTL() time.Duration {
+	if global.Config != nil && global.Config.Cron.LeaseTTLSeconds > 0 {
+		return time.Duration(global.Config.Cron.LeaseTTLSeconds) * time.Second
+	}
+	return defaultLeaseTTL
+}
+
+func leaseKey(name string) string {
+	return leaseKeyPrefix + name
+}
+
+// addLeasedFunc 注册受租约保护的定时任务：同一时刻仅有一个实例能执行
+func addLeasedFunc(c *cron.Cron, name, spec string, cmd func()) (cron.EntryID, error) {
+	return addLeasedFuncWithHold(c, name, spec, leaseMinHold, cmd)
+}
+
+// addLeasedFuncWithHold 同 addLeasedFunc，但指定任务结束后租约的最短保留时长；
+// 触发周期短于 leaseMinHold 的任务需传入小于周期的值，否则每隔一次触发都会被跳过
+func addLeasedFuncWithHold(c *cron.Cron, name, spec string, minHold time.Duration, cmd func()) (cron.EntryID, error) {
+	jobsMu.Lock()
+	if _, ok := jobsByKey[name]; !ok {
+		jobsByKey[name] = &jobEntry{name: name, spec: spec}
+	}
+	jobsMu.Unlock()
+	return c.AddFunc(spec, withLease(name, minHold, cmd))
+}
+
+// withLease 包装任务函数：获取租约成功才执行，执行期间自动续期，结束后释放
+func withLease(name string, minHold time.Duration, cmd func()) func() {
+	return func() {
+		lease, err := acquireLease(name, minHold)
+		if err != nil {
+			global.Logger.Error("[Cron Lease] 获取租约失败，跳过本次执行", zap.String("job", name), zap.Error(err))
+			return
+		}
+		if lease == nil {
+			markJob(name, func(e *jobEntry) {
+				e.lastSkippedAt = time.Now()
+				e.skippedCount++
+			})
+			global.Logger.Debug("[Cron Lease] 租约已被其他实例持有，跳过", zap.String("job", name))
+			return
+		}
+		defer lease.release()
+
+		markJob(name, func(e *jobEntry) {
+			e.running = true
+			e.lastRunAt = lease.holder.AcquiredAt
+		})
+		defer markJob(name, func(e *jobEntry) {
+			e.running = false
+			e.lastFinishedAt = time.Now()
+		})
+
+		cmd()
+	}
+}
+
+func markJob(name string, fn func(e *jobEntry)) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	e, ok := jobsByKey[name]
+	if !ok {
+		e = &jobEntry{name: name}
+		jobsByKey[name] = e
+	}
+	fn(e)
+}
+
+// jobLease 一次成功获取的租约
+type jobLease struct {
+	name    string
+	key     string
+	value   string
+	ttl     time.Duration
+	minHold time.Duration
+	holder  LeaseHolder
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// acquireLease 尝试获取租约；已被其他实例持有时返回 (nil, nil)
+func acquireLease(name string, minHold time.Duration) (*jobLease, error) {
+	holder := LeaseHolder{
+		InstanceID: instanceID(),
+		Hostname:   instance.Hostname,
+		PID:        instance.PID,
+		AcquiredAt: time.Now(),
+	}
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := leaseTTL()
+	key := leaseKey(name)
+	ok, err := global.Redis.SetNX(context.Background(), key, string(data), ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	l := &jobLease{
+		name:    name,
+		key:     key,
+		value:   string(data),
+		ttl:     ttl,
+		minHold: minHold,
+		holder:  holder,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.keepAlive()
+	return l, nil
+}
+
+// keepAlive 按 TTL/3 周期续期，直到任务结束
+func (l *jobLease) keepAlive() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			n, err := renewScript.Run(context.Background(), global.Redis, []string{l.key}, l.value, l.ttl.Milliseconds()).Int64()
+			if err != nil {
+				global.Logger.Warn("[Cron Lease] 租约续期失败", zap.String("job", l.name), zap.Error(err))
+				continue
+			}
+			if n == 0 {
+				global.Logger.Error("[Cron Lease] 租约已丢失，任务可能在其他实例重复执行", zap.String("job", l.name))
+				return
+			}
+		}
+	}
+}
+
+// release 停止续期并释放租约（保留至少 minHold，避免其他实例在同一触发点重复执行）
+func (l *jobLease) release() {
+	close(l.stop)
+	<-l.done
+
+	hold := l.minHold - time.Since(l.holder.AcquiredAt)
+	var holdMs int64
+	if hold > 0 {
+		holdMs = hold.Milliseconds()
+	}
+	if err := releaseScript.Run(context.Background(), global.Redis, []string{l.key}, l.value, holdMs).Err(); err != nil {
+		global.Logger.Warn("[Cron Lease] 释放租约失败", zap.String("job", l.name), zap.Error(err))
+	}
+}
+
+// ─────────────────────────────────────────────
+//  实例心跳
+// ─────────────────────────────────────────────
+
+// startInstanceHeartbeat 周期写入本实例心跳，供管理端查看在线实例
+func startInstanceHeartbeat() {
+	heartbeatOnce.Do(func() {
+		go func() {
+			writeInstanceHeartbeat()
+			ticker := time.NewTicker(instanceHeartbeatInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				writeInstanceHeartbeat()
+			}
+		}()
+	})
+}
+
+func writeInstanceHeartbeat() {
+	info := instance
+	info.InstanceID = instanceID()
+	info.LastSeenAt = time.Now()
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	if err := global.Redis.Set(context.Background(), instanceKeyPrefix+info.InstanceID, data, instanceHeartbeatTTL).Err(); err != nil {
+		global.Logger.Warn("[Cron Lease] 写入实例心跳失败", zap.Error(err))
+	}
+}
+
+// ─────────────────────────────────────────────
+//  管理端查询
+// ─────────────────────────────────────────────
+
+// GetCronLeaseOverview 查询在线实例以及各定时任务的租约持有情况
+func GetCronLeaseOverview(ctx context.Context) (*CronLeaseOverview, error) {
+	instances, err := listInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsMu.RLock()
+	entries := make([]jobEntry, 0, len(jobsByKey))
+	for _, e := range jobsByKey {
+		entries = append(entries, *e)
+	}
+	jobsMu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	list := make([]JobLeaseStatus, 0, len(entries))
+	for _, e := range entries {
+		st := JobLeaseStatus{
+			Name:           e.name,
+			Spec:           e.spec,
+			RunningLocal:   e.running,
+			LastRunAt:      timePtr(e.lastRunAt),
+			LastFinishedAt: timePtr(e.lastFinishedAt),
+			LastSkippedAt:  timePtr(e.lastSkippedAt),
+			SkippedCount:   e.skippedCount,
+		}
+		key := leaseKey(e.name)
+		raw, err := global.Redis.Get(ctx, key).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if err == nil {
+			var holder LeaseHolder
+			if json.Unmarshal([]byte(raw), &holder) == nil {
+				st.Holder = &holder
+			}
+			if ttl, err := global.Redis.PTTL(ctx, key).Result(); err == nil && ttl > 0 {
+				st.LeaseTTL = ttl.Round(time.Second).String()
+			}
+		}
+		list = append(list, st)
+	}
+
+	return &CronLeaseOverview{
+		InstanceID: instanceID(),
+		Instances:  instances,
+		Jobs:       list,
+	}, nil
+}
+
+func listInstances(ctx context.Context) ([]CronInstance, error) {
+	var keys []string
+	iter := global.Redis.Scan(ctx, 0, instanceKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]CronInstance, 0, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := global.Redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var info CronInstance
+		if json.Unmarshal([]byte(s), &info) == nil {
+			result = append(result, info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.Before(result[j].StartedAt) })
+	return result, nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/service"
+	"context"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// registerMarketPriceJob 注册市场价格拉取任务：每小时第 15 分钟拉取 ESI 全宇宙价格与交易中心订单簿
+// （ESI 订单簿缓存 5 分钟、/markets/prices/ 缓存 1 小时，小时级刷新足够估值使用）
+func registerMarketPriceJob(c *cron.Cron) {
+	svc := service.NewMarketPriceService()
+
+	id, err := addLeasedFunc(c, "market_price_refresh", "0 15 * * * *", func() {
+		global.Logger.Info("开始拉取市场价格")
+		if err := svc.RefreshAll(context.Background()); err != nil {
+			global.Logger.Warn("市场价格拉取部分失败", zap.Error(err))
+		}
+	})
+	if err != nil {
+		global.Logger.Error("注册市场价格任务失败", zap.Error(err))
+		return
+	}
+	global.Logger.Info("注册市场价格任务成功", zap.Int("entry_id", int(id)))
+}
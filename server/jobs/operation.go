@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/service"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// fleetTrackingLeaseHold 舰队跟踪任务的租约保留时长，须短于 15 秒的触发周期
+const fleetTrackingLeaseHold = 10 * time.Second
+
+// registerOperationJobs 注册行动日历任务：每分钟发送到期提醒，每小时按周期模板生成未来的行动；
+// 以及舰队持续跟踪：每 15 秒检查一次，各舰队按配置的间隔轮询 ESI 成员
+// 各任务独立注册，某个任务注册失败不影响其他任务
+func registerOperationJobs(c *cron.Cron) {
+	svc := service.NewOperationCalendarService()
+	fleetSvc := service.NewFleetService()
+
+	tid, err := addLeasedFuncWithHold(c, "fleet_tracking", "*/15 * * * * *", fleetTrackingLeaseHold, func() {
+		if _, err := fleetSvc.TrackActiveFleets(time.Now()); err != nil {
+			global.Logger.Warn("舰队持续跟踪失败", zap.Error(err))
+		}
+	})
+	if err != nil {
+		global.Logger.Error("注册舰队持续跟踪任务失败", zap.Error(err))
+	} else {
+		global.Logger.Info("注册舰队持续跟踪任务成功", zap.Int("entry_id", int(tid)))
+	}
+
+	id, err := addLeasedFunc(c, "operation_reminder", "0 * * * * *", func() {
+		sent, err := svc.SendDueReminders(time.Now())
+		if err != nil {
+			global.Logger.Warn("发送行动提醒失败", zap.Error(err))
+			return
+		}
+		if sent > 0 {
+			global.Logger.Info("发送行动提醒完成", zap.Int("sent", sent))
+		}
+	})
+	if err != nil {
+		global.Logger.Error("注册行动提醒任务失败", zap.Error(err))
+	} else {
+		global.Logger.Info("注册行动提醒任务成功", zap.Int("entry_id", int(id)))
+	}
+
+	gid, err := addLeasedFunc(c, "operation_template_generate", "0 5 * * * *", func() {
+		created, err := svc.GenerateFromTemplates(time.Now())
+		if err != nil {
+			global.Logger.Warn("生成周期行动失败", zap.Error(err))
+			return
+		}
+		if created > 0 {
+			global.Logger.Info("生成周期行动完成", zap.Int("created", created))
+		}
+	})
+	if err != nil {
+		global.Logger.Error("注册周期行动生成任务失败", zap.Error(err))
+		return
+	}
+	global.Logger.Info("注册周期行动生成任务成功", zap.Int("entry_id", int(gid)))
+}
@@ -6,13 +6,14 @@ import (
 	"amiya-eden/internal/repository"
 	"amiya-eden/internal/service"
 	"context"
+	"errors"
 
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
 
 func RegisterRoleJobs(c *cron.Cron) {
-	id, err := c.AddFunc("0 0/5 * * * ?", roleCheckTask)
+	id, err := addLeasedFunc(c, "corp_access_check", "0 0/5 * * * ?", roleCheckTask)
 	if err != nil {
 		global.Logger.Error("注册角色检查定时任务失败", zap.Error(err))
 		return
@@ -21,6 +22,7 @@ func RegisterRoleJobs(c *cron.Cron) {
 }
 
 // roleCheckTask 遍历所有用户，根据军团/联盟准入列表调整用户权限
+// 移除比例超过保护阈值时整轮中止并告警（见 service.SyncAllBasicAccess）
 func roleCheckTask() {
 	// 未配置基础访问准入名单时跳过
 	allowRepo := repository.NewAllowedEntityRepository()
@@ -29,23 +31,14 @@ func roleCheckTask() {
 		return
 	}
 
-	ctx := context.Background()
-	userRepo := repository.NewUserRepository()
-	rollSvc := service.NewRoleService()
-
-	ids, err := userRepo.ListAllIDs()
+	global.Logger.Info("[CorpCheck] 开始军团准入检查")
+	preview, err := service.NewRoleService().SyncAllBasicAccess(context.Background(), false)
 	if err != nil {
-		global.Logger.Error("[CorpCheck] 查询用户 ID 列表失败", zap.Error(err))
-		return
-	}
-
-	global.Logger.Info("[CorpCheck] 开始军团准入检查", zap.Int("users", len(ids)))
-	for _, uid := range ids {
-		if err := rollSvc.CheckCorpAccessAndAdjustRole(ctx, uid); err != nil {
-			global.Logger.Warn("[CorpCheck] 检查失败",
-				zap.Uint("user_id", uid),
-				zap.Error(err))
+		if !errors.Is(err, service.ErrRoleSyncGuardTripped) {
+			global.Logger.Error("[CorpCheck] 军团准入检查失败", zap.Error(err))
 		}
+		return
 	}
-	global.Logger.Info("[CorpCheck] 军团准入检查完成")
+	global.Logger.Info("[CorpCheck] 军团准入检查完成",
+		zap.Int("users", preview.TotalUsers), zap.Int("changed", preview.ChangedUsers))
 }
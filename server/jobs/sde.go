@@ -11,7 +11,7 @@ import (
 // registerSdeJob 注册每日 20:00 SDE 检查更新任务
 func registerSdeJob(c *cron.Cron) {
 	// WithSeconds() 已在 bootstrap 中开启，格式: 秒 分 时 日 月 周
-	id, err := c.AddFunc("0 0 20 * * *", sdeCheckUpdateTask)
+	id, err := addLeasedFunc(c, "sde_update", "0 0 20 * * *", sdeCheckUpdateTask)
 	if err != nil {
 		global.Logger.Error("注册 SDE 定时任务失败", zap.Error(err))
 		return
@@ -20,8 +20,9 @@ func registerSdeJob(c *cron.Cron) {
 }
 
 // SdeCheckOnStartup 启动时执行一次 SDE 检查更新（供 main 调用）
+// 与定时任务共用租约，多实例同时启动时只有一个实例执行
 func SdeCheckOnStartup() {
-	sdeCheckUpdateTask()
+	withLease("sde_update", leaseMinHold, sdeCheckUpdateTask)()
 }
 
 // sdeCheckUpdateTask SDE 检查更新任务入口
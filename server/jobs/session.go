@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/service"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// sessionRetention 过期 / 吊销的会话保留时长（便于排查登录记录）
+const sessionRetention = 30 * 24 * time.Hour
+
+// registerSessionCleanupJob 注册会话清理任务：每天 04:30 删除过期或吊销超过 30 天的会话
+func registerSessionCleanupJob(c *cron.Cron) {
+	svc := service.NewSessionService()
+
+	id, err := addLeasedFunc(c, "user_session_cleanup", "0 30 4 * * *", func() {
+		deleted, err := svc.CleanupExpired(sessionRetention)
+		if err != nil {
+			global.Logger.Warn("清理登录会话失败", zap.Error(err))
+			return
+		}
+		global.Logger.Info("清理登录会话完成", zap.Int64("deleted", deleted))
+	})
+	if err != nil {
+		global.Logger.Error("注册会话清理任务失败", zap.Error(err))
+		return
+	}
+	global.Logger.Info("注册会话清理任务成功", zap.Int("entry_id", int(id)))
+}
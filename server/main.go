@@ -31,6 +31,18 @@ func main() {
 	// 初始化 Redis
 	bootstrap.InitRedis()
 
+	// 密钥轮换命令：./server rotate-encryption-key
+	if len(os.Args) > 1 && os.Args[1] == "rotate-encryption-key" {
+		bootstrap.InitEncryption()
+		if err := bootstrap.RotateEncryptionKey(); err != nil {
+			global.Logger.Fatal("密钥轮换失败", zap.Error(err))
+		}
+		return
+	}
+
+	// 初始化敏感字段加密（首次启用时加密历史明文数据）
+	bootstrap.InitEncryption()
+
 	// 初始化定时任务
 	bootstrap.InitCron()
 
@@ -0,0 +1,256 @@
+// Package envelope 提供敏感字段的 AES-GCM 信封加密
+//
+// 每个值使用随机生成的数据密钥（DEK）加密，DEK 再由主密钥（KEK）加密后与密文一起保存：
+//
+//	enc:v{主密钥版本}:{base64(nonce|加密后的 DEK)}:{base64(nonce|密文)}
+//
+// 主密钥按版本号管理，轮换时只需用新版本主密钥重新加密 DEK（Rewrap），无需重新加密数据本身。
+// 未配置主密钥时 Encrypt 原样返回明文；不带 enc: 前缀的值视为历史明文，Decrypt 原样返回。
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	prefix = "enc:"
+	// KeySize 主密钥长度（AES-256）
+	KeySize = 32
+)
+
+var (
+	// ErrNoKey 未配置主密钥或缺少对应版本的主密钥
+	ErrNoKey = errors.New("envelope: 未配置对应版本的加密主密钥")
+	// ErrMalformed 密文格式错误
+	ErrMalformed = errors.New("envelope: 密文格式错误")
+)
+
+var (
+	mu      sync.RWMutex
+	keys    = make(map[int][]byte)
+	current int
+)
+
+// Init 设置主密钥（版本 → 32 字节密钥）以及当前用于加密的版本
+func Init(keyring map[int][]byte, currentVersion int) error {
+	if len(keyring) == 0 {
+		mu.Lock()
+		keys = make(map[int][]byte)
+		current = 0
+		mu.Unlock()
+		return nil
+	}
+	for v, k := range keyring {
+		if v <= 0 {
+			return fmt.Errorf("envelope: 无效的密钥版本 %d", v)
+		}
+		if len(k) != KeySize {
+			return fmt.Errorf("envelope: 版本 %d 的密钥长度应为 %d 字节，实际 %d", v, KeySize, len(k))
+		}
+	}
+	if _, ok := keyring[currentVersion]; !ok {
+		return fmt.Errorf("envelope: 当前版本 %d 的密钥不存在", currentVersion)
+	}
+
+	copied := make(map[int][]byte, len(keyring))
+	for v, k := range keyring {
+		copied[v] = append([]byte(nil), k...)
+	}
+	mu.Lock()
+	keys = copied
+	current = currentVersion
+	mu.Unlock()
+	return nil
+}
+
+// Enabled 是否已配置主密钥
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current > 0
+}
+
+// CurrentVersion 当前用于加密的主密钥版本，未配置时为 0
+func CurrentVersion() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// IsEncrypted 判断值是否为本包生成的密文
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// Version 返回密文使用的主密钥版本；明文返回 0
+func Version(s string) int {
+	v, _, _, err := parse(s)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// Encrypt 使用当前版本主密钥加密；空字符串、已加密的值以及未配置主密钥时原样返回
+func Encrypt(plain string) (string, error) {
+	if plain == "" || IsEncrypted(plain) {
+		return plain, nil
+	}
+	mu.RLock()
+	version, kek := current, keys[current]
+	mu.RUnlock()
+	if version == 0 {
+		return plain, nil
+	}
+
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek, dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dek, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	return format(version, wrapped, ciphertext), nil
+}
+
+// Decrypt 解密密文；不带 enc: 前缀的值视为明文原样返回
+func Decrypt(s string) (string, error) {
+	if !IsEncrypted(s) {
+		return s, nil
+	}
+	version, wrapped, ciphertext, err := parse(s)
+	if err != nil {
+		return "", err
+	}
+	dek, err := unwrap(version, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plain, err := open(dek, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// Rewrap 将值迁移到当前版本主密钥：明文会被加密，旧版本密文仅重新加密 DEK
+// 返回的 changed 表示值是否发生变化（需要回写）
+func Rewrap(s string) (result string, changed bool, err error) {
+	if s == "" || !Enabled() {
+		return s, false, nil
+	}
+	if !IsEncrypted(s) {
+		enc, err := Encrypt(s)
+		if err != nil {
+			return "", false, err
+		}
+		return enc, true, nil
+	}
+
+	version, wrapped, ciphertext, err := parse(s)
+	if err != nil {
+		return "", false, err
+	}
+	mu.RLock()
+	curVersion, kek := current, keys[current]
+	mu.RUnlock()
+	if version == curVersion {
+		return s, false, nil
+	}
+
+	dek, err := unwrap(version, wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, err := seal(kek, dek)
+	if err != nil {
+		return "", false, err
+	}
+	return format(curVersion, rewrapped, ciphertext), true, nil
+}
+
+// ─────────────────────────────────────────────
+//  内部实现
+// ─────────────────────────────────────────────
+
+func format(version int, wrapped, ciphertext []byte) string {
+	return prefix + "v" + strconv.Itoa(version) + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext)
+}
+
+func parse(s string) (version int, wrapped, ciphertext []byte, err error) {
+	if !IsEncrypted(s) {
+		return 0, nil, nil, ErrMalformed
+	}
+	parts := strings.Split(strings.TrimPrefix(s, prefix), ":")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "v") {
+		return 0, nil, nil, ErrMalformed
+	}
+	version, err = strconv.Atoi(parts[0][1:])
+	if err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	if wrapped, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	if ciphertext, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	return version, wrapped, ciphertext, nil
+}
+
+func unwrap(version int, wrapped []byte) ([]byte, error) {
+	mu.RLock()
+	kek, ok := keys[version]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w（v%d）", ErrNoKey, version)
+	}
+	return open(kek, wrapped)
+}
+
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
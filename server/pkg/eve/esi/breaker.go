@@ -0,0 +1,220 @@
+package esi
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  熔断器（按 任务 + 角色）
+//  连续出现 4xx/5xx 后进入 open 并指数退避，退避结束后 half_open 试探执行一次：
+//  成功则恢复，失败则加倍退避。403 缺少 scope 时进入 scope_missing，直到角色 scope 变化。
+//  目的是避免被踢出军团 / 撤销授权的角色每轮都失败，消耗 ESI 全局错误配额。
+// ─────────────────────────────────────────────
+
+const (
+	breakerThreshold   = 3                // 连续失败多少次后熔断
+	breakerBaseBackoff = 15 * time.Minute // 首次熔断退避时长
+	breakerMaxBackoff  = 24 * time.Hour   // 最大退避时长
+	// scopeMissingRetry scope_missing 状态下的兜底试探间隔（scope 未变化时）
+	scopeMissingRetry = 24 * time.Hour
+)
+
+// BreakerInfo 熔断状态（供 API 展示）
+type BreakerInfo struct {
+	State          string    `json:"state"`
+	Failures       int       `json:"failures"`
+	LastHTTPStatus int       `json:"last_http_status"`
+	LastError      string    `json:"last_error,omitempty"`
+	OpenedAt       time.Time `json:"opened_at"`
+	RetryAt        time.Time `json:"retry_at"`
+}
+
+func newBreakerInfo(b *model.EsiTaskBreaker) *BreakerInfo {
+	return &BreakerInfo{
+		State:          b.State,
+		Failures:       b.Failures,
+		LastHTTPStatus: b.LastHTTPStatus,
+		LastError:      b.LastError,
+		OpenedAt:       b.OpenedAt,
+		RetryAt:        b.RetryAt,
+	}
+}
+
+// loadBreakers 从数据库刷新熔断状态缓存
+func (q *Queue) loadBreakers() error {
+	list, err := q.runRepo.ListBreakers()
+	if err != nil {
+		return err
+	}
+	breakers := make(map[string]*model.EsiTaskBreaker, len(list))
+	for i := range list {
+		b := list[i]
+		breakers[taskStatusKey(b.TaskName, b.CharacterID)] = &b
+	}
+	q.breakerMu.Lock()
+	q.breakers = breakers
+	q.breakerMu.Unlock()
+	return nil
+}
+
+// breakerAllows 判断调度时是否允许执行该任务；允许时若处于熔断中则标记为 half_open 试探
+func (q *Queue) breakerAllows(task RefreshTask, char model.EveCharacter) bool {
+	key := taskStatusKey(task.Name(), char.CharacterID)
+	q.breakerMu.Lock()
+	defer q.breakerMu.Unlock()
+
+	b, ok := q.breakers[key]
+	if !ok {
+		return true
+	}
+	switch b.State {
+	case model.EsiBreakerScopeMissing:
+		// 角色重新授权后 scope 发生变化，立即试探
+		if b.Scopes != char.Scopes {
+			b.State = model.EsiBreakerHalfOpen
+			return true
+		}
+	case model.EsiBreakerHalfOpen:
+		// 上一次试探尚未结束
+		return false
+	}
+	if time.Now().Before(b.RetryAt) {
+		return false
+	}
+	b.State = model.EsiBreakerHalfOpen
+	return true
+}
+
+// recordBreaker 根据执行结果更新熔断状态
+// status 为本次执行的代表 HTTP 状态码；未收到异常响应（网络错误、Token 获取失败等）时不计入熔断
+func (q *Queue) recordBreaker(task RefreshTask, char model.EveCharacter, status int, execErr error) {
+	key := taskStatusKey(task.Name(), char.CharacterID)
+
+	if execErr == nil {
+		q.breakerMu.Lock()
+		_, ok := q.breakers[key]
+		delete(q.breakers, key)
+		q.breakerMu.Unlock()
+		if ok {
+			if err := q.runRepo.DeleteBreaker(task.Name(), char.CharacterID); err != nil {
+				global.Logger.Warn("[ESI Breaker] 清除熔断记录失败", zap.String("task", task.Name()), zap.Int64("character_id", char.CharacterID), zap.Error(err))
+			} else {
+				global.Logger.Info("[ESI Breaker] 任务恢复", zap.String("task", task.Name()), zap.Int64("character_id", char.CharacterID))
+			}
+		}
+		return
+	}
+
+	if !countsTowardsBreaker(status) {
+		return
+	}
+
+	now := time.Now()
+	q.breakerMu.Lock()
+	b, ok := q.breakers[key]
+	if !ok {
+		b = &model.EsiTaskBreaker{
+			TaskName:    task.Name(),
+			CharacterID: char.CharacterID,
+			State:       model.EsiBreakerClosed,
+		}
+		q.breakers[key] = b
+	}
+	b.Failures++
+	b.LastHTTPStatus = status
+	b.LastError = execErr.Error()
+
+	switch {
+	case isScopeMissing(status, execErr):
+		if b.State != model.EsiBreakerScopeMissing {
+			b.OpenedAt = now
+		}
+		b.State = model.EsiBreakerScopeMissing
+		b.Scopes = char.Scopes
+		b.RetryAt = now.Add(scopeMissingRetry)
+	case b.Failures >= breakerThreshold:
+		if b.State == model.EsiBreakerClosed {
+			b.OpenedAt = now
+		}
+		b.State = model.EsiBreakerOpen
+		b.RetryAt = now.Add(breakerBackoff(b.Failures))
+	default:
+		b.State = model.EsiBreakerClosed
+		b.RetryAt = now
+		if b.OpenedAt.IsZero() {
+			b.OpenedAt = now
+		}
+	}
+	snapshot := *b
+	q.breakerMu.Unlock()
+
+	if err := q.runRepo.SaveBreaker(&snapshot); err != nil {
+		global.Logger.Warn("[ESI Breaker] 保存熔断记录失败", zap.String("task", task.Name()), zap.Int64("character_id", char.CharacterID), zap.Error(err))
+		return
+	}
+	if snapshot.State != model.EsiBreakerClosed {
+		global.Logger.Warn("[ESI Breaker] 任务熔断",
+			zap.String("task", task.Name()),
+			zap.Int64("character_id", char.CharacterID),
+			zap.String("state", snapshot.State),
+			zap.Int("failures", snapshot.Failures),
+			zap.Int("http_status", status),
+			zap.Time("retry_at", snapshot.RetryAt),
+		)
+	}
+}
+
+// ResetBreaker 手动清除某个 (任务, 角色) 的熔断状态
+func (q *Queue) ResetBreaker(taskName string, characterID int64) error {
+	q.breakerMu.Lock()
+	delete(q.breakers, taskStatusKey(taskName, characterID))
+	q.breakerMu.Unlock()
+	return q.runRepo.DeleteBreaker(taskName, characterID)
+}
+
+// ListBreakers 查询所有熔断记录（含连续失败但尚未熔断的记录）
+func (q *Queue) ListBreakers() ([]model.EsiTaskBreaker, error) {
+	return q.runRepo.ListBreakers()
+}
+
+// breakerInfo 返回某 (任务, 角色) 的熔断状态，正常时返回 nil
+func (q *Queue) breakerInfo(key string) *BreakerInfo {
+	q.breakerMu.Lock()
+	defer q.breakerMu.Unlock()
+	b, ok := q.breakers[key]
+	if !ok || b.State == model.EsiBreakerClosed {
+		return nil
+	}
+	return newBreakerInfo(b)
+}
+
+// breakerBackoff 第 failures 次失败后的退避时长：base * 2^(failures-threshold)，上限 breakerMaxBackoff
+func breakerBackoff(failures int) time.Duration {
+	backoff := breakerBaseBackoff
+	for i := breakerThreshold; i < failures; i++ {
+		backoff *= 2
+		if backoff >= breakerMaxBackoff {
+			return breakerMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// countsTowardsBreaker 仅角色相关的 4xx/5xx 计入熔断；420/429 为全局限速，由 RateLimiter 处理
+func countsTowardsBreaker(status int) bool {
+	if status < 400 {
+		return false
+	}
+	return status != 420 && status != http.StatusTooManyRequests
+}
+
+// isScopeMissing 判断是否为 403 缺少 scope（ESI 返回 "token not valid for scope"）
+func isScopeMissing(status int, err error) bool {
+	return status == http.StatusForbidden && strings.Contains(strings.ToLower(err.Error()), "scope")
+}
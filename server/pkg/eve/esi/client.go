@@ -2,6 +2,7 @@
 package esi
 
 import (
+	"amiya-eden/pkg/eve"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -12,8 +13,8 @@ import (
 )
 
 const (
-	// BaseURL ESI API 基础地址
-	BaseURL = "https://esi.evetech.net"
+	// BaseURL ESI API 默认基础地址（实际地址由配置 esi.base_url 决定，见 eve.ESIBaseURL）
+	BaseURL = eve.DefaultESIBaseURL
 	// DefaultTimeout HTTP 默认超时
 	DefaultTimeout = 30 * time.Second
 )
@@ -23,15 +24,14 @@ type Client struct {
 	baseURL     string
 	httpClient  *http.Client
 	rateLimiter *RateLimiter
+	stats       *requestStats // 任务执行期间的响应统计（仅任务内副本非空）
 }
 
 // NewClient 创建 ESI 客户端
 func NewClient() *Client {
 	return &Client{
-		baseURL: BaseURL,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
+		baseURL:     eve.ESIBaseURL(),
+		httpClient:  eve.NewESIHTTPClient(DefaultTimeout),
 		rateLimiter: NewRateLimiter(),
 	}
 }
@@ -54,6 +54,7 @@ func (c *Client) Get(ctx context.Context, path string, accessToken string, dest
 		return fmt.Errorf("ESI request %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -93,6 +94,7 @@ func (c *Client) GetRaw(ctx context.Context, path string, accessToken string) ([
 		return nil, 0, fmt.Errorf("ESI request %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -125,6 +127,7 @@ func (c *Client) PostJSON(ctx context.Context, path string, accessToken string,
 		return fmt.Errorf("ESI POST %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -167,6 +170,7 @@ func (c *Client) PutJSON(ctx context.Context, path string, accessToken string, r
 		return fmt.Errorf("ESI PUT %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -203,6 +207,7 @@ func (c *Client) PostNoContent(ctx context.Context, path string, accessToken str
 		return fmt.Errorf("ESI POST %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -233,6 +238,7 @@ func (c *Client) Delete(ctx context.Context, path string, accessToken string) er
 		return fmt.Errorf("ESI DELETE %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	c.stats.record(resp.StatusCode)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
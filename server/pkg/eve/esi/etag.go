@@ -0,0 +1,157 @@
+package esi
+
+import (
+	"amiya-eden/global"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  ETag 持久化（条件请求）
+//  ESI 对未变化的数据返回 304，携带上次的 ETag 可省去下载与入库
+//  key: esi:etag:{characterID}:{path}  hash field: 页码 → ETag
+// ─────────────────────────────────────────────
+
+const (
+	etagKeyPrefix = "esi:etag:"
+	// etagTTL ETag 保存时间，超过后下一次请求将完整拉取
+	etagTTL = 30 * 24 * time.Hour
+)
+
+// ETagStore 基于 Redis 的 ESI ETag 存储，按 (角色, 路径, 页码) 保存
+type ETagStore struct{}
+
+// NewETagStore 创建 ETag 存储
+func NewETagStore() *ETagStore {
+	return &ETagStore{}
+}
+
+func etagKey(characterID int64, path string) string {
+	return fmt.Sprintf("%s%d:%s", etagKeyPrefix, characterID, path)
+}
+
+// Load 读取某角色某路径的各页 ETag（页码 → ETag）
+func (s *ETagStore) Load(ctx context.Context, characterID int64, path string) (map[int]string, error) {
+	raw, err := global.Redis.HGetAll(ctx, etagKey(characterID, path)).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int]string, len(raw))
+	for field, etag := range raw {
+		page, convErr := strconv.Atoi(field)
+		if convErr != nil || etag == "" {
+			continue
+		}
+		result[page] = etag
+	}
+	return result, nil
+}
+
+// Save 覆盖保存某角色某路径的各页 ETag；etags 为空时删除记录
+func (s *ETagStore) Save(ctx context.Context, characterID int64, path string, etags map[int]string) error {
+	key := etagKey(characterID, path)
+	values := make([]interface{}, 0, len(etags)*2)
+	for page, etag := range etags {
+		if etag == "" {
+			continue
+		}
+		values = append(values, strconv.Itoa(page), etag)
+	}
+
+	pipe := global.Redis.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(values) > 0 {
+		pipe.HSet(ctx, key, values...)
+		pipe.Expire(ctx, key, etagTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ─────────────────────────────────────────────
+//  单次任务执行的 ETag 上下文
+// ─────────────────────────────────────────────
+
+// etagScope 记录一次任务执行中获得的新 ETag
+// 只有任务成功返回后才提交到存储，避免数据未入库却已记录 ETag，导致后续请求被 304 跳过
+type etagScope struct {
+	store       *ETagStore
+	characterID int64
+	fresh       bool // true 时不携带旧 ETag（强制完整拉取），但仍记录新 ETag
+
+	mu        sync.Mutex
+	pending   map[string]map[int]string
+	discarded bool
+}
+
+func newETagScope(store *ETagStore, characterID int64, fresh bool) *etagScope {
+	return &etagScope{
+		store:       store,
+		characterID: characterID,
+		fresh:       fresh,
+		pending:     make(map[string]map[int]string),
+	}
+}
+
+// load 读取已保存的 ETag；scope 为 nil 或强制刷新时返回 nil
+func (s *etagScope) load(ctx context.Context, path string) map[int]string {
+	if s == nil || s.fresh {
+		return nil
+	}
+	etags, err := s.store.Load(ctx, s.characterID, path)
+	if err != nil {
+		global.Logger.Warn("[ESI ETag] 读取 ETag 失败，改为完整拉取",
+			zap.Int64("character_id", s.characterID),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return etags
+}
+
+// stage 暂存本次响应的 ETag，等待任务成功后提交
+func (s *etagScope) stage(path string, etags map[int]string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[path] = etags
+}
+
+// discard 放弃本次暂存的所有 ETag
+func (s *etagScope) discard() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discarded = true
+}
+
+// commit 将暂存的 ETag 写入存储
+func (s *etagScope) commit(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discarded {
+		return
+	}
+	for path, etags := range s.pending {
+		if err := s.store.Save(ctx, s.characterID, path, etags); err != nil {
+			global.Logger.Warn("[ESI ETag] 保存 ETag 失败",
+				zap.Int64("character_id", s.characterID),
+				zap.String("path", path),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -24,9 +24,14 @@ type Queue struct {
 	client   *Client
 	ssoSvc   *service.EveSSOService
 	charRepo *repository.EveCharacterRepository
+	runRepo  *repository.EsiTaskRunRepository
+	etags    *ETagStore
 
-	mu       sync.RWMutex
-	statuses map[string]*TaskStatus // key: "taskName:characterID"
+	mu      sync.RWMutex
+	running map[string]*TaskStatus // 正在执行的任务，key: "taskName:characterID"
+
+	breakerMu sync.Mutex
+	breakers  map[string]*model.EsiTaskBreaker // 熔断状态缓存，key: "taskName:characterID"
 
 	// 并发控制：同一时间最多执行的任务数
 	concurrency int
@@ -38,7 +43,10 @@ func NewQueue() *Queue {
 		client:      NewClient(),
 		ssoSvc:      service.NewEveSSOService(),
 		charRepo:    repository.NewEveCharacterRepository(),
-		statuses:    make(map[string]*TaskStatus),
+		runRepo:     repository.NewEsiTaskRunRepository(),
+		etags:       NewETagStore(),
+		running:     make(map[string]*TaskStatus),
+		breakers:    make(map[string]*model.EsiTaskBreaker),
 		concurrency: 5, // 默认 5 并发
 	}
 }
@@ -76,7 +84,17 @@ func (q *Queue) Run() {
 	// 2. 检测角色活跃度
 	activityMap := q.checkActivity(ctx, characters)
 
-	// 3. 获取所有任务并按优先级排序
+	// 3. 读取各任务最近一次成功执行时间
+	lastSuccess, err := q.loadLastSuccess()
+	if err != nil {
+		global.Logger.Error("[ESI Queue] 读取任务执行记录失败", zap.Error(err))
+		return
+	}
+	if err := q.loadBreakers(); err != nil {
+		global.Logger.Warn("[ESI Queue] 读取熔断状态失败，沿用缓存", zap.Error(err))
+	}
+
+	// 获取所有任务并按优先级排序
 	allTasks := AllTasks()
 	sortedTasks := sortTasksByPriority(allTasks)
 
@@ -99,7 +117,12 @@ func (q *Queue) Run() {
 			}
 
 			// 检查是否需要刷新（基于上次执行时间和刷新间隔）
-			if !q.needsRefresh(task, char.CharacterID, isActive) {
+			if !q.needsRefresh(task, char.CharacterID, isActive, lastSuccess) {
+				continue
+			}
+
+			// 熔断中（退避未结束 / 缺少 scope）则跳过
+			if !q.breakerAllows(task, char) {
 				continue
 			}
 
@@ -133,7 +156,7 @@ func (q *Queue) Run() {
 			defer wg.Done()
 			defer func() { <-sem }() // 释放
 
-			q.executeTask(ctx, j.task, j.character, j.isActive)
+			q.executeTask(ctx, j.task, j.character, j.isActive, false)
 		}(job)
 	}
 
@@ -156,7 +179,7 @@ func (q *Queue) RunTask(taskName string, characterID int64) error {
 	ctx := context.Background()
 	isActive := q.checkSingleActivity(ctx, *char)
 
-	q.executeTask(ctx, task, *char, isActive)
+	q.executeTask(ctx, task, *char, isActive, false)
 	return nil
 }
 
@@ -203,7 +226,8 @@ func (q *Queue) RunAllForCharacter(ctx context.Context, characterID int64) {
 		go func(t RefreshTask) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			q.executeTask(ctx, t, *char, isActive)
+			// 新角色首次刷新：忽略已保存的 ETag，保证数据完整入库
+			q.executeTask(ctx, t, *char, isActive, true)
 		}(task)
 	}
 
@@ -241,7 +265,7 @@ func (q *Queue) RunTaskByName(taskName string) error {
 		go func(ch model.EveCharacter, active bool) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			q.executeTask(ctx, task, ch, active)
+			q.executeTask(ctx, task, ch, active, false)
 		}(char, isActive)
 	}
 
@@ -253,18 +277,29 @@ func (q *Queue) RunTaskByName(taskName string) error {
 //  内部方法
 // ─────────────────────────────────────────────
 
-// executeTask 执行单个任务
-func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.EveCharacter, isActive bool) {
-	statusKey := fmt.Sprintf("%s:%d", task.Name(), char.CharacterID)
+// executeTask 执行单个任务，并将执行结果写入 esi_task_run
+// fresh 为 true 时不携带已保存的 ETag，强制完整拉取
+func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.EveCharacter, isActive bool, fresh bool) {
+	statusKey := taskStatusKey(task.Name(), char.CharacterID)
+	startedAt := time.Now()
 
-	// 更新状态为 running
-	q.setStatus(statusKey, &TaskStatus{
+	// 标记为 running（仅内存，执行结束后以数据库记录为准）
+	q.setRunning(statusKey, &TaskStatus{
 		TaskName:    task.Name(),
 		Description: task.Description(),
 		CharacterID: char.CharacterID,
 		Priority:    task.Priority(),
 		Status:      "running",
 	})
+	defer q.clearRunning(statusKey)
+
+	stats := &requestStats{}
+	run := &model.EsiTaskRun{
+		TaskName:    task.Name(),
+		CharacterID: char.CharacterID,
+		IsActive:    isActive,
+		StartedAt:   startedAt,
+	}
 
 	// 获取有效 Token
 	accessToken, err := q.ssoSvc.GetValidToken(ctx, char.CharacterID)
@@ -274,14 +309,8 @@ func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.Ev
 			zap.Int64("character_id", char.CharacterID),
 			zap.Error(err),
 		)
-		q.setStatus(statusKey, &TaskStatus{
-			TaskName:    task.Name(),
-			Description: task.Description(),
-			CharacterID: char.CharacterID,
-			Priority:    task.Priority(),
-			Status:      "failed",
-			Error:       err.Error(),
-		})
+		q.saveRun(run, stats, err)
+		q.recordBreaker(task, char, run.HTTPStatus, err)
 		return
 	}
 
@@ -289,8 +318,9 @@ func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.Ev
 	taskCtx := &TaskContext{
 		CharacterID: char.CharacterID,
 		AccessToken: accessToken,
-		Client:      q.client,
+		Client:      q.client.withStats(stats),
 		IsActive:    isActive,
+		etags:       newETagScope(q.etags, char.CharacterID, fresh),
 	}
 
 	if err := task.Execute(taskCtx); err != nil {
@@ -299,38 +329,17 @@ func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.Ev
 			zap.Int64("character_id", char.CharacterID),
 			zap.Error(err),
 		)
-		q.setStatus(statusKey, &TaskStatus{
-			TaskName:    task.Name(),
-			Description: task.Description(),
-			CharacterID: char.CharacterID,
-			Priority:    task.Priority(),
-			Status:      "failed",
-			Error:       err.Error(),
-		})
+		q.saveRun(run, stats, err)
+		q.recordBreaker(task, char, run.HTTPStatus, err)
 		return
 	}
 
-	// 成功：记录上次执行时间
-	now := time.Now()
-	interval := task.Interval()
-	nextDur := interval.Active
-	if !isActive {
-		nextDur = interval.Inactive
-	}
-	nextRun := now.Add(nextDur)
-
-	q.setStatus(statusKey, &TaskStatus{
-		TaskName:    task.Name(),
-		Description: task.Description(),
-		CharacterID: char.CharacterID,
-		Priority:    task.Priority(),
-		LastRun:     &now,
-		NextRun:     &nextRun,
-		Status:      "success",
-	})
+	// 成功：提交本次获得的 ETag
+	taskCtx.etags.commit(ctx)
 
-	// 将上次执行时间持久化到 Redis
-	q.setLastRun(task.Name(), char.CharacterID, now)
+	// 记录执行结果（调度依据）
+	q.saveRun(run, stats, nil)
+	q.recordBreaker(task, char, run.HTTPStatus, nil)
 
 	global.Logger.Debug("[ESI Queue] 任务执行成功",
 		zap.String("task", task.Name()),
@@ -338,20 +347,48 @@ func (q *Queue) executeTask(ctx context.Context, task RefreshTask, char model.Ev
 	)
 }
 
-// needsRefresh 判断任务是否需要刷新
-func (q *Queue) needsRefresh(task RefreshTask, characterID int64, isActive bool) bool {
-	lastRun, err := q.getLastRun(task.Name(), characterID)
+// saveRun 补全执行记录并写入数据库
+func (q *Queue) saveRun(run *model.EsiTaskRun, stats *requestStats, execErr error) {
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Pages, run.NotModified, run.HTTPStatus = stats.snapshot()
+	run.Status = model.EsiTaskRunSuccess
+	if execErr != nil {
+		run.Status = model.EsiTaskRunFailed
+		run.Error = execErr.Error()
+	}
+
+	if err := q.runRepo.Create(run); err != nil {
+		global.Logger.Error("[ESI Queue] 写入任务执行记录失败",
+			zap.String("task", run.TaskName),
+			zap.Int64("character_id", run.CharacterID),
+			zap.Error(err),
+		)
+	}
+}
+
+// loadLastSuccess 读取每个 (任务, 角色) 最近一次成功执行时间
+// key: "taskName:characterID"
+func (q *Queue) loadLastSuccess() (map[string]time.Time, error) {
+	rows, err := q.runRepo.ListLastSuccess()
 	if err != nil {
-		return true // 没有记录则需要刷新
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(rows))
+	for _, r := range rows {
+		result[taskStatusKey(r.TaskName, r.CharacterID)] = r.FinishedAt
 	}
+	return result, nil
+}
 
-	interval := task.Interval()
-	dur := interval.Active
-	if !isActive {
-		dur = interval.Inactive
+// needsRefresh 判断任务是否需要刷新（基于数据库中最近一次成功执行时间）
+func (q *Queue) needsRefresh(task RefreshTask, characterID int64, isActive bool, lastSuccess map[string]time.Time) bool {
+	lastRun, ok := lastSuccess[taskStatusKey(task.Name(), characterID)]
+	if !ok {
+		return true // 没有成功记录则需要刷新
 	}
 
-	return time.Since(lastRun) >= dur
+	return time.Since(lastRun) >= refreshInterval(task, isActive)
 }
 
 // hasRequiredScopes 检查角色是否拥有任务所需的 scope
@@ -371,44 +408,81 @@ func (q *Queue) hasRequiredScopes(char model.EveCharacter, task RefreshTask) boo
 }
 
 // ─────────────────────────────────────────────
-//  Redis 状态存储
+//  状态查询（可视化用）
 // ─────────────────────────────────────────────
 
-const (
-	lastRunKeyPrefix = "esi:refresh:lastrun:" // esi:refresh:lastrun:{task}:{characterID}
-)
-
-func (q *Queue) setLastRun(taskName string, characterID int64, t time.Time) {
-	key := fmt.Sprintf("%s%s:%d", lastRunKeyPrefix, taskName, characterID)
-	global.Redis.Set(context.Background(), key, t.Unix(), 0)
-}
-
-func (q *Queue) getLastRun(taskName string, characterID int64) (time.Time, error) {
-	key := fmt.Sprintf("%s%s:%d", lastRunKeyPrefix, taskName, characterID)
-	val, err := global.Redis.Get(context.Background(), key).Int64()
-	if err != nil {
-		return time.Time{}, err
-	}
-	return time.Unix(val, 0), nil
+func (q *Queue) setRunning(key string, status *TaskStatus) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running[key] = status
 }
 
-// ─────────────────────────────────────────────
-//  状态管理（可视化用）
-// ─────────────────────────────────────────────
-
-func (q *Queue) setStatus(key string, status *TaskStatus) {
+func (q *Queue) clearRunning(key string) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	q.statuses[key] = status
+	delete(q.running, key)
 }
 
 // GetAllStatuses 获取所有任务状态（用于 API 展示）
-func (q *Queue) GetAllStatuses() []*TaskStatus {
+// 以数据库中每个 (任务, 角色) 的最近一次执行为准，正在执行的任务覆盖为 running
+func (q *Queue) GetAllStatuses() ([]*TaskStatus, error) {
+	latest, err := q.runRepo.ListLatest()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.loadBreakers(); err != nil {
+		return nil, err
+	}
+	lastSuccess, err := q.loadLastSuccess()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]*TaskStatus, len(latest))
+	for _, run := range latest {
+		task, ok := GetTask(run.TaskName)
+		if !ok {
+			continue // 任务已下线
+		}
+		key := taskStatusKey(run.TaskName, run.CharacterID)
+		s := &TaskStatus{
+			TaskName:    run.TaskName,
+			Description: task.Description(),
+			CharacterID: run.CharacterID,
+			Priority:    task.Priority(),
+			Status:      run.Status,
+			Error:       run.Error,
+		}
+		if t, ok := lastSuccess[key]; ok {
+			lastRun := t
+			nextRun := t.Add(refreshInterval(task, run.IsActive))
+			s.LastRun = &lastRun
+			s.NextRun = &nextRun
+		}
+		if b := q.breakerInfo(key); b != nil {
+			s.Breaker = b
+			if s.NextRun == nil || s.NextRun.Before(b.RetryAt) {
+				retryAt := b.RetryAt
+				s.NextRun = &retryAt
+			}
+		}
+		statuses[key] = s
+	}
+
 	q.mu.RLock()
-	defer q.mu.RUnlock()
+	for key, s := range q.running {
+		running := *s
+		if prev, ok := statuses[key]; ok {
+			running.LastRun = prev.LastRun
+			running.NextRun = prev.NextRun
+			running.Breaker = prev.Breaker
+		}
+		statuses[key] = &running
+	}
+	q.mu.RUnlock()
 
-	result := make([]*TaskStatus, 0, len(q.statuses))
-	for _, s := range q.statuses {
+	result := make([]*TaskStatus, 0, len(statuses))
+	for _, s := range statuses {
 		result = append(result, s)
 	}
 
@@ -417,30 +491,42 @@ func (q *Queue) GetAllStatuses() []*TaskStatus {
 		if result[i].Priority != result[j].Priority {
 			return result[i].Priority < result[j].Priority
 		}
-		return result[i].TaskName < result[j].TaskName
+		if result[i].TaskName != result[j].TaskName {
+			return result[i].TaskName < result[j].TaskName
+		}
+		return result[i].CharacterID < result[j].CharacterID
 	})
-	return result
+	return result, nil
 }
 
-// GetTaskStatuses 获取指定任务的所有角色状态
-func (q *Queue) GetTaskStatuses(taskName string) []*TaskStatus {
-	q.mu.RLock()
-	defer q.mu.RUnlock()
+// ListRuns 分页查询任务执行历史
+func (q *Queue) ListRuns(page, pageSize int, filter repository.EsiTaskRunFilter) ([]model.EsiTaskRun, int64, error) {
+	return q.runRepo.List(page, pageSize, filter)
+}
 
-	var result []*TaskStatus
-	prefix := taskName + ":"
-	for key, s := range q.statuses {
-		if strings.HasPrefix(key, prefix) {
-			result = append(result, s)
-		}
-	}
-	return result
+// CleanupRuns 删除 retention 之前的执行记录
+func (q *Queue) CleanupRuns(retention time.Duration) (int64, error) {
+	return q.runRepo.DeleteBefore(time.Now().Add(-retention))
 }
 
 // ─────────────────────────────────────────────
 //  辅助
 // ─────────────────────────────────────────────
 
+// taskStatusKey 任务状态 key: "taskName:characterID"
+func taskStatusKey(taskName string, characterID int64) string {
+	return fmt.Sprintf("%s:%d", taskName, characterID)
+}
+
+// refreshInterval 根据角色活跃度返回任务刷新间隔
+func refreshInterval(task RefreshTask, isActive bool) time.Duration {
+	interval := task.Interval()
+	if isActive {
+		return interval.Active
+	}
+	return interval.Inactive
+}
+
 // sortTasksByPriority 按优先级排序任务
 func sortTasksByPriority(tasks map[string]RefreshTask) []RefreshTask {
 	sorted := make([]RefreshTask, 0, len(tasks))
@@ -83,6 +83,53 @@ func parseResponseMeta(resp *http.Response) *ResponseMeta {
 	return meta
 }
 
+// ─────────────────────────────────────────────
+//  请求统计
+// ─────────────────────────────────────────────
+
+// requestStats 单次任务执行期间的 ESI 响应统计（用于写入执行记录）
+type requestStats struct {
+	mu          sync.Mutex
+	pages       int // 收到的响应数（含分页）
+	notModified int // 其中 304 响应数
+	lastStatus  int // 最后一次响应状态码
+	errStatus   int // 最近一次异常状态码（非 2xx / 304）
+}
+
+// record 记录一次响应状态码；stats 为 nil 时忽略
+func (s *requestStats) record(status int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages++
+	s.lastStatus = status
+	if status == http.StatusNotModified {
+		s.notModified++
+	} else if status < 200 || status >= 300 {
+		s.errStatus = status
+	}
+}
+
+// snapshot 返回统计结果：响应数、304 数、代表状态码（优先异常状态码）
+func (s *requestStats) snapshot() (pages, notModified, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status = s.lastStatus
+	if s.errStatus != 0 {
+		status = s.errStatus
+	}
+	return s.pages, s.notModified, status
+}
+
+// withStats 返回共享连接与限速器、但记录到独立统计的客户端副本
+func (c *Client) withStats(stats *requestStats) *Client {
+	cp := *c
+	cp.stats = stats
+	return &cp
+}
+
 // ─────────────────────────────────────────────
 //  限速器
 // ─────────────────────────────────────────────
@@ -224,8 +271,9 @@ func (rl *RateLimiter) Wait(ctx context.Context, group string) error {
 // ─────────────────────────────────────────────
 
 // doRequest 底层 GET 请求：发送请求、读取响应、解析元数据、限速更新
+// etag 非空时携带 If-None-Match 头，ESI 数据未变化时返回 304
 // 遇到 420 限速错误会自动指数退避重试
-func (c *Client) doRequest(ctx context.Context, url string, accessToken string) ([]byte, *ResponseMeta, error) {
+func (c *Client) doRequest(ctx context.Context, url string, accessToken string, etag string) ([]byte, *ResponseMeta, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
@@ -238,6 +286,9 @@ func (c *Client) doRequest(ctx context.Context, url string, accessToken string)
 			req.Header.Set("Authorization", "Bearer "+accessToken)
 		}
 		req.Header.Set("Accept", "application/json")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
 
 		// 发送请求
 		resp, err := c.httpClient.Do(req)
@@ -252,6 +303,7 @@ func (c *Client) doRequest(ctx context.Context, url string, accessToken string)
 		}
 
 		meta := parseResponseMeta(resp)
+		c.stats.record(resp.StatusCode)
 
 		// 更新限速器
 		if c.rateLimiter != nil {
@@ -287,8 +339,14 @@ func (c *Client) doRequest(ctx context.Context, url string, accessToken string)
 // GetWithMeta 发起 GET 请求并返回解码后的数据和响应元数据
 // 相比 Get，额外返回 ResponseMeta 以便调用方感知缓存/限速状态
 func (c *Client) GetWithMeta(ctx context.Context, path string, accessToken string, dest interface{}) (*ResponseMeta, error) {
+	return c.GetIfNoneMatch(ctx, path, accessToken, "", dest)
+}
+
+// GetIfNoneMatch 条件 GET：etag 非空时携带 If-None-Match 请求头
+// ESI 数据未变化（304）时返回 ErrNotModified 且不写入 dest；成功时 meta.ETag 为最新 ETag
+func (c *Client) GetIfNoneMatch(ctx context.Context, path string, accessToken string, etag string, dest interface{}) (*ResponseMeta, error) {
 	url := c.baseURL + path
-	body, meta, err := c.doRequest(ctx, url, accessToken)
+	body, meta, err := c.doRequest(ctx, url, accessToken, etag)
 	if err != nil {
 		return meta, fmt.Errorf("ESI GET %s: %w", path, err)
 	}
@@ -319,46 +377,112 @@ func (c *Client) GetWithMeta(ctx context.Context, path string, accessToken strin
 //
 // 返回第一页的 ResponseMeta 和任何错误
 func (c *Client) GetPaginated(ctx context.Context, path string, accessToken string, dest interface{}) (*ResponseMeta, error) {
+	meta, _, err := c.GetPaginatedIfNoneMatch(ctx, path, accessToken, nil, dest)
+	return meta, err
+}
+
+// GetPaginatedIfNoneMatch 带 ETag 的分页条件 GET
+//
+// etags 为上次成功拉取时各页的 ETag（页码 → ETag，可为 nil），每页请求携带对应的 If-None-Match：
+//   - 所有页均返回 304 时返回 ErrNotModified，dest 不会被写入
+//   - 任意一页有变化时，对返回 304 的页无条件重新拉取，保证 dest 得到完整数据
+//
+// 返回第一页的 ResponseMeta 以及本次各页的最新 ETag
+func (c *Client) GetPaginatedIfNoneMatch(ctx context.Context, path string, accessToken string, etags map[int]string, dest interface{}) (*ResponseMeta, map[int]string, error) {
 	// 1. 请求第一页
 	page1URL := c.buildPageURL(path, 1)
-	body, meta, err := c.doRequest(ctx, page1URL, accessToken)
+	body, meta, err := c.doRequest(ctx, page1URL, accessToken, etags[1])
 	if err != nil {
-		return nil, fmt.Errorf("ESI GET %s page 1: %w", path, err)
+		return nil, nil, fmt.Errorf("ESI GET %s page 1: %w", path, err)
 	}
 
-	if meta.StatusCode == http.StatusNotModified {
-		return meta, ErrNotModified
-	}
-	if meta.StatusCode != http.StatusOK {
-		return meta, fmt.Errorf("ESI error %d on %s: %s", meta.StatusCode, path, string(body))
+	if meta.StatusCode != http.StatusOK && meta.StatusCode != http.StatusNotModified {
+		return meta, nil, fmt.Errorf("ESI error %d on %s: %s", meta.StatusCode, path, string(body))
 	}
 
 	totalPages := meta.Pages
+	if totalPages <= 0 && meta.StatusCode == http.StatusNotModified {
+		// 304 响应可能不带 x-pages，沿用上次保存的页数
+		totalPages = len(etags)
+	}
 	if totalPages <= 0 {
 		totalPages = 1
 	}
 
+	allBodies := make([][]byte, totalPages)
+	newETags := make(map[int]string, totalPages)
+	if meta.StatusCode == http.StatusOK {
+		allBodies[0] = body
+		newETags[1] = meta.ETag
+	}
+
+	// 2. 多页：并发拉取剩余页面
+	if totalPages > 1 {
+		global.Logger.Debug("[ESI Paginated] 检测到多页响应，开始并发拉取",
+			zap.String("path", path),
+			zap.Int("total_pages", totalPages),
+			zap.String("ratelimit_group", meta.RateLimitGroup),
+			zap.Int("ratelimit_remaining", meta.RateLimitRemain),
+		)
+
+		rest := make([]int, 0, totalPages-1)
+		for page := 2; page <= totalPages; page++ {
+			rest = append(rest, page)
+		}
+		if err := c.fetchPages(ctx, path, accessToken, meta.RateLimitGroup, rest, etags, allBodies, newETags); err != nil {
+			return meta, nil, err
+		}
+	}
+
+	// 3. 处理未变化的页
+	var unchanged []int
+	for i, b := range allBodies {
+		if b == nil {
+			unchanged = append(unchanged, i+1)
+		}
+	}
+	if len(unchanged) == totalPages {
+		return meta, etags, ErrNotModified
+	}
+	if len(unchanged) > 0 {
+		// 部分页有变化：304 的页没有响应体，需无条件重新拉取才能合并出完整数据
+		if err := c.fetchPages(ctx, path, accessToken, meta.RateLimitGroup, unchanged, nil, allBodies, newETags); err != nil {
+			return meta, nil, err
+		}
+	}
+
 	// 单页直接解码返回
 	if totalPages == 1 {
 		if dest != nil {
-			if err := json.Unmarshal(body, dest); err != nil {
-				return meta, fmt.Errorf("decode ESI response: %w", err)
+			if err := json.Unmarshal(allBodies[0], dest); err != nil {
+				return meta, nil, fmt.Errorf("decode ESI response: %w", err)
 			}
 		}
-		return meta, nil
+		return meta, newETags, nil
 	}
 
-	// 2. 多页：并发拉取剩余页面
-	global.Logger.Debug("[ESI Paginated] 检测到多页响应，开始并发拉取",
+	// 4. 合并所有 JSON 数组
+	if dest != nil {
+		merged, mergeErr := mergeJSONArrays(allBodies)
+		if mergeErr != nil {
+			return meta, nil, fmt.Errorf("merge paginated results for %s: %w", path, mergeErr)
+		}
+		if err := json.Unmarshal(merged, dest); err != nil {
+			return meta, nil, fmt.Errorf("decode merged ESI response for %s: %w", path, err)
+		}
+	}
+
+	global.Logger.Debug("[ESI Paginated] 分页数据合并完成",
 		zap.String("path", path),
 		zap.Int("total_pages", totalPages),
-		zap.String("ratelimit_group", meta.RateLimitGroup),
-		zap.Int("ratelimit_remaining", meta.RateLimitRemain),
 	)
 
-	allBodies := make([][]byte, totalPages)
-	allBodies[0] = body
+	return meta, newETags, nil
+}
 
+// fetchPages 并发拉取指定页面（受限速器约束），响应体写入 bodies[page-1]，ETag 写入 newETags
+// etags 非空时各页携带 If-None-Match，返回 304 的页 bodies 保持为 nil
+func (c *Client) fetchPages(ctx context.Context, path string, accessToken string, group string, pages []int, etags map[int]string, bodies [][]byte, newETags map[int]string) error {
 	var (
 		mu       sync.Mutex
 		fetchErr error
@@ -366,7 +490,7 @@ func (c *Client) GetPaginated(ctx context.Context, path string, accessToken stri
 	sem := make(chan struct{}, paginationConcurrency)
 	var wg sync.WaitGroup
 
-	for page := 2; page <= totalPages; page++ {
+	for _, page := range pages {
 		wg.Add(1)
 		sem <- struct{}{}
 
@@ -375,8 +499,8 @@ func (c *Client) GetPaginated(ctx context.Context, path string, accessToken stri
 			defer func() { <-sem }()
 
 			// 限速等待（使用第 1 页获知的 group）
-			if c.rateLimiter != nil && meta.RateLimitGroup != "" {
-				if waitErr := c.rateLimiter.Wait(ctx, meta.RateLimitGroup); waitErr != nil {
+			if c.rateLimiter != nil && group != "" {
+				if waitErr := c.rateLimiter.Wait(ctx, group); waitErr != nil {
 					mu.Lock()
 					if fetchErr == nil {
 						fetchErr = fmt.Errorf("rate limit wait for page %d: %w", p, waitErr)
@@ -387,7 +511,7 @@ func (c *Client) GetPaginated(ctx context.Context, path string, accessToken stri
 			}
 
 			pageURL := c.buildPageURL(path, p)
-			pageBody, pageMeta, reqErr := c.doRequest(ctx, pageURL, accessToken)
+			pageBody, pageMeta, reqErr := c.doRequest(ctx, pageURL, accessToken, etags[p])
 			if reqErr != nil {
 				mu.Lock()
 				if fetchErr == nil {
@@ -397,6 +521,9 @@ func (c *Client) GetPaginated(ctx context.Context, path string, accessToken stri
 				return
 			}
 
+			if pageMeta.StatusCode == http.StatusNotModified && etags[p] != "" {
+				return
+			}
 			if pageMeta.StatusCode != http.StatusOK {
 				mu.Lock()
 				if fetchErr == nil {
@@ -408,34 +535,14 @@ func (c *Client) GetPaginated(ctx context.Context, path string, accessToken stri
 			}
 
 			mu.Lock()
-			allBodies[p-1] = pageBody
+			bodies[p-1] = pageBody
+			newETags[p] = pageMeta.ETag
 			mu.Unlock()
 		}(page)
 	}
 
 	wg.Wait()
-
-	if fetchErr != nil {
-		return meta, fetchErr
-	}
-
-	// 3. 合并所有 JSON 数组
-	if dest != nil {
-		merged, mergeErr := mergeJSONArrays(allBodies)
-		if mergeErr != nil {
-			return meta, fmt.Errorf("merge paginated results for %s: %w", path, mergeErr)
-		}
-		if err := json.Unmarshal(merged, dest); err != nil {
-			return meta, fmt.Errorf("decode merged ESI response for %s: %w", path, err)
-		}
-	}
-
-	global.Logger.Debug("[ESI Paginated] 分页数据合并完成",
-		zap.String("path", path),
-		zap.Int("total_pages", totalPages),
-	)
-
-	return meta, nil
+	return fetchErr
 }
 
 // ─────────────────────────────────────────────
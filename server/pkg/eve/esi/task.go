@@ -1,6 +1,7 @@
 package esi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -81,6 +82,40 @@ type TaskContext struct {
 	AccessToken string
 	Client      *Client
 	IsActive    bool // 角色是否活跃
+
+	etags *etagScope // 本次执行的 ETag 上下文（nil 表示不使用条件请求）
+}
+
+// GetIfModified 带 ETag 的条件 GET
+// 携带上次成功执行时保存的 ETag；数据未变化时返回 ErrNotModified，调用方应跳过入库
+// 新 ETag 在任务成功返回后才会保存
+func (c *TaskContext) GetIfModified(path string, dest interface{}) error {
+	ctx := context.Background()
+	known := c.etags.load(ctx, path)
+	meta, err := c.Client.GetIfNoneMatch(ctx, path, c.AccessToken, known[1], dest)
+	if err != nil {
+		return err
+	}
+	c.etags.stage(path, map[int]string{1: meta.ETag})
+	return nil
+}
+
+// GetPaginatedIfModified 带 ETag 的分页条件 GET，所有页均未变化时返回 ErrNotModified
+func (c *TaskContext) GetPaginatedIfModified(path string, dest interface{}) error {
+	ctx := context.Background()
+	known := c.etags.load(ctx, path)
+	_, etags, err := c.Client.GetPaginatedIfNoneMatch(ctx, path, c.AccessToken, known, dest)
+	if err != nil {
+		return err
+	}
+	c.etags.stage(path, etags)
+	return nil
+}
+
+// DiscardETags 放弃本次执行获得的 ETag
+// 任务内部吞掉了部分入库错误时调用，保证下次执行重新完整拉取
+func (c *TaskContext) DiscardETags() {
+	c.etags.discard()
 }
 
 // ─────────────────────────────────────────────
@@ -150,4 +185,6 @@ type TaskStatus struct {
 	NextRun     *time.Time `json:"next_run,omitempty"`
 	Status      string     `json:"status"` // pending | running | success | failed
 	Error       string     `json:"error,omitempty"`
+	// Breaker 熔断状态（open / half_open / scope_missing），正常时为空
+	Breaker *BreakerInfo `json:"breaker,omitempty"`
 }
@@ -3,6 +3,8 @@ package esi
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
+	"amiya-eden/internal/repository"
+	"amiya-eden/internal/service"
 	"context"
 	"fmt"
 	"time"
@@ -14,6 +16,7 @@ import (
 //  Character Affiliation 角色归属
 //  POST /characters/affiliation
 //  批量任务：每次最多 1000 个角色 ID
+//  同时为创建时间未知的角色回填 birthday（GET /characters/{character_id}）
 //  默认刷新间隔: 2 Hours
 // ─────────────────────────────────────────────
 
@@ -76,6 +79,17 @@ func (t *AffiliationTask) fetchAffiliation(client *Client, ids []int64) error {
 		return fmt.Errorf("fetch affiliation: %w", err)
 	}
 
+	// 读取更新前的归属，用于记录加入 / 离开事件
+	var existing []model.EveCharacter
+	if err := global.DB.Where("character_id IN ?", ids).Find(&existing).Error; err != nil {
+		global.Logger.Warn("[ESI] 读取角色归属失败", zap.Error(err))
+	}
+	oldChars := make(map[int64]*model.EveCharacter, len(existing))
+	for i := range existing {
+		oldChars[existing[i].CharacterID] = &existing[i]
+	}
+	lifecycleSvc := service.NewMemberLifecycleService()
+
 	// 入库：更新 eve_character 表的归属字段
 	for _, r := range results {
 		updates := map[string]interface{}{
@@ -90,12 +104,52 @@ func (t *AffiliationTask) fetchAffiliation(client *Client, ids []int64) error {
 				zap.Int64("character_id", r.CharacterID),
 				zap.Error(err),
 			)
+			continue
+		}
+		if old, ok := oldChars[r.CharacterID]; ok {
+			lifecycleSvc.RecordAffiliationChange(old, r.CorporationID, r.AllianceID)
 		}
 	}
 
+	t.backfillBirthdays(ctx, client, existing)
+
 	global.Logger.Debug("[ESI] 角色归属刷新并入库完成",
 		zap.Int("count", len(results)),
 	)
 
 	return nil
 }
+
+// birthdayBackfillLimit 每批最多回填的角色创建时间数量，避免首次上线时集中请求 ESI
+const birthdayBackfillLimit = 100
+
+// backfillBirthdays 为创建时间未知的角色从公开接口回填 birthday（供自动权限"角色创建天数"条件使用）
+func (t *AffiliationTask) backfillBirthdays(ctx context.Context, client *Client, chars []model.EveCharacter) {
+	charRepo := repository.NewEveCharacterRepository()
+	filled := 0
+	for i := range chars {
+		if chars[i].Birthday != nil {
+			continue
+		}
+		if filled >= birthdayBackfillLimit {
+			break
+		}
+		filled++
+		var info struct {
+			Birthday time.Time `json:"birthday"`
+		}
+		if err := client.Get(ctx, fmt.Sprintf("/characters/%d/", chars[i].CharacterID), "", &info); err != nil {
+			global.Logger.Warn("[ESI] 获取角色创建时间失败",
+				zap.Int64("character_id", chars[i].CharacterID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if err := charRepo.UpdateBirthday(chars[i].CharacterID, info.Birthday); err != nil {
+			global.Logger.Warn("[ESI] 回写角色创建时间失败",
+				zap.Int64("character_id", chars[i].CharacterID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -84,7 +85,13 @@ func (t *AssetsTask) Execute(ctx *TaskContext) error {
 	// 1. 获取资产列表（自动分页）
 	path := fmt.Sprintf("/characters/%d/assets/", ctx.CharacterID)
 	var assets []AssetItem
-	if _, err := ctx.Client.GetPaginated(bgCtx, path, ctx.AccessToken, &assets); err != nil {
+	if err := ctx.GetPaginatedIfModified(path, &assets); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			global.Logger.Debug("[ESI] 角色资产未变化，跳过入库",
+				zap.Int64("character_id", ctx.CharacterID),
+			)
+			return nil
+		}
 		return fmt.Errorf("fetch assets: %w", err)
 	}
 
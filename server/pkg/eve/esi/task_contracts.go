@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -92,7 +93,13 @@ func (t *ContractsTask) Execute(ctx *TaskContext) error {
 	// 1. 获取合同列表（自动分页）
 	contractPath := fmt.Sprintf("/characters/%d/contracts/", ctx.CharacterID)
 	var contracts []Contract
-	if _, err := ctx.Client.GetPaginated(bgCtx, contractPath, ctx.AccessToken, &contracts); err != nil {
+	if err := ctx.GetPaginatedIfModified(contractPath, &contracts); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			global.Logger.Debug("[ESI] 角色合同未变化，跳过入库",
+				zap.Int64("character_id", ctx.CharacterID),
+			)
+			return nil
+		}
 		return fmt.Errorf("fetch contracts: %w", err)
 	}
 
@@ -138,6 +145,7 @@ func (t *ContractsTask) Execute(ctx *TaskContext) error {
 					zap.Int64("contract_id", c.ContractID),
 					zap.Error(err),
 				)
+				ctx.DiscardETags()
 				continue
 			}
 		} else {
@@ -147,6 +155,7 @@ func (t *ContractsTask) Execute(ctx *TaskContext) error {
 					zap.Int64("contract_id", c.ContractID),
 					zap.Error(err),
 				)
+				ctx.DiscardETags()
 				continue
 			}
 		}
@@ -3,7 +3,7 @@ package esi
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/repository"
-	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -50,11 +50,13 @@ type corpRolesResponse struct {
 }
 
 func (t *CorpRolesTask) Execute(ctx *TaskContext) error {
-	bgCtx := context.Background()
 	path := fmt.Sprintf("/characters/%d/roles/", ctx.CharacterID)
 
 	var rolesResp corpRolesResponse
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &rolesResp); err != nil {
+	if err := ctx.GetIfModified(path, &rolesResp); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			return nil
+		}
 		return fmt.Errorf("fetch corporation roles: %w", err)
 	}
 
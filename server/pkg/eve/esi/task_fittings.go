@@ -3,7 +3,7 @@ package esi
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
-	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -49,11 +49,13 @@ type fittingInfo struct {
 type fittingsResponse []fittingInfo
 
 func (t *FittingsTask) Execute(ctx *TaskContext) error {
-	bgCtx := context.Background()
 	path := fmt.Sprintf("/characters/%d/fittings/", ctx.CharacterID)
 
 	var fittings fittingsResponse
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &fittings); err != nil {
+	if err := ctx.GetIfModified(path, &fittings); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			return nil
+		}
 		return fmt.Errorf("fetch fittings: %w", err)
 	}
 
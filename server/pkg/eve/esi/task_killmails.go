@@ -4,6 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -94,7 +95,10 @@ func (t *KillmailsTask) Execute(ctx *TaskContext) error {
 	// 1. 获取最近的 killmail 列表（自动分页）
 	recentPath := fmt.Sprintf("/characters/%d/killmails/recent/", ctx.CharacterID)
 	var refs []KillmailRef
-	if _, err := ctx.Client.GetPaginated(bgCtx, recentPath, ctx.AccessToken, &refs); err != nil {
+	if err := ctx.GetPaginatedIfModified(recentPath, &refs); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			return nil
+		}
 		return fmt.Errorf("fetch recent killmails: %w", err)
 	}
 
@@ -137,6 +141,7 @@ func (t *KillmailsTask) Execute(ctx *TaskContext) error {
 				zap.Int64("killmail_id", ref.KillmailID),
 				zap.Error(err),
 			)
+			ctx.DiscardETags()
 			continue
 		}
 
@@ -251,6 +256,7 @@ func (t *KillmailsTask) Execute(ctx *TaskContext) error {
 				zap.Int64("killmail_id", ref.KillmailID),
 				zap.Error(err),
 			)
+			ctx.DiscardETags()
 			continue
 		}
 
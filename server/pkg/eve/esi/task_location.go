@@ -1 +1,112 @@
 package esi
+
+import (
+	"amiya-eden/global"
+	"amiya-eden/internal/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────
+//  Character Location 角色位置与当前舰船
+//  GET /characters/{character_id}/location
+//  GET /characters/{character_id}/ship
+//  位置或舰船变化时新增一条记录，用于 PAP 补登申请等事后核对
+//  默认刷新间隔: 15 Minutes / 不活跃: 6 Hours
+// ─────────────────────────────────────────────
+
+// locationRetention 位置记录保留时长
+const locationRetention = 90 * 24 * time.Hour
+
+func init() {
+	Register(&LocationTask{})
+}
+
+// LocationTask 角色位置刷新任务
+type LocationTask struct{}
+
+func (t *LocationTask) Name() string        { return "character_location" }
+func (t *LocationTask) Description() string { return "角色位置与当前舰船" }
+func (t *LocationTask) Priority() Priority  { return PriorityNormal }
+
+func (t *LocationTask) Interval() RefreshInterval {
+	return RefreshInterval{
+		Active:   15 * time.Minute,
+		Inactive: 6 * time.Hour,
+	}
+}
+
+func (t *LocationTask) RequiredScopes() []TaskScope {
+	return []TaskScope{
+		{Scope: "esi-location.read_location.v1", Description: "读取角色位置"},
+		{Scope: "esi-location.read_ship_type.v1", Description: "读取角色当前舰船"},
+	}
+}
+
+// CharacterLocation 角色位置
+type CharacterLocation struct {
+	SolarSystemID int64 `json:"solar_system_id"`
+	StationID     int64 `json:"station_id,omitempty"`
+	StructureID   int64 `json:"structure_id,omitempty"`
+}
+
+// CharacterShip 角色当前舰船
+type CharacterShip struct {
+	ShipItemID int64  `json:"ship_item_id"`
+	ShipName   string `json:"ship_name"`
+	ShipTypeID int64  `json:"ship_type_id"`
+}
+
+func (t *LocationTask) Execute(ctx *TaskContext) error {
+	bgCtx := context.Background()
+
+	var loc CharacterLocation
+	if err := ctx.Client.Get(bgCtx, fmt.Sprintf("/characters/%d/location/", ctx.CharacterID), ctx.AccessToken, &loc); err != nil {
+		return fmt.Errorf("fetch location: %w", err)
+	}
+	var ship CharacterShip
+	if err := ctx.Client.Get(bgCtx, fmt.Sprintf("/characters/%d/ship/", ctx.CharacterID), ctx.AccessToken, &ship); err != nil {
+		global.Logger.Warn("[ESI] 获取角色当前舰船失败",
+			zap.Int64("character_id", ctx.CharacterID),
+			zap.Error(err),
+		)
+	}
+
+	now := time.Now()
+	var last model.EveCharacterLocation
+	err := global.DB.Where("character_id = ?", ctx.CharacterID).Order("last_seen_at DESC").First(&last).Error
+	if err == nil && last.SolarSystemID == loc.SolarSystemID && last.StationID == loc.StationID &&
+		last.StructureID == loc.StructureID && (ship.ShipTypeID == 0 || last.ShipTypeID == ship.ShipTypeID) {
+		if err := global.DB.Model(&last).Update("last_seen_at", now).Error; err != nil {
+			return fmt.Errorf("touch location: %w", err)
+		}
+	} else {
+		record := model.EveCharacterLocation{
+			CharacterID:   ctx.CharacterID,
+			SolarSystemID: loc.SolarSystemID,
+			StationID:     loc.StationID,
+			StructureID:   loc.StructureID,
+			ShipTypeID:    ship.ShipTypeID,
+			FirstSeenAt:   now,
+			LastSeenAt:    now,
+		}
+		if err := global.DB.Create(&record).Error; err != nil {
+			return fmt.Errorf("insert location: %w", err)
+		}
+	}
+
+	if err := global.DB.Where("character_id = ? AND last_seen_at < ?", ctx.CharacterID, now.Add(-locationRetention)).
+		Delete(&model.EveCharacterLocation{}).Error; err != nil {
+		global.Logger.Warn("[ESI] 清理过期位置记录失败", zap.Int64("character_id", ctx.CharacterID), zap.Error(err))
+	}
+
+	global.Logger.Debug("[ESI] 角色位置刷新完成",
+		zap.Int64("character_id", ctx.CharacterID),
+		zap.Int64("solar_system_id", loc.SolarSystemID),
+		zap.Int64("ship_type_id", ship.ShipTypeID),
+	)
+	return nil
+}
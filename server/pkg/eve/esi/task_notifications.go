@@ -3,7 +3,7 @@ package esi
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
-	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -52,11 +52,13 @@ type Notification struct {
 }
 
 func (t *NotificationsTask) Execute(ctx *TaskContext) error {
-	bgCtx := context.Background()
 	path := fmt.Sprintf("/characters/%d/notifications/", ctx.CharacterID)
 
 	var notifications []Notification
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &notifications); err != nil {
+	if err := ctx.GetIfModified(path, &notifications); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			return nil
+		}
 		return fmt.Errorf("fetch notifications: %w", err)
 	}
 
@@ -83,6 +85,7 @@ func (t *NotificationsTask) Execute(ctx *TaskContext) error {
 				zap.Int64("notification_id", n.NotificationID),
 				zap.Error(err),
 			)
+			ctx.DiscardETags()
 		}
 	}
 
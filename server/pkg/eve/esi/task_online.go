@@ -43,7 +43,10 @@ func (t *OnlineTask) RequiredScopes() []TaskScope {
 func (t *OnlineTask) Execute(ctx *TaskContext) error {
 	bgCtx := context.Background()
 	path := fmt.Sprintf("/characters/%d/online/", ctx.CharacterID)
+	cacheKey := fmt.Sprintf("%s%d", activityCachePrefix, ctx.CharacterID)
 
+	// 不使用 ETag：活跃度取决于 last_login 与当前时间的差值，last_login 不变时结果也会随时间变化，
+	// 304 无法据此判断，需每次取回 last_login 重新计算
 	var status OnlineStatus
 	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &status); err != nil {
 		return fmt.Errorf("fetch online status: %w", err)
@@ -55,7 +58,6 @@ func (t *OnlineTask) Execute(ctx *TaskContext) error {
 		isActive = time.Since(*status.LastLogin) < time.Duration(InactiveDays)*24*time.Hour
 	}
 
-	cacheKey := fmt.Sprintf("%s%d", activityCachePrefix, ctx.CharacterID)
 	activeVal := "0"
 	if isActive {
 		activeVal = "1"
@@ -4,7 +4,7 @@ import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
 
-	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -60,73 +60,89 @@ type SkillInfo struct {
 }
 
 func (t *SkillTask) Execute(ctx *TaskContext) error {
-	bgCtx := context.Background()
-
+	// 技能与技能队列分别使用条件请求，未变化的部分跳过入库
 	var skillInfo SkillInfo
 	path := fmt.Sprintf("/characters/%d/skills", ctx.CharacterID)
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &skillInfo); err != nil {
-		return fmt.Errorf("fetch skill info: %w", err)
+	skillsChanged := true
+	if err := ctx.GetIfModified(path, &skillInfo); err != nil {
+		if !errors.Is(err, ErrNotModified) {
+			return fmt.Errorf("fetch skill info: %w", err)
+		}
+		skillsChanged = false
 	}
 
 	var skillQueue []SkillQueueEntry
 	path = fmt.Sprintf("/characters/%d/skillqueue", ctx.CharacterID)
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &skillQueue); err != nil {
-		return fmt.Errorf("fetch skill queue: %w", err)
+	queueChanged := true
+	if err := ctx.GetIfModified(path, &skillQueue); err != nil {
+		if !errors.Is(err, ErrNotModified) {
+			return fmt.Errorf("fetch skill queue: %w", err)
+		}
+		queueChanged = false
 	}
 
-	tx := global.DB.Begin()
-	if err := tx.Model(&model.EveCharacterSkill{}).
-		Where("character_id = ?", ctx.CharacterID).
-		FirstOrCreate(&model.EveCharacterSkill{
-			CharacterID:   ctx.CharacterID,
-			TotalSP:       skillInfo.TotalSP,
-			UnallocatedSP: skillInfo.UnallocatedSP,
-		}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("create or update skill: %w", err)
+	if !skillsChanged && !queueChanged {
+		return nil
 	}
 
-	for _, skill := range skillInfo.Skills {
-		if err := tx.Model(&model.EveCharacterSkills{}).
-			Where("character_id = ? AND skill_id = ?", ctx.CharacterID, skill.SkillID).
-			FirstOrCreate(&model.EveCharacterSkills{
-				CharacterID:        ctx.CharacterID,
-				SkillID:            skill.SkillID,
-				ActiveLevel:        int(skill.ActiveSkillLevel),
-				TrainedLevel:       int(skill.TrainedSkillLevel),
-				SkillpointsInSkill: skill.SkillpointsInSkill,
+	tx := global.DB.Begin()
+	if skillsChanged {
+		if err := tx.Model(&model.EveCharacterSkill{}).
+			Where("character_id = ?", ctx.CharacterID).
+			FirstOrCreate(&model.EveCharacterSkill{
+				CharacterID:   ctx.CharacterID,
+				TotalSP:       skillInfo.TotalSP,
+				UnallocatedSP: skillInfo.UnallocatedSP,
 			}).Error; err != nil {
-			global.Logger.Warn("[ESI] 创建或更新技能记录失败",
-				zap.Int64("character_id", ctx.CharacterID),
-				zap.Int("skill_id", skill.SkillID),
-				zap.Error(err),
-			)
+			tx.Rollback()
+			return fmt.Errorf("create or update skill: %w", err)
 		}
-	}
 
-	if err := tx.Where("character_id = ?", ctx.CharacterID).Delete(&model.EveCharacterSkillQueue{}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("delete old skill queue: %w", err)
+		for _, skill := range skillInfo.Skills {
+			if err := tx.Model(&model.EveCharacterSkills{}).
+				Where("character_id = ? AND skill_id = ?", ctx.CharacterID, skill.SkillID).
+				FirstOrCreate(&model.EveCharacterSkills{
+					CharacterID:        ctx.CharacterID,
+					SkillID:            skill.SkillID,
+					ActiveLevel:        int(skill.ActiveSkillLevel),
+					TrainedLevel:       int(skill.TrainedSkillLevel),
+					SkillpointsInSkill: skill.SkillpointsInSkill,
+				}).Error; err != nil {
+				global.Logger.Warn("[ESI] 创建或更新技能记录失败",
+					zap.Int64("character_id", ctx.CharacterID),
+					zap.Int("skill_id", skill.SkillID),
+					zap.Error(err),
+				)
+				ctx.DiscardETags()
+			}
+		}
 	}
 
-	if len(skillQueue) > 0 {
-		var queueRecords []model.EveCharacterSkillQueue
-		for _, q := range skillQueue {
-			queueRecords = append(queueRecords, model.EveCharacterSkillQueue{
-				CharacterID:     ctx.CharacterID,
-				QueuePosition:   q.QueuePosition,
-				SkillID:         q.SkillID,
-				LevelEndSP:      q.LevelEndSP,
-				LevelStartSP:    q.LevelStartSP,
-				TrainingStartSP: q.TrainingStartSP,
-				FinishedLevel:   q.FinishedLevel,
-				StartDate:       q.StartDate.Unix(),
-				FinishDate:      q.FinishDate.Unix(),
-			})
-		}
-		if err := tx.Create(&queueRecords).Error; err != nil {
+	if queueChanged {
+		if err := tx.Where("character_id = ?", ctx.CharacterID).Delete(&model.EveCharacterSkillQueue{}).Error; err != nil {
 			tx.Rollback()
-			return fmt.Errorf("insert skill queue: %w", err)
+			return fmt.Errorf("delete old skill queue: %w", err)
+		}
+
+		if len(skillQueue) > 0 {
+			var queueRecords []model.EveCharacterSkillQueue
+			for _, q := range skillQueue {
+				queueRecords = append(queueRecords, model.EveCharacterSkillQueue{
+					CharacterID:     ctx.CharacterID,
+					QueuePosition:   q.QueuePosition,
+					SkillID:         q.SkillID,
+					LevelEndSP:      q.LevelEndSP,
+					LevelStartSP:    q.LevelStartSP,
+					TrainingStartSP: q.TrainingStartSP,
+					FinishedLevel:   q.FinishedLevel,
+					StartDate:       q.StartDate.Unix(),
+					FinishDate:      q.FinishDate.Unix(),
+				})
+			}
+			if err := tx.Create(&queueRecords).Error; err != nil {
+				tx.Rollback()
+				return fmt.Errorf("insert skill queue: %w", err)
+			}
 		}
 	}
 
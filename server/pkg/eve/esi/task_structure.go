@@ -5,6 +5,7 @@ import (
 	"amiya-eden/internal/model"
 	"amiya-eden/pkg/utils"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -101,7 +102,10 @@ func (t *StructureTask) Execute(ctx *TaskContext) error {
 	// 1. 获取角色所在军团的建筑列表
 	var esiStructures []corpStructureESIResp
 	corpStructuresPath := fmt.Sprintf("/corporations/%d/structures/", corpID)
-	_, err = ctx.Client.GetPaginated(bgCtx, corpStructuresPath, ctx.AccessToken, &esiStructures)
+	err = ctx.GetPaginatedIfModified(corpStructuresPath, &esiStructures)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
 	if err != nil {
 		global.Logger.Warn("[ESI] 获取军团建筑信息失败",
 			zap.Int64("character_id", ctx.CharacterID),
@@ -150,6 +154,7 @@ func (t *StructureTask) Execute(ctx *TaskContext) error {
 	}
 
 	// 3. 逐个获取建筑详情并 Upsert EveStructure
+	// 任一详情获取或入库失败时放弃本次 ETag，避免下次列表 304 后永久跳过缺失的详情
 	for _, s := range esiStructures {
 		var detail eveStructureDetail
 		structurePath := fmt.Sprintf("/universe/structures/%d/", s.StructureID)
@@ -158,6 +163,7 @@ func (t *StructureTask) Execute(ctx *TaskContext) error {
 				zap.Int64("structure_id", s.StructureID),
 				zap.Error(err),
 			)
+			ctx.DiscardETags()
 			continue
 		}
 
@@ -178,6 +184,7 @@ func (t *StructureTask) Execute(ctx *TaskContext) error {
 				zap.Int64("structure_id", s.StructureID),
 				zap.Error(err),
 			)
+			ctx.DiscardETags()
 		}
 	}
 
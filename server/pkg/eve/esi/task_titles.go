@@ -3,7 +3,7 @@ package esi
 import (
 	"amiya-eden/global"
 	"amiya-eden/internal/model"
-	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -47,11 +47,13 @@ type CharacterTitle struct {
 }
 
 func (t *TitlesTask) Execute(ctx *TaskContext) error {
-	bgCtx := context.Background()
 	path := fmt.Sprintf("/characters/%d/titles/", ctx.CharacterID)
 
 	var titles []CharacterTitle
-	if err := ctx.Client.Get(bgCtx, path, ctx.AccessToken, &titles); err != nil {
+	if err := ctx.GetIfModified(path, &titles); err != nil {
+		if errors.Is(err, ErrNotModified) {
+			return nil
+		}
 		return fmt.Errorf("fetch titles: %w", err)
 	}
 
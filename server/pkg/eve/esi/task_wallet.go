@@ -5,6 +5,7 @@ import (
 	"amiya-eden/internal/model"
 
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -78,17 +79,17 @@ func (t *WalletTask) Execute(ctx *TaskContext) error {
 		return fmt.Errorf("fetch wallet balance: %w", err)
 	}
 
-	// 2. 获取钱包记录
+	// 2. 获取钱包记录（条件请求，未变化时为空，不会写入新记录）
 	var walletJournal WalletJournalResult
 	path = fmt.Sprintf("/characters/%d/wallet/journal", ctx.CharacterID)
-	if _, err := ctx.Client.GetPaginated(bgCtx, path, ctx.AccessToken, &walletJournal); err != nil {
+	if err := ctx.GetPaginatedIfModified(path, &walletJournal); err != nil && !errors.Is(err, ErrNotModified) {
 		return fmt.Errorf("fetch wallet journal: %w", err)
 	}
 
-	// 3. 获取钱包市场交易
+	// 3. 获取钱包市场交易（条件请求）
 	var walletTransactions []WalletTransaction
 	path = fmt.Sprintf("/characters/%d/wallet/transactions", ctx.CharacterID)
-	if _, err := ctx.Client.GetPaginated(bgCtx, path, ctx.AccessToken, &walletTransactions); err != nil {
+	if err := ctx.GetPaginatedIfModified(path, &walletTransactions); err != nil && !errors.Is(err, ErrNotModified) {
 		return fmt.Errorf("fetch wallet transactions: %w", err)
 	}
 
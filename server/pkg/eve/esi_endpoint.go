@@ -0,0 +1,72 @@
+package eve
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ─────────────────────────────────────────────
+//  ESI 端点与 HTTP 传输（所有 ESI 调用方共享）
+//  通过配置 esi.base_url 可将整个服务指向本地 ESI 替身（录制的 fixtures），
+//  通过 SetESITransport 可注入自定义 http.RoundTripper（测试 / 预发环境）
+// ─────────────────────────────────────────────
+
+// DefaultESIBaseURL ESI API 默认基础地址
+const DefaultESIBaseURL = "https://esi.evetech.net"
+
+var (
+	esiMu        sync.RWMutex
+	esiBaseURL   = DefaultESIBaseURL
+	esiTransport http.RoundTripper
+)
+
+// SetESIBaseURL 设置 ESI 基础地址（不含末尾斜杠），空字符串恢复默认
+func SetESIBaseURL(baseURL string) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultESIBaseURL
+	}
+	esiMu.Lock()
+	esiBaseURL = baseURL
+	esiMu.Unlock()
+}
+
+// ESIBaseURL 返回当前 ESI 基础地址
+func ESIBaseURL() string {
+	esiMu.RLock()
+	defer esiMu.RUnlock()
+	return esiBaseURL
+}
+
+// ESIURL 拼接 ESI 完整地址，path 以 / 开头（如 /latest/characters/1/）
+func ESIURL(path string) string {
+	return ESIBaseURL() + path
+}
+
+// SetESITransport 注入 ESI 请求使用的 http.RoundTripper，nil 恢复为 http.DefaultTransport
+func SetESITransport(rt http.RoundTripper) {
+	esiMu.Lock()
+	esiTransport = rt
+	esiMu.Unlock()
+}
+
+// NewESIHTTPClient 创建用于 ESI 请求的 http.Client
+// 传输层在每次请求时读取 SetESITransport 的当前值，因此可在客户端创建后再替换
+func NewESIHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: esiRoundTripper{}}
+}
+
+// esiRoundTripper 转发到当前注入的传输层
+type esiRoundTripper struct{}
+
+func (esiRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	esiMu.RLock()
+	rt := esiTransport
+	esiMu.RUnlock()
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return rt.RoundTrip(req)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +23,9 @@ const (
 	PortraitURLFmt = "https://images.evetech.net/characters/%d/portrait?size=128"
 )
 
+// ErrInvalidGrant refresh_token 已被撤销或过期，只能由用户重新授权恢复
+var ErrInvalidGrant = errors.New("invalid_grant")
+
 // Client EVE SSO OAuth 客户端
 type Client struct {
 	ClientID     string
@@ -96,6 +100,13 @@ func (c *Client) doTokenRequest(ctx context.Context, data url.Values) (*TokenRes
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		// 仅 400 invalid_grant 表示 Token 本身失效，其他错误（5xx、网络等）可能是暂时性的
+		var oauthErr struct {
+			Error string `json:"error"`
+		}
+		if resp.StatusCode == http.StatusBadRequest && json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGrant, string(body))
+		}
 		return nil, fmt.Errorf("EVE SSO error %d: %s", resp.StatusCode, string(body))
 	}
 
@@ -3,8 +3,10 @@ package jwt
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -28,22 +30,23 @@ var headerEncoded = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256",
 
 // Claims 我们系统的 JWT 载荷
 type Claims struct {
-	UserID      uint   `json:"uid"`
-	CharacterID int64  `json:"cid"`  // 登录时使用的 EVE 角色 ID
-	Role        string `json:"role"` // 用户角色
-	ExpiresAt   int64  `json:"exp"`
-	IssuedAt    int64  `json:"iat"`
+	ID             string `json:"jti"`           // Token 唯一 ID
+	SessionID      string `json:"sid,omitempty"` // 服务端会话 ID（user_session.id），用于吊销
+	UserID         uint   `json:"uid"`
+	CharacterID    int64  `json:"cid"`           // 登录时使用的 EVE 角色 ID
+	Role           string `json:"role"`          // 用户角色
+	ImpersonatorID uint   `json:"imp,omitempty"` // 模拟登录：发起模拟的管理员用户 ID（非模拟登录为 0）
+	ReadOnly       bool   `json:"ro,omitempty"`  // 模拟登录：只读，禁止写操作
+	ExpiresAt      int64  `json:"exp"`
+	IssuedAt       int64  `json:"iat"`
 }
 
-// GenerateToken 生成 JWT Token
-func GenerateToken(userID uint, characterID int64, role string, expireDays int) (string, error) {
-	claims := Claims{
-		UserID:      userID,
-		CharacterID: characterID,
-		Role:        role,
-		ExpiresAt:   time.Now().Add(time.Duration(expireDays) * 24 * time.Hour).Unix(),
-		IssuedAt:    time.Now().Unix(),
-	}
+// GenerateToken 生成 JWT Token（自动填充 jti / iat / exp）
+func GenerateToken(claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.ID = newTokenID()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
 
 	payload, err := json.Marshal(claims)
 	if err != nil {
@@ -87,6 +90,12 @@ func ParseToken(tokenStr string) (*Claims, error) {
 	return &claims, nil
 }
 
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 func sign(message string) string {
 	mac := hmac.New(sha256.New, secret)
 	mac.Write([]byte(message))
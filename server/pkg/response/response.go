@@ -13,6 +13,7 @@ const (
 	CodeUnauthorized = 401
 	CodeForbidden    = 403
 	CodeNotFound     = 404
+	CodeTooMany      = 429
 	CodeBizError     = 500
 )
 
@@ -58,6 +59,8 @@ func Fail(c *gin.Context, code int, msg string) {
 		httpStatus = http.StatusUnauthorized
 	case CodeForbidden:
 		httpStatus = http.StatusForbidden
+	case CodeTooMany:
+		httpStatus = http.StatusTooManyRequests
 	}
 	c.JSON(httpStatus, Response{
 		Code: code,